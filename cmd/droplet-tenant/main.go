@@ -0,0 +1,132 @@
+// Command droplet-tenant exports a school to a tenant archive, or imports one as a new school.
+//
+//	droplet-tenant export -school <uuid> [-o file.json.gz] [-include-passwords]
+//	droplet-tenant import [-name "New name"] [-subdomain new-sub] file.json.gz
+//
+// It connects to the database the same way as the server: DATABASE_URL from the environment (or .env),
+// or the database section of CONFIG_FILE.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/5tuartw/droplet/internal/config"
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/tenantarchive"
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+	_ "github.com/lib/pq"
+)
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	switch os.Args[1] {
+	case "export":
+		runExport(os.Args[2:])
+	case "import":
+		runImport(os.Args[2:])
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage:")
+	fmt.Fprintln(os.Stderr, "  droplet-tenant export -school <uuid> [-o file.json.gz] [-include-passwords]")
+	fmt.Fprintln(os.Stderr, "  droplet-tenant import [-name name] [-subdomain subdomain] file.json.gz")
+	os.Exit(2)
+}
+
+func runExport(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	schoolFlag := fs.String("school", "", "ID of the school to export")
+	out := fs.String("o", "", "file to write the archive to (default stdout)")
+	includePasswords := fs.Bool("include-passwords", false, "include password hashes so users keep their passwords")
+	fs.Parse(args)
+
+	schoolID, err := uuid.Parse(*schoolFlag)
+	if err != nil {
+		log.Fatalf("-school must be a school ID: %v", err)
+	}
+
+	db, dbq := openDatabase()
+	defer db.Close()
+
+	archive, err := tenantarchive.Export(context.Background(), dbq, schoolID, tenantarchive.ExportOptions{IncludePasswordHashes: *includePasswords})
+	if err != nil {
+		log.Fatalf("Export failed: %v", err)
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			log.Fatalf("Could not create %s: %v", *out, err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := tenantarchive.Write(w, archive); err != nil {
+		log.Fatalf("Export failed: %v", err)
+	}
+
+	log.Printf("Exported %s: %d users, %d pupils, %d drops", archive.School.Name, len(archive.Users), len(archive.Pupils), len(archive.Drops))
+	if !*includePasswords {
+		log.Println("Password hashes were left out; imported users will need their passwords reset.")
+	}
+}
+
+func runImport(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	name := fs.String("name", "", "name for the new school (default the archived name)")
+	subdomain := fs.String("subdomain", "", "subdomain for the new school (default the archived subdomain)")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		usage()
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		log.Fatalf("Could not open archive: %v", err)
+	}
+	defer f.Close()
+
+	archive, err := tenantarchive.Read(f)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	db, dbq := openDatabase()
+	defer db.Close()
+
+	school, err := tenantarchive.Import(context.Background(), db, dbq, archive, tenantarchive.ImportOptions{Name: *name, Subdomain: *subdomain})
+	if err != nil {
+		log.Fatalf("Import failed, nothing was written: %v", err)
+	}
+	log.Printf("Imported %s as school %s: %d users, %d pupils, %d drops", school.Name, school.ID, len(archive.Users), len(archive.Pupils), len(archive.Drops))
+}
+
+func openDatabase() (*sql.DB, *database.Queries) {
+	if err := godotenv.Load(); err != nil {
+		log.Printf("Info: No .env file found or error loading: %v. Relying on system environment variables.", err)
+	}
+	dbConfig, err := config.LoadDatabase(os.Getenv("CONFIG_FILE"), os.LookupEnv)
+	if err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+	db, err := config.OpenDatabase(dbConfig)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return db, database.New(db)
+}
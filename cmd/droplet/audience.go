@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/5tuartw/droplet/internal/database"
+)
+
+// maxListedDifferences caps how many differing rows `droplet audience check` prints
+const maxListedDifferences = 20
+
+// runAudience handles `droplet audience check [-repair]`: it rebuilds every drop's "My Drops" audience
+// from the visibility rules, diffs it against the drop_audience table the triggers maintain and,
+// with -repair, brings the table back in line. It exits 1 if differences were found and not repaired.
+func runAudience(args []string) {
+	if len(args) < 1 || args[0] != "check" {
+		fmt.Fprintln(os.Stderr, "usage: droplet audience check [-repair]")
+		os.Exit(2)
+	}
+	fs := flag.NewFlagSet("audience check", flag.ExitOnError)
+	repair := fs.Bool("repair", false, "rewrite drop_audience to match the rebuilt audience")
+	fs.Parse(args[1:])
+
+	db := openDatabase()
+	defer db.Close()
+	dbq := database.New(db)
+	ctx := context.Background()
+
+	diff, err := dbq.DiffDropAudience(ctx)
+	if err != nil {
+		log.Fatalf("FATAL: Could not rebuild the drop audience: %v", err)
+	}
+	missing := 0
+	for i, row := range diff {
+		if row.Missing {
+			missing++
+		}
+		if i < maxListedDifferences {
+			problem := "unexpected"
+			if row.Missing {
+				problem = "missing"
+			}
+			fmt.Printf("    %-10s drop %s user %s\n", problem, row.DropID, row.UserID)
+		}
+	}
+	if len(diff) > maxListedDifferences {
+		fmt.Printf("    ... and %d more\n", len(diff)-maxListedDifferences)
+	}
+	if len(diff) == 0 {
+		log.Println("drop_audience is consistent.")
+		return
+	}
+	log.Printf("drop_audience is missing %d row(s) and has %d it should not.", missing, len(diff)-missing)
+
+	if !*repair {
+		log.Println("Run `droplet audience check -repair` to fix it.")
+		os.Exit(1)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Fatalf("FATAL: Could not start repair: %v", err)
+	}
+	defer tx.Rollback()
+	qtx := dbq.WithTx(tx)
+
+	removed, err := qtx.DeleteUnexpectedDropAudience(ctx)
+	if err != nil {
+		log.Fatalf("FATAL: Repair failed: %v", err)
+	}
+	added, err := qtx.InsertMissingDropAudience(ctx)
+	if err != nil {
+		log.Fatalf("FATAL: Repair failed: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		log.Fatalf("FATAL: Repair failed: %v", err)
+	}
+	log.Printf("Repaired drop_audience: added %d row(s), removed %d.", added, removed)
+}
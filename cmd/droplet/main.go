@@ -1,28 +1,102 @@
 package main
 
 import (
-	"net/http"
-	//"time"
+	"context"
 	"log"
-	//"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/5tuartw/droplet/internal/config"
+	"github.com/5tuartw/droplet/internal/controllers/drops"
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/logging"
+	"github.com/5tuartw/droplet/internal/metrics"
 	"github.com/5tuartw/droplet/internal/router"
+	"github.com/5tuartw/droplet/internal/server"
+	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 )
 
 func main() {
 
-	cfg, dbQueries, db := config.LoadConfig()
-	defer db.Close()
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "audience" {
+		runAudience(os.Args[2:])
+		return
+	}
 
-	mux := router.NewRouter(cfg, db, dbQueries)
+	envErr := godotenv.Load()
+	settings, err := config.Load(os.Getenv("CONFIG_FILE"), os.LookupEnv)
+	if err != nil {
+		log.Fatalf("FATAL: Invalid configuration:\n%v", err)
+	}
+	// Set up logging first, so everything below is logged in the configured format
+	if err := logging.Setup(os.Stdout, settings.Logging.Format, settings.Logging.Level); err != nil {
+		log.Fatalf("FATAL: %v", err)
+	}
+	if envErr != nil {
+		log.Printf("Info: No .env file found or error loading: %v. Relying on system environment variables.", envErr)
+	}
+
+	db, err := config.OpenDatabase(settings.Database)
+	if err != nil {
+		log.Fatalf("FATAL: %v", err)
+	}
+	if settings.Database.MigrateOnStart || settings.Database.RequireCurrentSchema {
+		checkSchema(settings.Database, db)
+	}
 
-	// Start the server on port 8080.
-	listenAddr := ":" + cfg.Port
-	log.Printf("Server listening on %s", listenAddr)
-	err := http.ListenAndServe(listenAddr, mux) // Use the mux multiplexer
+	cfg, err := config.NewApiConfig(settings)
 	if err != nil {
+		log.Fatalf("FATAL: %v", err)
+	}
+
+	runner := &server.Runner{ShutdownTimeout: settings.Server.HTTP.ShutdownTimeout}
+
+	dbq := database.New(db)
+	mux := router.NewRouter(cfg, db, dbq)
+	if err := runner.Listen(server.New(settings.Server.HTTP, ":"+cfg.Port, mux)); err != nil {
+		log.Fatal(err)
+	}
+
+	// Serve /metrics on its own address, so it can be bound to an internal interface
+	if cfg.MetricsAddr != "" {
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("GET /metrics", metrics.Handler(db, cfg.MetricsToken))
+		if err := runner.Listen(server.New(settings.Server.HTTP, cfg.MetricsAddr, metricsMux)); err != nil {
+			log.Fatalf("Metrics server failed: %v", err)
+		}
+	}
+
+	// Push urgent drops as their post dates arrive
+	runner.Every("urgent drop pushes", time.Minute, func(ctx context.Context) error {
+		_, err := drops.PushDueUrgentDrops(ctx, cfg, dbq)
+		return err
+	})
+
+	// Delete the files of attachments removed along with their drop, user or school
+	if cfg.Attachments != nil {
+		runner.Every("attachment reaper", 10*time.Minute, func(ctx context.Context) error {
+			_, err := drops.ReapDeletedAttachments(ctx, cfg.Attachments, dbq)
+			return err
+		})
+	}
+
+	// Once requests have drained and the jobs have stopped: let urgent drop pushes finish, then close the database
+	runner.OnShutdown("notifications", cfg.Notifier.Shutdown)
+	runner.OnShutdown("database", func(ctx context.Context) error { return db.Close() })
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	if err := runner.Run(ctx); err != nil {
 		log.Fatal(err)
 	}
+	log.Println("Server stopped.")
 }
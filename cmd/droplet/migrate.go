@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/5tuartw/droplet/internal/config"
+	"github.com/5tuartw/droplet/internal/migrate"
+	"github.com/5tuartw/droplet/sql/schema"
+	"github.com/joho/godotenv"
+)
+
+// runMigrate handles `droplet migrate up|down|status`
+func runMigrate(args []string) {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: droplet migrate up|down|status")
+		os.Exit(2)
+	}
+
+	db := openDatabase()
+	defer db.Close()
+
+	runner, err := migrate.New(db, schema.FS)
+	if err != nil {
+		log.Fatalf("FATAL: Could not load migrations: %v", err)
+	}
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := runner.Up(ctx)
+		if err != nil {
+			log.Fatalf("FATAL: Migration failed: %v", err)
+		}
+		log.Printf("Applied %d migration(s); schema is at version %d.", applied, runner.Latest())
+	case "down":
+		m, err := runner.Down(ctx)
+		if err != nil {
+			if errors.Is(err, migrate.ErrNoCurrentVersion) {
+				log.Println("Nothing to roll back.")
+				return
+			}
+			log.Fatalf("FATAL: Rollback failed: %v", err)
+		}
+		log.Printf("Rolled back %s.", m.Name)
+	case "status":
+		statuses, err := runner.Status(ctx)
+		if err != nil {
+			log.Fatalf("FATAL: Could not read migration status: %v", err)
+		}
+		fmt.Printf("    %-25s %s\n", "Applied At", "Migration")
+		for _, s := range statuses {
+			appliedAt := "Pending"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("    %-25s %s\n", appliedAt, s.Name)
+		}
+	default:
+		fmt.Fprintln(os.Stderr, "usage: droplet migrate up|down|status")
+		os.Exit(2)
+	}
+}
+
+// openDatabase connects the command line tools to the database the same way as the server:
+// DATABASE_URL from the environment (or .env), or the database section of CONFIG_FILE
+func openDatabase() *sql.DB {
+	if err := godotenv.Load(); err != nil {
+		log.Printf("Info: No .env file found or error loading: %v. Relying on system environment variables.", err)
+	}
+	dbConfig, err := config.LoadDatabase(os.Getenv("CONFIG_FILE"), os.LookupEnv)
+	if err != nil {
+		log.Fatalf("FATAL: Invalid configuration:\n%v", err)
+	}
+	db, err := config.OpenDatabase(dbConfig)
+	if err != nil {
+		log.Fatalf("FATAL: %v", err)
+	}
+	return db
+}
+
+// checkSchema applies pending migrations (MIGRATE_ON_START) and/or refuses to start while any are
+// still pending (REQUIRE_CURRENT_SCHEMA), so a replica never serves against a schema it doesn't expect
+func checkSchema(cfg config.DatabaseConfig, db *sql.DB) {
+	runner, err := migrate.New(db, schema.FS)
+	if err != nil {
+		log.Fatalf("FATAL: Could not load migrations: %v", err)
+	}
+	ctx := context.Background()
+
+	if cfg.MigrateOnStart {
+		applied, err := runner.Up(ctx)
+		if err != nil {
+			log.Fatalf("FATAL: Migration failed: %v", err)
+		}
+		log.Printf("Applied %d migration(s) on start.", applied)
+	}
+
+	if cfg.RequireCurrentSchema {
+		pending, err := runner.Pending(ctx)
+		if err != nil {
+			log.Fatalf("FATAL: Could not read migration status: %v", err)
+		}
+		if len(pending) > 0 {
+			log.Fatalf("FATAL: Database schema is behind: %d migration(s) pending, starting with %s. Run `droplet migrate up`.", len(pending), pending[0].Name)
+		}
+		log.Printf("Database schema is current (version %d).", runner.Latest())
+	}
+}
@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/5tuartw/droplet/internal/config"
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/schoolsettings"
+	"github.com/google/uuid"
+)
+
+// DemoRestricted reports whether demo restrictions apply to this request: always in DEMO_MODE,
+// otherwise only for schools whose settings mark them as a demo school.
+func DemoRestricted(cfg *config.ApiConfig, dbq *database.Queries, r *http.Request) bool {
+	if cfg.IsDemoMode {
+		return true
+	}
+	schoolID, ok := r.Context().Value(UserSchoolKey).(uuid.UUID)
+	if !ok {
+		return false
+	}
+	settings, err := schoolsettings.Load(r.Context(), dbq, schoolID)
+	if err != nil {
+		slog.WarnContext(r.Context(), "could not load school settings, demo restrictions not applied", "error", err)
+		return false
+	}
+	return settings.Demo.Restricted
+}
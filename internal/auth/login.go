@@ -1,88 +1,112 @@
 package auth
 
 import (
-	"encoding/json"
 	"net/http"
 	"time"
 
 	"github.com/5tuartw/droplet/internal/config"
 	"github.com/5tuartw/droplet/internal/database"
 	"github.com/5tuartw/droplet/internal/helpers"
+	"github.com/5tuartw/droplet/internal/metrics"
 	"github.com/5tuartw/droplet/internal/models"
 )
 
 func Login(c *config.ApiConfig, dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
 	if dbq == nil {
-		http.Error(w, "Database connection is not initialized", http.StatusInternalServerError)
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Database connection is not initialized", nil)
 		return
 	}
 
 	var requestBody struct {
 		Email    string `json:"email"`
 		Password string `json:"password"`
+		// RememberMe picks the school's long session lifetime; leave it off on shared computers
+		RememberMe bool `json:"remember_me"`
 	}
 
-	decoder := json.NewDecoder(r.Body)
-	defer r.Body.Close()
-	err := decoder.Decode(&requestBody)
-	if err != nil {
-		helpers.RespondWithError(w, http.StatusInternalServerError, "Error decoding json data", err)
+	if !helpers.DecodeJSON(w, r, &requestBody) {
 		return
 	}
 
 	if requestBody.Email == "" {
-		helpers.RespondWithError(w, http.StatusBadRequest, "Email is required", err)
+		helpers.RespondWithError(w, http.StatusBadRequest, "Email is required", nil)
 		return
 	}
 
 	if requestBody.Password == "" {
-		helpers.RespondWithError(w, http.StatusBadRequest, "Password is required", err)
+		helpers.RespondWithError(w, http.StatusBadRequest, "Password is required", nil)
 		return
 	}
 
-	const oneHourInSeconds int64 = 3600
-
 	user, err := dbq.GetUserByEmail(r.Context(), requestBody.Email)
 	if err != nil {
-		helpers.RespondWithError(w, http.StatusUnauthorized, "Unable to find user", err)
+		metrics.Logins.Inc("failure")
+		helpers.RespondWithProblem(w, http.StatusUnauthorized, helpers.CodeInvalidCredentials, "Incorrect email or password", nil)
+		return
+	}
+	// On a school's own subdomain only that school's users may log in
+	if otherTenant(r, user.SchoolID) {
+		metrics.Logins.Inc("failure")
+		helpers.RespondWithProblem(w, http.StatusUnauthorized, helpers.CodeInvalidCredentials, "Incorrect email or password", nil)
 		return
 	}
 	hashedPassword, err := dbq.GetPasswordByEmail(r.Context(), requestBody.Email)
 	if err != nil {
-		helpers.RespondWithError(w, http.StatusUnauthorized, "Unable to find user", err)
+		metrics.Logins.Inc("failure")
+		helpers.RespondWithProblem(w, http.StatusUnauthorized, helpers.CodeInvalidCredentials, "Incorrect email or password", nil)
 		return
 	}
 	err = CheckPasswordHash(requestBody.Password, hashedPassword)
 	if err != nil {
-		helpers.RespondWithError(w, http.StatusUnauthorized, "Incorrect email or password", err)
+		metrics.Logins.Inc("failure")
+		helpers.RespondWithProblem(w, http.StatusUnauthorized, helpers.CodeInvalidCredentials, "Incorrect email or password", nil)
+		return
+	}
+
+	suspended, err := schoolSuspended(r.Context(), dbq, user.SchoolID)
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not check school status", err)
+		return
+	}
+	if suspended {
+		metrics.Logins.Inc("failure")
+		helpers.RespondWithProblem(w, http.StatusForbidden, helpers.CodeSchoolSuspended, "This school's account is suspended", nil)
 		return
 	}
 
-	token, err := MakeJWT(user.ID, user.SchoolID, string(user.Role), c.JWTSecret, time.Duration(oneHourInSeconds)*time.Second)
+	policy := loadSessionPolicy(r.Context(), c, dbq, user.SchoolID)
+	now := time.Now()
+	sessionExpiresAt := now.Add(policy.absolute)
+
+	token, err := MakeJWT(user.ID, user.SchoolID, string(user.Role), c.JWTSecret, policy.accessTTLAt(now, sessionExpiresAt))
 	if err != nil {
-		helpers.RespondWithError(w, http.StatusBadRequest, "could not create access token", err)
+		helpers.RespondWithError(w, http.StatusInternalServerError, "could not create access token", err)
 		return
 	}
 
 	refreshToken, err := MakeRefreshToken()
 	if err != nil {
-		helpers.RespondWithError(w, http.StatusBadRequest, "could not create refresh token", err)
+		helpers.RespondWithError(w, http.StatusInternalServerError, "could not create refresh token", err)
 		return
 	}
-	sixtyDaysInSeconds := 60 * 60 * 24 * 60
-	expiry := time.Now().Add(time.Duration(sixtyDaysInSeconds) * time.Second)
 	rToken, err := dbq.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
-		Token:     refreshToken,
-		UserID:    user.ID,
-		SchoolID:  user.SchoolID,
-		Role:      user.Role,
-		ExpiresAt: expiry,
+		Token:            refreshToken,
+		UserID:           user.ID,
+		SchoolID:         user.SchoolID,
+		Role:             user.Role,
+		ExpiresAt:        policy.refreshExpiry(now, sessionExpiresAt, requestBody.RememberMe),
+		RememberMe:       requestBody.RememberMe,
+		LastUsedAt:       now,
+		SessionExpiresAt: sessionExpiresAt,
 	})
 	if err != nil {
 		helpers.RespondWithError(w, http.StatusInternalServerError, "could not add new token to database", err)
 		return
 	}
 
+	metrics.RefreshTokensIssued.Inc()
+	metrics.Logins.Inc("success")
+
 	thisRToken := rToken.Token
 
 	userData := models.TokenUser{
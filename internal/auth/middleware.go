@@ -2,12 +2,19 @@ package auth
 
 import (
 	"context"
-	"log"
+	"database/sql"
+	"errors"
+	"log/slog"
 	"net/http"
-	"strings"
+	"strconv"
 
 	"github.com/5tuartw/droplet/internal/config"
+	"github.com/5tuartw/droplet/internal/database"
 	"github.com/5tuartw/droplet/internal/helpers"
+	"github.com/5tuartw/droplet/internal/logging"
+	"github.com/5tuartw/droplet/internal/tenant"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 func RequireAuth(cfg *config.ApiConfig, next http.HandlerFunc) http.HandlerFunc {
@@ -15,24 +22,30 @@ func RequireAuth(cfg *config.ApiConfig, next http.HandlerFunc) http.HandlerFunc
 	return func(w http.ResponseWriter, r *http.Request) {
 		tokenString, err := GetBearerToken(r.Header)
 		if err != nil {
-			log.Printf("Auth Error: %v\n", err)
-			// Consistently return 401 error
-			// You might want to use your helpers.RespondWithError if it sets JSON content type
-			// helpers.RespondWithError(w, http.StatusUnauthorized, "Unauthorized: Missing or malformed token", err)
-			http.Error(w, "Unauthorized: Missing or malformed token", http.StatusUnauthorized)
+			slog.InfoContext(r.Context(), "authentication failed", "reason", "missing or malformed token")
+			helpers.RespondWithProblem(w, http.StatusUnauthorized, helpers.CodeTokenMissing, "Unauthorized: Missing or malformed token", nil)
 			return
 		}
 
 		userID, schoolID, userRole, err := ValidateJWT(tokenString, cfg.JWTSecret)
 		if err != nil {
-			log.Printf("Auth Error: Invalid token - %v\n", err)
-			// Consistently return 401 error
-			// helpers.RespondWithError(w, http.StatusUnauthorized, "Unauthorized: Invalid or expired token", err)
-			http.Error(w, "Unauthorized: Invalid or expired token", http.StatusUnauthorized)
+			// Expired tokens get their own code so clients know to refresh rather than log in again
+			if errors.Is(err, jwt.ErrTokenExpired) {
+				slog.InfoContext(r.Context(), "authentication failed", "reason", "expired token")
+				helpers.RespondWithProblem(w, http.StatusUnauthorized, helpers.CodeTokenExpired, "Unauthorized: Token has expired", nil)
+				return
+			}
+			slog.InfoContext(r.Context(), "authentication failed", "reason", "invalid token", "error", err)
+			helpers.RespondWithProblem(w, http.StatusUnauthorized, helpers.CodeTokenInvalid, "Unauthorized: Invalid token", nil)
 			return
 		}
 
-		//log.Printf("User %s authenticated successfully.\n", userID)
+		// A token from one school is no good on another school's subdomain
+		if otherTenant(r, schoolID) {
+			slog.InfoContext(r.Context(), "authentication failed", "reason", "token for another school")
+			helpers.RespondWithProblem(w, http.StatusUnauthorized, helpers.CodeTokenInvalid, "Unauthorized: Invalid token", nil)
+			return
+		}
 
 		//To pass on userID, create a new context with the userID value
 		ctx := context.WithValue(r.Context(), UserIDKey, userID)
@@ -40,22 +53,85 @@ func RequireAuth(cfg *config.ApiConfig, next http.HandlerFunc) http.HandlerFunc
 		ctx = context.WithValue(ctx, UserSchoolKey, schoolID)
 		//Create a new request objedefct with the updated context
 		r = r.WithContext(ctx)
+		logging.SetUser(ctx, userID, schoolID, userRole)
 
 		next.ServeHTTP(w, r)
 	}
 }
 
-func RequireAdmin(cfg *config.ApiConfig, next http.HandlerFunc) http.HandlerFunc {
+// otherTenant reports whether the request was sent to the subdomain of a school other than schoolID
+func otherTenant(r *http.Request, schoolID uuid.UUID) bool {
+	tenantSchoolID, ok := tenant.FromContext(r.Context())
+	return ok && tenantSchoolID != schoolID
+}
+
+// RequirePermission only lets the request through if the user's role grants perm.
+// For scoped grants (own/targets) the resource named in the path ({dropID} or {pupilID}) is checked
+// against the user's scope; routes without one are left to the handler to check.
+func RequirePermission(cfg *config.ApiConfig, dbq *database.Queries, perm Permission, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		role, ok := r.Context().Value(UserRoleKey).(string) // Use your actual role context key
+		userID, idOk := r.Context().Value(UserIDKey).(uuid.UUID)
+		schoolID, schoolOk := r.Context().Value(UserSchoolKey).(uuid.UUID)
+		role, roleOk := r.Context().Value(UserRoleKey).(string)
+		if !idOk || !schoolOk || !roleOk {
+			slog.ErrorContext(r.Context(), "user not found in request context")
+			helpers.RespondWithError(w, http.StatusInternalServerError, "Internal Server Error (context error)", nil)
+			return
+		}
 
-		// Use case-insensitive compare for robustness
-		if !ok || !strings.EqualFold(role, "Admin") {
-			helpers.RespondWithError(w, http.StatusForbidden, "Forbidden: Admin access required", nil)
-			return // Stop processing if not admin
+		scope := ScopeFor(role, perm)
+		if scope == ScopeNone {
+			slog.InfoContext(r.Context(), "authorization failed", "reason", "lacks permission", "permission", perm)
+			helpers.RespondWithError(w, http.StatusForbidden, "Forbidden: insufficient permissions", nil)
+			return
+		}
+
+		if scope != ScopeSchool {
+			allowed, err := resourceInScope(r, dbq, perm, userID, schoolID, role)
+			if err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					helpers.RespondWithError(w, http.StatusNotFound, "Resource not found", nil)
+				} else if errors.Is(err, errBadResourceID) {
+					helpers.RespondWithError(w, http.StatusBadRequest, "Invalid resource id", err)
+				} else {
+					slog.ErrorContext(r.Context(), "could not check permission scope", "permission", perm, "error", err)
+					helpers.RespondWithError(w, http.StatusInternalServerError, "Could not check permissions", err)
+				}
+				return
+			}
+			if !allowed {
+				slog.InfoContext(r.Context(), "authorization failed", "reason", "outside scope", "permission", perm)
+				helpers.RespondWithProblem(w, http.StatusForbidden, helpers.CodeOutOfScope, "Forbidden: resource is outside your scope", nil)
+				return
+			}
 		}
 
-		// If role is admin, call the next handler in the chain
 		next.ServeHTTP(w, r)
 	}
 }
+
+var errBadResourceID = errors.New("invalid resource id in path")
+
+func resourceInScope(r *http.Request, dbq *database.Queries, perm Permission, userID, schoolID uuid.UUID, role string) (bool, error) {
+	switch perm {
+	case PermDropsManage:
+		if r.PathValue("dropID") == "" {
+			return true, nil
+		}
+		dropID, err := uuid.Parse(r.PathValue("dropID"))
+		if err != nil {
+			return false, errBadResourceID
+		}
+		return CanManageDrop(r.Context(), dbq, userID, schoolID, role, dropID)
+	case PermPupilsManage:
+		if r.PathValue("pupilID") == "" {
+			return true, nil
+		}
+		pupilID, err := strconv.Atoi(r.PathValue("pupilID"))
+		if err != nil {
+			return false, errBadResourceID
+		}
+		return CanManagePupil(r.Context(), dbq, userID, schoolID, role, int32(pupilID))
+	}
+	return true, nil
+}
@@ -4,19 +4,18 @@ import (
 	"errors"
 	"fmt"
 	"unicode"
-)
 
-const (
-	minPasswordLength = 8
-	maxPasswordLength = 72 // = max for bcrypt
+	"github.com/5tuartw/droplet/internal/schoolsettings"
 )
 
-func ValidatePassword(password string) error {
-	if len(password) < minPasswordLength {
-		return fmt.Errorf("password must be at least %d characters long", minPasswordLength)
+// ValidatePassword checks a new password against a school's password policy
+func ValidatePassword(password string, policy schoolsettings.PasswordPolicy) error {
+	minLength := max(policy.MinLength, schoolsettings.MinPasswordLength)
+	if len(password) < minLength {
+		return fmt.Errorf("password must be at least %d characters long", minLength)
 	}
-	if len(password) > maxPasswordLength {
-		return fmt.Errorf("password must be no more than %d characters long", maxPasswordLength)
+	if len(password) > schoolsettings.MaxPasswordLength {
+		return fmt.Errorf("password must be no more than %d characters long", schoolsettings.MaxPasswordLength)
 	}
 
 	var (
@@ -36,13 +35,13 @@ func ValidatePassword(password string) error {
 		}
 	}
 
-	if !hasUpper {
+	if policy.RequireUpper && !hasUpper {
 		return errors.New("password must contain at least one uppercase letter")
 	}
-	if !hasLower {
+	if policy.RequireLower && !hasLower {
 		return errors.New("password must contain at least one lowercase letter")
 	}
-	if !hasNumber {
+	if policy.RequireNumber && !hasNumber {
 		return errors.New("password must contain at least one number")
 	}
 
@@ -0,0 +1,180 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/google/uuid"
+)
+
+// Permission names an action a role may be granted
+type Permission string
+
+const (
+	PermDropsCreate      Permission = "drops.create"       // post new drops
+	PermDropsManage      Permission = "drops.manage"       // edit, delete and retarget existing drops
+	PermDropsPublishWide Permission = "drops.publish_wide" // post General or Division drops without approval
+	PermDropsApprove     Permission = "drops.approve"      // review the pending drops queue
+	PermPupilsManage     Permission = "pupils.manage"      // add, edit and remove pupils
+	PermUsersManage      Permission = "users.manage"       // create staff accounts, reset passwords, change roles
+	PermStructureView    Permission = "structure.view"     // view the full school structure
+	PermStructureManage  Permission = "structure.manage"   // add, rename, move and delete divisions, year groups and classes
+	PermSchoolManage     Permission = "school.manage"      // change school-wide policies
+	PermTagsManage       Permission = "tags.manage"        // add, rename and delete the school's drop tags
+
+	// PermPlatformManage reaches across schools, so it is deliberately left out of allPermissions
+	PermPlatformManage Permission = "platform.manage" // create, suspend and reactivate schools
+)
+
+// Scope limits how far a granted permission reaches
+type Scope int
+
+const (
+	ScopeNone    Scope = iota // permission not granted
+	ScopeOwn                  // only resources the user authored
+	ScopeTargets              // resources inside the user's role_scopes, plus their own
+	ScopeSchool               // any resource in the user's school
+)
+
+var allPermissions = []Permission{
+	PermDropsCreate,
+	PermDropsManage,
+	PermDropsPublishWide,
+	PermDropsApprove,
+	PermPupilsManage,
+	PermUsersManage,
+	PermStructureView,
+	PermStructureManage,
+	PermSchoolManage,
+	PermTagsManage,
+}
+
+// rolePermissions maps each role to the permissions it grants and how far each reaches.
+// Anything not listed is ScopeNone.
+var rolePermissions = map[database.UserRole]map[Permission]Scope{
+	database.UserRoleAdmin: grantAll(ScopeSchool),
+	database.UserRoleHeadOfYear: {
+		PermDropsCreate:      ScopeSchool,
+		PermDropsManage:      ScopeTargets,
+		PermDropsPublishWide: ScopeSchool,
+		PermDropsApprove:     ScopeSchool,
+		PermPupilsManage:     ScopeTargets,
+		PermStructureView:    ScopeSchool,
+	},
+	database.UserRoleOffice: {
+		PermPupilsManage:  ScopeSchool,
+		PermStructureView: ScopeSchool,
+	},
+	database.UserRoleUser: {
+		PermDropsCreate:      ScopeSchool,
+		PermDropsManage:      ScopeOwn,
+		PermDropsPublishWide: ScopeSchool,
+	},
+	// Trainees and cover staff: as user, but school-wide drops may need sign-off (see the drops.require_approval school setting)
+	database.UserRoleTrainee: {
+		PermDropsCreate: ScopeSchool,
+		PermDropsManage: ScopeOwn,
+	},
+	database.UserRoleReadOnly: {},
+	// Platform operators: provisioning only, via /api/platform. They see nothing inside a school.
+	database.UserRolePlatformAdmin: {
+		PermPlatformManage: ScopeSchool,
+	},
+}
+
+func grantAll(scope Scope) map[Permission]Scope {
+	grants := make(map[Permission]Scope, len(allPermissions))
+	for _, perm := range allPermissions {
+		grants[perm] = scope
+	}
+	return grants
+}
+
+// IsValidRole reports whether role is one of the roles a school admin may assign.
+// The platform admin role is known to the permissions model but can't be handed out from inside a school.
+func IsValidRole(role string) bool {
+	if database.UserRole(role) == database.UserRolePlatformAdmin {
+		return false
+	}
+	_, ok := rolePermissions[database.UserRole(role)]
+	return ok
+}
+
+// ScopeFor returns how far perm reaches for the given role
+func ScopeFor(role string, perm Permission) Scope {
+	grants, ok := rolePermissions[database.UserRole(role)]
+	if !ok {
+		return ScopeNone
+	}
+	return grants[perm]
+}
+
+// HasPermission reports whether role is granted perm at any scope
+func HasPermission(role string, perm Permission) bool {
+	return ScopeFor(role, perm) != ScopeNone
+}
+
+// CanManageDrop checks PermDropsManage against a specific drop, taking the caller's scope into account.
+// It returns sql.ErrNoRows (wrapped) if the drop does not exist in the school.
+func CanManageDrop(ctx context.Context, dbq *database.Queries, userID, schoolID uuid.UUID, role string, dropID uuid.UUID) (bool, error) {
+	scope := ScopeFor(role, PermDropsManage)
+	if scope == ScopeNone {
+		return false, nil
+	}
+
+	authorID, err := dbq.GetUserIdFromDropID(ctx, database.GetUserIdFromDropIDParams{
+		ID:       dropID,
+		SchoolID: schoolID,
+	})
+	if err != nil {
+		return false, fmt.Errorf("could not look up drop author: %w", err)
+	}
+
+	switch scope {
+	case ScopeSchool:
+		return true, nil
+	case ScopeOwn:
+		return authorID == userID, nil
+	case ScopeTargets:
+		if authorID == userID {
+			return true, nil
+		}
+		return dbq.IsDropInUserScope(ctx, database.IsDropInUserScopeParams{
+			UserID:   userID,
+			SchoolID: schoolID,
+			DropID:   dropID,
+		})
+	}
+	return false, nil
+}
+
+// CanManagePupil checks PermPupilsManage against an existing pupil
+func CanManagePupil(ctx context.Context, dbq *database.Queries, userID, schoolID uuid.UUID, role string, pupilID int32) (bool, error) {
+	switch ScopeFor(role, PermPupilsManage) {
+	case ScopeSchool:
+		return true, nil
+	case ScopeTargets:
+		return dbq.IsPupilInUserScope(ctx, database.IsPupilInUserScopeParams{
+			UserID:   userID,
+			SchoolID: schoolID,
+			PupilID:  pupilID,
+		})
+	}
+	return false, nil
+}
+
+// CanManageClassPupils checks PermPupilsManage against a class, used when placing a pupil into it
+func CanManageClassPupils(ctx context.Context, dbq *database.Queries, userID, schoolID uuid.UUID, role string, classID int32) (bool, error) {
+	switch ScopeFor(role, PermPupilsManage) {
+	case ScopeSchool:
+		return true, nil
+	case ScopeTargets:
+		return dbq.IsClassInUserScope(ctx, database.IsClassInUserScopeParams{
+			UserID:   userID,
+			SchoolID: schoolID,
+			ClassID:  classID,
+		})
+	}
+	return false, nil
+}
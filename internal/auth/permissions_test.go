@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/5tuartw/droplet/internal/config"
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopeFor(t *testing.T) {
+	// Everything not listed for a role must be ScopeNone
+	want := map[database.UserRole]map[Permission]Scope{
+		database.UserRoleAdmin: {
+			PermDropsCreate:      ScopeSchool,
+			PermDropsManage:      ScopeSchool,
+			PermDropsPublishWide: ScopeSchool,
+			PermDropsApprove:     ScopeSchool,
+			PermPupilsManage:     ScopeSchool,
+			PermUsersManage:      ScopeSchool,
+			PermStructureView:    ScopeSchool,
+			PermStructureManage:  ScopeSchool,
+			PermSchoolManage:     ScopeSchool,
+			PermTagsManage:       ScopeSchool,
+		},
+		database.UserRoleHeadOfYear: {
+			PermDropsCreate:      ScopeSchool,
+			PermDropsManage:      ScopeTargets,
+			PermDropsPublishWide: ScopeSchool,
+			PermDropsApprove:     ScopeSchool,
+			PermPupilsManage:     ScopeTargets,
+			PermStructureView:    ScopeSchool,
+		},
+		database.UserRoleOffice: {
+			PermPupilsManage:  ScopeSchool,
+			PermStructureView: ScopeSchool,
+		},
+		database.UserRoleUser: {
+			PermDropsCreate:      ScopeSchool,
+			PermDropsManage:      ScopeOwn,
+			PermDropsPublishWide: ScopeSchool,
+		},
+		database.UserRoleTrainee: {
+			PermDropsCreate: ScopeSchool,
+			PermDropsManage: ScopeOwn,
+		},
+		database.UserRoleReadOnly: {},
+		database.UserRolePlatformAdmin: {
+			PermPlatformManage: ScopeSchool,
+		},
+		"no_such_role": {},
+	}
+
+	perms := append(append([]Permission{}, allPermissions...), PermPlatformManage)
+	for role, grants := range want {
+		for _, perm := range perms {
+			require.Equal(t, grants[perm], ScopeFor(string(role), perm), "%s: %s", role, perm)
+			require.Equal(t, grants[perm] != ScopeNone, HasPermission(string(role), perm), "%s: %s", role, perm)
+		}
+	}
+}
+
+func TestIsValidRole(t *testing.T) {
+	for _, role := range []string{"admin", "head_of_year", "office", "user", "trainee", "read_only"} {
+		require.True(t, IsValidRole(role), role)
+	}
+	require.False(t, IsValidRole("platform_admin"), "platform admins can't be made from inside a school")
+	require.False(t, IsValidRole("superuser"))
+	require.False(t, IsValidRole(""))
+}
+
+func TestRequirePermission(t *testing.T) {
+	cfg := &config.ApiConfig{}
+	userID, schoolID := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name       string
+		role       string
+		perm       Permission
+		dropID     string
+		wantStatus int
+	}{
+		{"school-wide grant", "admin", PermUsersManage, "", http.StatusOK},
+		{"not granted", "read_only", PermDropsCreate, "", http.StatusForbidden},
+		{"office can't manage users", "office", PermUsersManage, "", http.StatusForbidden},
+		{"platform admin sees nothing in a school", "platform_admin", PermStructureView, "", http.StatusForbidden},
+		{"scoped grant without a resource in the path", "head_of_year", PermDropsManage, "", http.StatusOK},
+		{"scoped grant with a bad resource id", "trainee", PermDropsManage, "not-a-uuid", http.StatusBadRequest},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// Only the out-of-scope checks reach the database, and none of these cases get that far
+			handler := RequirePermission(cfg, nil, tc.perm, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPut, "/", nil)
+			if tc.dropID != "" {
+				req.SetPathValue("dropID", tc.dropID)
+			}
+			ctx := context.WithValue(req.Context(), UserIDKey, userID)
+			ctx = context.WithValue(ctx, UserSchoolKey, schoolID)
+			ctx = context.WithValue(ctx, UserRoleKey, tc.role)
+			rr := httptest.NewRecorder()
+			handler(rr, req.WithContext(ctx))
+			require.Equal(t, tc.wantStatus, rr.Code, rr.Body.String())
+		})
+	}
+
+	// Without the user in the context the request never got through RequireAuth
+	rr := httptest.NewRecorder()
+	RequirePermission(cfg, nil, PermDropsCreate, nil)(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusInternalServerError, rr.Code)
+}
+
+func TestCanManageDropWithoutPermission(t *testing.T) {
+	allowed, err := CanManageDrop(context.Background(), nil, uuid.New(), uuid.New(), "read_only", uuid.New())
+	require.NoError(t, err)
+	require.False(t, allowed)
+}
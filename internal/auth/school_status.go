@@ -0,0 +1,23 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/google/uuid"
+)
+
+// Values of schools.status
+const (
+	SchoolStatusActive    = "active"
+	SchoolStatusSuspended = "suspended"
+)
+
+// schoolSuspended reports whether the platform has suspended a school, which blocks new logins and token refreshes
+func schoolSuspended(ctx context.Context, dbq *database.Queries, schoolID uuid.UUID) (bool, error) {
+	status, err := dbq.GetSchoolStatus(ctx, schoolID)
+	if err != nil {
+		return false, err
+	}
+	return status == SchoolStatusSuspended, nil
+}
@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/5tuartw/droplet/internal/config"
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/schoolsettings"
+	"github.com/google/uuid"
+)
+
+// sessionPolicy is a school's SessionPolicy with the server defaults filled in
+type sessionPolicy struct {
+	accessTTL   time.Duration
+	sessionTTL  time.Duration // without remember me
+	rememberTTL time.Duration // with remember me
+	absolute    time.Duration
+	idle        time.Duration // 0 for no idle timeout
+}
+
+func resolveSessionPolicy(c *config.ApiConfig, p schoolsettings.SessionPolicy) sessionPolicy {
+	policy := sessionPolicy{
+		accessTTL:   c.AccessTokenTTL,
+		sessionTTL:  time.Duration(p.SessionHours) * time.Hour,
+		rememberTTL: c.RefreshTokenTTL,
+		absolute:    time.Duration(p.AbsoluteLifetimeDays) * 24 * time.Hour,
+		idle:        time.Duration(p.IdleTimeoutMinutes) * time.Minute,
+	}
+	if p.AccessTokenMinutes > 0 {
+		policy.accessTTL = time.Duration(p.AccessTokenMinutes) * time.Minute
+	}
+	if p.RememberMeDays > 0 {
+		policy.rememberTTL = time.Duration(p.RememberMeDays) * 24 * time.Hour
+	}
+	if policy.idle > 0 && policy.idle < policy.accessTTL {
+		policy.idle = policy.accessTTL
+	}
+	return policy
+}
+
+// loadSessionPolicy reads the school's policy, falling back to the defaults if its settings can't be read
+func loadSessionPolicy(ctx context.Context, c *config.ApiConfig, dbq *database.Queries, schoolID uuid.UUID) sessionPolicy {
+	settings, err := schoolsettings.Load(ctx, dbq, schoolID)
+	if err != nil {
+		slog.WarnContext(ctx, "could not load school settings, using the default session policy", "school_id", schoolID, "error", err)
+	}
+	return resolveSessionPolicy(c, settings.Sessions)
+}
+
+func (p sessionPolicy) lifetime(rememberMe bool) time.Duration {
+	if rememberMe {
+		return p.rememberTTL
+	}
+	return p.sessionTTL
+}
+
+// refreshExpiry is when a session refreshed at now expires, never beyond its absolute end
+func (p sessionPolicy) refreshExpiry(now, sessionExpiresAt time.Time, rememberMe bool) time.Time {
+	expiry := now.Add(p.lifetime(rememberMe))
+	if expiry.After(sessionExpiresAt) {
+		return sessionExpiresAt
+	}
+	return expiry
+}
+
+// accessTTL is how long an access token issued at now lasts, never beyond the session's absolute end
+func (p sessionPolicy) accessTTLAt(now, sessionExpiresAt time.Time) time.Duration {
+	if remaining := sessionExpiresAt.Sub(now); remaining < p.accessTTL {
+		return remaining
+	}
+	return p.accessTTL
+}
+
+// idleExpired reports whether a session has gone unused for longer than the idle timeout.
+// Remembered sessions are on the user's own device, so the idle timeout doesn't apply to them.
+func (p sessionPolicy) idleExpired(now, lastUsedAt time.Time, rememberMe bool) bool {
+	return !rememberMe && p.idle > 0 && now.Sub(lastUsedAt) > p.idle
+}
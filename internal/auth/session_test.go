@@ -0,0 +1,40 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/5tuartw/droplet/internal/config"
+	"github.com/5tuartw/droplet/internal/schoolsettings"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionPolicy(t *testing.T) {
+	cfg := &config.ApiConfig{AccessTokenTTL: time.Hour, RefreshTokenTTL: 60 * 24 * time.Hour}
+	now := time.Date(2025, 9, 1, 8, 0, 0, 0, time.UTC)
+
+	policy := resolveSessionPolicy(cfg, schoolsettings.Default().Sessions)
+	require.Equal(t, time.Hour, policy.accessTTL, "server default")
+	sessionEnd := now.Add(policy.absolute)
+	require.Equal(t, now.Add(12*time.Hour), policy.refreshExpiry(now, sessionEnd, false))
+	require.Equal(t, now.Add(60*24*time.Hour), policy.refreshExpiry(now, sessionEnd, true))
+	require.False(t, policy.idleExpired(now, now.Add(-48*time.Hour), false), "no idle timeout by default")
+
+	// A staffroom-style policy
+	policy = resolveSessionPolicy(cfg, schoolsettings.SessionPolicy{
+		AccessTokenMinutes:   15,
+		SessionHours:         8,
+		RememberMeDays:       120,
+		AbsoluteLifetimeDays: 30,
+		IdleTimeoutMinutes:   10,
+	})
+	require.Equal(t, 15*time.Minute, policy.idle, "idle timeout is never shorter than the access token")
+	require.True(t, policy.idleExpired(now, now.Add(-20*time.Minute), false))
+	require.False(t, policy.idleExpired(now, now.Add(-20*time.Minute), true), "remembered sessions don't idle out")
+
+	sessionEnd = now.Add(policy.absolute)
+	require.Equal(t, sessionEnd, policy.refreshExpiry(now, sessionEnd, true), "remember me can't outlast the absolute lifetime")
+	later := sessionEnd.Add(-5 * time.Minute)
+	require.Equal(t, sessionEnd, policy.refreshExpiry(later, sessionEnd, false))
+	require.Equal(t, 5*time.Minute, policy.accessTTLAt(later, sessionEnd), "access tokens end with the session")
+}
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"log/slog"
 	"net/http"
 	"time"
 
@@ -16,32 +17,68 @@ func Refresh(c *config.ApiConfig, dbq *database.Queries, w http.ResponseWriter,
 
 	token, err := GetBearerToken(r.Header)
 	if err != nil {
-		helpers.RespondWithError(w, 401, "Unauthorized, cannot get token", err)
+		helpers.RespondWithProblem(w, http.StatusUnauthorized, helpers.CodeTokenMissing, "Unauthorized, cannot get token", nil)
 		return
 	}
 
 	rToken, err := dbq.GetRefreshToken(r.Context(), token)
 	if err != nil {
-		helpers.RespondWithError(w, 401, "No valid token found", err)
+		helpers.RespondWithProblem(w, http.StatusUnauthorized, helpers.CodeTokenInvalid, "No valid token found", nil)
+		return
+	}
+	// Sessions only refresh on their own school's subdomain (or the bare domain)
+	if otherTenant(r, rToken.SchoolID) {
+		helpers.RespondWithProblem(w, http.StatusUnauthorized, helpers.CodeTokenInvalid, "No valid token found", nil)
 		return
 	}
 
 	// Check if token is expired
 	if time.Now().After(rToken.ExpiresAt) {
-		helpers.RespondWithError(w, 401, "Token expired", err)
+		helpers.RespondWithProblem(w, http.StatusUnauthorized, helpers.CodeSessionExpired, "Token expired", nil)
 		return
 	}
 
 	// Check if token is revoked
 	if rToken.RevokedAt.Valid {
-		helpers.RespondWithError(w, 401, "Token revoked", err)
+		helpers.RespondWithProblem(w, http.StatusUnauthorized, helpers.CodeSessionRevoked, "Token revoked", nil)
+		return
+	}
+
+	suspended, err := schoolSuspended(r.Context(), dbq, rToken.SchoolID)
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not check school status", err)
+		return
+	}
+	if suspended {
+		helpers.RespondWithProblem(w, http.StatusForbidden, helpers.CodeSchoolSuspended, "This school's account is suspended", nil)
+		return
+	}
+
+	// The school's current policy applies, so tightening it takes effect at each session's next refresh
+	policy := loadSessionPolicy(r.Context(), c, dbq, rToken.SchoolID)
+	now := time.Now()
+	if policy.idleExpired(now, rToken.LastUsedAt, rToken.RememberMe) {
+		if err := dbq.RevokeToken(r.Context(), token); err != nil {
+			slog.ErrorContext(r.Context(), "could not revoke idle session", "error", err)
+		}
+		helpers.RespondWithProblem(w, http.StatusUnauthorized, helpers.CodeSessionTimedOut, "Session timed out", nil)
+		return
+	}
+
+	accessToken, err := MakeJWT(rToken.UserID, rToken.SchoolID, string(rToken.Role), c.JWTSecret, policy.accessTTLAt(now, rToken.SessionExpiresAt))
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "could not create access token", err)
 		return
 	}
 
-	const oneHourInSeconds int64 = 3600
-	accessToken, err := MakeJWT(rToken.UserID, rToken.SchoolID, string(rToken.Role), c.JWTSecret, time.Duration(oneHourInSeconds)*time.Second)
+	// Slide the session forward
+	err = dbq.TouchRefreshToken(r.Context(), database.TouchRefreshTokenParams{
+		Token:      token,
+		LastUsedAt: now,
+		ExpiresAt:  policy.refreshExpiry(now, rToken.SessionExpiresAt, rToken.RememberMe),
+	})
 	if err != nil {
-		helpers.RespondWithError(w, http.StatusBadRequest, "could not create access token", err)
+		helpers.RespondWithError(w, http.StatusInternalServerError, "could not extend session", err)
 		return
 	}
 
@@ -52,20 +89,20 @@ func Refresh(c *config.ApiConfig, dbq *database.Queries, w http.ResponseWriter,
 func Revoke(c *config.ApiConfig, dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
 	token, err := GetBearerToken(r.Header)
 	if err != nil {
-		helpers.RespondWithError(w, 401, "Unauthorized, cannot get token", err)
+		helpers.RespondWithProblem(w, http.StatusUnauthorized, helpers.CodeTokenMissing, "Unauthorized, cannot get token", nil)
 		return
 	}
 
 	// Check if the token exists first
 	rToken, err := dbq.GetRefreshToken(r.Context(), token)
 	if err != nil {
-		helpers.RespondWithError(w, 401, "No valid token found", err)
+		helpers.RespondWithProblem(w, http.StatusUnauthorized, helpers.CodeTokenInvalid, "No valid token found", nil)
 		return
 	}
 
 	// Check if token is already revoked
 	if rToken.RevokedAt.Valid {
-		helpers.RespondWithError(w, 400, "Token already revoked", err)
+		helpers.RespondWithProblem(w, http.StatusBadRequest, helpers.CodeSessionRevoked, "Token already revoked", nil)
 		return
 	}
 
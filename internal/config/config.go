@@ -1,69 +1,126 @@
 package config
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"log"
-	"os"
+	"time"
 
-	"github.com/5tuartw/droplet/internal/database"
 	"github.com/5tuartw/droplet/internal/models"
-	"github.com/joho/godotenv"
+	"github.com/5tuartw/droplet/internal/notify"
+	"github.com/5tuartw/droplet/internal/storage"
 	_ "github.com/lib/pq"
 )
 
+// ApiConfig is what handlers need at runtime: settings from Config plus the services built from them
 type ApiConfig struct {
 	JWTSecret   string
 	DevMode     bool
 	DevModeUser *models.User
 	Port        string
 	IsDemoMode  bool
+	Notifier    *notify.Dispatcher // channels for pushing urgent drops; nil sends nothing
+
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	Attachments        storage.Store // where drop attachments are kept; nil disables uploads
+	MaxAttachmentBytes int64
+
+	BaseDomain string // schools are served from <subdomain>.BaseDomain; empty disables tenant lookup by host
+
+	// /metrics is served on MetricsAddr if set, otherwise on the main port if MetricsToken is set, otherwise not at all.
+	// When MetricsToken is set, scrapers must send it as a bearer token.
+	MetricsAddr  string
+	MetricsToken string
 }
 
-func LoadConfig() (*ApiConfig, *database.Queries, *sql.DB) {
-	err := godotenv.Load()
+// NewApiConfig builds the runtime config from validated settings, setting up attachment storage and notification channels
+func NewApiConfig(c *Config) (*ApiConfig, error) {
+	attachmentStore, err := newAttachmentStore(c.Storage)
 	if err != nil {
-		log.Printf("Info: No .env file found or error loading: %v. Relying on system environment variables.", err)
+		return nil, err
 	}
 
-	dbURL := os.Getenv("DATABASE_URL")
-	if dbURL == "" {
-		log.Fatal("FATAL: DATABASE_URL environment variable is required but not set.")
-	}
-	db, err := sql.Open("postgres", dbURL)
-	if err != nil {
-		log.Fatalf("FATAL: Error opening database connection (driver/dsn issue): %v", err)
+	var channels []notify.Channel
+	if c.Notifications.UrgentWebhookURL != "" {
+		channels = append(channels, notify.NewWebhookChannel(c.Notifications.UrgentWebhookURL))
 	}
+	notifier := notify.NewDispatcher(channels...)
 
-	err = db.Ping() // verifies a connection can be made
-	if err != nil {
-		log.Fatalf("FATAL: Could not ping database. Check connection string/DB status: %v", err)
+	log.Printf("Demo mode active: %t", c.Features.DemoMode)
+	log.Printf("Urgent drop notification channels: %v", notifier.Channels())
+	if c.Server.BaseDomain != "" {
+		log.Printf("Resolving schools from subdomains of %s", c.Server.BaseDomain)
 	}
-	log.Println("Database connection successful.")
 
-	dbQueries := database.New(db)
+	return &ApiConfig{
+		JWTSecret:  c.Auth.JWTSecret,
+		DevMode:    c.Features.DevMode,
+		Port:       c.Server.Port,
+		IsDemoMode: c.Features.DemoMode,
+		Notifier:   notifier,
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		// CRITICAL: Fail fast if JWT Secret isn't set.
-		log.Fatal("FATAL: JWT_SECRET environment variable is required but not set.")
-	}
+		AccessTokenTTL:  c.Auth.AccessTokenTTL,
+		RefreshTokenTTL: c.Auth.RefreshTokenTTL,
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080" // Default if not set
-		log.Println("Info: PORT environment variable not set, defaulting to 8080")
-	}
+		Attachments:        attachmentStore,
+		MaxAttachmentBytes: c.Storage.MaxAttachmentMB << 20,
 
-	isDemo := os.Getenv("DEMO_MODE") == "true"
-	log.Printf("Demo mode active: %t", isDemo)
+		BaseDomain: c.Server.BaseDomain,
 
-	cfg := ApiConfig{
-		JWTSecret:   jwtSecret,
-		DevMode:     os.Getenv("PLATFORM") == "DEV",
-		DevModeUser: nil,
-		Port:        port,
-		IsDemoMode:  isDemo,
+		MetricsAddr:  c.Server.MetricsAddr,
+		MetricsToken: c.Server.MetricsToken,
+	}, nil
+}
+
+// OpenDatabase connects with the configured pool limits and checks the database answers
+func OpenDatabase(c DatabaseConfig) (*sql.DB, error) {
+	db, err := sql.Open("postgres", c.URL)
+	if err != nil {
+		return nil, fmt.Errorf("error opening database connection (driver/dsn issue): %w", err)
+	}
+	db.SetMaxOpenConns(c.MaxOpenConns)
+	db.SetMaxIdleConns(c.MaxIdleConns)
+	db.SetConnMaxLifetime(c.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(c.ConnMaxIdleTime)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("could not ping database, check connection string/DB status: %w", err)
 	}
+	log.Println("Database connection successful.")
+	return db, nil
+}
 
-	return &cfg, dbQueries, db
+// newAttachmentStore sets up the configured attachment backend (local, s3 or none)
+func newAttachmentStore(c StorageConfig) (storage.Store, error) {
+	var store storage.Store
+	var err error
+	switch c.Backend {
+	case "none":
+		log.Println("Info: ATTACHMENT_STORAGE=none, drop attachments are disabled")
+		return nil, nil
+	case "local":
+		store, err = storage.NewLocalStore(c.Dir)
+	case "s3":
+		store, err = storage.NewS3Store(storage.S3Config{
+			Endpoint:  c.S3.Endpoint,
+			AccessKey: c.S3.AccessKey,
+			SecretKey: c.S3.SecretKey,
+			Bucket:    c.S3.Bucket,
+			Region:    c.S3.Region,
+			UseSSL:    c.S3.UseSSL,
+		})
+	default:
+		return nil, fmt.Errorf("unknown ATTACHMENT_STORAGE %q (expected local, s3 or none)", c.Backend)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not set up %s attachment storage: %w", c.Backend, err)
+	}
+	log.Printf("Attachment storage: %s", store.Name())
+	return store, nil
 }
@@ -0,0 +1,105 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/5tuartw/droplet/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+// env is a fake environment for config.Load
+func env(vars map[string]string) config.LookupFunc {
+	return func(key string) (string, bool) {
+		v, ok := vars[key]
+		return v, ok
+	}
+}
+
+func writeFile(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "droplet.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+	return path
+}
+
+func TestLoadFromEnvironment(t *testing.T) {
+	c, err := config.Load("", env(map[string]string{
+		"DATABASE_URL":      "postgres://localhost/droplet",
+		"JWT_SECRET":        "secret",
+		"ACCESS_TOKEN_TTL":  "15m",
+		"DB_MAX_OPEN_CONNS": "50",
+		"DEMO_MODE":         "true",
+		"PLATFORM":          "DEV",
+	}))
+	require.NoError(t, err)
+	require.Equal(t, 15*time.Minute, c.Auth.AccessTokenTTL)
+	require.Equal(t, config.DefaultRefreshTokenTTL, c.Auth.RefreshTokenTTL, "unset settings keep their defaults")
+	require.Equal(t, 50, c.Database.MaxOpenConns)
+	require.True(t, c.Features.DemoMode)
+	require.True(t, c.Features.DevMode)
+	require.Equal(t, "8080", c.Server.Port)
+}
+
+func TestEnvironmentOverridesFile(t *testing.T) {
+	path := writeFile(t, `
+server:
+  port: "9000"
+  http:
+    write_timeout: 45s
+database:
+  url: postgres://file/droplet
+  conn_max_lifetime: 1h
+auth:
+  jwt_secret: from-file
+storage:
+  backend: none
+`)
+	c, err := config.Load(path, env(map[string]string{"JWT_SECRET": "from-env"}))
+	require.NoError(t, err)
+	require.Equal(t, "9000", c.Server.Port)
+	require.Equal(t, 45*time.Second, c.Server.HTTP.WriteTimeout)
+	require.Equal(t, time.Hour, c.Database.ConnMaxLifetime)
+	require.Equal(t, "from-env", c.Auth.JWTSecret)
+	require.Equal(t, "none", c.Storage.Backend)
+}
+
+func TestLoadReportsEveryProblem(t *testing.T) {
+	_, err := config.Load("", env(map[string]string{
+		"PORT":               "http",
+		"ATTACHMENT_STORAGE": "floppy",
+		"LOG_FORMAT":         "xml",
+		"DB_MAX_OPEN_CONNS":  "5",
+		"DB_MAX_IDLE_CONNS":  "10",
+	}))
+	require.Error(t, err)
+	for _, want := range []string{"PORT", "DATABASE_URL", "JWT_SECRET", "ATTACHMENT_STORAGE", "LOG_FORMAT", "DB_MAX_IDLE_CONNS"} {
+		require.Contains(t, err.Error(), want)
+	}
+
+	_, err = config.Load("", env(map[string]string{"SHUTDOWN_TIMEOUT": "soon", "S3_USE_SSL": "maybe"}))
+	require.ErrorContains(t, err, "SHUTDOWN_TIMEOUT")
+	require.ErrorContains(t, err, "S3_USE_SSL")
+}
+
+func TestLoadRejectsUnknownFileKeys(t *testing.T) {
+	path := writeFile(t, "database:\n  urll: postgres://typo\n")
+	_, err := config.Load(path, env(nil))
+	require.ErrorContains(t, err, "urll")
+}
+
+func TestLoadDatabaseOnlyNeedsDatabaseSettings(t *testing.T) {
+	db, err := config.LoadDatabase("", env(map[string]string{"DATABASE_URL": "postgres://localhost/droplet"}))
+	require.NoError(t, err, "JWT_SECRET isn't needed to migrate")
+	require.Equal(t, "postgres://localhost/droplet", db.URL)
+
+	_, err = config.LoadDatabase("", env(nil))
+	require.ErrorContains(t, err, "DATABASE_URL")
+}
+
+func TestExampleFileIsValid(t *testing.T) {
+	_, err := config.Load("../../config.example.yaml", env(nil))
+	require.NoError(t, err)
+}
@@ -0,0 +1,257 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is droplet's settings, read from an optional YAML file and then the environment.
+// Each setting's environment variable is in its env tag; environment variables win over the file.
+type Config struct {
+	Server        ServerConfig        `yaml:"server"`
+	Database      DatabaseConfig      `yaml:"database"`
+	Auth          AuthConfig          `yaml:"auth"`
+	Notifications NotificationsConfig `yaml:"notifications"`
+	Storage       StorageConfig       `yaml:"storage"`
+	Logging       LoggingConfig       `yaml:"logging"`
+	Features      FeaturesConfig      `yaml:"features"`
+}
+
+type ServerConfig struct {
+	Port string `yaml:"port" env:"PORT"`
+	// BaseDomain serves schools from <subdomain>.BaseDomain; empty disables tenant lookup by host
+	BaseDomain string `yaml:"base_domain" env:"BASE_DOMAIN"`
+	// /metrics is served on MetricsAddr if set, otherwise on the main port if MetricsToken is set, otherwise not at all
+	MetricsAddr  string     `yaml:"metrics_addr" env:"METRICS_ADDR"`
+	MetricsToken string     `yaml:"metrics_token" env:"METRICS_TOKEN"`
+	HTTP         HTTPConfig `yaml:"http"`
+}
+
+// HTTPConfig bounds how long clients may hold connections, so slow or idle clients can't tie up the server
+type HTTPConfig struct {
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"HTTP_READ_HEADER_TIMEOUT"` // time to send the request headers
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"HTTP_READ_TIMEOUT"`               // time to send the whole request, including uploads
+	WriteTimeout      time.Duration `yaml:"write_timeout" env:"HTTP_WRITE_TIMEOUT"`             // time from the end of the request headers to the end of the response
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT"`               // how long a keep-alive connection may wait for its next request
+	MaxHeaderBytes    int           `yaml:"max_header_bytes" env:"HTTP_MAX_HEADER_BYTES"`
+	// ShutdownTimeout is how long in-flight requests and background work get to finish after SIGTERM
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+}
+
+type DatabaseConfig struct {
+	URL string `yaml:"url" env:"DATABASE_URL"`
+	// Pool limits; 0 means no limit for MaxOpenConns and the lifetimes
+	MaxOpenConns    int           `yaml:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME"`
+
+	MigrateOnStart       bool `yaml:"migrate_on_start" env:"MIGRATE_ON_START"`             // apply pending migrations before serving
+	RequireCurrentSchema bool `yaml:"require_current_schema" env:"REQUIRE_CURRENT_SCHEMA"` // refuse to serve while migrations are pending
+}
+
+type AuthConfig struct {
+	JWTSecret       string        `yaml:"jwt_secret" env:"JWT_SECRET"`
+	AccessTokenTTL  time.Duration `yaml:"access_token_ttl" env:"ACCESS_TOKEN_TTL"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl" env:"REFRESH_TOKEN_TTL"`
+}
+
+// NotificationsConfig sets up the channels urgent drops are pushed through
+type NotificationsConfig struct {
+	UrgentWebhookURL string `yaml:"urgent_webhook_url" env:"URGENT_WEBHOOK_URL"`
+}
+
+type StorageConfig struct {
+	Backend         string   `yaml:"backend" env:"ATTACHMENT_STORAGE"` // local, s3 or none
+	Dir             string   `yaml:"dir" env:"ATTACHMENT_DIR"`         // for the local backend
+	MaxAttachmentMB int64    `yaml:"max_attachment_mb" env:"ATTACHMENT_MAX_MB"`
+	S3              S3Config `yaml:"s3"`
+}
+
+type S3Config struct {
+	Endpoint  string `yaml:"endpoint" env:"S3_ENDPOINT"`
+	AccessKey string `yaml:"access_key" env:"S3_ACCESS_KEY"`
+	SecretKey string `yaml:"secret_key" env:"S3_SECRET_KEY"`
+	Bucket    string `yaml:"bucket" env:"S3_BUCKET"`
+	Region    string `yaml:"region" env:"S3_REGION"`
+	UseSSL    bool   `yaml:"use_ssl" env:"S3_USE_SSL"`
+}
+
+type LoggingConfig struct {
+	Format string `yaml:"format" env:"LOG_FORMAT"` // json or text
+	Level  string `yaml:"level" env:"LOG_LEVEL"`   // debug, info, warn or error
+}
+
+type FeaturesConfig struct {
+	DemoMode bool `yaml:"demo_mode" env:"DEMO_MODE"`
+	// DevMode enables development-only endpoints. PLATFORM=DEV also turns it on.
+	DevMode bool `yaml:"dev_mode" env:"DEV_MODE"`
+}
+
+const (
+	DefaultAccessTokenTTL  = time.Hour
+	DefaultRefreshTokenTTL = 60 * 24 * time.Hour
+)
+
+// Defaults returns the settings used for anything not set in the file or environment
+func Defaults() Config {
+	return Config{
+		Server: ServerConfig{
+			Port: "8080",
+			HTTP: HTTPConfig{
+				ReadHeaderTimeout: 10 * time.Second,
+				ReadTimeout:       2 * time.Minute, // allows for large uploads such as tenant archives
+				WriteTimeout:      2 * time.Minute, // allows for large exports
+				IdleTimeout:       2 * time.Minute,
+				MaxHeaderBytes:    64 << 10,
+				ShutdownTimeout:   30 * time.Second,
+			},
+		},
+		Database: DatabaseConfig{
+			MaxOpenConns:    25,
+			MaxIdleConns:    10,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+		},
+		Auth: AuthConfig{
+			AccessTokenTTL:  DefaultAccessTokenTTL,
+			RefreshTokenTTL: DefaultRefreshTokenTTL,
+		},
+		Storage: StorageConfig{
+			Backend:         "local",
+			Dir:             "data/attachments",
+			MaxAttachmentMB: 10,
+			S3:              S3Config{UseSSL: true},
+		},
+		Logging: LoggingConfig{Format: "json", Level: "info"},
+	}
+}
+
+// LookupFunc finds an environment variable; os.LookupEnv is one
+type LookupFunc func(key string) (string, bool)
+
+// Load reads the defaults, then the YAML file at path (if path isn't empty), then the environment,
+// and validates the result. The error lists every problem found, not just the first.
+func Load(path string, lookup LookupFunc) (*Config, error) {
+	c, err := load(path, lookup)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// LoadDatabase is Load for commands that only need the database, so they don't need the server's settings
+func LoadDatabase(path string, lookup LookupFunc) (DatabaseConfig, error) {
+	c, err := load(path, lookup)
+	if err != nil {
+		return DatabaseConfig{}, err
+	}
+	return c.Database, errors.Join(c.Database.validate()...)
+}
+
+func load(path string, lookup LookupFunc) (*Config, error) {
+	c := Defaults()
+	if path != "" {
+		if err := readFile(path, &c); err != nil {
+			return nil, err
+		}
+	}
+	if err := applyEnv(&c, lookup); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func readFile(path string, c *Config) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("could not read config file: %w", err)
+	}
+	defer f.Close()
+
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true) // a misspelt key is an error rather than silently ignored
+	if err := dec.Decode(c); err != nil {
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			errs := make([]error, len(typeErr.Errors))
+			for i, e := range typeErr.Errors {
+				errs[i] = fmt.Errorf("%s: %s", path, e)
+			}
+			return errors.Join(errs...)
+		}
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// applyEnv sets every field with an env tag whose variable is set, collecting every unparseable value
+func applyEnv(c *Config, lookup LookupFunc) error {
+	var errs []error
+	var walk func(v reflect.Value)
+	walk = func(v reflect.Value) {
+		for i := 0; i < v.NumField(); i++ {
+			field, sf := v.Field(i), v.Type().Field(i)
+			if sf.Type.Kind() == reflect.Struct && sf.Type != durationType {
+				walk(field)
+				continue
+			}
+			name := sf.Tag.Get("env")
+			if name == "" {
+				continue
+			}
+			raw, ok := lookup(name)
+			if !ok {
+				continue
+			}
+			if err := setField(field, raw); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			}
+		}
+	}
+	walk(reflect.ValueOf(c).Elem())
+
+	if platform, ok := lookup("PLATFORM"); ok && platform == "DEV" {
+		c.Features.DevMode = true
+	}
+	return errors.Join(errs...)
+}
+
+func setField(field reflect.Value, raw string) error {
+	switch {
+	case field.Type() == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("must be a duration such as 30s or 2m, got %q", raw)
+		}
+		field.SetInt(int64(d))
+	case field.Kind() == reflect.String:
+		field.SetString(raw)
+	case field.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("must be true or false, got %q", raw)
+		}
+		field.SetBool(b)
+	case field.Kind() == reflect.Int || field.Kind() == reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("must be a whole number, got %q", raw)
+		}
+		field.SetInt(n)
+	default:
+		return fmt.Errorf("unsupported setting type %s", field.Type())
+	}
+	return nil
+}
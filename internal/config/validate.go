@@ -0,0 +1,132 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+)
+
+// Validate checks every setting and returns all the problems at once, one per line
+func (c *Config) Validate() error {
+	var errs []error
+	errs = append(errs, c.Server.validate()...)
+	errs = append(errs, c.Database.validate()...)
+	errs = append(errs, c.Auth.validate()...)
+	errs = append(errs, c.Notifications.validate()...)
+	errs = append(errs, c.Storage.validate()...)
+	errs = append(errs, c.Logging.validate()...)
+	return errors.Join(errs...)
+}
+
+func (s ServerConfig) validate() []error {
+	var errs []error
+	if port, err := strconv.Atoi(s.Port); err != nil || port < 1 || port > 65535 {
+		errs = append(errs, fmt.Errorf("PORT must be a port number, got %q", s.Port))
+	}
+	h := s.HTTP
+	for _, d := range []struct {
+		name  string
+		value int64
+	}{
+		{"HTTP_READ_HEADER_TIMEOUT", int64(h.ReadHeaderTimeout)},
+		{"HTTP_READ_TIMEOUT", int64(h.ReadTimeout)},
+		{"HTTP_WRITE_TIMEOUT", int64(h.WriteTimeout)},
+		{"HTTP_IDLE_TIMEOUT", int64(h.IdleTimeout)},
+		{"SHUTDOWN_TIMEOUT", int64(h.ShutdownTimeout)},
+		{"HTTP_MAX_HEADER_BYTES", int64(h.MaxHeaderBytes)},
+	} {
+		if d.value <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive", d.name))
+		}
+	}
+	return errs
+}
+
+func (d DatabaseConfig) validate() []error {
+	var errs []error
+	if d.URL == "" {
+		errs = append(errs, errors.New("DATABASE_URL is required"))
+	}
+	if d.MaxOpenConns < 0 {
+		errs = append(errs, errors.New("DB_MAX_OPEN_CONNS can't be negative"))
+	}
+	if d.MaxIdleConns < 0 {
+		errs = append(errs, errors.New("DB_MAX_IDLE_CONNS can't be negative"))
+	}
+	if d.MaxOpenConns > 0 && d.MaxIdleConns > d.MaxOpenConns {
+		errs = append(errs, fmt.Errorf("DB_MAX_IDLE_CONNS (%d) can't be more than DB_MAX_OPEN_CONNS (%d)", d.MaxIdleConns, d.MaxOpenConns))
+	}
+	if d.ConnMaxLifetime < 0 {
+		errs = append(errs, errors.New("DB_CONN_MAX_LIFETIME can't be negative"))
+	}
+	if d.ConnMaxIdleTime < 0 {
+		errs = append(errs, errors.New("DB_CONN_MAX_IDLE_TIME can't be negative"))
+	}
+	return errs
+}
+
+func (a AuthConfig) validate() []error {
+	var errs []error
+	if a.JWTSecret == "" {
+		errs = append(errs, errors.New("JWT_SECRET is required"))
+	}
+	if a.AccessTokenTTL <= 0 {
+		errs = append(errs, errors.New("ACCESS_TOKEN_TTL must be positive"))
+	}
+	if a.RefreshTokenTTL <= 0 {
+		errs = append(errs, errors.New("REFRESH_TOKEN_TTL must be positive"))
+	} else if a.RefreshTokenTTL < a.AccessTokenTTL {
+		errs = append(errs, errors.New("REFRESH_TOKEN_TTL can't be shorter than ACCESS_TOKEN_TTL"))
+	}
+	return errs
+}
+
+func (n NotificationsConfig) validate() []error {
+	if n.UrgentWebhookURL == "" {
+		return nil
+	}
+	u, err := url.Parse(n.UrgentWebhookURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		// Don't echo the URL: webhook URLs often carry a secret
+		return []error{errors.New("URGENT_WEBHOOK_URL must be an http(s) URL")}
+	}
+	return nil
+}
+
+func (s StorageConfig) validate() []error {
+	var errs []error
+	switch s.Backend {
+	case "none":
+	case "local":
+		if s.Dir == "" {
+			errs = append(errs, errors.New("ATTACHMENT_DIR is required for local attachment storage"))
+		}
+	case "s3":
+		if s.S3.Endpoint == "" {
+			errs = append(errs, errors.New("S3_ENDPOINT is required for s3 attachment storage"))
+		}
+		if s.S3.Bucket == "" {
+			errs = append(errs, errors.New("S3_BUCKET is required for s3 attachment storage"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("ATTACHMENT_STORAGE must be local, s3 or none, got %q", s.Backend))
+	}
+	if s.MaxAttachmentMB <= 0 {
+		errs = append(errs, fmt.Errorf("ATTACHMENT_MAX_MB must be positive, got %d", s.MaxAttachmentMB))
+	}
+	return errs
+}
+
+func (l LoggingConfig) validate() []error {
+	var errs []error
+	if l.Format != "json" && l.Format != "text" {
+		errs = append(errs, fmt.Errorf("LOG_FORMAT must be json or text, got %q", l.Format))
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(l.Level)); err != nil {
+		errs = append(errs, fmt.Errorf("LOG_LEVEL must be debug, info, warn or error, got %q", l.Level))
+	}
+	return errs
+}
@@ -0,0 +1,119 @@
+package api_test
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/models"
+	"github.com/5tuartw/droplet/internal/schoolsettings"
+	"github.com/google/uuid"
+	"github.com/sqlc-dev/pqtype"
+	"github.com/stretchr/testify/require"
+)
+
+// A trainee's school-wide drop waits for approval; editing it never publishes it, and a rejected drop
+// only goes back into the queue when resubmitted
+func TestDropApprovalWorkflow(t *testing.T) {
+	if testDB == nil {
+		t.Fatal("Test database connection pool (testDB) is nil")
+	}
+	server := newRouterTestServer(t)
+
+	_, traineeToken := seedTestUserWithRole(t, "approval.trainee@example.com", database.UserRoleTrainee)
+	adminID, adminToken := seedTestUserWithRole(t, "approval.admin@example.com", database.UserRoleAdmin)
+
+	var original pqtype.NullRawMessage
+	require.NoError(t, testDB.QueryRow(`SELECT settings FROM schools WHERE id = $1`, testSchoolID).Scan(&original))
+	t.Cleanup(func() { testDB.Exec(`UPDATE schools SET settings = $1 WHERE id = $2`, original, testSchoolID) })
+	settings := schoolsettings.Default()
+	settings.Drops.RequireApproval = true
+	rr := doJSON(t, server, http.MethodPut, "/api/school/settings", adminToken, settings)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	dropBody := func(title string, targets ...models.Target) map[string]any {
+		return map[string]any{
+			"title":       title,
+			"content":     "Seeded for TestDropApprovalWorkflow",
+			"post_date":   time.Now().UTC().Format(time.DateOnly),
+			"expire_date": time.Now().Add(48 * time.Hour).UTC().Format(time.DateOnly),
+			"targets":     targets,
+		}
+	}
+	type review struct {
+		status     database.DropStatus
+		reviewedBy uuid.NullUUID
+		reason     sql.NullString
+	}
+	reviewOf := func(dropID uuid.UUID) review {
+		t.Helper()
+		var r review
+		err := testDB.QueryRow(`SELECT status, reviewed_by, rejection_reason FROM drops WHERE id = $1`, dropID).Scan(&r.status, &r.reviewedBy, &r.reason)
+		require.NoError(t, err)
+		return r
+	}
+
+	// Trainee -> pending
+	rr = doJSON(t, server, http.MethodPost, "/api/drops", traineeToken, dropBody("Whole school", models.Target{Type: "General"}))
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	var created database.Drop
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &created))
+	require.Equal(t, database.DropStatusPendingApproval, created.Status)
+	dropPath := "/api/drops/" + created.ID.String()
+
+	rr = doJSON(t, server, http.MethodPost, "/api/drops", traineeToken, dropBody("One class", models.Target{Type: "Class", ID: 7}))
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	var classDrop database.Drop
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &classDrop))
+	require.Equal(t, database.DropStatusPublished, classDrop.Status, "only school-wide drops need approval")
+
+	// Trainees can't review their own drops
+	rr = doJSON(t, server, http.MethodPost, dropPath+"/approve", traineeToken, nil)
+	require.Equal(t, http.StatusForbidden, rr.Code)
+
+	// An admin editing a pending drop doesn't approve it
+	rr = doJSON(t, server, http.MethodPut, dropPath, adminToken, dropBody("Whole school (tidied)", models.Target{Type: "General"}))
+	require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+	require.Equal(t, database.DropStatusPendingApproval, reviewOf(created.ID).status)
+
+	// Reject
+	rr = doJSON(t, server, http.MethodPost, dropPath+"/reject", adminToken, map[string]string{})
+	require.Equal(t, http.StatusBadRequest, rr.Code, "a reason is required")
+	rr = doJSON(t, server, http.MethodPost, dropPath+"/reject", adminToken, map[string]string{"reason": "Check the date"})
+	require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+	require.Equal(t, review{database.DropStatusRejected, uuid.NullUUID{UUID: adminID, Valid: true}, sql.NullString{String: "Check the date", Valid: true}}, reviewOf(created.ID))
+
+	// Narrowing a rejected drop's targets doesn't publish it, whoever edits it
+	rr = doJSON(t, server, http.MethodPut, dropPath, traineeToken, dropBody("Just 4A", models.Target{Type: "Class", ID: 7}))
+	require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+	require.Equal(t, database.DropStatusRejected, reviewOf(created.ID).status)
+	rr = doJSON(t, server, http.MethodPut, dropPath, adminToken, dropBody("Just 4A", models.Target{Type: "Class", ID: 7}))
+	require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+	require.Equal(t, database.DropStatusRejected, reviewOf(created.ID).status)
+
+	// Resubmit
+	rr = doJSON(t, server, http.MethodPost, dropPath+"/resubmit", traineeToken, nil)
+	require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+	require.Equal(t, review{status: database.DropStatusPendingApproval}, reviewOf(created.ID))
+	rr = doJSON(t, server, http.MethodPost, dropPath+"/resubmit", traineeToken, nil)
+	require.Equal(t, http.StatusNotFound, rr.Code, "only rejected drops can be resubmitted")
+
+	// Approve
+	rr = doJSON(t, server, http.MethodPost, dropPath+"/approve", adminToken, nil)
+	require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+	require.Equal(t, database.DropStatusPublished, reviewOf(created.ID).status)
+	require.Equal(t, uuid.NullUUID{UUID: adminID, Valid: true}, reviewOf(created.ID).reviewedBy)
+	rr = doJSON(t, server, http.MethodPost, dropPath+"/approve", adminToken, nil)
+	require.Equal(t, http.StatusNotFound, rr.Code, "only pending drops can be approved")
+
+	// Widening a published drop to the whole school sends it back for approval
+	rr = doJSON(t, server, http.MethodPost, "/api/droptargets", traineeToken, map[string]any{"drop_id": classDrop.ID, "type": "General"})
+	require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+	require.Equal(t, database.DropStatusPendingApproval, reviewOf(classDrop.ID).status)
+	var targetCount int
+	require.NoError(t, testDB.QueryRow(`SELECT COUNT(*) FROM drop_targets WHERE drop_id = $1`, classDrop.ID).Scan(&targetCount))
+	require.Equal(t, 2, targetCount)
+}
@@ -0,0 +1,195 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/5tuartw/droplet/internal/controllers/drops"
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/models"
+	"github.com/5tuartw/droplet/internal/router"
+	"github.com/5tuartw/droplet/internal/storage"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+var testPDF = []byte("%PDF-1.4\n1 0 obj << /Type /Catalog >> endobj\n%%EOF\n")
+
+// Uploads are checked for size and type, downloads follow the drop's visibility, and files go when
+// their attachments do, whether deleted directly or swept away with a drop or user
+func TestAttachments(t *testing.T) {
+	if testDB == nil {
+		t.Fatal("Test database connection pool (testDB) is nil")
+	}
+	store, err := storage.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	cfg := *testCfg
+	cfg.Attachments = store
+	cfg.MaxAttachmentBytes = 1 << 20
+	dbq := database.New(testDB)
+	server := router.NewRouter(&cfg, testDB, dbq)
+
+	authorID, authorToken := seedTestUserWithRole(t, "attach.author@example.com", database.UserRoleUser)
+	_, colleagueToken := seedTestUserWithRole(t, "attach.colleague@example.com", database.UserRoleUser)
+	_, adminToken := seedTestUserWithRole(t, "attach.admin@example.com", database.UserRoleAdmin)
+
+	createDrop := func(token string) uuid.UUID {
+		t.Helper()
+		rr := doJSON(t, server, http.MethodPost, "/api/drops", token, map[string]any{
+			"title":       "With attachments",
+			"content":     "Seeded for TestAttachments",
+			"post_date":   time.Now().UTC().Format(time.DateOnly),
+			"expire_date": time.Now().Add(48 * time.Hour).UTC().Format(time.DateOnly),
+			"targets":     []models.Target{{Type: "Class", ID: 7}},
+		})
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		var drop database.Drop
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &drop))
+		return drop.ID
+	}
+	upload := func(dropID uuid.UUID, token, filename string, content []byte) *httptest.ResponseRecorder {
+		t.Helper()
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		part, err := mw.CreateFormFile("file", filename)
+		require.NoError(t, err)
+		_, err = part.Write(content)
+		require.NoError(t, err)
+		require.NoError(t, mw.Close())
+
+		req := httptest.NewRequest(http.MethodPost, "/api/drops/"+dropID.String()+"/attachments", &body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, req)
+		return rr
+	}
+	uploaded := func(dropID uuid.UUID, token string) (database.AttachmentInfo, string) {
+		t.Helper()
+		rr := upload(dropID, token, "../../letter.pdf", testPDF)
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		var info database.AttachmentInfo
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &info))
+		var key string
+		require.NoError(t, testDB.QueryRow(`SELECT storage_key FROM drop_attachments WHERE id = $1`, info.ID).Scan(&key))
+		return info, key
+	}
+	stored := func(key string) bool {
+		t.Helper()
+		body, err := store.Get(context.Background(), key)
+		if errors.Is(err, storage.ErrNotFound) {
+			return false
+		}
+		require.NoError(t, err)
+		body.Close()
+		return true
+	}
+	queued := func(key string) bool {
+		t.Helper()
+		var exists bool
+		require.NoError(t, testDB.QueryRow(`SELECT EXISTS (SELECT 1 FROM attachment_deletions WHERE storage_key = $1)`, key).Scan(&exists))
+		return exists
+	}
+
+	dropID := createDrop(authorToken)
+
+	t.Run("uploads are checked", func(t *testing.T) {
+		rr := upload(dropID, authorToken, "huge.pdf", append(testPDF, make([]byte, cfg.MaxAttachmentBytes)...))
+		require.Equal(t, http.StatusRequestEntityTooLarge, rr.Code, rr.Body.String())
+		rr = upload(dropID, authorToken, "page.pdf", []byte("<!DOCTYPE html><html><script>alert(1)</script></html>"))
+		require.Equal(t, http.StatusUnsupportedMediaType, rr.Code, "the type is sniffed, not taken from the name")
+		rr = upload(dropID, authorToken, "setup.exe", append([]byte("MZ\x90\x00"), make([]byte, 64)...))
+		require.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+		rr = upload(dropID, authorToken, "archive.zip", []byte("PK\x03\x04"+strings.Repeat("\x00", 26)))
+		require.Equal(t, http.StatusUnsupportedMediaType, rr.Code, "only office documents may be zips")
+		rr = upload(dropID, authorToken, "empty.txt", nil)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		rr = upload(dropID, colleagueToken, "letter.pdf", testPDF)
+		require.Equal(t, http.StatusForbidden, rr.Code, "only those who can manage the drop can attach files")
+		rr = upload(uuid.New(), adminToken, "letter.pdf", testPDF)
+		require.Equal(t, http.StatusNotFound, rr.Code)
+
+		var count int
+		require.NoError(t, testDB.QueryRow(`SELECT COUNT(*) FROM drop_attachments WHERE drop_id = $1`, dropID).Scan(&count))
+		require.Zero(t, count)
+	})
+
+	t.Run("downloads follow the drop's visibility", func(t *testing.T) {
+		info, _ := uploaded(dropID, authorToken)
+		require.Equal(t, "letter.pdf", info.Filename, "paths are stripped from file names")
+		require.Equal(t, "application/pdf", info.ContentType)
+
+		download := func(token string) *httptest.ResponseRecorder {
+			t.Helper()
+			return doJSON(t, server, http.MethodGet, info.URL, token, nil)
+		}
+		rr := download(colleagueToken)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		require.Equal(t, testPDF, rr.Body.Bytes())
+		require.Equal(t, "application/pdf", rr.Header().Get("Content-Type"))
+		require.Equal(t, `attachment; filename=letter.pdf`, rr.Header().Get("Content-Disposition"))
+		require.Equal(t, "nosniff", rr.Header().Get("X-Content-Type-Options"))
+
+		_, err := testDB.Exec(`UPDATE drops SET status = 'pending_approval' WHERE id = $1`, dropID)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusNotFound, download(colleagueToken).Code, "a pending drop's files are hidden like the drop")
+		require.Equal(t, http.StatusOK, download(authorToken).Code, "but not from its author")
+		require.Equal(t, http.StatusOK, download(adminToken).Code, "or its approvers")
+		_, err = testDB.Exec(`UPDATE drops SET status = 'published' WHERE id = $1`, dropID)
+		require.NoError(t, err)
+
+		otherDropID := createDrop(authorToken)
+		wrongDrop := strings.Replace(info.URL, dropID.String(), otherDropID.String(), 1)
+		require.Equal(t, http.StatusNotFound, doJSON(t, server, http.MethodGet, wrongDrop, colleagueToken, nil).Code)
+	})
+
+	t.Run("deleting an attachment deletes its file", func(t *testing.T) {
+		info, key := uploaded(dropID, authorToken)
+		require.True(t, stored(key))
+		require.Equal(t, http.StatusForbidden, doJSON(t, server, http.MethodDelete, info.URL, colleagueToken, nil).Code)
+		require.Equal(t, http.StatusNoContent, doJSON(t, server, http.MethodDelete, info.URL, authorToken, nil).Code)
+		require.False(t, stored(key))
+		require.False(t, queued(key), "nothing is left for the reaper")
+	})
+
+	t.Run("deleting a drop deletes its files", func(t *testing.T) {
+		doomedID := createDrop(authorToken)
+		_, first := uploaded(doomedID, authorToken)
+		_, second := uploaded(doomedID, authorToken)
+		rr := doJSON(t, server, http.MethodDelete, "/api/drops/"+doomedID.String(), authorToken, nil)
+		require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+		require.False(t, stored(first))
+		require.False(t, stored(second))
+		require.False(t, queued(first))
+		require.False(t, queued(second))
+	})
+
+	t.Run("files swept away with their user are reaped", func(t *testing.T) {
+		leaverID, leaverToken := seedTestUserWithRole(t, "attach.leaver@example.com", database.UserRoleUser)
+		_, key := uploaded(createDrop(leaverToken), leaverToken)
+
+		// As the dev-only DELETE /api/users does, so the user's drops cascade away
+		_, err := testDB.Exec(`DELETE FROM users WHERE id = $1`, leaverID)
+		require.NoError(t, err)
+		require.True(t, stored(key), "the cascade can't reach storage")
+		require.True(t, queued(key))
+
+		_, err = drops.ReapDeletedAttachments(context.Background(), store, dbq)
+		require.NoError(t, err)
+		require.False(t, stored(key))
+		require.False(t, queued(key))
+	})
+
+	// The author's other drops are untouched by all of the above
+	var remaining int
+	require.NoError(t, testDB.QueryRow(`SELECT COUNT(*) FROM drops WHERE user_id = $1`, authorID).Scan(&remaining))
+	require.Equal(t, 2, remaining)
+}
@@ -254,7 +254,7 @@ func TestCreateDrop(t *testing.T) {
 				return userID, accessToken
 			},
 			requestBody:    `{"title": "bad", "content":`,
-			expectedStatus: http.StatusInternalServerError,
+			expectedStatus: http.StatusBadRequest,
 		},
 		{
 			name: "Fail_NoBody",
@@ -264,7 +264,7 @@ func TestCreateDrop(t *testing.T) {
 				return userID, accessToken
 			},
 			requestBody:    nil,
-			expectedStatus: http.StatusInternalServerError,
+			expectedStatus: http.StatusBadRequest,
 		},
 	}
 
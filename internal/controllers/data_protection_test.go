@@ -0,0 +1,229 @@
+package api_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/5tuartw/droplet/internal/controllers/dataprotection"
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// readBundle returns export.json from a ?format=zip export
+func readBundle(t *testing.T, body []byte) []byte {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	require.NoError(t, err)
+	for _, file := range zr.File {
+		if file.Name == "export.json" {
+			rc, err := file.Open()
+			require.NoError(t, err)
+			defer rc.Close()
+			raw, err := io.ReadAll(rc)
+			require.NoError(t, err)
+			return raw
+		}
+	}
+	t.Fatal("export.json missing from bundle")
+	return nil
+}
+
+func TestPupilExportAndErasure(t *testing.T) {
+	if testDB == nil {
+		t.Fatal("Test database connection pool (testDB) is nil")
+	}
+	server := newRouterTestServer(t)
+	_, adminToken := seedTestUserWithRole(t, "dp.pupil.admin@example.com", database.UserRoleAdmin)
+	_, userToken := seedTestUserWithRole(t, "dp.pupil.user@example.com", database.UserRoleUser)
+	teacherID, _ := seedTestUserWithRole(t, "dp.pupil.teacher@example.com", database.UserRoleUser)
+
+	rr := doJSON(t, server, http.MethodPost, "/api/pupils", adminToken, models.PupilRequest{FirstName: "Erin", Surname: "Erasable", ClassID: 7})
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	var pupil models.Pupil
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &pupil))
+	pupilPath := "/api/pupils/" + strconv.Itoa(int(pupil.ID))
+
+	createDrop := func(title string, targets []models.Target) uuid.UUID {
+		t.Helper()
+		rr := doJSON(t, server, http.MethodPost, "/api/drops", adminToken, map[string]any{
+			"title":       title,
+			"content":     "Seeded for TestPupilExportAndErasure",
+			"post_date":   time.Now().UTC().Format(time.DateOnly),
+			"expire_date": time.Now().Add(48 * time.Hour).UTC().Format(time.DateOnly),
+			"targets":     targets,
+		})
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		var drop database.Drop
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &drop))
+		return drop.ID
+	}
+	justThePupil := createDrop("Just Erin", []models.Target{{Type: "Student", ID: pupil.ID}})
+	pupilAndClass := createDrop("Erin and 4A", []models.Target{{Type: "Student", ID: pupil.ID}, {Type: "Class", ID: 7}})
+	_, err := testDB.Exec(`INSERT INTO target_subscriptions (user_id, school_id, type, target_id) VALUES ($1, $2, 'Student', $3)`,
+		teacherID, testSchoolID, pupil.ID)
+	require.NoError(t, err)
+
+	t.Run("export", func(t *testing.T) {
+		rr := doJSON(t, server, http.MethodGet, pupilPath+"/export", adminToken, nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var export dataprotection.PupilExport
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &export))
+		require.Equal(t, "Erin", export.FirstName)
+		require.Equal(t, "4A", export.ClassName)
+		require.Len(t, export.ClassHistory, 1)
+		require.NotNil(t, export.ClassHistory[0].StartedAt)
+		var dropIDs []uuid.UUID
+		for _, drop := range export.Drops {
+			dropIDs = append(dropIDs, drop.ID)
+		}
+		require.ElementsMatch(t, []uuid.UUID{justThePupil, pupilAndClass}, dropIDs)
+
+		rr = doJSON(t, server, http.MethodGet, pupilPath+"/export?format=zip", adminToken, nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		require.Equal(t, "application/zip", rr.Header().Get("Content-Type"))
+		var bundled dataprotection.PupilExport
+		require.NoError(t, json.Unmarshal(readBundle(t, rr.Body.Bytes()), &bundled))
+		require.Equal(t, pupil.ID, bundled.PupilID)
+
+		require.Equal(t, http.StatusBadRequest, doJSON(t, server, http.MethodGet, pupilPath+"/export?format=xml", adminToken, nil).Code)
+		require.Equal(t, http.StatusForbidden, doJSON(t, server, http.MethodGet, pupilPath+"/export", userToken, nil).Code)
+		require.Equal(t, http.StatusNotFound, doJSON(t, server, http.MethodGet, "/api/pupils/999999/export", adminToken, nil).Code)
+	})
+
+	t.Run("erase", func(t *testing.T) {
+		require.Equal(t, http.StatusForbidden, doJSON(t, server, http.MethodPost, pupilPath+"/erase", userToken, nil).Code)
+		require.Equal(t, http.StatusBadRequest, doJSON(t, server, http.MethodPost, "/api/pupils/erin/erase", adminToken, nil).Code)
+
+		rr := doJSON(t, server, http.MethodPost, pupilPath+"/erase", adminToken, nil)
+		require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+		require.Equal(t, http.StatusNotFound, doJSON(t, server, http.MethodPost, pupilPath+"/erase", adminToken, nil).Code)
+		require.Equal(t, http.StatusNotFound, doJSON(t, server, http.MethodGet, pupilPath+"/export", adminToken, nil).Code)
+
+		var left int
+		require.NoError(t, testDB.QueryRow(`
+			SELECT (SELECT COUNT(*) FROM pupils WHERE id = $1)
+				+ (SELECT COUNT(*) FROM pupil_class_history WHERE pupil_id = $1)
+				+ (SELECT COUNT(*) FROM drop_targets WHERE type = 'Student' AND target_id = $1)
+				+ (SELECT COUNT(*) FROM target_subscriptions WHERE type = 'Student' AND target_id = $1)`,
+			pupil.ID).Scan(&left))
+		require.Zero(t, left, "nothing naming the pupil is left")
+
+		var classTargets int
+		require.NoError(t, testDB.QueryRow(`SELECT COUNT(*) FROM drop_targets WHERE drop_id = $1 AND type = 'Class'`, pupilAndClass).Scan(&classTargets))
+		require.Equal(t, 1, classTargets, "drops keep their other audiences")
+	})
+
+	t.Run("delete is erasure too", func(t *testing.T) {
+		rr := doJSON(t, server, http.MethodPost, "/api/pupils", adminToken, models.PupilRequest{FirstName: "Dee", Surname: "Leted", ClassID: 7})
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		var other models.Pupil
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &other))
+		createDrop("Just Dee", []models.Target{{Type: "Student", ID: other.ID}})
+
+		path := "/api/pupils/" + strconv.Itoa(int(other.ID))
+		require.Equal(t, http.StatusNoContent, doJSON(t, server, http.MethodDelete, path, adminToken, nil).Code)
+		require.Equal(t, http.StatusNotFound, doJSON(t, server, http.MethodDelete, path, adminToken, nil).Code)
+		var left int
+		require.NoError(t, testDB.QueryRow(`SELECT COUNT(*) FROM drop_targets WHERE type = 'Student' AND target_id = $1`, other.ID).Scan(&left))
+		require.Zero(t, left)
+	})
+}
+
+func TestUserExportAndErasure(t *testing.T) {
+	if testDB == nil {
+		t.Fatal("Test database connection pool (testDB) is nil")
+	}
+	server := newRouterTestServer(t)
+	adminID, adminToken := seedTestUserWithRole(t, "dp.user.admin@example.com", database.UserRoleAdmin)
+	_, userToken := seedTestUserWithRole(t, "dp.user.colleague@example.com", database.UserRoleUser)
+	leaverID, leaverToken := seedTestUserWithRole(t, "dp.user.leaver@example.com", database.UserRoleUser)
+	leaverPath := "/api/users/" + leaverID.String()
+
+	// Give the leaver a session, settings, a subscription and a drop
+	rr := doJSON(t, server, http.MethodPost, "/api/login", "", map[string]string{"email": "dp.user.leaver@example.com", "password": "password123"})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var session models.TokenUser
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &session))
+	_, err := testDB.Exec(`INSERT INTO user_settings (user_id, school_id, color_theme) VALUES ($1, $2, 'dark')`, leaverID, testSchoolID)
+	require.NoError(t, err)
+	_, err = testDB.Exec(`INSERT INTO target_subscriptions (user_id, school_id, type, target_id) VALUES ($1, $2, 'Class', 7)`, leaverID, testSchoolID)
+	require.NoError(t, err)
+	_, err = testDB.Exec(`INSERT INTO class_staff (class_id, user_id, school_id, role) VALUES (7, $1, $2, 'tutor')`, leaverID, testSchoolID)
+	require.NoError(t, err)
+	rr = doJSON(t, server, http.MethodPost, "/api/drops", leaverToken, map[string]any{
+		"title":       "Written by the leaver",
+		"content":     "Seeded for TestUserExportAndErasure",
+		"post_date":   time.Now().UTC().Format(time.DateOnly),
+		"expire_date": time.Now().Add(48 * time.Hour).UTC().Format(time.DateOnly),
+		"targets":     []models.Target{{Type: "Class", ID: 7}},
+	})
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	var drop database.Drop
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &drop))
+
+	t.Run("export", func(t *testing.T) {
+		rr := doJSON(t, server, http.MethodGet, leaverPath+"/export", adminToken, nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		require.NotContains(t, rr.Body.String(), session.RefreshToken, "token values are never exported")
+		var export dataprotection.UserExport
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &export))
+		require.Equal(t, "dp.user.leaver@example.com", export.Email)
+		require.NotNil(t, export.Settings)
+		require.Equal(t, "dark", export.Settings.ColorTheme)
+		require.Len(t, export.TargetSubscriptions, 1)
+		require.NotEmpty(t, export.Sessions)
+		require.Len(t, export.Drops, 1)
+		require.Equal(t, drop.ID, export.Drops[0].ID)
+
+		rr = doJSON(t, server, http.MethodGet, leaverPath+"/export?format=zip", adminToken, nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var bundled dataprotection.UserExport
+		require.NoError(t, json.Unmarshal(readBundle(t, rr.Body.Bytes()), &bundled))
+		require.Equal(t, leaverID, bundled.UserID)
+
+		require.Equal(t, http.StatusForbidden, doJSON(t, server, http.MethodGet, leaverPath+"/export", userToken, nil).Code)
+		require.Equal(t, http.StatusNotFound, doJSON(t, server, http.MethodGet, "/api/users/"+uuid.NewString()+"/export", adminToken, nil).Code)
+	})
+
+	t.Run("erase", func(t *testing.T) {
+		require.Equal(t, http.StatusForbidden, doJSON(t, server, http.MethodPost, leaverPath+"/erase", userToken, nil).Code)
+		require.Equal(t, http.StatusBadRequest, doJSON(t, server, http.MethodPost, "/api/users/"+adminID.String()+"/erase", adminToken, nil).Code,
+			"admins can't erase themselves")
+
+		rr := doJSON(t, server, http.MethodPost, leaverPath+"/erase", adminToken, nil)
+		require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+		require.Equal(t, http.StatusNotFound, doJSON(t, server, http.MethodPost, leaverPath+"/erase", adminToken, nil).Code)
+
+		var email, firstName, role string
+		require.NoError(t, testDB.QueryRow(`SELECT email, first_name, role FROM users WHERE id = $1`, leaverID).Scan(&email, &firstName, &role))
+		require.NotContains(t, email, "dp.user.leaver")
+		require.Equal(t, "Former", firstName)
+		require.Equal(t, string(database.UserRoleReadOnly), role)
+
+		var left int
+		require.NoError(t, testDB.QueryRow(`
+			SELECT (SELECT COUNT(*) FROM user_settings WHERE user_id = $1)
+				+ (SELECT COUNT(*) FROM target_subscriptions WHERE user_id = $1)
+				+ (SELECT COUNT(*) FROM class_staff WHERE user_id = $1)
+				+ (SELECT COUNT(*) FROM refresh_tokens WHERE user_id = $1)`,
+			leaverID).Scan(&left))
+		require.Zero(t, left)
+
+		var author uuid.UUID
+		require.NoError(t, testDB.QueryRow(`SELECT user_id FROM drops WHERE id = $1`, drop.ID).Scan(&author))
+		require.Equal(t, leaverID, author, "drops they wrote keep their author")
+
+		rr = doJSON(t, server, http.MethodPost, "/api/token/refresh", session.RefreshToken, nil)
+		require.Equal(t, http.StatusUnauthorized, rr.Code, "their sessions are gone")
+		rr = doJSON(t, server, http.MethodPost, "/api/login", "", map[string]string{"email": "dp.user.leaver@example.com", "password": "password123"})
+		require.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+}
@@ -0,0 +1,152 @@
+package dataprotection
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/5tuartw/droplet/internal/auth"
+	"github.com/5tuartw/droplet/internal/config"
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/helpers"
+	"github.com/google/uuid"
+)
+
+// EraseUser removes a staff member's personal data. The user row itself is
+// anonymised rather than deleted so that drops they wrote keep an author.
+func EraseUser(cfg *config.ApiConfig, db *sql.DB, dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+	if auth.DemoRestricted(cfg, dbq, r) {
+		slog.InfoContext(r.Context(), "blocked in demo mode", "action", "user erasure")
+		helpers.RespondWithError(w, http.StatusForbidden, "User erasure is disabled in demo mode", errors.New("demo mode restriction"))
+		return
+	}
+
+	userToErase, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusBadRequest, "No valid user id in path", err)
+		return
+	}
+
+	contextValueSchoolID := r.Context().Value(auth.UserSchoolKey)
+	schoolID, schoolOk := contextValueSchoolID.(uuid.UUID)
+	contextValueID := r.Context().Value(auth.UserIDKey)
+	editorUserID, idOk := contextValueID.(uuid.UUID)
+	if !schoolOk || !idOk {
+		slog.ErrorContext(r.Context(), "user not found in request context")
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Internal Server Error (context error)", nil)
+		return
+	}
+	if userToErase == editorUserID {
+		helpers.RespondWithError(w, http.StatusBadRequest, "Cannot erase own account", nil)
+		return
+	}
+
+	// begin transaction
+	tx, err := db.BeginTx(r.Context(), nil)
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not start database transaction", err)
+		return
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			slog.ErrorContext(r.Context(), "panic in transaction, rolling back")
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			slog.WarnContext(r.Context(), "rolling back transaction", "error", err)
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+			if err != nil {
+				slog.ErrorContext(r.Context(), "could not commit transaction", "error", err)
+			} else {
+				slog.DebugContext(r.Context(), "transaction committed")
+			}
+		}
+	}()
+
+	qtx := dbq.WithTx(tx)
+
+	user, err := qtx.GetUserById(r.Context(), database.GetUserByIdParams{
+		ID:       userToErase,
+		SchoolID: schoolID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			helpers.RespondWithError(w, http.StatusNotFound, "User not found in school", err)
+		} else {
+			helpers.RespondWithError(w, http.StatusInternalServerError, "Could not get user from database", err)
+		}
+		return
+	}
+	if user.Role == database.UserRolePlatformAdmin {
+		err = errors.New("platform admin")
+		helpers.RespondWithError(w, http.StatusForbidden, "Platform admin accounts can't be erased from a school", nil)
+		return
+	}
+
+	rowsAffected, err := qtx.AnonymiseUser(r.Context(), database.AnonymiseUserParams{
+		ID:       userToErase,
+		SchoolID: schoolID,
+	})
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not erase user", err)
+		return
+	}
+	if rowsAffected == 0 {
+		err = errors.New("user not found")
+		helpers.RespondWithError(w, http.StatusNotFound, "User not found in school", nil)
+		return
+	}
+
+	err = qtx.DeleteUserPersonalData(r.Context(), userToErase)
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not erase user data", err)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "user erased", "target_user_id", userToErase)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ErasePupil removes a pupil's record, their class history and custom group memberships, and
+// any drop targets and staff subscriptions naming them. Drops that named them stay for their other audiences.
+func ErasePupil(cfg *config.ApiConfig, dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+	if auth.DemoRestricted(cfg, dbq, r) {
+		slog.InfoContext(r.Context(), "blocked in demo mode", "action", "pupil erasure")
+		helpers.RespondWithError(w, http.StatusForbidden, "Pupil erasure is disabled in demo mode", errors.New("demo mode restriction"))
+		return
+	}
+
+	pupilID, err := strconv.Atoi(r.PathValue("pupilID"))
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusBadRequest, "No valid pupil id in path", err)
+		return
+	}
+
+	contextValueSchoolID := r.Context().Value(auth.UserSchoolKey)
+	schoolID, schoolOk := contextValueSchoolID.(uuid.UUID)
+	if !schoolOk {
+		slog.ErrorContext(r.Context(), "user not found in request context")
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Internal Server Error (context error)", nil)
+		return
+	}
+
+	rowsAffected, err := dbq.ErasePupil(r.Context(), database.ErasePupilParams{
+		SchoolID: schoolID,
+		PupilID:  int32(pupilID),
+	})
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not erase pupil", err)
+		return
+	}
+	if rowsAffected == 0 {
+		helpers.RespondWithError(w, http.StatusNotFound, "Pupil not found in school", nil)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "pupil erased", "pupil_id", pupilID)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,409 @@
+package dataprotection
+
+import (
+	"archive/zip"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/5tuartw/droplet/internal/auth"
+	"github.com/5tuartw/droplet/internal/config"
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/helpers"
+	"github.com/google/uuid"
+)
+
+// ExportDrop is a drop as it appears in a data subject export.
+type ExportDrop struct {
+	ID              uuid.UUID  `json:"id"`
+	Title           string     `json:"title"`
+	Content         string     `json:"content"`
+	Status          string     `json:"status"`
+	Priority        string     `json:"priority"`
+	PostDate        time.Time  `json:"post_date"`
+	ExpireDate      time.Time  `json:"expire_date"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	AuthorID        uuid.UUID  `json:"author_id"`
+	EditedBy        *uuid.UUID `json:"edited_by,omitempty"`
+	ReviewedBy      *uuid.UUID `json:"reviewed_by,omitempty"`
+	ReviewedAt      *time.Time `json:"reviewed_at,omitempty"`
+	RejectionReason string     `json:"rejection_reason,omitempty"`
+}
+
+type ClassPlacement struct {
+	ClassID   *int32     `json:"class_id"`
+	ClassName string     `json:"class_name"`
+	StartedAt *time.Time `json:"started_at"`
+	EndedAt   *time.Time `json:"ended_at"`
+}
+
+type PupilExport struct {
+	ExportedAt   time.Time        `json:"exported_at"`
+	PupilID      int32            `json:"pupil_id"`
+	FirstName    string           `json:"first_name"`
+	Surname      string           `json:"surname"`
+	ClassID      *int32           `json:"class_id"`
+	ClassName    string           `json:"class_name,omitempty"`
+	ClassHistory []ClassPlacement `json:"class_history"`
+	Drops        []ExportDrop     `json:"drops"`
+}
+
+type ExportSession struct {
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+type ExportAttachment struct {
+	ID          int32     `json:"id"`
+	DropID      uuid.UUID `json:"drop_id"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	SizeBytes   int64     `json:"size_bytes"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type ExportSettings struct {
+	ColorTheme string    `json:"color_theme"`
+	LayoutPref string    `json:"layout_pref"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type UserExport struct {
+	ExportedAt          time.Time                                `json:"exported_at"`
+	UserID              uuid.UUID                                `json:"user_id"`
+	Email               string                                   `json:"email"`
+	Role                string                                   `json:"role"`
+	Title               string                                   `json:"title"`
+	FirstName           string                                   `json:"first_name"`
+	Surname             string                                   `json:"surname"`
+	CreatedAt           time.Time                                `json:"created_at"`
+	UpdatedAt           time.Time                                `json:"updated_at"`
+	Settings            *ExportSettings                          `json:"settings"`
+	TargetSubscriptions []database.GetSubscriptionsForUserRow    `json:"target_subscriptions"`
+	TagSubscriptions    []database.GetTagSubscriptionsForUserRow `json:"tag_subscriptions"`
+	RoleScopes          []database.GetRoleScopesForUserRow       `json:"role_scopes"`
+	Sessions            []ExportSession                          `json:"sessions"`
+	Drops               []ExportDrop                             `json:"drops"`
+	Attachments         []ExportAttachment                       `json:"attachments"`
+}
+
+func ExportPupil(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+	contextValueSchool := r.Context().Value(auth.UserSchoolKey)
+	schoolID, schoolOk := contextValueSchool.(uuid.UUID)
+	if !schoolOk {
+		slog.ErrorContext(r.Context(), "user not found in request context")
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Context error", nil)
+		return
+	}
+
+	pupilID, err := strconv.Atoi(r.PathValue("pupilID"))
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusBadRequest, "Could not parse pupil ID in path", err)
+		return
+	}
+
+	pupil, err := dbq.GetPupil(r.Context(), database.GetPupilParams{
+		ID:       int32(pupilID),
+		SchoolID: schoolID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			helpers.RespondWithError(w, http.StatusNotFound, "Pupil not found", err)
+		} else {
+			helpers.RespondWithError(w, http.StatusInternalServerError, "Could not get pupil", err)
+		}
+		return
+	}
+
+	history, err := dbq.GetPupilClassHistory(r.Context(), database.GetPupilClassHistoryParams{
+		PupilID:  pupil.ID,
+		SchoolID: schoolID,
+	})
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not get class history", err)
+		return
+	}
+
+	drops, err := dbq.GetDropsTargetingPupil(r.Context(), database.GetDropsTargetingPupilParams{
+		SchoolID: schoolID,
+		TargetID: sql.NullInt32{Int32: pupil.ID, Valid: true},
+	})
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not get drops", err)
+		return
+	}
+
+	export := PupilExport{
+		ExportedAt:   time.Now().UTC(),
+		PupilID:      pupil.ID,
+		FirstName:    pupil.FirstName,
+		Surname:      pupil.Surname,
+		ClassID:      nullInt32(pupil.ClassID),
+		ClassName:    pupil.ClassName.String,
+		ClassHistory: make([]ClassPlacement, 0, len(history)),
+		Drops:        exportDrops(drops),
+	}
+	for _, h := range history {
+		export.ClassHistory = append(export.ClassHistory, ClassPlacement{
+			ClassID:   nullInt32(h.ClassID),
+			ClassName: h.ClassName,
+			StartedAt: nullTime(h.StartedAt),
+			EndedAt:   nullTime(h.EndedAt),
+		})
+	}
+
+	slog.InfoContext(r.Context(), "pupil data exported", "pupil_id", pupil.ID)
+	respondWithExport(w, r, fmt.Sprintf("pupil-%d", pupil.ID), export, nil)
+}
+
+func ExportUser(cfg *config.ApiConfig, dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+	contextValueSchool := r.Context().Value(auth.UserSchoolKey)
+	schoolID, schoolOk := contextValueSchool.(uuid.UUID)
+	if !schoolOk {
+		slog.ErrorContext(r.Context(), "user not found in request context")
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Internal Server Error (context error)", nil)
+		return
+	}
+
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusBadRequest, "No valid user id in path", err)
+		return
+	}
+
+	user, err := dbq.GetUserById(r.Context(), database.GetUserByIdParams{
+		ID:       userID,
+		SchoolID: schoolID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			helpers.RespondWithError(w, http.StatusNotFound, "User not found in school", err)
+		} else {
+			helpers.RespondWithError(w, http.StatusInternalServerError, "Could not get user", err)
+		}
+		return
+	}
+
+	export := UserExport{
+		ExportedAt: time.Now().UTC(),
+		UserID:     user.ID,
+		Email:      user.Email,
+		Role:       string(user.Role),
+		Title:      user.Title,
+		FirstName:  user.FirstName,
+		Surname:    user.Surname,
+		CreatedAt:  user.CreatedAt,
+		UpdatedAt:  user.UpdatedAt,
+	}
+
+	settings, err := dbq.GetUserSettings(r.Context(), database.GetUserSettingsParams{UserID: userID, SchoolID: schoolID})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not get user settings", err)
+		return
+	}
+	if err == nil {
+		export.Settings = &ExportSettings{
+			ColorTheme: settings.ColorTheme,
+			LayoutPref: settings.LayoutPref,
+			UpdatedAt:  settings.UpdatedAt,
+		}
+	}
+
+	export.TargetSubscriptions, err = dbq.GetSubscriptionsForUser(r.Context(), database.GetSubscriptionsForUserParams{UserID: userID, SchoolID: schoolID})
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not get subscriptions", err)
+		return
+	}
+	export.TagSubscriptions, err = dbq.GetTagSubscriptionsForUser(r.Context(), database.GetTagSubscriptionsForUserParams{UserID: userID, SchoolID: schoolID})
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not get tag subscriptions", err)
+		return
+	}
+	export.RoleScopes, err = dbq.GetRoleScopesForUser(r.Context(), database.GetRoleScopesForUserParams{UserID: userID, SchoolID: schoolID})
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not get role scopes", err)
+		return
+	}
+	if export.TargetSubscriptions == nil {
+		export.TargetSubscriptions = []database.GetSubscriptionsForUserRow{}
+	}
+	if export.TagSubscriptions == nil {
+		export.TagSubscriptions = []database.GetTagSubscriptionsForUserRow{}
+	}
+	if export.RoleScopes == nil {
+		export.RoleScopes = []database.GetRoleScopesForUserRow{}
+	}
+
+	sessions, err := dbq.GetSessionsForUser(r.Context(), database.GetSessionsForUserParams{UserID: userID, SchoolID: schoolID})
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not get sessions", err)
+		return
+	}
+	export.Sessions = make([]ExportSession, 0, len(sessions))
+	for _, s := range sessions {
+		export.Sessions = append(export.Sessions, ExportSession{
+			CreatedAt: s.CreatedAt,
+			ExpiresAt: s.ExpiresAt,
+			RevokedAt: nullTime(s.RevokedAt),
+		})
+	}
+
+	drops, err := dbq.GetDropsByUser(r.Context(), database.GetDropsByUserParams{SchoolID: schoolID, UserID: userID})
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not get drops", err)
+		return
+	}
+	export.Drops = exportDrops(drops)
+
+	attachments, err := dbq.GetAttachmentsUploadedByUser(r.Context(), database.GetAttachmentsUploadedByUserParams{
+		SchoolID:   schoolID,
+		UploadedBy: uuid.NullUUID{UUID: userID, Valid: true},
+	})
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not get attachments", err)
+		return
+	}
+	export.Attachments = make([]ExportAttachment, 0, len(attachments))
+	for _, a := range attachments {
+		export.Attachments = append(export.Attachments, ExportAttachment{
+			ID:          a.ID,
+			DropID:      a.DropID,
+			Filename:    a.Filename,
+			ContentType: a.ContentType,
+			SizeBytes:   a.SizeBytes,
+			CreatedAt:   a.CreatedAt,
+		})
+	}
+
+	// Zipped exports carry the uploaded files themselves as well as their metadata
+	var files []bundleFile
+	if cfg.Attachments != nil {
+		for _, a := range attachments {
+			a := a
+			files = append(files, bundleFile{
+				name: fmt.Sprintf("attachments/%d-%s", a.ID, a.Filename),
+				open: func() (io.ReadCloser, error) { return cfg.Attachments.Get(r.Context(), a.StorageKey) },
+			})
+		}
+	}
+
+	slog.InfoContext(r.Context(), "user data exported", "target_user_id", userID)
+	respondWithExport(w, r, "user-"+userID.String(), export, files)
+}
+
+type bundleFile struct {
+	name string
+	open func() (io.ReadCloser, error)
+}
+
+// respondWithExport writes the export as JSON, or as a ZIP bundle containing
+// export.json and any extra files when ?format=zip is requested.
+func respondWithExport(w http.ResponseWriter, r *http.Request, name string, export interface{}, files []bundleFile) {
+	switch r.URL.Query().Get("format") {
+	case "", "json":
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.json"`, name))
+		helpers.RespondWithJSON(w, http.StatusOK, export)
+		return
+	case "zip":
+	default:
+		helpers.RespondWithError(w, http.StatusBadRequest, "format must be json or zip", nil)
+		return
+	}
+
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not encode export", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.zip"`, name))
+	w.WriteHeader(http.StatusOK)
+
+	zw := zip.NewWriter(w)
+	defer zw.Close()
+
+	f, err := zw.Create("export.json")
+	if err != nil {
+		slog.ErrorContext(r.Context(), "could not write export bundle", "bundle", name, "error", err)
+		return
+	}
+	if _, err := f.Write(data); err != nil {
+		slog.ErrorContext(r.Context(), "could not write export bundle", "bundle", name, "error", err)
+		return
+	}
+
+	for _, file := range files {
+		if err := copyToBundle(zw, file); err != nil {
+			// Headers are already sent, so a missing file is logged rather than failing the export
+			slog.ErrorContext(r.Context(), "could not add file to export bundle", "bundle", name, "file", file.name, "error", err)
+		}
+	}
+}
+
+func copyToBundle(zw *zip.Writer, file bundleFile) error {
+	body, err := file.open()
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	f, err := zw.Create(file.name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, body)
+	return err
+}
+
+func exportDrops(drops []database.Drop) []ExportDrop {
+	result := make([]ExportDrop, 0, len(drops))
+	for _, d := range drops {
+		result = append(result, ExportDrop{
+			ID:              d.ID,
+			Title:           d.Title,
+			Content:         d.Content,
+			Status:          string(d.Status),
+			Priority:        string(d.Priority),
+			PostDate:        d.PostDate,
+			ExpireDate:      d.ExpireDate,
+			CreatedAt:       d.CreatedAt,
+			UpdatedAt:       d.UpdatedAt,
+			AuthorID:        d.UserID,
+			EditedBy:        nullUUID(d.EditedBy),
+			ReviewedBy:      nullUUID(d.ReviewedBy),
+			ReviewedAt:      nullTime(d.ReviewedAt),
+			RejectionReason: d.RejectionReason.String,
+		})
+	}
+	return result
+}
+
+func nullInt32(v sql.NullInt32) *int32 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Int32
+}
+
+func nullTime(v sql.NullTime) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Time
+}
+
+func nullUUID(v uuid.NullUUID) *uuid.UUID {
+	if !v.Valid {
+		return nil
+	}
+	return &v.UUID
+}
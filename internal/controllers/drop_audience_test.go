@@ -0,0 +1,250 @@
+package api_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// drop_audience is kept by triggers; follow one drop through the changes that move it in and out of a
+// teacher's "My Drops" and check the stored audience matches the rebuilt one after each
+func TestDropAudienceFollowsChanges(t *testing.T) {
+	if testDB == nil {
+		t.Fatal("Test database connection pool (testDB) is nil")
+	}
+	ctx := context.Background()
+	dbq := database.New(testDB)
+
+	teacherID := seedTestUser(t, testDB, "audience.teacher@example.com", "password123", testSchoolID, false)
+	dropID := uuid.New()
+
+	var pupilID int32
+	err := testDB.QueryRow(`INSERT INTO pupils (first_name, surname, class_id, school_id) VALUES ('Ada', 'Audience', 5, $1) RETURNING id`,
+		testSchoolID).Scan(&pupilID)
+	require.NoError(t, err)
+	_, err = testDB.Exec(`
+		INSERT INTO drops (id, user_id, school_id, title, content, created_at, updated_at, post_date)
+		VALUES ($1, $2, $3, 'For one pupil', 'Seeded for TestDropAudienceFollowsChanges', NOW(), NOW(), NOW())`,
+		dropID, teacherID, testSchoolID)
+	require.NoError(t, err)
+	_, err = testDB.Exec(`INSERT INTO drop_targets (drop_id, type, target_id, school_id) VALUES ($1, 'Student', $2, $3)`,
+		dropID, pupilID, testSchoolID)
+	require.NoError(t, err)
+
+	sees := func(step string, want bool) {
+		t.Helper()
+		var got bool
+		err := testDB.QueryRow(`SELECT EXISTS (SELECT 1 FROM drop_audience WHERE drop_id = $1 AND user_id = $2)`, dropID, teacherID).Scan(&got)
+		require.NoError(t, err)
+		require.Equal(t, want, got, step)
+
+		diff, err := dbq.DiffDropAudience(ctx)
+		require.NoError(t, err)
+		require.Empty(t, diff, "drop_audience differs from the rebuilt audience after: %s", step)
+	}
+	exec := func(query string, args ...any) {
+		t.Helper()
+		_, err := testDB.Exec(query, args...)
+		require.NoError(t, err, query)
+	}
+
+	sees("targeting a pupil in a class the teacher doesn't teach", false)
+
+	_, err = dbq.AssignClassStaff(ctx, database.AssignClassStaffParams{Role: database.ClassStaffRoleSubjectTeacher, ClassID: 6, UserID: teacherID, SchoolID: testSchoolID})
+	require.NoError(t, err)
+	sees("teaching class 3B", false)
+
+	exec(`UPDATE pupils SET class_id = 6 WHERE id = $1`, pupilID)
+	sees("the pupil moving into 3B", true)
+
+	var tagID int32
+	err = testDB.QueryRow(`INSERT INTO tags (school_id, name) VALUES ($1, 'audience-test') RETURNING id`, testSchoolID).Scan(&tagID)
+	require.NoError(t, err)
+	exec(`INSERT INTO drop_tags (drop_id, tag_id, school_id) VALUES ($1, $2, $3)`, dropID, tagID, testSchoolID)
+	exec(`INSERT INTO tag_subscriptions (user_id, school_id, tag_id, muted) VALUES ($1, $2, $3, true)`, teacherID, testSchoolID, tagID)
+	sees("muting the drop's tag", false)
+
+	exec(`UPDATE drops SET priority = 'urgent' WHERE id = $1`, dropID)
+	sees("the drop becoming urgent", true)
+
+	_, err = dbq.RemoveClassStaff(ctx, database.RemoveClassStaffParams{ClassID: 6, UserID: teacherID, SchoolID: testSchoolID})
+	require.NoError(t, err)
+	sees("handing back class 3B", false)
+
+	exec(`DELETE FROM tag_subscriptions WHERE user_id = $1`, teacherID)
+	exec(`INSERT INTO tag_subscriptions (user_id, school_id, tag_id, muted) VALUES ($1, $2, $3, false)`, teacherID, testSchoolID, tagID)
+	sees("following the drop's tag", true)
+}
+
+// The preview must name the same staff the posted drop reaches
+func TestPreviewAudienceMatchesMyDrops(t *testing.T) {
+	if testDB == nil {
+		t.Fatal("Test database connection pool (testDB) is nil")
+	}
+	testServer, testCfg := newTestServer(t, testDB)
+
+	teacherID := seedTestUser(t, testDB, "preview.teacher@example.com", "password123", testSchoolID, false)
+	_, err := testDB.Exec(`INSERT INTO class_staff (class_id, user_id, school_id, role) VALUES (7, $1, $2, 'teaching_assistant')`, teacherID, testSchoolID)
+	require.NoError(t, err)
+	t.Cleanup(func() { testDB.Exec(`DELETE FROM class_staff WHERE class_id = 7 AND user_id = $1`, teacherID) })
+	var pupilID int32
+	err = testDB.QueryRow(`INSERT INTO pupils (first_name, surname, class_id, school_id) VALUES ('Grace', 'Preview', 7, $1) RETURNING id`,
+		testSchoolID).Scan(&pupilID)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/drops/preview-audience", strings.NewReader(`[{"type": "YearGroup", "id": 4}]`))
+	req.Header.Set("Authorization", "Bearer "+getTestAuthToken(t, testCfg, teacherID))
+	rr := httptest.NewRecorder()
+	testServer.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var preview models.AudiencePreview
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &preview))
+	require.Equal(t, len(preview.Staff), preview.StaffCount)
+	require.Contains(t, preview.Pupils, models.AudiencePupil{ID: pupilID, FirstName: "Grace", Surname: "Preview", ClassName: "4A"})
+
+	dropID := uuid.New()
+	_, err = testDB.Exec(`
+		INSERT INTO drops (id, user_id, school_id, title, content, created_at, updated_at, post_date)
+		VALUES ($1, $2, $3, 'Year 4', 'Seeded for TestPreviewAudienceMatchesMyDrops', NOW(), NOW(), NOW())`,
+		dropID, teacherID, testSchoolID)
+	require.NoError(t, err)
+	_, err = testDB.Exec(`INSERT INTO drop_targets (drop_id, type, target_id, school_id) VALUES ($1, 'YearGroup', 4, $2)`, dropID, testSchoolID)
+	require.NoError(t, err)
+
+	rows, err := testDB.Query(`SELECT user_id FROM drop_audience WHERE drop_id = $1`, dropID)
+	require.NoError(t, err)
+	defer rows.Close()
+	var reached []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		require.NoError(t, rows.Scan(&id))
+		reached = append(reached, id)
+	}
+	require.NoError(t, rows.Err())
+
+	previewed := make([]uuid.UUID, len(preview.Staff))
+	for i, s := range preview.Staff {
+		previewed[i] = s.ID
+	}
+	require.Contains(t, previewed, teacherID)
+	require.ElementsMatch(t, reached, previewed)
+}
+
+// A Lesson target reaches whoever the timetable has teaching the class on that date, and moves with the
+// timetable and its day cycle
+func TestLessonTargetFollowsTimetable(t *testing.T) {
+	if testDB == nil {
+		t.Fatal("Test database connection pool (testDB) is nil")
+	}
+	ctx := context.Background()
+	dbq := database.New(testDB)
+	testServer, testCfg := newTestServer(t, testDB)
+
+	teacherID := seedTestUser(t, testDB, "lesson.teacher@example.com", "password123", testSchoolID, false)
+	t.Cleanup(func() {
+		testDB.Exec(`DELETE FROM lessons WHERE school_id = $1`, testSchoolID)
+		testDB.Exec(`DELETE FROM timetable_cycles WHERE school_id = $1`, testSchoolID)
+	})
+
+	periodID, err := dbq.UpsertTimetablePeriod(ctx, database.UpsertTimetablePeriodParams{SchoolID: testSchoolID, Name: "P2"})
+	require.NoError(t, err)
+	// 2025-01-06 is a Monday: day 1 of the default one-week cycle
+	_, err = dbq.CreateLessons(ctx, database.CreateLessonsParams{
+		SchoolID:  testSchoolID,
+		CycleDays: []int32{1},
+		PeriodIds: []int32{periodID},
+		ClassIds:  []int32{5},
+		UserIds:   []uuid.UUID{teacherID},
+		RoomIds:   []int32{0},
+	})
+	require.NoError(t, err)
+
+	lessonTargets := func() int {
+		t.Helper()
+		var n int
+		require.NoError(t, testDB.QueryRow(`SELECT COUNT(*) FROM lesson_targets WHERE school_id = $1`, testSchoolID).Scan(&n))
+		return n
+	}
+	before := lessonTargets()
+
+	body := `[{"type": "Lesson", "lesson": {"class_id": 5, "period_id": ` + strconv.Itoa(int(periodID)) + `, "date": "2025-01-06"}}]`
+	req := httptest.NewRequest(http.MethodPost, "/api/drops/preview-audience", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+getTestAuthToken(t, testCfg, teacherID))
+	rr := httptest.NewRecorder()
+	testServer.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var preview models.AudiencePreview
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &preview))
+	previewed := make([]uuid.UUID, len(preview.Staff))
+	for i, s := range preview.Staff {
+		previewed[i] = s.ID
+	}
+	require.Contains(t, previewed, teacherID)
+
+	require.Equal(t, before, lessonTargets(), "previewing a lesson doesn't create its lesson target")
+
+	// A drop rejected after its targets are checked leaves no lesson target behind
+	req = httptest.NewRequest(http.MethodPost, "/api/drops", strings.NewReader(`{"title": "Cover for P2",
+		"content": "Seeded for TestLessonTargetFollowsTimetable", "targets": `+body+`, "tag_ids": [999999]}`))
+	req.Header.Set("Authorization", "Bearer "+getTestAuthToken(t, testCfg, teacherID))
+	rr = httptest.NewRecorder()
+	testServer.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusBadRequest, rr.Code, rr.Body.String())
+	require.Equal(t, before, lessonTargets())
+
+	lessonTargetID, err := dbq.UpsertLessonTarget(ctx, database.UpsertLessonTargetParams{
+		ClassID:    sql.NullInt32{Int32: 5, Valid: true},
+		LessonDate: time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC),
+		PeriodID:   periodID,
+		SchoolID:   testSchoolID,
+	})
+	require.NoError(t, err)
+	dropID := uuid.New()
+	_, err = testDB.Exec(`
+		INSERT INTO drops (id, user_id, school_id, title, content, created_at, updated_at, post_date)
+		VALUES ($1, $2, $3, 'Cover for P2', 'Seeded for TestLessonTargetFollowsTimetable', NOW(), NOW(), NOW())`,
+		dropID, teacherID, testSchoolID)
+	require.NoError(t, err)
+	_, err = testDB.Exec(`INSERT INTO drop_targets (drop_id, type, target_id, school_id) VALUES ($1, 'Lesson', $2, $3)`,
+		dropID, lessonTargetID, testSchoolID)
+	require.NoError(t, err)
+
+	sees := func(step string, want bool) {
+		t.Helper()
+		var got bool
+		err := testDB.QueryRow(`SELECT EXISTS (SELECT 1 FROM drop_audience WHERE drop_id = $1 AND user_id = $2)`, dropID, teacherID).Scan(&got)
+		require.NoError(t, err)
+		require.Equal(t, want, got, step)
+
+		diff, err := dbq.DiffDropAudience(ctx)
+		require.NoError(t, err)
+		require.Empty(t, diff, "drop_audience differs from the rebuilt audience after: %s", step)
+	}
+
+	sees("teaching the class in that period", true)
+
+	// 53 weeks after 2024-01-01, 2025-01-06 is day 6 of a fortnightly cycle
+	_, err = dbq.SetTimetableCycle(ctx, database.SetTimetableCycleParams{SchoolID: testSchoolID, CycleDays: 10, StartsOn: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)})
+	require.NoError(t, err)
+	sees("moving to a fortnightly timetable", false)
+
+	_, err = testDB.Exec(`UPDATE lessons SET cycle_day = 6 WHERE user_id = $1`, teacherID)
+	require.NoError(t, err)
+	sees("the lesson moving to day 6", true)
+
+	_, err = dbq.DeleteLessons(ctx, testSchoolID)
+	require.NoError(t, err)
+	sees("the timetable being cleared", false)
+}
@@ -0,0 +1,77 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/pagination"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// Pinned and urgent drops lead the feed across pages, not just within each page
+func TestDropPagingKeepsPinnedAndPriorityOrder(t *testing.T) {
+	if testDB == nil {
+		t.Fatal("Test database connection pool (testDB) is nil")
+	}
+	server := newRouterTestServer(t)
+	authorID, token := seedTestUserWithRole(t, "paging.author@example.com", database.UserRoleUser)
+
+	seed := func(title string, hoursAgo int, priority, pinned string) {
+		t.Helper()
+		dropID := uuid.New()
+		_, err := testDB.Exec(`
+			INSERT INTO drops (id, user_id, school_id, title, content, created_at, updated_at, post_date, expire_date, priority, pinned_until)
+			VALUES ($1, $2, $3, $4, 'Seeded for TestDropPagingKeepsPinnedAndPriorityOrder', NOW(), NOW(),
+				NOW() - make_interval(hours => $5), NOW() + INTERVAL '1 day', $6, NOW() + NULLIF($7, '')::interval)`,
+			dropID, authorID, testSchoolID, title, hoursAgo, priority, pinned)
+		require.NoError(t, err)
+		_, err = testDB.Exec(`INSERT INTO drop_targets (drop_id, type, school_id) VALUES ($1, 'General', $2)`, dropID, testSchoolID)
+		require.NoError(t, err)
+	}
+	// No pin interval leaves pinned_until NULL
+	seed("A", 1, "normal", "")
+	seed("B", 2, "urgent", "")
+	seed("C", 3, "normal", "1 day")
+	seed("D", 4, "important", "")
+	seed("E", 5, "normal", "-1 day") // its pin has run out
+	seed("F", 6, "urgent", "1 day")
+	seed("G", 7, "normal", "")
+
+	// readAll follows X-Next-Cursor from the first page to the last, two drops at a time
+	readAll := func(path, sort string) []string {
+		t.Helper()
+		var titles []string
+		cursor := ""
+		for range 10 {
+			q := url.Values{"author": {authorID.String()}, "limit": {"2"}}
+			if sort != "" {
+				q.Set("sort", sort)
+			}
+			if cursor != "" {
+				q.Set("cursor", cursor)
+			}
+			rr := doJSON(t, server, http.MethodGet, path+"?"+q.Encode(), token, nil)
+			require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+			var page []database.DropWithTargets
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &page))
+			require.LessOrEqual(t, len(page), 2)
+			for _, drop := range page {
+				titles = append(titles, drop.Title)
+			}
+			if cursor = rr.Header().Get(pagination.NextCursorHeader); cursor == "" {
+				return titles
+			}
+		}
+		t.Fatalf("%s did not reach a last page", path)
+		return nil
+	}
+
+	for _, path := range []string{"/api/drops", "/api/mydrops"} {
+		require.Equal(t, []string{"F", "C", "B", "D", "A", "E", "G"}, readAll(path, ""), path)
+		require.Equal(t, []string{"F", "C", "B", "D", "G", "E", "A"}, readAll(path, "post_date"), path+" oldest first")
+	}
+}
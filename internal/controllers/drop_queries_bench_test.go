@@ -0,0 +1,296 @@
+package api_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/pagination"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// The drop list queries against a school with 10k live drops, each with three targets:
+//
+//	go test ./internal/controllers -run '^$' -bench DropQueries -benchmem
+//
+// row_per_target is the query shape the drop lists used before json_agg (one row per target,
+// regrouped in Go); json_agg is the current query reading every drop; json_agg_page reads the first page.
+func BenchmarkDropQueries(b *testing.B) {
+	authorID := seedBenchDrops(b)
+	dbq := database.New(testDB)
+	ctx := context.Background()
+	params := database.GetActiveDropsWithTargetsParams{
+		SchoolID: testSchoolID,
+		AuthorID: uuid.NullUUID{UUID: authorID, Valid: true},
+		SortDesc: true,
+	}
+
+	b.Run("row_per_target", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			drops, err := legacyActiveDrops(ctx, testDB, testSchoolID, authorID)
+			require.NoError(b, err)
+			require.Len(b, drops, benchDropCount)
+		}
+	})
+
+	b.Run("json_agg", func(b *testing.B) {
+		params.PageLimit = benchDropCount + 1
+		for i := 0; i < b.N; i++ {
+			rows, err := dbq.GetActiveDropsWithTargets(ctx, params)
+			require.NoError(b, err)
+			require.Len(b, database.NewDropsWithTargets(rows), benchDropCount)
+		}
+	})
+
+	b.Run("json_agg_page", func(b *testing.B) {
+		params.PageLimit = pagination.DefaultLimit + 1
+		for i := 0; i < b.N; i++ {
+			rows, err := dbq.GetActiveDropsWithTargets(ctx, params)
+			require.NoError(b, err)
+			require.Len(b, database.NewDropsWithTargets(rows), pagination.DefaultLimit+1)
+		}
+	})
+}
+
+// "My Drops" for a class teacher from many concurrent readers, over the bench drops plus
+// benchDropCount more targeted class by class (one in twelve at the teacher's class):
+//
+//	go test ./internal/controllers -run '^$' -bench MyDrops -benchmem -cpu 1,8
+//
+// exists evaluates the visibility rules for every drop, as GET /api/mydrops did before drop_audience;
+// drop_audience is the same page read through the drop_audience table; query is the full current query.
+func BenchmarkMyDrops(b *testing.B) {
+	seedBenchDrops(b)
+	teacherID := seedBenchClassDrops(b)
+	dbq := database.New(testDB)
+	ctx := context.Background()
+	limit := pagination.DefaultLimit + 1
+
+	exists, err := benchMyDropsPage(ctx, "drop_audience_expected", teacherID, limit)
+	require.NoError(b, err)
+	stored, err := benchMyDropsPage(ctx, "drop_audience", teacherID, limit)
+	require.NoError(b, err)
+	require.Len(b, stored, limit)
+	require.Equal(b, exists, stored, "both approaches must return the same page")
+
+	b.Run("exists", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if _, err := benchMyDropsPage(ctx, "drop_audience_expected", teacherID, limit); err != nil {
+					b.Error(err)
+				}
+			}
+		})
+	})
+
+	b.Run("drop_audience", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if _, err := benchMyDropsPage(ctx, "drop_audience", teacherID, limit); err != nil {
+					b.Error(err)
+				}
+			}
+		})
+	})
+
+	b.Run("query", func(b *testing.B) {
+		params := database.GetDropsForUserWithTargetsParams{
+			UserID:    teacherID,
+			SchoolID:  testSchoolID,
+			SortDesc:  true,
+			PageLimit: int32(limit),
+		}
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if _, err := dbq.GetDropsForUserWithTargets(ctx, params); err != nil {
+					b.Error(err)
+				}
+			}
+		})
+	})
+}
+
+// benchMyDropsPage is the first page of a user's "My Drops" IDs, with the audience read from
+// drop_audience or computed by the drop_audience_expected view
+func benchMyDropsPage(ctx context.Context, audience string, userID uuid.UUID, limit int) ([]uuid.UUID, error) {
+	rows, err := testDB.QueryContext(ctx, `
+		SELECT d.id
+		FROM drops d
+		JOIN `+audience+` da ON da.drop_id = d.id AND da.user_id = $1
+		WHERE d.school_id = $2 AND d.status = 'published'
+			AND d.post_date <= NOW() AND (d.expire_date IS NULL OR d.expire_date > NOW())
+		ORDER BY d.post_date DESC, d.id DESC
+		LIMIT $3`,
+		userID, testSchoolID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+const benchDropCount = 10000
+
+var (
+	benchSeedOnce sync.Once
+	benchAuthorID = uuid.MustParse("0b5e7c1a-52d4-4c1e-9a57-bbe9c1d0c0de")
+
+	benchClassSeedOnce sync.Once
+	benchTeacherID     = uuid.MustParse("7eac4e12-52d4-4c1e-9a57-bbe9c1d0c0de")
+)
+
+// seedBenchDrops adds benchDropCount published drops by one author to the test school,
+// each targeted at General, class 2B and Year 2
+func seedBenchDrops(b *testing.B) uuid.UUID {
+	b.Helper()
+	if testDB == nil {
+		b.Fatal("Test database connection pool (testDB) is nil")
+	}
+
+	benchSeedOnce.Do(func() {
+		_, err := testDB.Exec(`
+			INSERT INTO users (id, school_id, created_at, updated_at, email, role, title, first_name, surname)
+			VALUES ($1, $2, NOW(), NOW(), 'bench.author@example.com', 'user', 'Mx', 'Bench', 'Author')`,
+			benchAuthorID, testSchoolID)
+		require.NoError(b, err, "Failed to seed bench author")
+
+		_, err = testDB.Exec(`
+			INSERT INTO drops (id, user_id, school_id, title, content, created_at, updated_at, post_date, expire_date)
+			SELECT gen_random_uuid(), $1, $2, 'Bench drop ' || n, 'Seeded for BenchmarkDropQueries', NOW(), NOW(),
+				NOW() - n * INTERVAL '1 minute', NOW() + INTERVAL '30 days'
+			FROM generate_series(1, $3::int) AS n`,
+			benchAuthorID, testSchoolID, benchDropCount)
+		require.NoError(b, err, "Failed to seed bench drops")
+
+		_, err = testDB.Exec(`
+			INSERT INTO drop_targets (drop_id, type, target_id, school_id)
+			SELECT d.id, t.type::target_type, t.target_id, d.school_id
+			FROM drops d
+			CROSS JOIN (VALUES ('General', NULL::int), ('Class', 4), ('YearGroup', 2)) AS t(type, target_id)
+			WHERE d.user_id = $1`,
+			benchAuthorID)
+		require.NoError(b, err, "Failed to seed bench drop targets")
+
+		_, err = testDB.Exec(`ANALYZE drops, drop_targets`)
+		require.NoError(b, err)
+	})
+	return benchAuthorID
+}
+
+// seedBenchClassDrops adds a tutor of class 1A and benchDropCount published drops by them, each
+// targeted at one class in turn, so most are outside the teacher's audience
+func seedBenchClassDrops(b *testing.B) uuid.UUID {
+	b.Helper()
+	benchClassSeedOnce.Do(func() {
+		_, err := testDB.Exec(`
+			INSERT INTO users (id, school_id, created_at, updated_at, email, role, title, first_name, surname)
+			VALUES ($1, $2, NOW(), NOW(), 'bench.teacher@example.com', 'user', 'Mx', 'Bench', 'Teacher')`,
+			benchTeacherID, testSchoolID)
+		require.NoError(b, err, "Failed to seed bench teacher")
+
+		_, err = testDB.Exec(`INSERT INTO class_staff (class_id, user_id, school_id) VALUES (1, $1, $2)`, benchTeacherID, testSchoolID)
+		require.NoError(b, err)
+
+		_, err = testDB.Exec(`
+			INSERT INTO drops (id, user_id, school_id, title, content, created_at, updated_at, post_date, expire_date)
+			SELECT gen_random_uuid(), $1, $2, 'Class drop ' || n, 'Seeded for BenchmarkMyDrops', NOW(), NOW(),
+				NOW() - n * INTERVAL '1 minute' - INTERVAL '30 seconds', NOW() + INTERVAL '30 days'
+			FROM generate_series(1, $3::int) AS n`,
+			benchTeacherID, testSchoolID, benchDropCount)
+		require.NoError(b, err, "Failed to seed bench class drops")
+
+		_, err = testDB.Exec(`
+			INSERT INTO drop_targets (drop_id, type, target_id, school_id)
+			SELECT d.id, 'Class', row_number() OVER (ORDER BY d.post_date) % 12 + 1, d.school_id
+			FROM drops d
+			WHERE d.user_id = $1`,
+			benchTeacherID)
+		require.NoError(b, err, "Failed to seed bench class drop targets")
+
+		_, err = testDB.Exec(`ANALYZE drops, drop_targets, drop_audience`)
+		require.NoError(b, err)
+	})
+	return benchTeacherID
+}
+
+// legacyActiveDrops is GetActiveDropsWithTargets as it was before json_agg, with the Go regrouping it needed
+func legacyActiveDrops(ctx context.Context, db *sql.DB, schoolID, authorID uuid.UUID) ([]database.DropWithTargets, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT
+			d.id, d.user_id, d.title, d.content, d.post_date, d.updated_at, d.expire_date, d.priority, d.pinned_until,
+			dt.type, dt.target_id,
+			COALESCE(cls.class_name, yg.year_group_name, div.division_name, p.surname || ', ' || p.first_name, 'General'),
+			COALESCE(CONCAT_WS(' ', author.first_name, author.surname), 'Unknown Author')::text,
+			COALESCE(CONCAT_WS(' ', editor.first_name,  editor.surname))::text
+		FROM drops d
+		LEFT JOIN drop_targets dt ON d.id = dt.drop_id
+		LEFT JOIN classes cls ON dt.type = 'Class' AND dt.target_id = cls.id
+		LEFT JOIN year_groups yg ON dt.type = 'YearGroup' AND dt.target_id = yg.id
+		LEFT JOIN divisions div ON dt.type = 'Division' AND dt.target_id = div.id
+		LEFT JOIN pupils p ON dt.type = 'Student' AND dt.target_id = p.id
+		LEFT JOIN users AS author ON d.user_id = author.id
+		LEFT JOIN users AS editor on d.edited_by = editor.id
+		WHERE (d.expire_date IS NULL OR d.expire_date > NOW()) AND d.post_date <= NOW() AND d.school_id = $1
+			AND d.status = 'published' AND d.user_id = $2
+		ORDER BY (d.pinned_until IS NOT NULL AND d.pinned_until > NOW()) DESC, d.priority DESC, d.post_date DESC, d.id, dt.type`,
+		schoolID, authorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byID := make(map[uuid.UUID]*database.DropWithTargets)
+	var order []uuid.UUID
+	for rows.Next() {
+		var drop database.DropWithTargets
+		var priority string
+		var pinnedUntil sql.NullTime
+		var targetType sql.NullString
+		var targetID sql.NullInt32
+		var targetName string
+		if err := rows.Scan(&drop.ID, &drop.UserID, &drop.Title, &drop.Content, &drop.PostDate, &drop.UpdatedAt,
+			&drop.ExpireDate, &priority, &pinnedUntil, &targetType, &targetID, &targetName,
+			&drop.AuthorName, &drop.EditorName); err != nil {
+			return nil, err
+		}
+		existing, ok := byID[drop.ID]
+		if !ok {
+			drop.Priority = priority
+			if pinnedUntil.Valid {
+				drop.PinnedUntil = &pinnedUntil.Time
+			}
+			drop.Targets = make([]database.TargetInfo, 0)
+			existing = &drop
+			byID[drop.ID] = existing
+			order = append(order, drop.ID)
+		}
+		if !targetType.Valid {
+			continue
+		}
+		if targetName == "General" && targetType.String != "General" {
+			targetName = fmt.Sprintf("%s %d", targetType.String, targetID.Int32)
+		}
+		existing.Targets = append(existing.Targets, database.TargetInfo{Type: targetType.String, ID: targetID.Int32, Name: targetName})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	drops := make([]database.DropWithTargets, len(order))
+	for i, id := range order {
+		drops[i] = *byID[id]
+	}
+	return drops, nil
+}
@@ -0,0 +1,204 @@
+package drops
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/5tuartw/droplet/internal/auth"
+	"github.com/5tuartw/droplet/internal/config"
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/helpers"
+	"github.com/5tuartw/droplet/internal/models"
+	"github.com/5tuartw/droplet/internal/schoolsettings"
+	"github.com/google/uuid"
+)
+
+// isSchoolWideTarget reports whether a target reaches beyond a single year group
+func isSchoolWideTarget(targetType string) bool {
+	return targetType == string(database.TargetTypeGeneral) || targetType == string(database.TargetTypeDivision)
+}
+
+// dropStatusFor decides whether a drop posted by role with these targets can go straight out,
+// or must wait in the approval queue under the school's policy.
+func dropStatusFor(ctx context.Context, dbq *database.Queries, role string, schoolID uuid.UUID, dropTargets []models.Target) (database.DropStatus, error) {
+	if auth.HasPermission(role, auth.PermDropsPublishWide) {
+		return database.DropStatusPublished, nil
+	}
+
+	schoolWide := false
+	for _, target := range dropTargets {
+		if isSchoolWideTarget(target.Type) {
+			schoolWide = true
+			break
+		}
+	}
+	if !schoolWide {
+		return database.DropStatusPublished, nil
+	}
+
+	settings, err := schoolsettings.Load(ctx, dbq, schoolID)
+	if err != nil {
+		return "", err
+	}
+	if settings.Drops.RequireApproval {
+		return database.DropStatusPendingApproval, nil
+	}
+	return database.DropStatusPublished, nil
+}
+
+func GetPendingDrops(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+	contextValueSchool := r.Context().Value(auth.UserSchoolKey)
+	schoolID, schoolOk := contextValueSchool.(uuid.UUID)
+	if !schoolOk {
+		slog.ErrorContext(r.Context(), "user not found in request context")
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Internal Server Error (context error)", nil)
+		return
+	}
+
+	rows, err := dbq.GetPendingDropsWithTargets(r.Context(), schoolID)
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not get pending drops", err)
+		return
+	}
+	aggregatedDrops := database.NewDropsWithTargets(rows)
+	if err := attachDropDetails(r.Context(), dbq, schoolID, aggregatedDrops); err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not get drop details", err)
+		return
+	}
+	helpers.RespondWithJSON(w, http.StatusOK, aggregatedDrops)
+}
+
+func ApproveDrop(cfg *config.ApiConfig, dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+	contextValueID := r.Context().Value(auth.UserIDKey)
+	userID, idOk := contextValueID.(uuid.UUID)
+	contextValueSchool := r.Context().Value(auth.UserSchoolKey)
+	schoolID, schoolOk := contextValueSchool.(uuid.UUID)
+
+	if !idOk || !schoolOk {
+		slog.ErrorContext(r.Context(), "user not found in request context")
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Internal Server Error (context error)", nil)
+		return
+	}
+
+	dropID, err := uuid.Parse(r.PathValue("dropID"))
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusBadRequest, "Invalid Drop ID", err)
+		return
+	}
+
+	rowsAffected, err := dbq.ApproveDrop(r.Context(), database.ApproveDropParams{
+		ID:         dropID,
+		SchoolID:   schoolID,
+		ReviewedBy: uuid.NullUUID{UUID: userID, Valid: true},
+	})
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not approve drop", err)
+		return
+	}
+	if rowsAffected == 0 {
+		helpers.RespondWithProblem(w, http.StatusNotFound, helpers.CodeDropNotFound, "No pending drop found with that ID", nil)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "drop approved", "drop_id", dropID)
+
+	approvedDrop, err := dbq.GetDropByID(r.Context(), database.GetDropByIDParams{
+		ID:       dropID,
+		SchoolID: schoolID,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "could not re-read approved drop for notifications", "drop_id", dropID, "error", err)
+	} else if claimUrgentPush(r.Context(), dbq, approvedDrop) {
+		cfg.Notifier.SendUrgentAsync(r.Context(), urgentDrop(approvedDrop))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type RejectDropRequest struct {
+	Reason string `json:"reason"`
+}
+
+func RejectDrop(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+	contextValueID := r.Context().Value(auth.UserIDKey)
+	userID, idOk := contextValueID.(uuid.UUID)
+	contextValueSchool := r.Context().Value(auth.UserSchoolKey)
+	schoolID, schoolOk := contextValueSchool.(uuid.UUID)
+
+	if !idOk || !schoolOk {
+		slog.ErrorContext(r.Context(), "user not found in request context")
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Internal Server Error (context error)", nil)
+		return
+	}
+
+	dropID, err := uuid.Parse(r.PathValue("dropID"))
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusBadRequest, "Invalid Drop ID", err)
+		return
+	}
+
+	requestBody := RejectDropRequest{}
+	if !helpers.DecodeJSON(w, r, &requestBody) {
+		return
+	}
+
+	if requestBody.Reason == "" {
+		helpers.RespondWithError(w, http.StatusBadRequest, "A reason is required when rejecting a drop", errors.New("missing rejection reason"))
+		return
+	}
+
+	rowsAffected, err := dbq.RejectDrop(r.Context(), database.RejectDropParams{
+		ID:              dropID,
+		SchoolID:        schoolID,
+		ReviewedBy:      uuid.NullUUID{UUID: userID, Valid: true},
+		RejectionReason: sql.NullString{String: requestBody.Reason, Valid: true},
+	})
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not reject drop", err)
+		return
+	}
+	if rowsAffected == 0 {
+		helpers.RespondWithProblem(w, http.StatusNotFound, helpers.CodeDropNotFound, "No pending drop found with that ID", nil)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "drop rejected", "drop_id", dropID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ResubmitDrop sends a rejected drop back to the approval queue, normally after the author has edited it.
+// Permission to manage the drop is checked by RequirePermission.
+func ResubmitDrop(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+	contextValueSchool := r.Context().Value(auth.UserSchoolKey)
+	schoolID, schoolOk := contextValueSchool.(uuid.UUID)
+
+	if !schoolOk {
+		slog.ErrorContext(r.Context(), "user not found in request context")
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Internal Server Error (context error)", nil)
+		return
+	}
+
+	dropID, err := uuid.Parse(r.PathValue("dropID"))
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusBadRequest, "Invalid Drop ID", err)
+		return
+	}
+
+	rowsAffected, err := dbq.ResubmitDrop(r.Context(), database.ResubmitDropParams{
+		ID:       dropID,
+		SchoolID: schoolID,
+	})
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not resubmit drop", err)
+		return
+	}
+	if rowsAffected == 0 {
+		helpers.RespondWithProblem(w, http.StatusNotFound, helpers.CodeDropNotFound, "No rejected drop found with that ID", nil)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "drop resubmitted for approval", "drop_id", dropID)
+	w.WriteHeader(http.StatusNoContent)
+}
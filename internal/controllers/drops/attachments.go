@@ -0,0 +1,375 @@
+package drops
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/5tuartw/droplet/internal/auth"
+	"github.com/5tuartw/droplet/internal/config"
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/helpers"
+	"github.com/5tuartw/droplet/internal/storage"
+	"github.com/google/uuid"
+)
+
+// Attachment types are decided by sniffing the file, not by trusting the browser's Content-Type
+var allowedAttachmentTypes = map[string]bool{
+	"application/pdf":           true,
+	"image/png":                 true,
+	"image/jpeg":                true,
+	"image/gif":                 true,
+	"image/webp":                true,
+	"text/plain; charset=utf-8": true,
+}
+
+// Office documents sniff as zip files, so they are recognised by extension instead
+var officeAttachmentTypes = map[string]string{
+	".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+}
+
+// attachmentContentType returns the type to store a file as, or false if it is not allowed
+func attachmentContentType(head []byte, filename string) (string, bool) {
+	sniffed := http.DetectContentType(head)
+	if allowedAttachmentTypes[sniffed] {
+		return sniffed, true
+	}
+	if sniffed == "application/zip" {
+		officeType, ok := officeAttachmentTypes[strings.ToLower(filepath.Ext(filename))]
+		return officeType, ok
+	}
+	return sniffed, false
+}
+
+// cleanAttachmentFilename keeps just the base name, without control characters or quotes
+func cleanAttachmentFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	if len(name) > 255 {
+		name = name[len(name)-255:]
+	}
+	if name == "" || name == "." || name == "/" {
+		name = "attachment"
+	}
+	return name
+}
+
+// canViewDrop applies the same rule as GET /api/drops/{dropID}: drops awaiting approval
+// (or rejected) are only visible to their author and to approvers
+func canViewDrop(drop database.Drop, userID uuid.UUID, userRole string) bool {
+	return drop.Status == database.DropStatusPublished || drop.UserID == userID || auth.HasPermission(userRole, auth.PermDropsApprove)
+}
+
+// UploadAttachment stores a multipart "file" upload against a drop. Permission to manage the drop is checked by RequirePermission.
+func UploadAttachment(cfg *config.ApiConfig, dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+	if cfg.Attachments == nil {
+		helpers.RespondWithError(w, http.StatusServiceUnavailable, "Attachments are not enabled on this server", nil)
+		return
+	}
+
+	contextValueID := r.Context().Value(auth.UserIDKey)
+	userID, idOk := contextValueID.(uuid.UUID)
+	contextValueSchool := r.Context().Value(auth.UserSchoolKey)
+	schoolID, schoolOk := contextValueSchool.(uuid.UUID)
+
+	if !idOk || !schoolOk {
+		slog.ErrorContext(r.Context(), "user not found in request context")
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Internal Server Error (context error)", nil)
+		return
+	}
+
+	dropID, err := uuid.Parse(r.PathValue("dropID"))
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusBadRequest, "Invalid Drop ID", err)
+		return
+	}
+
+	_, err = dbq.GetDropByID(r.Context(), database.GetDropByIDParams{
+		ID:       dropID,
+		SchoolID: schoolID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			helpers.RespondWithProblem(w, http.StatusNotFound, helpers.CodeDropNotFound, "Drop not found", nil)
+		} else {
+			helpers.RespondWithError(w, http.StatusInternalServerError, "Could not look up drop", err)
+		}
+		return
+	}
+
+	// Allow a little over the file limit for the multipart envelope; larger parts spill to temp files
+	r.Body = http.MaxBytesReader(w, r.Body, cfg.MaxAttachmentBytes+(1<<20))
+	err = r.ParseMultipartForm(1 << 20)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			helpers.RespondWithError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Attachments must be %d MB or smaller", cfg.MaxAttachmentBytes>>20), err)
+		} else {
+			helpers.RespondWithError(w, http.StatusBadRequest, "Could not read multipart upload", err)
+		}
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusBadRequest, "Upload must include a \"file\" field", err)
+		return
+	}
+	defer file.Close()
+
+	if header.Size > cfg.MaxAttachmentBytes {
+		helpers.RespondWithError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Attachments must be %d MB or smaller", cfg.MaxAttachmentBytes>>20), nil)
+		return
+	}
+	if header.Size == 0 {
+		helpers.RespondWithError(w, http.StatusBadRequest, "Attachment is empty", nil)
+		return
+	}
+
+	filename := cleanAttachmentFilename(header.Filename)
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		helpers.RespondWithError(w, http.StatusBadRequest, "Could not read attachment", err)
+		return
+	}
+	contentType, ok := attachmentContentType(head[:n], filename)
+	if !ok {
+		helpers.RespondWithError(w, http.StatusUnsupportedMediaType, fmt.Sprintf("Attachments of type %s are not allowed", contentType), nil)
+		return
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not read attachment", err)
+		return
+	}
+
+	storageKey := fmt.Sprintf("%s/%s/%s", schoolID, dropID, uuid.New())
+	err = cfg.Attachments.Put(r.Context(), storageKey, file, header.Size, contentType)
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not store attachment", err)
+		return
+	}
+
+	attachment, err := dbq.CreateAttachment(r.Context(), database.CreateAttachmentParams{
+		DropID:      dropID,
+		SchoolID:    schoolID,
+		Filename:    filename,
+		ContentType: contentType,
+		SizeBytes:   header.Size,
+		StorageKey:  storageKey,
+		UploadedBy:  uuid.NullUUID{UUID: userID, Valid: true},
+	})
+	if err != nil {
+		if delErr := cfg.Attachments.Delete(r.Context(), storageKey); delErr != nil {
+			slog.ErrorContext(r.Context(), "could not clean up orphaned attachment", "storage_key", storageKey, "error", delErr)
+		}
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not save attachment", err)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "attachment added", "drop_id", dropID, "attachment_id", attachment.ID, "content_type", contentType, "bytes", header.Size)
+	helpers.RespondWithJSON(w, http.StatusCreated, database.NewAttachmentInfo(attachment))
+}
+
+// GetAttachment streams an attachment back to anyone who can see its drop
+func GetAttachment(cfg *config.ApiConfig, dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+	if cfg.Attachments == nil {
+		helpers.RespondWithError(w, http.StatusServiceUnavailable, "Attachments are not enabled on this server", nil)
+		return
+	}
+
+	contextValueID := r.Context().Value(auth.UserIDKey)
+	userID, idOk := contextValueID.(uuid.UUID)
+	contextValueSchool := r.Context().Value(auth.UserSchoolKey)
+	schoolID, schoolOk := contextValueSchool.(uuid.UUID)
+	contextValueRole := r.Context().Value(auth.UserRoleKey)
+	userRole, roleOk := contextValueRole.(string)
+	if !idOk || !schoolOk || !roleOk {
+		slog.ErrorContext(r.Context(), "user not found in request context")
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Internal Server Error (context error)", nil)
+		return
+	}
+
+	dropID, err := uuid.Parse(r.PathValue("dropID"))
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusBadRequest, "Invalid Drop ID", err)
+		return
+	}
+	attachmentID, err := strconv.Atoi(r.PathValue("attachmentID"))
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusBadRequest, "Invalid attachment ID", err)
+		return
+	}
+
+	drop, err := dbq.GetDropByID(r.Context(), database.GetDropByIDParams{
+		ID:       dropID,
+		SchoolID: schoolID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			helpers.RespondWithProblem(w, http.StatusNotFound, helpers.CodeAttachmentNotFound, "Attachment not found", nil)
+		} else {
+			helpers.RespondWithError(w, http.StatusInternalServerError, "Could not look up drop", err)
+		}
+		return
+	}
+	if !canViewDrop(drop, userID, userRole) {
+		helpers.RespondWithProblem(w, http.StatusNotFound, helpers.CodeAttachmentNotFound, "Attachment not found", nil)
+		return
+	}
+
+	attachment, err := dbq.GetAttachmentByID(r.Context(), database.GetAttachmentByIDParams{
+		ID:       int32(attachmentID),
+		DropID:   dropID,
+		SchoolID: schoolID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			helpers.RespondWithProblem(w, http.StatusNotFound, helpers.CodeAttachmentNotFound, "Attachment not found", nil)
+		} else {
+			helpers.RespondWithError(w, http.StatusInternalServerError, "Could not look up attachment", err)
+		}
+		return
+	}
+
+	body, err := cfg.Attachments.Get(r.Context(), attachment.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			slog.ErrorContext(r.Context(), "attachment missing from storage", "attachment_id", attachment.ID, "storage", cfg.Attachments.Name())
+			helpers.RespondWithProblem(w, http.StatusNotFound, helpers.CodeAttachmentNotFound, "Attachment not found", nil)
+		} else {
+			helpers.RespondWithError(w, http.StatusInternalServerError, "Could not read attachment", err)
+		}
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.SizeBytes, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, body); err != nil {
+		slog.ErrorContext(r.Context(), "could not stream attachment", "attachment_id", attachment.ID, "error", err)
+	}
+}
+
+// DeleteAttachment removes one attachment. Permission to manage the drop is checked by RequirePermission.
+func DeleteAttachment(cfg *config.ApiConfig, dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+	contextValueSchool := r.Context().Value(auth.UserSchoolKey)
+	schoolID, schoolOk := contextValueSchool.(uuid.UUID)
+
+	if !schoolOk {
+		slog.ErrorContext(r.Context(), "user not found in request context")
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Internal Server Error (context error)", nil)
+		return
+	}
+
+	dropID, err := uuid.Parse(r.PathValue("dropID"))
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusBadRequest, "Invalid Drop ID", err)
+		return
+	}
+	attachmentID, err := strconv.Atoi(r.PathValue("attachmentID"))
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusBadRequest, "Invalid attachment ID", err)
+		return
+	}
+
+	attachment, err := dbq.GetAttachmentByID(r.Context(), database.GetAttachmentByIDParams{
+		ID:       int32(attachmentID),
+		DropID:   dropID,
+		SchoolID: schoolID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			helpers.RespondWithProblem(w, http.StatusNotFound, helpers.CodeAttachmentNotFound, "Attachment not found", nil)
+		} else {
+			helpers.RespondWithError(w, http.StatusInternalServerError, "Could not look up attachment", err)
+		}
+		return
+	}
+
+	err = dbq.DeleteAttachment(r.Context(), database.DeleteAttachmentParams{
+		ID:       attachment.ID,
+		DropID:   dropID,
+		SchoolID: schoolID,
+	})
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not delete attachment", err)
+		return
+	}
+	deleteStoredAttachments(cfg, dbq, r, []string{attachment.StorageKey})
+
+	slog.InfoContext(r.Context(), "attachment removed", "drop_id", dropID, "attachment_id", attachment.ID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// deleteStoredAttachments removes files once their database rows are gone. Files that can't be deleted
+// now stay queued in attachment_deletions for ReapDeletedAttachments, so failures are only logged.
+func deleteStoredAttachments(cfg *config.ApiConfig, dbq *database.Queries, r *http.Request, storageKeys []string) {
+	if cfg.Attachments == nil {
+		return
+	}
+	deleted := deleteStoredFiles(r.Context(), cfg.Attachments, storageKeys)
+	if err := dbq.ForgetAttachmentDeletions(r.Context(), deleted); err != nil {
+		slog.ErrorContext(r.Context(), "could not mark attachment files as deleted", "error", err)
+	}
+}
+
+// deleteStoredFiles deletes what it can and returns the keys it deleted
+func deleteStoredFiles(ctx context.Context, store storage.Store, storageKeys []string) []string {
+	deleted := make([]string, 0, len(storageKeys))
+	for _, key := range storageKeys {
+		if err := store.Delete(ctx, key); err != nil {
+			slog.ErrorContext(ctx, "could not delete stored attachment", "storage_key", key, "error", err)
+			continue
+		}
+		deleted = append(deleted, key)
+	}
+	return deleted
+}
+
+// reapBatch is how many queued files ReapDeletedAttachments deletes between database round trips
+const reapBatch = 100
+
+// ReapDeletedAttachments deletes the files of attachments whose rows have gone, however they went: a drop
+// or user deleted, a school removed or replaced by an import. It returns how many files it deleted.
+func ReapDeletedAttachments(ctx context.Context, store storage.Store, dbq *database.Queries) (int, error) {
+	total := 0
+	for {
+		keys, err := dbq.GetAttachmentDeletions(ctx, reapBatch)
+		if err != nil {
+			return total, err
+		}
+		deleted := deleteStoredFiles(ctx, store, keys)
+		if err := dbq.ForgetAttachmentDeletions(ctx, deleted); err != nil {
+			return total, err
+		}
+		total += len(deleted)
+		// Stop at the end of the queue, or if the store is failing so the same files would come round again
+		if len(keys) < reapBatch || len(deleted) < len(keys) {
+			if total > 0 {
+				slog.InfoContext(ctx, "deleted files of removed attachments", "count", total)
+			}
+			return total, nil
+		}
+	}
+}
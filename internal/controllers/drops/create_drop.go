@@ -2,38 +2,37 @@ package drops
 
 import (
 	"database/sql"
-	"encoding/json"
-	"errors"
-	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 
 	"github.com/5tuartw/droplet/internal/auth"
+	"github.com/5tuartw/droplet/internal/config"
+	"github.com/5tuartw/droplet/internal/controllers/tags"
 	"github.com/5tuartw/droplet/internal/controllers/targets"
 	"github.com/5tuartw/droplet/internal/database"
 	"github.com/5tuartw/droplet/internal/helpers"
+	"github.com/5tuartw/droplet/internal/metrics"
 	"github.com/5tuartw/droplet/internal/models"
+	"github.com/5tuartw/droplet/internal/schoolsettings"
 	"github.com/google/uuid"
 )
 
-func CreateDrop(db *sql.DB, dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+func CreateDrop(cfg *config.ApiConfig, db *sql.DB, dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
 	contextValueID := r.Context().Value(auth.UserIDKey)
 	userID, idOk := contextValueID.(uuid.UUID)
 	contextValueSchool := r.Context().Value(auth.UserSchoolKey)
 	schoolID, schoolOk := contextValueSchool.(uuid.UUID)
+	contextValueRole := r.Context().Value(auth.UserRoleKey)
+	userRole, roleOk := contextValueRole.(string)
 
-	if !idOk || !schoolOk {
-		log.Println("Error: one or more value not found in context")
+	if !idOk || !schoolOk || !roleOk {
+		slog.ErrorContext(r.Context(), "user not found in request context")
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Internal Server Error (context error)", nil)
 		return
 	}
 
 	requestBody := models.DropRequest{}
-	decoder := json.NewDecoder(r.Body)
-	defer r.Body.Close()
-	err := decoder.Decode(&requestBody)
-	if err != nil {
-		helpers.RespondWithError(w, http.StatusInternalServerError, "Error decoding json data", err)
+	if !helpers.DecodeJSON(w, r, &requestBody) {
 		return
 	}
 
@@ -44,16 +43,28 @@ func CreateDrop(db *sql.DB, dbq *database.Queries, w http.ResponseWriter, r *htt
 		return
 	}
 
-	expireTime, err := helpers.ParseExpireDate(requestBody.ExpireDate)
+	schoolSettings, err := schoolsettings.Load(r.Context(), dbq, schoolID)
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not load school settings", err)
+		return
+	}
+
+	expireTime, err := helpers.ParseExpireDate(requestBody.ExpireDate, schoolSettings.Drops.DefaultDurationDays)
 	if err != nil {
 		// Use the specific error message returned by the helper
 		helpers.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
 		return
 	}
 
-	//logic to check drop data - NYI length check
-	if requestBody.Content == "" && requestBody.Title == "" {
-		helpers.RespondWithError(w, http.StatusBadRequest, "Title and Content cannot both be empty", errors.New("title and content both empty"))
+	priority, err := parsePriority(requestBody.Priority)
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	pinnedUntil, err := helpers.ParsePinnedUntil(requestBody.PinnedUntil)
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
 		return
 	}
 
@@ -61,8 +72,20 @@ func CreateDrop(db *sql.DB, dbq *database.Queries, w http.ResponseWriter, r *htt
 
 	err = targets.ValidateTargetsBelongToSchool(r.Context(), dbq, schoolID, requestBody.Targets)
 	if err != nil {
-		log.Printf("Target validation failed for user %s school %s: %v", userID, schoolID, err)
-		helpers.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid target(s) provided: %v", err), err)
+		slog.InfoContext(r.Context(), "drop targets rejected", "error", err)
+		helpers.RespondWithProblem(w, http.StatusBadRequest, helpers.CodeTargetInvalidForSchool, "Invalid target(s) provided", err)
+		return
+	}
+
+	err = tags.ValidateTagsBelongToSchool(r.Context(), dbq, schoolID, requestBody.TagIDs)
+	if err != nil {
+		helpers.RespondWithProblem(w, http.StatusBadRequest, helpers.CodeTagInvalidForSchool, "Invalid tag(s) provided", err)
+		return
+	}
+
+	status, err := dropStatusFor(r.Context(), dbq, userRole, schoolID, requestBody.Targets)
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not check drop approval policy", err)
 		return
 	}
 
@@ -71,44 +94,33 @@ func CreateDrop(db *sql.DB, dbq *database.Queries, w http.ResponseWriter, r *htt
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not start database transaction", err)
 		return
 	}
-	defer func() {
-		if p := recover(); p != nil {
-			log.Println("Recovered from panic, rolling back transaction")
-			tx.Rollback() // Rollback on panic
-			panic(p)      // Re-panic after rollback attempt
-		} else if err != nil {
-			log.Printf("Error occurred, rolling back transaction: %v", err)
-			tx.Rollback() // Rollback on normal error
-		} else {
-			// No error, attempt to commit
-			err = tx.Commit() // Commit the transaction
-			if err != nil {
-				log.Printf("Failed to commit transaction: %v", err)
-				// If commit fails, we might have already sent a response,
-				// but ideally we catch this before responding.
-				// RespondWithError might be problematic if headers already sent.
-				// For now, just log it. A robust system might have more complex recovery.
-			} else {
-				log.Println("Transaction committed successfully.")
-			}
-		}
-	}()
+	defer tx.Rollback()
 
 	qtx := dbq.WithTx(tx)
 
 	drop, err := qtx.CreateDrop(r.Context(), database.CreateDropParams{
-		UserID:     userID,
-		SchoolID:   schoolID,
-		Title:      requestBody.Title,
-		Content:    requestBody.Content,
-		PostDate:   postTime,
-		ExpireDate: expireTime,
+		UserID:      userID,
+		SchoolID:    schoolID,
+		Title:       requestBody.Title,
+		Content:     requestBody.Content,
+		PostDate:    postTime,
+		ExpireDate:  expireTime,
+		Status:      status,
+		Priority:    priority,
+		PinnedUntil: pinnedUntil,
 	})
 	if err != nil {
 		helpers.RespondWithError(w, http.StatusInternalServerError, "could not create new drop", err)
 		return
 	}
 
+	err = targets.ResolveLessonTargets(r.Context(), qtx, schoolID, requestBody.Targets)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "could not resolve lesson targets", "drop_id", drop.ID, "error", err)
+		helpers.RespondWithError(w, http.StatusInternalServerError, "could not add new target(s)", err)
+		return
+	}
+
 	for _, target := range requestBody.Targets {
 		dbTargetType := database.TargetType(target.Type)
 		nullTargetID := sql.NullInt32{Valid: false}
@@ -122,11 +134,31 @@ func CreateDrop(db *sql.DB, dbq *database.Queries, w http.ResponseWriter, r *htt
 			SchoolID: schoolID,
 		})
 		if err != nil {
-			log.Printf("TX Error adding target %+v for drop %s: %v", target, drop.ID, err)
+			slog.ErrorContext(r.Context(), "could not add drop target", "drop_id", drop.ID, "target_type", target.Type, "target_id", target.ID, "error", err)
 			helpers.RespondWithError(w, http.StatusInternalServerError, "could not add new target(s)", err)
 			return
 		}
 	}
-	log.Printf("Drop %s added successfully by user %s.", drop.ID, userID)
+
+	err = setDropTags(r.Context(), qtx, schoolID, drop.ID, requestBody.TagIDs)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "could not add drop tags", "drop_id", drop.ID, "error", err)
+		helpers.RespondWithError(w, http.StatusInternalServerError, "could not add tag(s)", err)
+		return
+	}
+
+	pushUrgent := claimUrgentPush(r.Context(), qtx, drop)
+
+	if err = tx.Commit(); err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "could not save new drop", err)
+		return
+	}
+	// Only pushed once the drop is saved, so a failed save sends nothing
+	if pushUrgent {
+		cfg.Notifier.SendUrgentAsync(r.Context(), urgentDrop(drop))
+	}
+
+	slog.InfoContext(r.Context(), "drop created", "drop_id", drop.ID, "status", status)
+	metrics.DropsCreated.Inc(schoolID.String())
 	helpers.RespondWithJSON(w, http.StatusCreated, drop)
 }
@@ -1,26 +1,23 @@
 package drops
 
 import (
-	"errors"
-	"log"
+	"log/slog"
 	"net/http"
 
 	"github.com/5tuartw/droplet/internal/auth"
+	"github.com/5tuartw/droplet/internal/config"
 	"github.com/5tuartw/droplet/internal/database"
 	"github.com/5tuartw/droplet/internal/helpers"
 	"github.com/google/uuid"
 )
 
-func DeleteDrop(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
-	contextValueID := r.Context().Value(auth.UserIDKey)
-	userID, idOk := contextValueID.(uuid.UUID)
+// DeleteDrop removes a drop and its attachments. Permission to manage the drop is checked by RequirePermission.
+func DeleteDrop(cfg *config.ApiConfig, dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
 	contextValueSchool := r.Context().Value(auth.UserSchoolKey)
 	schoolID, schoolOk := contextValueSchool.(uuid.UUID)
-	contextValueRole := r.Context().Value(auth.UserRoleKey)
-	userRole, roleOk := contextValueRole.(string)
 
-	if !idOk || !schoolOk || !roleOk {
-		log.Println("Error: one or more value not found in context")
+	if !schoolOk {
+		slog.ErrorContext(r.Context(), "user not found in request context")
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Internal Server Error (context error)", nil)
 		return
 	}
@@ -32,19 +29,13 @@ func DeleteDrop(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	//check person logged in is writer of drop or admin or developer
-	dropAuthorId, err := dbq.GetUserIdFromDropID(r.Context(), database.GetUserIdFromDropIDParams{
-		ID:       dropId,
+	// note the stored attachment files before the drop's rows cascade away
+	attachmentKeys, err := dbq.GetAttachmentKeysForDrop(r.Context(), database.GetAttachmentKeysForDropParams{
+		DropID:   dropId,
 		SchoolID: schoolID,
 	})
 	if err != nil {
-		helpers.RespondWithError(w, http.StatusInternalServerError, "could not retrieve drop author id", err)
-		return
-	}
-
-	if !(dropAuthorId == userID || userRole == "admin") {
-		helpers.RespondWithError(w, http.StatusForbidden, "Forbidden", errors.New("Forbidden"))
-		log.Printf("Cannot perform drop deletion unless logged in as admin or drop creator")
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not look up drop attachments", err)
 		return
 	}
 
@@ -58,6 +49,9 @@ func DeleteDrop(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	deleteStoredAttachments(cfg, dbq, r, attachmentKeys)
+
+	slog.InfoContext(r.Context(), "drop deleted", "drop_id", dropId)
 	//respond with success/no content
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -0,0 +1,68 @@
+package drops
+
+import (
+	"context"
+
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/google/uuid"
+)
+
+// attachDropDetails looks up the tags and attachments for a page of aggregated drops
+// (one query each, not one per drop) and fills them in
+func attachDropDetails(ctx context.Context, dbq *database.Queries, schoolID uuid.UUID, drops []database.DropWithTargets) error {
+	if len(drops) == 0 {
+		return nil
+	}
+
+	dropIDs := make([]uuid.UUID, 0, len(drops))
+	for _, drop := range drops {
+		dropIDs = append(dropIDs, drop.ID)
+	}
+
+	tagRows, err := dbq.GetTagsForDrops(ctx, database.GetTagsForDropsParams{
+		SchoolID: schoolID,
+		Column2:  dropIDs,
+	})
+	if err != nil {
+		return err
+	}
+	database.AttachDropTags(drops, tagRows)
+
+	attachmentRows, err := dbq.GetAttachmentsForDrops(ctx, database.GetAttachmentsForDropsParams{
+		SchoolID: schoolID,
+		Column2:  dropIDs,
+	})
+	if err != nil {
+		return err
+	}
+	database.AttachDropAttachments(drops, attachmentRows)
+	return nil
+}
+
+// setDropTags replaces a drop's tags inside the caller's transaction
+func setDropTags(ctx context.Context, qtx *database.Queries, schoolID, dropID uuid.UUID, tagIDs []int32) error {
+	err := qtx.DeleteAllTagsForDrop(ctx, database.DeleteAllTagsForDropParams{
+		DropID:   dropID,
+		SchoolID: schoolID,
+	})
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[int32]bool)
+	for _, tagID := range tagIDs {
+		if seen[tagID] {
+			continue
+		}
+		seen[tagID] = true
+		err = qtx.AddDropTag(ctx, database.AddDropTagParams{
+			DropID:   dropID,
+			TagID:    tagID,
+			SchoolID: schoolID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,11 +2,9 @@ package drops
 
 import (
 	"database/sql"
-	"encoding/json"
 	"errors"
-	"log"
+	"log/slog"
 	"net/http"
-	"strings"
 
 	"github.com/5tuartw/droplet/internal/auth"
 	"github.com/5tuartw/droplet/internal/database"
@@ -15,7 +13,7 @@ import (
 	"github.com/google/uuid"
 )
 
-func AddDropTarget(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+func AddDropTarget(db *sql.DB, dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
 	contextValueID := r.Context().Value(auth.UserIDKey)
 	userID, idOk := contextValueID.(uuid.UUID)
 	contextValueSchool := r.Context().Value(auth.UserSchoolKey)
@@ -24,45 +22,31 @@ func AddDropTarget(dbq *database.Queries, w http.ResponseWriter, r *http.Request
 	userRole, roleOk := contextValueRole.(string)
 
 	if !idOk || !schoolOk || !roleOk {
-		log.Println("Error: one or more value not found in context")
+		slog.ErrorContext(r.Context(), "user not found in request context")
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Internal Server Error (context error)", nil)
 		return
 	}
 
 	requestBody := models.DropTarget{}
-	decoder := json.NewDecoder(r.Body)
-	defer r.Body.Close()
-	err := decoder.Decode(&requestBody)
-	if err != nil {
-		helpers.RespondWithError(w, http.StatusBadRequest, "Error decoding json data", err)
+	if !helpers.DecodeJSON(w, r, &requestBody) {
 		return
 	}
 
-	log.Printf("Decoded Request Body Type: '%s'", requestBody.TargetType)
-
-	//check if the current logged in user is the drop creator or an admin
-	dropAuthorId, err := dbq.GetUserIdFromDropID(r.Context(), database.GetUserIdFromDropIDParams{
-		ID: requestBody.DropID,
-		SchoolID: schoolID,
-	})
+	//check the drop is within what the current user may manage
+	allowed, err := auth.CanManageDrop(r.Context(), dbq, userID, schoolID, userRole, requestBody.DropID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			helpers.RespondWithError(w, http.StatusNotFound, "Drop not found", err)
+			helpers.RespondWithProblem(w, http.StatusNotFound, helpers.CodeDropNotFound, "Drop not found", nil)
 		} else {
-			log.Printf("Error getting drop author ID %s: %v", requestBody.DropID, err)
-			helpers.RespondWithError(w, http.StatusInternalServerError, "Could not identify drop author", err)
+			slog.ErrorContext(r.Context(), "could not check drop permissions", "drop_id", requestBody.DropID, "error", err)
+			helpers.RespondWithError(w, http.StatusInternalServerError, "Could not check drop permissions", err)
 		}
 		return
 	}
 
-	// Use case-insensitive role check
-	isAdmin := strings.EqualFold(userRole, "Admin")
-	isAuthor := userID == dropAuthorId
-
-	if !(isAdmin || isAuthor) {
-		log.Printf("Authorization Failed: User %s (Role: %s) attempted to add target to drop %s owned by %s",
-			userID, userRole, requestBody.DropID, dropAuthorId)
-		helpers.RespondWithError(w, http.StatusForbidden, "Forbidden: Only the drop creator or an admin can add targets.", errors.New("forbidden"))
+	if !allowed {
+		slog.InfoContext(r.Context(), "drop target change forbidden", "drop_id", requestBody.DropID)
+		helpers.RespondWithError(w, http.StatusForbidden, "Forbidden: you cannot manage this drop.", errors.New("forbidden"))
 		return
 	}
 
@@ -73,13 +57,55 @@ func AddDropTarget(dbq *database.Queries, w http.ResponseWriter, r *http.Request
 		Type:     dbType,
 		TargetID: sql.NullInt32{Int32: int32(requestBody.TargetID), Valid: requestBody.TargetID != 0},
 	}
-	log.Printf("Params for DB: Type='%s' (Value: %+v)", params.Type, params)
 
-	_, err = dbq.AddDropTarget(r.Context(), params)
+	// Widening a drop to a school-wide audience sends it back for approval where the policy requires it
+	status := database.DropStatusPublished
+	if isSchoolWideTarget(string(dbType)) {
+		status, err = dropStatusFor(r.Context(), dbq, userRole, schoolID, []models.Target{{Type: string(dbType)}})
+		if err != nil {
+			helpers.RespondWithError(w, http.StatusInternalServerError, "Could not check drop approval policy", err)
+			return
+		}
+	}
+
+	tx, err := db.BeginTx(r.Context(), nil)
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not start database transaction", err)
+		return
+	}
+	defer tx.Rollback()
+	qtx := dbq.WithTx(tx)
+
+	_, err = qtx.AddDropTarget(r.Context(), params)
 	if err != nil {
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not add drop target", err)
 		return
 	}
 
+	if status == database.DropStatusPendingApproval {
+		existingDrop, err := qtx.GetDropByID(r.Context(), database.GetDropByIDParams{ID: requestBody.DropID, SchoolID: schoolID})
+		if err != nil {
+			helpers.RespondWithError(w, http.StatusInternalServerError, "Could not read drop", err)
+			return
+		}
+		// Drops already pending or rejected stay as they are until reviewed or resubmitted
+		if existingDrop.Status == database.DropStatusPublished {
+			err = qtx.SetDropStatus(r.Context(), database.SetDropStatusParams{
+				ID:       requestBody.DropID,
+				SchoolID: schoolID,
+				Status:   status,
+			})
+			if err != nil {
+				helpers.RespondWithError(w, http.StatusInternalServerError, "Could not update drop status", err)
+				return
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not save drop target", err)
+		return
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -3,7 +3,7 @@ package drops
 import (
 	"database/sql"
 	"errors"
-	"log"
+	"log/slog"
 	"net/http"
 
 	"github.com/5tuartw/droplet/internal/auth"
@@ -16,17 +16,39 @@ func GetActiveDrops(dbq *database.Queries, w http.ResponseWriter, r *http.Reques
 	contextValueSchool := r.Context().Value(auth.UserSchoolKey)
 	schoolID, schoolOk := contextValueSchool.(uuid.UUID)
 	if !schoolOk {
-		log.Println("Error: school id not found in context")
+		slog.ErrorContext(r.Context(), "user not found in request context")
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Internal Server Error (context error)", nil)
 		return
 	}
 
-	rows, err := dbq.GetActiveDropsWithTargets(r.Context(), schoolID)
+	query, err := parseDropListQuery(r)
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusBadRequest, "Invalid drop list query", err)
+		return
+	}
+
+	rows, err := dbq.GetActiveDropsWithTargets(r.Context(), database.GetActiveDropsWithTargetsParams{
+		SchoolID:      schoolID,
+		Tags:          query.tags,
+		AuthorID:      query.authorID,
+		ClassID:       query.classID,
+		YearGroupID:   query.yearGroupID,
+		AfterPostDate: query.afterPostDate,
+		AfterPinned:   query.afterPinned,
+		AfterPriority: query.afterPriority,
+		SortDesc:      query.page.Desc,
+		AfterID:       query.afterID,
+		PageLimit:     query.page.FetchLimit(),
+	})
 	if err != nil {
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not get drops", err)
 		return
 	}
-	aggregatedDrops := database.AggregateDropRows(rows)
+	aggregatedDrops := pageDrops(w, r, query.page, database.NewDropsWithTargets(rows))
+	if err := attachDropDetails(r.Context(), dbq, schoolID, aggregatedDrops); err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not get drop details", err)
+		return
+	}
 	helpers.RespondWithJSON(w, http.StatusOK, aggregatedDrops)
 }
 
@@ -34,7 +56,7 @@ func GetUpcomingDrops(dbq *database.Queries, w http.ResponseWriter, r *http.Requ
 	contextValueSchool := r.Context().Value(auth.UserSchoolKey)
 	schoolID, schoolOk := contextValueSchool.(uuid.UUID)
 	if !schoolOk {
-		log.Println("Error: school id not found in context")
+		slog.ErrorContext(r.Context(), "user not found in request context")
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Internal Server Error (context error)", nil)
 		return
 	}
@@ -44,7 +66,11 @@ func GetUpcomingDrops(dbq *database.Queries, w http.ResponseWriter, r *http.Requ
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not get drops", err)
 		return
 	}
-	aggregatedDrops := database.AggregateUpcomingDropRows(rows)
+	aggregatedDrops := database.NewDropsWithTargets(rows)
+	if err := attachDropDetails(r.Context(), dbq, schoolID, aggregatedDrops); err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not get drop details", err)
+		return
+	}
 	helpers.RespondWithJSON(w, http.StatusOK, aggregatedDrops)
 }
 
@@ -55,28 +81,52 @@ func GetDropsForUser(dbq *database.Queries, w http.ResponseWriter, r *http.Reque
 	schoolID, schoolOk := contextValueSchool.(uuid.UUID)
 
 	if !idOk || !schoolOk {
-		log.Println("Error: one or more value not found in context")
+		slog.ErrorContext(r.Context(), "user not found in request context")
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Internal Server Error (context error)", nil)
 		return
 	}
 
+	query, err := parseDropListQuery(r)
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusBadRequest, "Invalid drop list query", err)
+		return
+	}
+
 	rows, err := dbq.GetDropsForUserWithTargets(r.Context(), database.GetDropsForUserWithTargetsParams{
-		UserID: userID,
-		SchoolID: schoolID,
+		SchoolID:      schoolID,
+		UserID:        userID,
+		Tags:          query.tags,
+		AuthorID:      query.authorID,
+		ClassID:       query.classID,
+		YearGroupID:   query.yearGroupID,
+		AfterPostDate: query.afterPostDate,
+		AfterPinned:   query.afterPinned,
+		AfterPriority: query.afterPriority,
+		SortDesc:      query.page.Desc,
+		AfterID:       query.afterID,
+		PageLimit:     query.page.FetchLimit(),
 	})
 	if err != nil {
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not get drops", err)
 		return
 	}
-	aggregatedDrops := database.AggregateCurrentUserDropRows(rows)
+	aggregatedDrops := pageDrops(w, r, query.page, database.NewDropsWithTargets(rows))
+	if err := attachDropDetails(r.Context(), dbq, schoolID, aggregatedDrops); err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not get drop details", err)
+		return
+	}
 	helpers.RespondWithJSON(w, http.StatusOK, aggregatedDrops)
 }
 
 func GetDropAndTargets(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+	contextValueID := r.Context().Value(auth.UserIDKey)
+	userID, idOk := contextValueID.(uuid.UUID)
 	contextValueSchool := r.Context().Value(auth.UserSchoolKey)
 	schoolID, schoolOk := contextValueSchool.(uuid.UUID)
-	if !schoolOk {
-		log.Println("Error: school id not found in context")
+	contextValueRole := r.Context().Value(auth.UserRoleKey)
+	userRole, roleOk := contextValueRole.(string)
+	if !idOk || !schoolOk || !roleOk {
+		slog.ErrorContext(r.Context(), "user not found in request context")
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Internal Server Error (context error)", nil)
 		return
 	}
@@ -87,21 +137,32 @@ func GetDropAndTargets(dbq *database.Queries, w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	rows, err := dbq.GetDropWithTargetsByID(r.Context(), database.GetDropWithTargetsByIDParams{
+	row, err := dbq.GetDropWithTargetsByID(r.Context(), database.GetDropWithTargetsByIDParams{
 		ID: dropID,
 		SchoolID: schoolID,
 	})
-	if err != nil && !errors.Is(err, sql.ErrNoRows) {
-		log.Printf("Database error fetching drop %s: %v", dropID, err)
+	if errors.Is(err, sql.ErrNoRows) {
+		helpers.RespondWithProblem(w, http.StatusNotFound, helpers.CodeDropNotFound, "Drop not found", nil)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "could not fetch drop", "drop_id", dropID, "error", err)
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Database error", err)
 		return
 	}
 
-	aggregateDropTargets := database.AggregateDropAndTargetRows(rows)
-	if len(aggregateDropTargets) == 0 {
-		helpers.RespondWithError(w, http.StatusNotFound, "Drop not found", nil)
+	// Drops awaiting approval (or rejected) are only visible to their author and to approvers
+	drop := database.NewDropWithTargets(row)
+	if !canViewDrop(database.Drop{Status: database.DropStatus(drop.Status), UserID: drop.UserID}, userID, userRole) {
+		helpers.RespondWithProblem(w, http.StatusNotFound, helpers.CodeDropNotFound, "Drop not found", nil)
+		return
+	}
+
+	drops := []database.DropWithTargets{drop}
+	if err := attachDropDetails(r.Context(), dbq, schoolID, drops); err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not get drop details", err)
 		return
 	}
 
-	helpers.RespondWithJSON(w, http.StatusOK, aggregateDropTargets[0])
+	helpers.RespondWithJSON(w, http.StatusOK, drops[0])
 }
@@ -0,0 +1,78 @@
+package drops
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/5tuartw/droplet/internal/controllers/tags"
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/helpers"
+	"github.com/5tuartw/droplet/internal/pagination"
+	"github.com/google/uuid"
+)
+
+// dropListQuery is the paging and filters of GET /api/drops and /api/mydrops
+type dropListQuery struct {
+	page          pagination.Params
+	tags          []string
+	authorID      uuid.NullUUID
+	classID       sql.NullInt32
+	yearGroupID   sql.NullInt32
+	afterPinned   bool
+	afterPriority database.NullDropPriority
+	afterPostDate sql.NullTime
+	afterID       uuid.NullUUID
+}
+
+// parseDropListQuery reads ?limit=, ?sort=, ?cursor=, ?tags=, ?author=, ?class= and ?year_group=.
+// Drops are paged pinned first, then by priority, then by post_date, newest first unless ?sort=post_date.
+func parseDropListQuery(r *http.Request) (dropListQuery, error) {
+	var q dropListQuery
+	var err error
+	if q.page, err = pagination.Parse(r.URL.Query(), "-post_date"); err != nil {
+		return q, err
+	}
+	if q.authorID, err = helpers.QueryUUID(r, "author"); err != nil {
+		return q, err
+	}
+	if q.classID, err = helpers.QueryInt32(r, "class"); err != nil {
+		return q, err
+	}
+	if q.yearGroupID, err = helpers.QueryInt32(r, "year_group"); err != nil {
+		return q, err
+	}
+	q.tags = tags.ParseTagFilter(r.URL.Query().Get("tags"))
+
+	if q.page.After != nil {
+		pinned, pinnedErr := strconv.ParseBool(q.page.Key(0))
+		priorityKey := q.page.Key(1)
+		priority, priorityErr := parsePriority(&priorityKey)
+		postDate, dateErr := time.Parse(time.RFC3339Nano, q.page.Key(2))
+		id, idErr := uuid.Parse(q.page.Key(3))
+		if pinnedErr != nil || priorityErr != nil || dateErr != nil || idErr != nil {
+			return q, errors.New("cursor is not valid")
+		}
+		q.afterPinned = pinned
+		q.afterPriority = database.NullDropPriority{DropPriority: priority, Valid: true}
+		q.afterPostDate = sql.NullTime{Time: postDate, Valid: true}
+		q.afterID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+	return q, nil
+}
+
+// pageDrops drops the extra drop fetched to see whether there is a next page and, if there is,
+// advertises the page after the last drop kept
+func pageDrops(w http.ResponseWriter, r *http.Request, page pagination.Params, drops []database.DropWithTargets) []database.DropWithTargets {
+	if len(drops) <= page.Limit {
+		return drops
+	}
+	drops = drops[:page.Limit]
+
+	last := drops[len(drops)-1]
+	pinned := last.PinnedUntil != nil && last.PinnedUntil.After(time.Now())
+	pagination.SetNext(w, r, page, strconv.FormatBool(pinned), last.Priority, last.PostDate.Format(time.RFC3339Nano), last.ID.String())
+	return drops
+}
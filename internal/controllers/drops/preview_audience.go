@@ -0,0 +1,85 @@
+package drops
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/5tuartw/droplet/internal/auth"
+	"github.com/5tuartw/droplet/internal/controllers/targets"
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/helpers"
+	"github.com/5tuartw/droplet/internal/models"
+	"github.com/google/uuid"
+)
+
+// PreviewAudience resolves a set of targets to the staff whose "My Drops" a drop with those targets
+// would appear in, and the pupils it is aimed at. Staff come from the same rules as drop_audience,
+// so the preview matches what is shown once the drop is posted (tags aside).
+func PreviewAudience(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+	contextValueSchool := r.Context().Value(auth.UserSchoolKey)
+	schoolID, schoolOk := contextValueSchool.(uuid.UUID)
+	if !schoolOk {
+		slog.ErrorContext(r.Context(), "user not found in request context")
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Internal Server Error (context error)", nil)
+		return
+	}
+
+	var dropTargets []models.Target
+	if !helpers.DecodeJSON(w, r, &dropTargets) {
+		return
+	}
+
+	if err := targets.ValidateTargetsBelongToSchool(r.Context(), dbq, schoolID, dropTargets); err != nil {
+		helpers.RespondWithProblem(w, http.StatusBadRequest, helpers.CodeTargetInvalidForSchool, "Invalid target(s) provided", err)
+		return
+	}
+
+	// Lessons described by class or room, period and date are matched to the timetable as they are,
+	// so previewing one doesn't create its lesson target
+	params := database.GetTargetStaffParams{SchoolID: schoolID}
+	for _, t := range dropTargets {
+		if t.Type == "Lesson" && t.Lesson != nil {
+			params.LessonClassIds = append(params.LessonClassIds, valueOrZero(t.Lesson.ClassID))
+			params.LessonRoomIds = append(params.LessonRoomIds, valueOrZero(t.Lesson.RoomID))
+			params.LessonPeriodIds = append(params.LessonPeriodIds, t.Lesson.PeriodID)
+			params.LessonDates = append(params.LessonDates, t.Lesson.Date)
+			continue
+		}
+		params.TargetTypes = append(params.TargetTypes, t.Type)
+		params.TargetIds = append(params.TargetIds, t.ID)
+	}
+
+	staffRows, err := dbq.GetTargetStaff(r.Context(), params)
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not resolve staff audience", err)
+		return
+	}
+	pupilRows, err := dbq.GetTargetPupils(r.Context(), database.GetTargetPupilsParams(params))
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not resolve pupil audience", err)
+		return
+	}
+
+	preview := models.AudiencePreview{
+		StaffCount: len(staffRows),
+		PupilCount: len(pupilRows),
+		Staff:      make([]models.AudienceStaff, len(staffRows)),
+		Pupils:     make([]models.AudiencePupil, len(pupilRows)),
+	}
+	for i, u := range staffRows {
+		preview.Staff[i] = models.AudienceStaff{ID: u.ID, Title: u.Title, FirstName: u.FirstName, Surname: u.Surname, Role: string(u.Role)}
+	}
+	for i, p := range pupilRows {
+		preview.Pupils[i] = models.AudiencePupil{ID: p.ID, FirstName: p.FirstName, Surname: p.Surname, ClassName: p.ClassName}
+	}
+
+	helpers.RespondWithJSON(w, http.StatusOK, preview)
+}
+
+// valueOrZero gives 0, which matches no class or room, for a lesson without one
+func valueOrZero(v *int32) int32 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
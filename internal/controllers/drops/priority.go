@@ -0,0 +1,71 @@
+package drops
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/5tuartw/droplet/internal/config"
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/notify"
+)
+
+// parsePriority validates a requested priority, defaulting to normal
+func parsePriority(priority *string) (database.DropPriority, error) {
+	if priority == nil || *priority == "" {
+		return database.DropPriorityNormal, nil
+	}
+	switch p := database.DropPriority(*priority); p {
+	case database.DropPriorityNormal, database.DropPriorityImportant, database.DropPriorityUrgent:
+		return p, nil
+	}
+	return "", fmt.Errorf("invalid priority '%s'. Use normal, important or urgent", *priority)
+}
+
+// claimUrgentPush claims the one push an urgent drop gets, as long as it is published, already live and
+// hasn't been pushed before, and reports whether the caller should send it. Future-dated drops are left
+// to PushDueUrgentDrops. When saving a drop, claim with the transaction's queries so the claim is only
+// kept if the drop is, and send once the transaction has committed.
+func claimUrgentPush(ctx context.Context, q *database.Queries, drop database.Drop) bool {
+	if drop.Priority != database.DropPriorityUrgent || drop.Status != database.DropStatusPublished {
+		return false
+	}
+	if drop.PostDate.After(time.Now()) {
+		return false
+	}
+	claimed, err := q.ClaimUrgentDropPush(ctx, database.ClaimUrgentDropPushParams{ID: drop.ID, SchoolID: drop.SchoolID})
+	if err != nil {
+		slog.ErrorContext(ctx, "could not claim urgent drop push", "drop_id", drop.ID, "error", err)
+		return false
+	}
+	return claimed > 0 // none if already pushed
+}
+
+func urgentDrop(drop database.Drop) notify.UrgentDrop {
+	return notify.UrgentDrop{
+		ID:         drop.ID,
+		SchoolID:   drop.SchoolID,
+		AuthorID:   drop.UserID,
+		Title:      drop.Title,
+		Content:    drop.Content,
+		PostDate:   drop.PostDate,
+		ExpireDate: drop.ExpireDate,
+	}
+}
+
+// PushDueUrgentDrops sends every urgent drop that has gone live without being pushed, in any school,
+// and returns how many it sent. Each drop is claimed before it's sent, so none is sent twice.
+func PushDueUrgentDrops(ctx context.Context, cfg *config.ApiConfig, dbq *database.Queries) (int, error) {
+	due, err := dbq.ClaimDueUrgentDropPushes(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, drop := range due {
+		cfg.Notifier.SendUrgentAsync(ctx, urgentDrop(drop))
+	}
+	if len(due) > 0 {
+		slog.InfoContext(ctx, "pushed urgent drops that have gone live", "count", len(due))
+	}
+	return len(due), nil
+}
@@ -0,0 +1,32 @@
+package drops
+
+import (
+	"testing"
+
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePriority(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	for _, tc := range []struct {
+		in   *string
+		want database.DropPriority
+	}{
+		{nil, database.DropPriorityNormal},
+		{str(""), database.DropPriorityNormal},
+		{str("normal"), database.DropPriorityNormal},
+		{str("important"), database.DropPriorityImportant},
+		{str("urgent"), database.DropPriorityUrgent},
+	} {
+		got, err := parsePriority(tc.in)
+		require.NoError(t, err)
+		require.Equal(t, tc.want, got)
+	}
+
+	for _, bad := range []string{"Urgent", "high", " urgent"} {
+		_, err := parsePriority(&bad)
+		require.Error(t, err, bad)
+	}
+}
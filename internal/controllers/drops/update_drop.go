@@ -2,21 +2,22 @@ package drops
 
 import (
 	"database/sql"
-	"encoding/json"
-	"errors"
-	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 
 	"github.com/5tuartw/droplet/internal/auth"
+	"github.com/5tuartw/droplet/internal/config"
+	"github.com/5tuartw/droplet/internal/controllers/tags"
 	"github.com/5tuartw/droplet/internal/controllers/targets"
 	"github.com/5tuartw/droplet/internal/database"
 	"github.com/5tuartw/droplet/internal/helpers"
 	"github.com/5tuartw/droplet/internal/models"
+	"github.com/5tuartw/droplet/internal/schoolsettings"
 	"github.com/google/uuid"
 )
 
-func UpdateDrop(db *sql.DB, dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+// UpdateDrop replaces a drop's content, dates and targets. Permission to manage the drop is checked by RequirePermission.
+func UpdateDrop(cfg *config.ApiConfig, db *sql.DB, dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
 	contextValueID := r.Context().Value(auth.UserIDKey)
 	userID, idOk := contextValueID.(uuid.UUID)
 	contextValueSchool := r.Context().Value(auth.UserSchoolKey)
@@ -25,7 +26,7 @@ func UpdateDrop(db *sql.DB, dbq *database.Queries, w http.ResponseWriter, r *htt
 	userRole, roleOk := contextValueRole.(string)
 
 	if !idOk || !schoolOk || !roleOk {
-		log.Println("Error: one or more value not found in context")
+		slog.ErrorContext(r.Context(), "user not found in request context")
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Internal Server Error (context error)", nil)
 		return
 	}
@@ -37,98 +38,97 @@ func UpdateDrop(db *sql.DB, dbq *database.Queries, w http.ResponseWriter, r *htt
 	}
 
 	requestBody := models.DropRequest{}
-	decoder := json.NewDecoder(r.Body)
-	defer r.Body.Close()
-	err = decoder.Decode(&requestBody)
-	if err != nil {
-		helpers.RespondWithError(w, http.StatusBadRequest, "Error decoding json data", err)
+	if !helpers.DecodeJSON(w, r, &requestBody) {
 		return
 	}
 
-	dropAuthorId, err := dbq.GetUserIdFromDropID(r.Context(), database.GetUserIdFromDropIDParams{
-		ID:       dropID,
-		SchoolID: schoolID,
-	})
+	err = targets.ValidateTargetsBelongToSchool(r.Context(), dbq, schoolID, requestBody.Targets)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			helpers.RespondWithError(w, http.StatusNotFound, "Author not found", err)
-		} else {
-			helpers.RespondWithError(w, http.StatusInternalServerError, "could not retrieve author data from database", err)
-		}
+		slog.InfoContext(r.Context(), "drop targets rejected", "drop_id", dropID, "error", err)
+		helpers.RespondWithProblem(w, http.StatusBadRequest, helpers.CodeTargetInvalidForSchool, "Invalid target(s) provided", err)
 		return
 	}
 
-	if !(dropAuthorId == userID || userRole == "admin") {
-		helpers.RespondWithError(w, http.StatusForbidden, "Forbidden", errors.New("Forbidden"))
-		log.Printf("Cannot perform drop update unless logged in as admin or original drop author")
+	postTime, err := helpers.ParsePostDate(requestBody.PostDate)
+	if err != nil {
+		// Use the specific error message returned by the helper
+		helpers.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
 		return
 	}
 
-	//logic to check drop data - NYI length check
-	if requestBody.Content == "" && requestBody.Title == "" {
-		helpers.RespondWithError(w, http.StatusBadRequest, "Title and Content cannot both be empty", errors.New("title and content both tempty"))
+	schoolSettings, err := schoolsettings.Load(r.Context(), dbq, schoolID)
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not load school settings", err)
 		return
 	}
 
-	err = targets.ValidateTargetsBelongToSchool(r.Context(), dbq, schoolID, requestBody.Targets)
+	expireTime, err := helpers.ParseExpireDate(requestBody.ExpireDate, schoolSettings.Drops.DefaultDurationDays)
 	if err != nil {
-		log.Printf("Target validation failed for user %s school %s: %v", userID, schoolID, err)
-		helpers.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid target(s) provided: %v", err), err)
+		// Use the specific error message returned by the helper
+		helpers.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
 		return
 	}
 
-	postTime, err := helpers.ParsePostDate(requestBody.PostDate)
+	priority, err := parsePriority(requestBody.Priority)
 	if err != nil {
-		// Use the specific error message returned by the helper
 		helpers.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
 		return
 	}
 
-	expireTime, err := helpers.ParseExpireDate(requestBody.ExpireDate)
+	pinnedUntil, err := helpers.ParsePinnedUntil(requestBody.PinnedUntil)
 	if err != nil {
-		// Use the specific error message returned by the helper
 		helpers.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
 		return
 	}
 
+	err = tags.ValidateTagsBelongToSchool(r.Context(), dbq, schoolID, requestBody.TagIDs)
+	if err != nil {
+		helpers.RespondWithProblem(w, http.StatusBadRequest, helpers.CodeTagInvalidForSchool, "Invalid tag(s) provided", err)
+		return
+	}
+
+	// Re-check the approval policy against the editor and the new targets
+	policyStatus, err := dropStatusFor(r.Context(), dbq, userRole, schoolID, requestBody.Targets)
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not check drop approval policy", err)
+		return
+	}
+
 	tx, err := db.BeginTx(r.Context(), nil)
 	if err != nil {
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not start database transaction", err)
 		return
 	}
-	defer func() {
-		if p := recover(); p != nil {
-			log.Println("Recovered from panic, rolling back transaction")
-			tx.Rollback() // Rollback on panic
-			panic(p)      // Re-panic after rollback attempt
-		} else if err != nil {
-			log.Printf("Error occurred, rolling back transaction: %v", err)
-			tx.Rollback() // Rollback on normal error
-		} else {
-			// No error, attempt to commit
-			err = tx.Commit() // Commit the transaction
-			if err != nil {
-				log.Printf("Failed to commit transaction: %v", err)
-				// If commit fails, we might have already sent a response,
-				// but ideally we catch this before responding.
-				// RespondWithError might be problematic if headers already sent.
-				// For now, just log it. A robust system might have more complex recovery.
-			} else {
-				log.Println("Transaction committed successfully.")
-			}
-		}
-	}()
+	defer tx.Rollback()
 
 	qtx := dbq.WithTx(tx)
 
+	existingDrop, err := qtx.GetDropByID(r.Context(), database.GetDropByIDParams{
+		ID:       dropID,
+		SchoolID: schoolID,
+	})
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "could not read existing drop", err)
+		return
+	}
+
+	// Editing never reviews a drop: pending and rejected drops keep their status until they are
+	// approved, rejected or resubmitted. Only published drops go back through the policy.
+	status := existingDrop.Status
+	if status == database.DropStatusPublished {
+		status = policyStatus
+	}
+
 	err = qtx.UpdateDrop(r.Context(), database.UpdateDropParams{
-		ID:         dropID,
-		SchoolID:   schoolID,
-		Title:      requestBody.Title,
-		Content:    requestBody.Content,
-		PostDate:   postTime,
-		ExpireDate: expireTime,
-		EditedBy:   uuid.NullUUID{UUID: userID, Valid: true},
+		ID:          dropID,
+		SchoolID:    schoolID,
+		Title:       requestBody.Title,
+		Content:     requestBody.Content,
+		PostDate:    postTime,
+		ExpireDate:  expireTime,
+		EditedBy:    uuid.NullUUID{UUID: userID, Valid: true},
+		Priority:    priority,
+		PinnedUntil: pinnedUntil,
 	})
 	if err != nil {
 		helpers.RespondWithError(w, http.StatusInternalServerError, "could not update drop", err)
@@ -140,11 +140,18 @@ func UpdateDrop(db *sql.DB, dbq *database.Queries, w http.ResponseWriter, r *htt
 		SchoolID: schoolID,
 	})
 	if err != nil {
-		log.Printf("TX Error deleting targets for drop %s: %v", dropID, err)
+		slog.ErrorContext(r.Context(), "could not clear drop targets", "drop_id", dropID, "error", err)
 		helpers.RespondWithError(w, http.StatusInternalServerError, "could not delete old target(s)", err)
 		return
 	}
 
+	err = targets.ResolveLessonTargets(r.Context(), qtx, schoolID, requestBody.Targets)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "could not resolve lesson targets", "drop_id", dropID, "error", err)
+		helpers.RespondWithError(w, http.StatusInternalServerError, "could not add new target(s)", err)
+		return
+	}
+
 	for _, target := range requestBody.Targets {
 		dbTargetType := database.TargetType(target.Type)
 		nullTargetID := sql.NullInt32{Valid: false}
@@ -158,12 +165,52 @@ func UpdateDrop(db *sql.DB, dbq *database.Queries, w http.ResponseWriter, r *htt
 			SchoolID: schoolID,
 		})
 		if err != nil {
-			log.Printf("TX Error adding target %+v for drop %s: %v", target, dropID, err)
+			slog.ErrorContext(r.Context(), "could not add drop target", "drop_id", dropID, "target_type", target.Type, "target_id", target.ID, "error", err)
 			helpers.RespondWithError(w, http.StatusInternalServerError, "could not add new target(s)", err)
 			return
 		}
 	}
 
-	log.Printf("Drop %s updated successfully by user %s.", dropID, userID)
+	if requestBody.TagIDs != nil {
+		err = setDropTags(r.Context(), qtx, schoolID, dropID, requestBody.TagIDs)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "could not set drop tags", "drop_id", dropID, "error", err)
+			helpers.RespondWithError(w, http.StatusInternalServerError, "could not update tag(s)", err)
+			return
+		}
+	}
+
+	if existingDrop.Status != status {
+		err = qtx.SetDropStatus(r.Context(), database.SetDropStatusParams{
+			ID:       dropID,
+			SchoolID: schoolID,
+			Status:   status,
+		})
+		if err != nil {
+			helpers.RespondWithError(w, http.StatusInternalServerError, "could not update drop status", err)
+			return
+		}
+	}
+
+	// An urgent drop is only ever pushed once, so editing one that has already been pushed sends nothing
+	updatedDrop := existingDrop
+	updatedDrop.Title = requestBody.Title
+	updatedDrop.Content = requestBody.Content
+	updatedDrop.PostDate = postTime
+	updatedDrop.ExpireDate = expireTime
+	updatedDrop.Priority = priority
+	updatedDrop.Status = status
+	pushUrgent := claimUrgentPush(r.Context(), qtx, updatedDrop)
+
+	if err = tx.Commit(); err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "could not save drop", err)
+		return
+	}
+	// Only pushed once the edit is saved, so a failed save sends nothing
+	if pushUrgent {
+		cfg.Notifier.SendUrgentAsync(r.Context(), urgentDrop(updatedDrop))
+	}
+
+	slog.InfoContext(r.Context(), "drop updated", "drop_id", dropID)
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -130,7 +130,7 @@ func TestUserLogin(t *testing.T) {
 				s := `{"email": "test@example.com", "password": "password123"`
 				return &s
 			}(),
-			expectedStatus: http.StatusInternalServerError,
+			expectedStatus: http.StatusBadRequest,
 			expectToken:    false,
 		},
 		// --- Failure Case: Empty string body ---
@@ -140,14 +140,14 @@ func TestUserLogin(t *testing.T) {
 				s := ""
 				return &s
 			}(),
-			expectedStatus: http.StatusInternalServerError,
+			expectedStatus: http.StatusBadRequest,
 			expectToken:    false,
 		},
 		// --- Failure Case: Nil request body ---
 		{
 			name:           "Fail_NilRequestBody",
 			rawRequestBody: nil,
-			expectedStatus: http.StatusInternalServerError,
+			expectedStatus: http.StatusBadRequest,
 			expectToken:    false,
 		},
 	}
@@ -223,6 +223,29 @@ func TestUserLogin(t *testing.T) {
 	} // End of loop
 }
 
+func TestUserLoginSuspendedSchool(t *testing.T) {
+	if testDB == nil {
+		t.Fatal("Test database connection pool (testDB) is nil")
+	}
+
+	suspendedSchoolID := uuid.New()
+	_, err := testDB.Exec(`INSERT INTO schools (id, name, status) VALUES ($1, 'Suspended Test School', 'suspended')`, suspendedSchoolID)
+	require.NoError(t, err)
+	_ = seedTestUser(t, testDB, "suspended@example.com", "password123", suspendedSchoolID, true)
+
+	requestBodyBytes, err := json.Marshal(map[string]string{"email": "suspended@example.com", "password": "password123"})
+	require.NoError(t, err)
+	req, err := http.NewRequest("POST", "/api/login", bytes.NewBuffer(requestBodyBytes))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+
+	testServer, _ := newTestServer(t, testDB)
+	testServer.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusForbidden, rr.Code, "Login to a suspended school should be refused")
+}
+
 // Optional helper for cleanup
 // func cleanupTables(t *testing.T, db *sql.DB) {
 // 	t.Helper()
@@ -1,13 +1,16 @@
 package api_test
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"net/http/httptest"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"testing"
 	"time"
@@ -16,6 +19,10 @@ import (
 	"github.com/5tuartw/droplet/internal/config"
 	"github.com/5tuartw/droplet/internal/controllers/drops"
 	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/migrate"
+	"github.com/5tuartw/droplet/internal/router"
+	"github.com/5tuartw/droplet/sql/schema"
+	"github.com/google/uuid"
 	_ "github.com/lib/pq"
 	"github.com/ory/dockertest"
 	"github.com/ory/dockertest/docker"
@@ -112,26 +119,20 @@ func TestMain(m *testing.M) {
 	// Initalise the package-level testCfg
 	log.Println("Initialising test configuration...")
 	testCfg = &config.ApiConfig{
-		JWTSecret: "test_jwt_secret_key_1234567890",
-		Port:      "8080",
+		JWTSecret:       "test_jwt_secret_key_1234567890",
+		Port:            "8080",
+		AccessTokenTTL:  config.DefaultAccessTokenTTL,
+		RefreshTokenTTL: config.DefaultRefreshTokenTTL,
 	}
 	log.Println("Test configuration initialised.")
 
-	// Run database migrations
-	migrationsDir := "../../sql/schema"
-
-	absMigrationDir, err := filepath.Abs(migrationsDir)
+	// Run database migrations with the same embedded runner as `droplet migrate up`
+	runner, err := migrate.New(testDB, schema.FS)
 	if err != nil {
-		log.Fatalf("Could not get absolute path for migrations dir: %s", err)
+		log.Fatalf("Could not load migrations: %s", err)
 	}
-
-	log.Printf("Running goose migrations from directory: %s", absMigrationDir)
-	cmd := exec.Command("goose", "-dir", absMigrationDir, "postgres", testDBConnectionString, "up")
-	cmd.Stdout = os.Stdout // Show goose output
-	cmd.Stderr = os.Stderr
-	err = cmd.Run()
-	if err != nil {
-		log.Fatalf("Failed to run goose migrations: %s", err)
+	if _, err := runner.Up(context.Background()); err != nil {
+		log.Fatalf("Failed to run migrations: %s", err)
 	}
 	log.Println("Migrations applied successfully.")
 
@@ -211,13 +212,55 @@ func newTestServer(t *testing.T, db *sql.DB) (http.Handler, *config.ApiConfig) {
 	})
 
 	createDropHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
-		drops.CreateDrop(db, testQueries, w, r)
+		drops.CreateDrop(testCfg, db, testQueries, w, r)
 	}
 	mux.HandleFunc("POST /api/drops", auth.RequireAuth(testCfg, createDropHandlerFunc))
+	mux.HandleFunc("POST /api/drops/preview-audience", auth.RequireAuth(testCfg, func(w http.ResponseWriter, r *http.Request) {
+		drops.PreviewAudience(testQueries, w, r)
+	}))
 
 	return mux, testCfg
 }
 
+// newRouterTestServer serves the application's full router, so requests go through the same
+// permission checks as in production
+func newRouterTestServer(t *testing.T) http.Handler {
+	t.Helper()
+	return router.NewRouter(testCfg, testDB, database.New(testDB))
+}
+
+// seedTestUserWithRole seeds a user with the given role and returns them with an access token for it
+func seedTestUserWithRole(t *testing.T, email string, role database.UserRole) (uuid.UUID, string) {
+	t.Helper()
+	userID := seedTestUser(t, testDB, email, "password123", testSchoolID, false)
+	_, err := testDB.Exec(`UPDATE users SET role = $1 WHERE id = $2`, role, userID)
+	require.NoError(t, err)
+	token, err := auth.MakeJWT(userID, testSchoolID, string(role), testCfg.JWTSecret, time.Hour)
+	require.NoError(t, err)
+	return userID, token
+}
+
+// doJSON sends a request with a JSON body (if any) and the token (if any) to handler
+func doJSON(t *testing.T, handler http.Handler, method, path, token string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	var reader io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(raw)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
 func seedDataFromSQLFile(db *sql.DB, filePath string) error {
 	absPath, err := filepath.Abs(filePath)
 	if err != nil {
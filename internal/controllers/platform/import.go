@@ -0,0 +1,54 @@
+package platform
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/helpers"
+	"github.com/5tuartw/droplet/internal/tenantarchive"
+	"github.com/lib/pq"
+)
+
+const maxArchiveBytes = 256 << 20
+
+// ImportSchool creates a new school from a tenant archive posted as the request body.
+// ?name= and ?subdomain= override the archived values.
+func ImportSchool(db *sql.DB, dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+	opts := tenantarchive.ImportOptions{
+		Name:      strings.TrimSpace(r.URL.Query().Get("name")),
+		Subdomain: strings.ToLower(strings.TrimSpace(r.URL.Query().Get("subdomain"))),
+	}
+	if opts.Subdomain != "" && !subdomainPattern.MatchString(opts.Subdomain) {
+		helpers.RespondWithError(w, http.StatusBadRequest, "Subdomain may only contain lowercase letters, numbers and hyphens", nil)
+		return
+	}
+
+	defer r.Body.Close()
+	archive, err := tenantarchive.Read(http.MaxBytesReader(w, r.Body, maxArchiveBytes))
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	school, err := tenantarchive.Import(r.Context(), db, dbq, archive, opts)
+	if err != nil {
+		var pqErr *pq.Error
+		switch {
+		case errors.As(err, &pqErr) && pqErr.Code == "23505":
+			helpers.RespondWithError(w, http.StatusConflict, "Import clashes with existing data (school name, subdomain or a user's email is already taken)", err)
+		case errors.As(err, &pqErr):
+			helpers.RespondWithError(w, http.StatusInternalServerError, "Could not import school", err)
+		default:
+			// The archive itself is at fault, e.g. a reference to a record it doesn't contain
+			helpers.RespondWithError(w, http.StatusBadRequest, "Invalid archive: "+err.Error(), err)
+		}
+		return
+	}
+
+	slog.InfoContext(r.Context(), "school imported", "target_school_id", school.ID, "school_name", school.Name, "users", len(archive.Users), "drops", len(archive.Drops))
+	helpers.RespondWithJSON(w, http.StatusCreated, schoolResponse(school))
+}
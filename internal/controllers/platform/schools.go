@@ -0,0 +1,335 @@
+package platform
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/5tuartw/droplet/internal/auth"
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/helpers"
+	"github.com/5tuartw/droplet/internal/models"
+	"github.com/5tuartw/droplet/internal/schoolsettings"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sqlc-dev/pqtype"
+)
+
+// Subdomains are single DNS labels
+var subdomainPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+func schoolResponse(school database.School) models.SchoolResponse {
+	return models.SchoolResponse{
+		ID:           school.ID,
+		Name:         school.Name,
+		Status:       school.Status,
+		Subdomain:    helpers.StringFromNullString(school.Subdomain),
+		Address:      helpers.StringFromNullString(school.Address),
+		ContactEmail: helpers.StringFromNullString(school.ContactEmail),
+		ContactPhone: helpers.StringFromNullString(school.ContactPhone),
+		LogoURL:      helpers.StringFromNullString(school.LogoUrl),
+		CreatedAt:    school.CreatedAt,
+		UpdatedAt:    school.UpdatedAt,
+	}
+}
+
+// GetSchools lists every school on the platform
+func GetSchools(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+	schools, err := dbq.GetSchools(r.Context())
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not get schools", err)
+		return
+	}
+
+	response := make([]models.SchoolResponse, 0, len(schools))
+	for _, school := range schools {
+		response = append(response, schoolResponse(school))
+	}
+	helpers.RespondWithJSON(w, http.StatusOK, response)
+}
+
+func GetSchool(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+	schoolID, err := uuid.Parse(r.PathValue("schoolID"))
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusBadRequest, "Invalid school ID", err)
+		return
+	}
+
+	school, err := dbq.GetSchoolByID(r.Context(), schoolID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			helpers.RespondWithError(w, http.StatusNotFound, "School not found", err)
+		} else {
+			helpers.RespondWithError(w, http.StatusInternalServerError, "Could not get school", err)
+		}
+		return
+	}
+	helpers.RespondWithJSON(w, http.StatusOK, schoolResponse(school))
+}
+
+// CreateSchool provisions a new, active school and, if "admin" is given, its first admin account in the same transaction
+func CreateSchool(db *sql.DB, dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+	requestBody := models.CreateSchoolRequest{}
+	if !helpers.DecodeJSON(w, r, &requestBody) {
+		return
+	}
+
+	requestBody.Name = strings.TrimSpace(requestBody.Name)
+	requestBody.Subdomain = strings.ToLower(strings.TrimSpace(requestBody.Subdomain))
+	if requestBody.Name == "" {
+		helpers.RespondWithError(w, http.StatusBadRequest, "School name is required", nil)
+		return
+	}
+	if requestBody.Subdomain != "" && !subdomainPattern.MatchString(requestBody.Subdomain) {
+		helpers.RespondWithError(w, http.StatusBadRequest, "Subdomain may only contain lowercase letters, numbers and hyphens", nil)
+		return
+	}
+	if requestBody.Admin != nil {
+		if err := validateNewAdmin(requestBody.Admin, schoolsettings.Default().Passwords); err != nil {
+			helpers.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+	}
+
+	tx, err := db.BeginTx(r.Context(), nil)
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not start database transaction", err)
+		return
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			slog.WarnContext(r.Context(), "rolling back transaction", "error", err)
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+			if err != nil {
+				slog.ErrorContext(r.Context(), "could not commit transaction", "error", err)
+			}
+		}
+	}()
+
+	qtx := dbq.WithTx(tx)
+
+	school, err := qtx.CreateSchool(r.Context(), database.CreateSchoolParams{
+		Name:         requestBody.Name,
+		Address:      helpers.NullStringFromString(strings.TrimSpace(requestBody.Address)),
+		ContactEmail: helpers.NullStringFromString(strings.TrimSpace(requestBody.ContactEmail)),
+		ContactPhone: helpers.NullStringFromString(strings.TrimSpace(requestBody.ContactPhone)),
+		Subdomain:    helpers.NullStringFromString(requestBody.Subdomain),
+	})
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			helpers.RespondWithError(w, http.StatusConflict, "A school with that name or subdomain already exists", err)
+		} else {
+			helpers.RespondWithError(w, http.StatusInternalServerError, "Could not create school", err)
+		}
+		return
+	}
+
+	var admin *models.UserResponse
+	if requestBody.Admin != nil {
+		var created models.UserResponse
+		created, err = createSchoolAdmin(r.Context(), qtx, school.ID, requestBody.Admin)
+		if err != nil {
+			respondAdminError(w, err)
+			return
+		}
+		admin = &created
+	}
+
+	slog.InfoContext(r.Context(), "school created", "target_school_id", school.ID, "school_name", school.Name)
+	helpers.RespondWithJSON(w, http.StatusCreated, struct {
+		models.SchoolResponse
+		Admin *models.UserResponse `json:"admin,omitempty"`
+	}{schoolResponse(school), admin})
+}
+
+// CreateSchoolAdmin adds an admin account to an existing school, e.g. one set up by hand with init_school_data.sql
+func CreateSchoolAdmin(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+	schoolID, err := uuid.Parse(r.PathValue("schoolID"))
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusBadRequest, "Invalid school ID", err)
+		return
+	}
+
+	requestBody := models.NewSchoolAdmin{}
+	if !helpers.DecodeJSON(w, r, &requestBody) {
+		return
+	}
+
+	_, err = dbq.GetSchoolByID(r.Context(), schoolID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			helpers.RespondWithError(w, http.StatusNotFound, "School not found", err)
+		} else {
+			helpers.RespondWithError(w, http.StatusInternalServerError, "Could not get school", err)
+		}
+		return
+	}
+
+	schoolSettings, err := schoolsettings.Load(r.Context(), dbq, schoolID)
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not load school settings", err)
+		return
+	}
+	if err := validateNewAdmin(&requestBody, schoolSettings.Passwords); err != nil {
+		helpers.RespondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	admin, err := createSchoolAdmin(r.Context(), dbq, schoolID, &requestBody)
+	if err != nil {
+		respondAdminError(w, err)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "school admin created", "target_school_id", schoolID, "target_user_id", admin.ID)
+	helpers.RespondWithJSON(w, http.StatusCreated, admin)
+}
+
+func validateNewAdmin(admin *models.NewSchoolAdmin, policy schoolsettings.PasswordPolicy) error {
+	admin.Email = strings.TrimSpace(admin.Email)
+	if admin.Email == "" {
+		return errors.New("admin email is required")
+	}
+	if err := auth.ValidatePassword(admin.Password, policy); err != nil {
+		return fmt.Errorf("admin %w", err)
+	}
+	return nil
+}
+
+func createSchoolAdmin(ctx context.Context, dbq *database.Queries, schoolID uuid.UUID, admin *models.NewSchoolAdmin) (models.UserResponse, error) {
+	hashedPword, err := auth.HashPassword(admin.Password)
+	if err != nil {
+		return models.UserResponse{}, err
+	}
+
+	newUser, err := dbq.CreateUser(ctx, database.CreateUserParams{
+		SchoolID:       schoolID,
+		Email:          admin.Email,
+		HashedPassword: string(hashedPword),
+		Role:           database.UserRoleAdmin,
+		Title:          admin.Title,
+		FirstName:      admin.FirstName,
+		Surname:        admin.Surname,
+	})
+	if err != nil {
+		return models.UserResponse{}, err
+	}
+
+	return models.UserResponse{
+		ID:        newUser.ID,
+		Email:     newUser.Email,
+		Role:      newUser.Role,
+		Title:     newUser.Title,
+		FirstName: newUser.FirstName,
+		Surname:   newUser.Surname,
+	}, nil
+}
+
+func respondAdminError(w http.ResponseWriter, err error) {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		helpers.RespondWithError(w, http.StatusConflict, "A user with that email already exists", err)
+	} else {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not create admin user", err)
+	}
+}
+
+// SuspendSchool blocks logins and token refreshes for a school. Access tokens already issued run until they expire.
+func SuspendSchool(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+	setSchoolStatus(dbq, w, r, auth.SchoolStatusSuspended)
+}
+
+func ReactivateSchool(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+	setSchoolStatus(dbq, w, r, auth.SchoolStatusActive)
+}
+
+func setSchoolStatus(dbq *database.Queries, w http.ResponseWriter, r *http.Request, status string) {
+	contextValueSchool := r.Context().Value(auth.UserSchoolKey)
+	ownSchoolID, schoolOk := contextValueSchool.(uuid.UUID)
+	if !schoolOk {
+		slog.ErrorContext(r.Context(), "user not found in request context")
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Internal Server Error (context error)", nil)
+		return
+	}
+
+	schoolID, err := uuid.Parse(r.PathValue("schoolID"))
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusBadRequest, "Invalid school ID", err)
+		return
+	}
+	if status == auth.SchoolStatusSuspended && schoolID == ownSchoolID {
+		helpers.RespondWithError(w, http.StatusBadRequest, "You cannot suspend your own school", nil)
+		return
+	}
+
+	school, err := dbq.SetSchoolStatus(r.Context(), database.SetSchoolStatusParams{
+		ID:     schoolID,
+		Status: status,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			helpers.RespondWithError(w, http.StatusNotFound, "School not found", err)
+		} else {
+			helpers.RespondWithError(w, http.StatusInternalServerError, "Could not update school status", err)
+		}
+		return
+	}
+
+	slog.InfoContext(r.Context(), "school status changed", "target_school_id", school.ID, "status", status)
+	helpers.RespondWithJSON(w, http.StatusOK, schoolResponse(school))
+}
+
+// SetSchoolDemo turns a school's demo restrictions on or off. School admins can't change them
+// through PUT /api/school/settings.
+func SetSchoolDemo(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+	schoolID, err := uuid.Parse(r.PathValue("schoolID"))
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusBadRequest, "Invalid school ID", err)
+		return
+	}
+
+	var requestBody schoolsettings.DemoSettings
+	if !helpers.DecodeJSON(w, r, &requestBody) {
+		return
+	}
+
+	settings, err := schoolsettings.Load(r.Context(), dbq, schoolID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			helpers.RespondWithError(w, http.StatusNotFound, "School not found", err)
+		} else {
+			helpers.RespondWithError(w, http.StatusInternalServerError, "Could not load school settings", err)
+		}
+		return
+	}
+	settings.Demo = requestBody
+
+	raw, err := json.Marshal(settings)
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not encode school settings", err)
+		return
+	}
+	err = dbq.SetSchoolSettings(r.Context(), database.SetSchoolSettingsParams{
+		ID:       schoolID,
+		Settings: pqtype.NullRawMessage{RawMessage: raw, Valid: true},
+	})
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not save school settings", err)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "school demo restrictions changed", "target_school_id", schoolID, "restricted", requestBody.Restricted)
+	helpers.RespondWithJSON(w, http.StatusOK, settings.Demo)
+}
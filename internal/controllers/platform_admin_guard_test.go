@@ -0,0 +1,54 @@
+package api_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/stretchr/testify/require"
+)
+
+// A school admin can't take over or remove a platform admin's account through the user endpoints
+func TestPlatformAdminsAreOutOfSchoolReach(t *testing.T) {
+	if testDB == nil {
+		t.Fatal("Test database connection pool (testDB) is nil")
+	}
+	server := newRouterTestServer(t)
+	_, adminToken := seedTestUserWithRole(t, "guard.admin@example.com", database.UserRoleAdmin)
+	platformID, _ := seedTestUserWithRole(t, "guard.platform@example.com", database.UserRolePlatformAdmin)
+	path := "/api/users/" + platformID.String()
+
+	var before string
+	require.NoError(t, testDB.QueryRow(`SELECT hashed_password FROM users WHERE id = $1`, platformID).Scan(&before))
+
+	rr := doJSON(t, server, http.MethodPut, path+"/password", adminToken, map[string]string{"password": "Takeover1234"})
+	require.Equal(t, http.StatusForbidden, rr.Code, rr.Body.String())
+	rr = doJSON(t, server, http.MethodPatch, path+"/role", adminToken, map[string]string{"role": "read_only"})
+	require.Equal(t, http.StatusForbidden, rr.Code, rr.Body.String())
+	rr = doJSON(t, server, http.MethodPatch, path+"/name", adminToken, map[string]string{"title": "Mx", "first_name": "Taken", "surname": "Over"})
+	require.Equal(t, http.StatusForbidden, rr.Code, rr.Body.String())
+	rr = doJSON(t, server, http.MethodPost, path+"/erase", adminToken, nil)
+	require.Equal(t, http.StatusForbidden, rr.Code, rr.Body.String())
+
+	var user struct {
+		email, role, hash, firstName string
+	}
+	require.NoError(t, testDB.QueryRow(`SELECT email, role, hashed_password, first_name FROM users WHERE id = $1`, platformID).
+		Scan(&user.email, &user.role, &user.hash, &user.firstName))
+	require.Equal(t, "guard.platform@example.com", user.email, "not erased")
+	require.Equal(t, string(database.UserRolePlatformAdmin), user.role)
+	require.Equal(t, before, user.hash)
+	require.NotEqual(t, "Taken", user.firstName)
+
+	// The same requests still work on school staff
+	staffID, _ := seedTestUserWithRole(t, "guard.staff@example.com", database.UserRoleUser)
+	path = "/api/users/" + staffID.String()
+	rr = doJSON(t, server, http.MethodPut, path+"/password", adminToken, map[string]string{"password": "Reset12345"})
+	require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+	rr = doJSON(t, server, http.MethodPatch, path+"/role", adminToken, map[string]string{"role": "read_only"})
+	require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+	rr = doJSON(t, server, http.MethodPatch, path+"/name", adminToken, map[string]string{"title": "Mx", "first_name": "Renamed", "surname": "Staff"})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	rr = doJSON(t, server, http.MethodPost, path+"/erase", adminToken, nil)
+	require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+}
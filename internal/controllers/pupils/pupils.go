@@ -2,9 +2,8 @@ package pupils
 
 import (
 	"database/sql"
-	"encoding/json"
 	"errors"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
 
@@ -13,6 +12,7 @@ import (
 	"github.com/5tuartw/droplet/internal/database"
 	"github.com/5tuartw/droplet/internal/helpers"
 	"github.com/5tuartw/droplet/internal/models"
+	"github.com/5tuartw/droplet/internal/pagination"
 	"github.com/google/uuid"
 )
 
@@ -21,24 +21,66 @@ func GetAllPupils(dbq *database.Queries, w http.ResponseWriter, r *http.Request)
 	requesterSchoolID, schoolOk := contextValueSchool.(uuid.UUID)
 
 	if !schoolOk {
-		log.Println("Error: school ID not found in context")
+		slog.ErrorContext(r.Context(), "user not found in request context")
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Context error", nil)
 		return
 	}
 
-	pupils, err := dbq.GetAllPupils(r.Context(), requesterSchoolID)
+	// Pupils are listed by class then name, or by name alone with ?sort=surname
+	page, err := pagination.Parse(r.URL.Query(), "class", "surname")
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusBadRequest, "Invalid pupil list query", err)
+		return
+	}
+	params := database.GetAllPupilsParams{
+		SortByClass: page.Sort == "class",
+		SchoolID:    requesterSchoolID,
+		SortDesc:    page.Desc,
+		PageLimit:   page.FetchLimit(),
+	}
+	if params.ClassID, err = helpers.QueryInt32(r, "class"); err != nil {
+		helpers.RespondWithError(w, http.StatusBadRequest, "Invalid pupil list query", err)
+		return
+	}
+	if params.YearGroupID, err = helpers.QueryInt32(r, "year_group"); err != nil {
+		helpers.RespondWithError(w, http.StatusBadRequest, "Invalid pupil list query", err)
+		return
+	}
+	if page.After != nil {
+		afterID, err := strconv.ParseInt(page.Key(3), 10, 32)
+		if err != nil {
+			helpers.RespondWithError(w, http.StatusBadRequest, "Invalid pupil list query", errors.New("cursor is not valid"))
+			return
+		}
+		params.AfterClass = page.Key(0)
+		params.AfterSurname = sql.NullString{String: page.Key(1), Valid: true}
+		params.AfterFirstName = page.Key(2)
+		params.AfterID = int32(afterID)
+	}
+
+	pupils, err := dbq.GetAllPupils(r.Context(), params)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			log.Printf("No pupils found for school %s", requesterSchoolID)
+			slog.DebugContext(r.Context(), "no pupils found")
 			helpers.RespondWithJSON(w, http.StatusOK, []models.Pupil{})
 			return
 		} else {
-			log.Printf("Database error fetching pupils for school %s: %v", requesterSchoolID, err)
+			slog.ErrorContext(r.Context(), "could not fetch pupils", "error", err)
 			helpers.RespondWithError(w, http.StatusInternalServerError, "Failed to get users", err)
 		}
 		return
 	}
 
+	if len(pupils) > page.Limit {
+		pupils = pupils[:page.Limit]
+		last := pupils[len(pupils)-1]
+		sortClass := ""
+		if params.SortByClass {
+			sortClass = last.ClassName
+		}
+		pagination.SetNext(w, r, page, sortClass, last.Surname, last.FirstName, strconv.Itoa(int(last.ID)))
+	}
+
 	responsePayload := make([]models.Pupil, 0, len(pupils))
 	for _, row := range pupils {
 		pupil := models.Pupil{
@@ -74,8 +116,8 @@ func GetPupils(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
 
 func UpdatePupil(cfg *config.ApiConfig, dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
 
-	if cfg.IsDemoMode {
-		log.Println("Attempted user deletion in demo mode - Forbidden.")
+	if auth.DemoRestricted(cfg, dbq, r) {
+		slog.InfoContext(r.Context(), "blocked in demo mode", "action", "pupil update")
 		helpers.RespondWithError(w, http.StatusForbidden, "User deletion is disabled in demo mode", errors.New("demo mode restriction"))
 		return
 	}
@@ -84,7 +126,7 @@ func UpdatePupil(cfg *config.ApiConfig, dbq *database.Queries, w http.ResponseWr
 	requesterSchoolID, schoolOk := contextValueSchool.(uuid.UUID)
 
 	if !schoolOk {
-		log.Println("Error: school ID not found in context")
+		slog.ErrorContext(r.Context(), "user not found in request context")
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Context error", nil)
 		return
 	}
@@ -95,16 +137,8 @@ func UpdatePupil(cfg *config.ApiConfig, dbq *database.Queries, w http.ResponseWr
 		return
 	}
 
-	var requestBody struct {
-		FirstName string `json:"first_name"`
-		Surname   string `json:"surname"`
-		ClassID   int32  `json:"class_id"`
-	}
-
-	decoder := json.NewDecoder(r.Body)
-	err = decoder.Decode(&requestBody)
-	if err != nil {
-		helpers.RespondWithError(w, http.StatusBadRequest, "Could not decode request", err)
+	requestBody := models.PupilRequest{}
+	if !helpers.DecodeJSON(w, r, &requestBody) {
 		return
 	}
 
@@ -121,8 +155,7 @@ func UpdatePupil(cfg *config.ApiConfig, dbq *database.Queries, w http.ResponseWr
 		return
 	}
 
-	if requestBody.FirstName == "" || requestBody.Surname == "" {
-		helpers.RespondWithError(w, http.StatusBadRequest, "First name and surname cannot be empty", nil)
+	if !classInScope(dbq, w, r, requesterSchoolID, requestBody.ClassID) {
 		return
 	}
 
@@ -145,10 +178,10 @@ func UpdatePupil(cfg *config.ApiConfig, dbq *database.Queries, w http.ResponseWr
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func DeletePupil(cfg *config.ApiConfig, db *sql.DB, dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+func DeletePupil(cfg *config.ApiConfig, dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
 
-	if cfg.IsDemoMode {
-		log.Println("Attempted user deletion in demo mode - Forbidden.")
+	if auth.DemoRestricted(cfg, dbq, r) {
+		slog.InfoContext(r.Context(), "blocked in demo mode", "action", "pupil deletion")
 		helpers.RespondWithError(w, http.StatusForbidden, "User deletion is disabled in demo mode", errors.New("demo mode restriction"))
 		return
 	}
@@ -156,7 +189,7 @@ func DeletePupil(cfg *config.ApiConfig, db *sql.DB, dbq *database.Queries, w htt
 	requesterSchoolID, schoolOk := contextValueSchool.(uuid.UUID)
 
 	if !schoolOk {
-		log.Println("Error: school ID not found in context")
+		slog.ErrorContext(r.Context(), "user not found in request context")
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Context error", nil)
 		return
 	}
@@ -167,54 +200,17 @@ func DeletePupil(cfg *config.ApiConfig, db *sql.DB, dbq *database.Queries, w htt
 		return
 	}
 
-	// begin transaction
-	tx, err := db.BeginTx(r.Context(), nil)
-	if err != nil {
-		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not start database transaction", err)
-		return
-	}
-	defer func() {
-		if p := recover(); p != nil {
-			log.Println("Recovered from panic, rolling back transaction")
-			tx.Rollback()
-			panic(p)
-		} else if err != nil {
-			log.Printf("Error occurred, rolling back transaction: %v", err)
-			tx.Rollback()
-		} else {
-			err = tx.Commit()
-			if err != nil {
-				log.Printf("Failed to commit transaction: %v", err)
-			} else {
-				log.Println("Transaction committed successfully.")
-			}
-		}
-	}()
-
-	qtx := dbq.WithTx(tx)
-
-	err = qtx.RemoveTarget(r.Context(), database.RemoveTargetParams{
+	// Drop targets and staff subscriptions naming the pupil are personal data about them too
+	rowsAffected, err := dbq.ErasePupil(r.Context(), database.ErasePupilParams{
 		SchoolID: requesterSchoolID,
-		Type:     "Student",
-		TargetID: sql.NullInt32{Int32: int32(targetPupilID), Valid: true},
+		PupilID:  int32(targetPupilID),
 	})
 	if err != nil {
-		if !errors.Is(err, sql.ErrNoRows) {
-			helpers.RespondWithError(w, http.StatusInternalServerError, "Could not delete target", err)
-			return
-		}
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not delete pupil", err)
+		return
 	}
-
-	err = qtx.DeletePupil(r.Context(), database.DeletePupilParams{
-		ID:       int32(targetPupilID),
-		SchoolID: requesterSchoolID,
-	})
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			helpers.RespondWithError(w, http.StatusNotFound, "Pupil not found to delete", err)
-		} else {
-			helpers.RespondWithError(w, http.StatusInternalServerError, "Could not delete pupil", err)
-		}
+	if rowsAffected == 0 {
+		helpers.RespondWithError(w, http.StatusNotFound, "Pupil not found to delete", nil)
 		return
 	}
 
@@ -226,34 +222,20 @@ func AddPupil(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
 	requesterSchoolID, schoolOk := contextValueSchool.(uuid.UUID)
 
 	if !schoolOk {
-		log.Println("Error: school ID not found in context")
+		slog.ErrorContext(r.Context(), "user not found in request context")
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Context error", nil)
 		return
 	}
 
-	var requestBody struct {
-		FirstName string `json:"first_name"`
-		Surname   string `json:"surname"`
-		ClassID   int32  `json:"class_id"`
-	}
-
-	err := json.NewDecoder(r.Body).Decode(&requestBody)
-	if err != nil {
-		helpers.RespondWithError(w, http.StatusBadRequest, "Could not decode request body", err)
+	requestBody := models.PupilRequest{}
+	if !helpers.DecodeJSON(w, r, &requestBody) {
 		return
 	}
 
-	if requestBody.FirstName == "" || requestBody.Surname == "" {
-		helpers.RespondWithError(w, http.StatusBadRequest, "First name and surname are required", nil)
-		return
-	}
-	if requestBody.ClassID <= 0 {
-		helpers.RespondWithError(w, http.StatusBadRequest, "A valid Class ID is required", nil)
+	if !classInScope(dbq, w, r, requesterSchoolID, requestBody.ClassID) {
 		return
 	}
 
-	// to add check valid class id
-
 	newPupil, err := dbq.CreatePupil(r.Context(), database.CreatePupilParams{
 		FirstName: requestBody.FirstName,
 		Surname:   requestBody.Surname,
@@ -261,7 +243,7 @@ func AddPupil(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
 		SchoolID:  requesterSchoolID,
 	})
 	if err != nil {
-		log.Printf("Error creating pupil in school %s: %v", requesterSchoolID, err)
+		slog.ErrorContext(r.Context(), "could not create pupil", "error", err)
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not create pupil", err)
 		return
 	}
@@ -282,7 +264,7 @@ func GetPupil(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
 	requesterSchoolID, schoolOk := contextValueSchool.(uuid.UUID)
 
 	if !schoolOk {
-		log.Println("Error: school ID not found in context")
+		slog.ErrorContext(r.Context(), "user not found in request context")
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Context error", nil)
 		return
 	}
@@ -303,7 +285,7 @@ func GetPupil(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
 			helpers.RespondWithError(w, http.StatusNotFound, "Pupil not found", err)
 		} else {
 			// Other potential database errors
-			log.Printf("Database error fetching pupil %d: %v", targetPupilID, err) // Log the error
+			slog.ErrorContext(r.Context(), "could not fetch pupil", "pupil_id", targetPupilID, "error", err)
 			helpers.RespondWithError(w, http.StatusInternalServerError, "Could not fetch pupil data", err)
 		}
 		return
@@ -321,3 +303,28 @@ func GetPupil(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
 	helpers.RespondWithJSON(w, http.StatusOK, responsePayload)
 
 }
+
+// classInScope checks the requester may place pupils into classID, writing the error response if not.
+// RequirePermission has already checked the pupil being edited; this covers the class they move into.
+func classInScope(dbq *database.Queries, w http.ResponseWriter, r *http.Request, schoolID uuid.UUID, classID int32) bool {
+	contextValueID := r.Context().Value(auth.UserIDKey)
+	userID, idOk := contextValueID.(uuid.UUID)
+	contextValueRole := r.Context().Value(auth.UserRoleKey)
+	role, roleOk := contextValueRole.(string)
+	if !idOk || !roleOk {
+		slog.ErrorContext(r.Context(), "user not found in request context")
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Context error", nil)
+		return false
+	}
+
+	allowed, err := auth.CanManageClassPupils(r.Context(), dbq, userID, schoolID, role, classID)
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not check class scope", err)
+		return false
+	}
+	if !allowed {
+		helpers.RespondWithError(w, http.StatusForbidden, "Forbidden: class is outside your scope", nil)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,149 @@
+package api_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/5tuartw/droplet/internal/auth"
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// Scopes widen downwards: a division covers its year groups, their classes and the pupils in them.
+// A drop is only in scope if every one of its targets is.
+func TestRoleScopeExpansion(t *testing.T) {
+	if testDB == nil {
+		t.Fatal("Test database connection pool (testDB) is nil")
+	}
+	ctx := context.Background()
+	dbq := database.New(testDB)
+
+	authorID := seedTestUser(t, testDB, "scope.author@example.com", "password123", testSchoolID, false)
+	upperID := seedTestUser(t, testDB, "scope.upper@example.com", "password123", testSchoolID, false)
+	year3ID := seedTestUser(t, testDB, "scope.year3@example.com", "password123", testSchoolID, false)
+	class8ID := seedTestUser(t, testDB, "scope.class8@example.com", "password123", testSchoolID, false)
+	for _, id := range []uuid.UUID{upperID, year3ID, class8ID} {
+		_, err := testDB.Exec(`UPDATE users SET role = 'head_of_year' WHERE id = $1`, id)
+		require.NoError(t, err)
+	}
+
+	addScope := func(userID uuid.UUID, targetType database.TargetType, targetID int32) {
+		t.Helper()
+		err := dbq.AddRoleScope(ctx, database.AddRoleScopeParams{UserID: userID, SchoolID: testSchoolID, Type: targetType, TargetID: targetID})
+		require.NoError(t, err)
+	}
+	addScope(upperID, database.TargetTypeDivision, 1) // Upper School: Years 3-6, classes 5-12
+	addScope(year3ID, database.TargetTypeYearGroup, 3)
+	addScope(class8ID, database.TargetTypeClass, 8)
+
+	pupilIn := func(classID int32) int32 {
+		t.Helper()
+		var id int32
+		err := testDB.QueryRow(`INSERT INTO pupils (first_name, surname, class_id, school_id) VALUES ('Scoped', 'Pupil', $1, $2) RETURNING id`,
+			classID, testSchoolID).Scan(&id)
+		require.NoError(t, err)
+		return id
+	}
+	pupil2, pupil5, pupil8 := pupilIn(2), pupilIn(5), pupilIn(8)
+
+	t.Run("classes", func(t *testing.T) {
+		for _, tc := range []struct {
+			userID  uuid.UUID
+			classID int32
+			want    bool
+		}{
+			{upperID, 5, true}, {upperID, 12, true}, {upperID, 1, false},
+			{year3ID, 5, true}, {year3ID, 6, true}, {year3ID, 7, false},
+			{class8ID, 8, true}, {class8ID, 7, false},
+		} {
+			got, err := dbq.IsClassInUserScope(ctx, database.IsClassInUserScopeParams{UserID: tc.userID, SchoolID: testSchoolID, ClassID: tc.classID})
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got, "class %d", tc.classID)
+		}
+	})
+
+	t.Run("pupils", func(t *testing.T) {
+		for _, tc := range []struct {
+			userID  uuid.UUID
+			pupilID int32
+			want    bool
+		}{
+			{upperID, pupil5, true}, {upperID, pupil8, true}, {upperID, pupil2, false},
+			{year3ID, pupil5, true}, {year3ID, pupil8, false},
+			{class8ID, pupil8, true}, {class8ID, pupil5, false},
+		} {
+			got, err := dbq.IsPupilInUserScope(ctx, database.IsPupilInUserScopeParams{UserID: tc.userID, SchoolID: testSchoolID, PupilID: tc.pupilID})
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got, "pupil %d", tc.pupilID)
+		}
+	})
+
+	type target struct {
+		targetType string
+		id         int32
+	}
+	newDrop := func(targets ...target) uuid.UUID {
+		t.Helper()
+		dropID := uuid.New()
+		_, err := testDB.Exec(`
+			INSERT INTO drops (id, user_id, school_id, title, content, created_at, updated_at, post_date)
+			VALUES ($1, $2, $3, 'Scoped', 'Seeded for TestRoleScopeExpansion', NOW(), NOW(), NOW())`,
+			dropID, authorID, testSchoolID)
+		require.NoError(t, err)
+		for _, tg := range targets {
+			_, err = testDB.Exec(`INSERT INTO drop_targets (drop_id, type, target_id, school_id) VALUES ($1, $2, $3, $4)`,
+				dropID, tg.targetType, tg.id, testSchoolID)
+			require.NoError(t, err)
+		}
+		return dropID
+	}
+
+	t.Run("drops", func(t *testing.T) {
+		for _, tc := range []struct {
+			name    string
+			userID  uuid.UUID
+			targets []target
+			want    bool
+		}{
+			{"division", upperID, []target{{"Division", 1}}, true},
+			{"other division", upperID, []target{{"Division", 2}}, false},
+			{"year group, class and pupil inside a division", upperID, []target{{"YearGroup", 4}, {"Class", 9}, {"Student", pupil8}}, true},
+			{"one target outside the division", upperID, []target{{"Class", 7}, {"Class", 1}}, false},
+			{"pupil outside the division", upperID, []target{{"YearGroup", 4}, {"Student", pupil2}}, false},
+			{"year group scope doesn't reach up to its division", year3ID, []target{{"Division", 1}}, false},
+			{"classes in a year group", year3ID, []target{{"Class", 5}, {"Class", 6}, {"Student", pupil5}}, true},
+			{"class scope doesn't reach its year group", class8ID, []target{{"YearGroup", 4}}, false},
+			{"pupil in a scoped class", class8ID, []target{{"Student", pupil8}}, true},
+			{"general drops are never in scope", upperID, []target{{"General", 0}}, false},
+			{"nor drops without targets", upperID, nil, false},
+		} {
+			dropID := newDrop(tc.targets...)
+			got, err := dbq.IsDropInUserScope(ctx, database.IsDropInUserScopeParams{UserID: tc.userID, SchoolID: testSchoolID, DropID: dropID})
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got, tc.name)
+
+			canManage, err := auth.CanManageDrop(ctx, dbq, tc.userID, testSchoolID, string(database.UserRoleHeadOfYear), dropID)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, canManage, tc.name)
+		}
+	})
+
+	t.Run("own and unknown drops", func(t *testing.T) {
+		dropID := newDrop(target{"Class", 1})
+		canManage, err := auth.CanManageDrop(ctx, dbq, authorID, testSchoolID, string(database.UserRoleUser), dropID)
+		require.NoError(t, err)
+		require.True(t, canManage, "authors manage their own drops")
+
+		canManage, err = auth.CanManageDrop(ctx, dbq, upperID, testSchoolID, string(database.UserRoleUser), dropID)
+		require.NoError(t, err)
+		require.False(t, canManage, "users only manage their own drops")
+
+		canManage, err = auth.CanManageDrop(ctx, dbq, upperID, testSchoolID, string(database.UserRoleAdmin), dropID)
+		require.NoError(t, err)
+		require.True(t, canManage, "admins manage any drop in the school")
+
+		_, err = auth.CanManageDrop(ctx, dbq, upperID, testSchoolID, string(database.UserRoleAdmin), uuid.New())
+		require.Error(t, err, "unknown drops are reported rather than allowed")
+	})
+}
@@ -0,0 +1,93 @@
+package school
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/5tuartw/droplet/internal/auth"
+	"github.com/5tuartw/droplet/internal/config"
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/helpers"
+	"github.com/5tuartw/droplet/internal/schoolsettings"
+	"github.com/5tuartw/droplet/internal/tenant"
+	"github.com/google/uuid"
+)
+
+type BrandingColours struct {
+	Primary string `json:"primary,omitempty"`
+	Accent  string `json:"accent,omitempty"`
+}
+
+type Branding struct {
+	Name    string          `json:"name"`
+	LogoURL string          `json:"logo_url"`
+	Colours BrandingColours `json:"colours"`
+}
+
+var defaultBranding = Branding{
+	Name:    "Droplet",
+	LogoURL: "/static/images/droplet.png",
+}
+
+// GetBranding returns the name, logo and colours of the school the request is for, so pages can be
+// styled before anyone logs in. The school comes from the subdomain, or failing that from a valid
+// access token; with neither, Droplet's own branding is returned.
+func GetBranding(cfg *config.ApiConfig, dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+	schoolID, ok := tenant.FromContext(r.Context())
+	if !ok {
+		if tokenString, err := auth.GetBearerToken(r.Header); err == nil {
+			if _, tokenSchoolID, _, err := auth.ValidateJWT(tokenString, cfg.JWTSecret); err == nil {
+				schoolID, ok = tokenSchoolID, true
+			}
+		}
+	}
+	if !ok {
+		helpers.RespondWithJSON(w, http.StatusOK, defaultBranding)
+		return
+	}
+
+	branding, err := loadBranding(r.Context(), dbq, schoolID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			helpers.RespondWithError(w, http.StatusNotFound, "School not found", err)
+		} else {
+			helpers.RespondWithError(w, http.StatusInternalServerError, "Could not get school branding", err)
+		}
+		return
+	}
+	helpers.RespondWithJSON(w, http.StatusOK, branding)
+}
+
+func loadBranding(ctx context.Context, dbq *database.Queries, schoolID uuid.UUID) (Branding, error) {
+	row, err := dbq.GetSchoolBranding(ctx, schoolID)
+	if err != nil {
+		return Branding{}, err
+	}
+
+	branding := Branding{
+		Name:    row.Name,
+		LogoURL: helpers.StringFromNullString(row.LogoUrl),
+	}
+	if branding.LogoURL == "" {
+		branding.LogoURL = defaultBranding.LogoURL
+	}
+
+	if row.Settings.Valid {
+		settings, err := schoolsettings.Parse(row.Settings.RawMessage)
+		if err != nil {
+			// A malformed settings document shouldn't stop the login page loading
+			slog.WarnContext(ctx, "ignoring unreadable school settings", "school_id", schoolID, "error", err)
+		}
+		// Colours end up in CSS on the login page, so only plain hex values are passed through
+		if schoolsettings.ValidHexColour(settings.Branding.PrimaryColour) {
+			branding.Colours.Primary = settings.Branding.PrimaryColour
+		}
+		if schoolsettings.ValidHexColour(settings.Branding.AccentColour) {
+			branding.Colours.Accent = settings.Branding.AccentColour
+		}
+	}
+	return branding, nil
+}
@@ -0,0 +1,55 @@
+package school
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/5tuartw/droplet/internal/auth"
+	"github.com/5tuartw/droplet/internal/config"
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/helpers"
+	"github.com/5tuartw/droplet/internal/tenantarchive"
+	"github.com/google/uuid"
+)
+
+// ExportSchool downloads the whole school as a tenant archive, for backups or moving to another instance.
+// Password hashes are never included: only someone with access to the server can export them, with droplet-tenant.
+func ExportSchool(cfg *config.ApiConfig, dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+	if auth.DemoRestricted(cfg, dbq, r) {
+		slog.InfoContext(r.Context(), "blocked in demo mode", "action", "school export")
+		helpers.RespondWithError(w, http.StatusForbidden, "School export is disabled in demo mode", errors.New("demo mode restriction"))
+		return
+	}
+
+	contextValueSchool := r.Context().Value(auth.UserSchoolKey)
+	schoolID, schoolOk := contextValueSchool.(uuid.UUID)
+	if !schoolOk {
+		slog.ErrorContext(r.Context(), "user not found in request context")
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Internal Server Error (context error)", nil)
+		return
+	}
+
+	if r.URL.Query().Has("include_passwords") {
+		helpers.RespondWithError(w, http.StatusBadRequest, "Password hashes can only be exported with droplet-tenant export -include-passwords", nil)
+		return
+	}
+
+	archive, err := tenantarchive.Export(r.Context(), dbq, schoolID, tenantarchive.ExportOptions{})
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not export school", err)
+		return
+	}
+
+	filename := fmt.Sprintf("droplet-%s-%s.json.gz", schoolID, time.Now().UTC().Format("20060102"))
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.WriteHeader(http.StatusOK)
+	if err := tenantarchive.Write(w, archive); err != nil {
+		slog.ErrorContext(r.Context(), "could not write school export", "error", err)
+		return
+	}
+	slog.InfoContext(r.Context(), "school exported")
+}
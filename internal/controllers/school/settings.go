@@ -0,0 +1,78 @@
+package school
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/5tuartw/droplet/internal/auth"
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/helpers"
+	"github.com/5tuartw/droplet/internal/schoolsettings"
+	"github.com/google/uuid"
+	"github.com/sqlc-dev/pqtype"
+)
+
+// GetSchoolSettings returns the school's settings document, with defaults for anything never set
+func GetSchoolSettings(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+	contextValueSchool := r.Context().Value(auth.UserSchoolKey)
+	schoolID, schoolOk := contextValueSchool.(uuid.UUID)
+	if !schoolOk {
+		slog.ErrorContext(r.Context(), "user not found in request context")
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Internal Server Error (context error)", nil)
+		return
+	}
+
+	settings, err := schoolsettings.Load(r.Context(), dbq, schoolID)
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not load school settings", err)
+		return
+	}
+	helpers.RespondWithJSON(w, http.StatusOK, settings)
+}
+
+// UpdateSchoolSettings replaces the settings document. Sections or fields left out fall back to their defaults.
+// The demo section is kept as stored: only a platform admin can turn demo restrictions on or off.
+func UpdateSchoolSettings(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+	contextValueSchool := r.Context().Value(auth.UserSchoolKey)
+	schoolID, schoolOk := contextValueSchool.(uuid.UUID)
+	if !schoolOk {
+		slog.ErrorContext(r.Context(), "user not found in request context")
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Internal Server Error (context error)", nil)
+		return
+	}
+
+	requestBody := schoolsettings.Default()
+	if !helpers.DecodeJSON(w, r, &requestBody) {
+		return
+	}
+
+	current, err := schoolsettings.Load(r.Context(), dbq, schoolID)
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not load school settings", err)
+		return
+	}
+	requestBody.Demo = current.Demo
+
+	if errs := requestBody.Validate(); len(errs) > 0 {
+		helpers.RespondWithValidationErrors(w, errs)
+		return
+	}
+
+	raw, err := json.Marshal(requestBody)
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not encode school settings", err)
+		return
+	}
+	err = dbq.SetSchoolSettings(r.Context(), database.SetSchoolSettingsParams{
+		ID:       schoolID,
+		Settings: pqtype.NullRawMessage{RawMessage: raw, Valid: true},
+	})
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not save school settings", err)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "school settings updated")
+	helpers.RespondWithJSON(w, http.StatusOK, requestBody)
+}
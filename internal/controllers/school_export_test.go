@@ -0,0 +1,46 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/tenantarchive"
+	"github.com/stretchr/testify/require"
+)
+
+// Password hashes never leave through the API, and platform admins' never leave at all
+func TestSchoolExportPasswordHashes(t *testing.T) {
+	if testDB == nil {
+		t.Fatal("Test database connection pool (testDB) is nil")
+	}
+	server := newRouterTestServer(t)
+	_, adminToken := seedTestUserWithRole(t, "export.admin@example.com", database.UserRoleAdmin)
+	seedTestUserWithRole(t, "export.platform@example.com", database.UserRolePlatformAdmin)
+
+	rr := doJSON(t, server, http.MethodGet, "/api/school/export?include_passwords=true", adminToken, nil)
+	require.Equal(t, http.StatusBadRequest, rr.Code, rr.Body.String())
+
+	rr = doJSON(t, server, http.MethodGet, "/api/school/export", adminToken, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	archive, err := tenantarchive.Read(bytes.NewReader(rr.Body.Bytes()))
+	require.NoError(t, err)
+	require.NotEmpty(t, archive.Users)
+	for _, user := range archive.Users {
+		require.Empty(t, user.PasswordHash, user.Email)
+	}
+
+	// From the command line hashes can be included, except platform admins'
+	archive, err = tenantarchive.Export(context.Background(), database.New(testDB), testSchoolID,
+		tenantarchive.ExportOptions{IncludePasswordHashes: true})
+	require.NoError(t, err)
+	hashes := make(map[string]string)
+	for _, user := range archive.Users {
+		hashes[user.Email] = user.PasswordHash
+	}
+	require.NotEmpty(t, hashes["export.admin@example.com"])
+	require.Contains(t, hashes, "export.platform@example.com")
+	require.Empty(t, hashes["export.platform@example.com"])
+}
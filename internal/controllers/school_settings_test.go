@@ -0,0 +1,97 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/helpers"
+	"github.com/5tuartw/droplet/internal/schoolsettings"
+	"github.com/5tuartw/droplet/internal/validate"
+	"github.com/google/uuid"
+	"github.com/sqlc-dev/pqtype"
+	"github.com/stretchr/testify/require"
+)
+
+// School admins edit their settings, but demo restrictions stay as the platform set them
+func TestSchoolSettings(t *testing.T) {
+	if testDB == nil {
+		t.Fatal("Test database connection pool (testDB) is nil")
+	}
+	var original pqtype.NullRawMessage
+	require.NoError(t, testDB.QueryRow(`SELECT settings FROM schools WHERE id = $1`, testSchoolID).Scan(&original))
+	t.Cleanup(func() { testDB.Exec(`UPDATE schools SET settings = $1 WHERE id = $2`, original, testSchoolID) })
+
+	server := newRouterTestServer(t)
+	_, adminToken := seedTestUserWithRole(t, "settings.admin@example.com", database.UserRoleAdmin)
+	_, userToken := seedTestUserWithRole(t, "settings.user@example.com", database.UserRoleUser)
+	_, platformToken := seedTestUserWithRole(t, "settings.platform@example.com", database.UserRolePlatformAdmin)
+
+	put := func(token string, settings schoolsettings.Settings) (int, schoolsettings.Settings) {
+		t.Helper()
+		rr := doJSON(t, server, http.MethodPut, "/api/school/settings", token, settings)
+		var saved schoolsettings.Settings
+		if rr.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &saved))
+		}
+		return rr.Code, saved
+	}
+	stored := func() schoolsettings.Settings {
+		t.Helper()
+		rr := doJSON(t, server, http.MethodGet, "/api/school/settings", adminToken, nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var settings schoolsettings.Settings
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &settings))
+		return settings
+	}
+	setDemo := func(token string, schoolID uuid.UUID, restricted bool) int {
+		t.Helper()
+		rr := doJSON(t, server, http.MethodPut, "/api/platform/schools/"+schoolID.String()+"/demo", token,
+			schoolsettings.DemoSettings{Restricted: restricted})
+		return rr.Code
+	}
+
+	settings := schoolsettings.Default()
+	settings.Drops.DefaultDurationDays = 30
+	settings.Demo.Restricted = true
+	code, saved := put(adminToken, settings)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, 30, saved.Drops.DefaultDurationDays)
+	require.False(t, saved.Demo.Restricted, "a school admin can't put their school into demo mode")
+	require.False(t, stored().Demo.Restricted)
+
+	settings.Drops.DefaultDurationDays = 0
+	settings.Branding.AccentColour = "red"
+	rr := doJSON(t, server, http.MethodPut, "/api/school/settings", adminToken, settings)
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+	var problem helpers.Problem
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+	require.Equal(t, helpers.CodeValidationFailed, problem.Code)
+	require.Equal(t, validate.Errors{
+		{Field: "drops.default_duration_days", Code: validate.CodeTooSmall, Message: "must be between 1 and 3650"},
+		{Field: "branding.accent_colour", Code: validate.CodeInvalid, Message: "must be a hex colour such as #1976D2"},
+	}, problem.Errors, "every bad field is reported on its own")
+	code, _ = put(userToken, settings)
+	require.Equal(t, http.StatusForbidden, code)
+	require.Equal(t, 30, stored().Drops.DefaultDurationDays, "rejected changes aren't saved")
+
+	// Only a platform admin can change demo restrictions
+	require.Equal(t, http.StatusForbidden, setDemo(adminToken, testSchoolID, true))
+	require.Equal(t, http.StatusNotFound, setDemo(platformToken, uuid.New(), true))
+	require.Equal(t, http.StatusOK, setDemo(platformToken, testSchoolID, true))
+	after := stored()
+	require.True(t, after.Demo.Restricted)
+	require.Equal(t, 30, after.Drops.DefaultDurationDays, "the rest of the settings are untouched")
+
+	// ... and a school admin can't take their school out of demo mode either
+	settings = schoolsettings.Default()
+	settings.Drops.DefaultDurationDays = 60
+	code, saved = put(adminToken, settings)
+	require.Equal(t, http.StatusOK, code)
+	require.True(t, saved.Demo.Restricted)
+	require.True(t, stored().Demo.Restricted)
+
+	require.Equal(t, http.StatusOK, setDemo(platformToken, testSchoolID, false))
+	require.False(t, stored().Demo.Restricted)
+}
@@ -0,0 +1,154 @@
+package school_structure
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/5tuartw/droplet/internal/auth"
+	"github.com/5tuartw/droplet/internal/config"
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/helpers"
+	"github.com/5tuartw/droplet/internal/models"
+	"github.com/google/uuid"
+)
+
+// GetClassStaff lists the staff assigned to a class and their roles there
+func GetClassStaff(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+	contextValueSchool := r.Context().Value(auth.UserSchoolKey)
+	schoolID, schoolOK := contextValueSchool.(uuid.UUID)
+	if !schoolOK {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Value missing from context", nil)
+		return
+	}
+
+	targetClassIDint, err := strconv.Atoi(r.PathValue("classID"))
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusBadRequest, "Invalid class ID format in path", err)
+		return
+	}
+	targetClassID := int32(targetClassIDint)
+
+	count, err := dbq.ValidateClassInSchool(r.Context(), database.ValidateClassInSchoolParams{SchoolID: schoolID, ID: targetClassID})
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not look up class", err)
+		return
+	}
+	if count == 0 {
+		helpers.RespondWithError(w, http.StatusNotFound, "Class not found within scope", nil)
+		return
+	}
+
+	staffRows, err := dbq.GetClassStaff(r.Context(), database.GetClassStaffParams{ClassID: targetClassID, SchoolID: schoolID})
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not look up class staff", err)
+		return
+	}
+
+	staff := make([]models.ClassStaffInfo, len(staffRows))
+	for i, s := range staffRows {
+		staff[i] = models.ClassStaffInfo{ID: s.ID, Title: s.Title, FirstName: s.FirstName, Surname: s.Surname, Role: string(s.Role)}
+	}
+	helpers.RespondWithJSON(w, http.StatusOK, staff)
+}
+
+// AssignClassStaff assigns a member of staff to a class, or changes their role if they are
+// already assigned
+func AssignClassStaff(cfg *config.ApiConfig, dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+	if auth.DemoRestricted(cfg, dbq, r) {
+		slog.InfoContext(r.Context(), "blocked in demo mode", "action", "class staff update")
+		helpers.RespondWithError(w, http.StatusForbidden, "Class updating is disabled in demo mode", errors.New("demo mode restriction"))
+		return
+	}
+
+	contextValueSchool := r.Context().Value(auth.UserSchoolKey)
+	schoolID, schoolOK := contextValueSchool.(uuid.UUID)
+	if !schoolOK {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Value missing from context", nil)
+		return
+	}
+
+	targetClassIDint, err := strconv.Atoi(r.PathValue("classID"))
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusBadRequest, "Invalid class ID format in path", err)
+		return
+	}
+	targetClassID := int32(targetClassIDint)
+
+	targetUserID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusBadRequest, "Could not parse user ID in path", err)
+		return
+	}
+
+	requestBody := models.AssignClassStaffRequest{}
+	if !helpers.DecodeJSON(w, r, &requestBody) {
+		return
+	}
+
+	assignment, err := dbq.AssignClassStaff(r.Context(), database.AssignClassStaffParams{
+		Role:     database.ClassStaffRole(requestBody.Role),
+		ClassID:  targetClassID,
+		UserID:   targetUserID,
+		SchoolID: schoolID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			helpers.RespondWithError(w, http.StatusNotFound, "Class or user not found within scope", err)
+		} else {
+			slog.ErrorContext(r.Context(), "could not assign user to class", "target_user_id", targetUserID, "class_id", targetClassID, "error", err)
+			helpers.RespondWithError(w, http.StatusInternalServerError, "Unable to assign staff to class", err)
+		}
+		return
+	}
+
+	helpers.RespondWithJSON(w, http.StatusOK, assignment)
+}
+
+// RemoveClassStaff unassigns a member of staff from a class
+func RemoveClassStaff(cfg *config.ApiConfig, dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+	if auth.DemoRestricted(cfg, dbq, r) {
+		slog.InfoContext(r.Context(), "blocked in demo mode", "action", "class staff update")
+		helpers.RespondWithError(w, http.StatusForbidden, "Class updating is disabled in demo mode", errors.New("demo mode restriction"))
+		return
+	}
+
+	contextValueSchool := r.Context().Value(auth.UserSchoolKey)
+	schoolID, schoolOK := contextValueSchool.(uuid.UUID)
+	if !schoolOK {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Value missing from context", nil)
+		return
+	}
+
+	targetClassIDint, err := strconv.Atoi(r.PathValue("classID"))
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusBadRequest, "Invalid class ID format in path", err)
+		return
+	}
+	targetClassID := int32(targetClassIDint)
+
+	targetUserID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusBadRequest, "Could not parse user ID in path", err)
+		return
+	}
+
+	rowsAffected, err := dbq.RemoveClassStaff(r.Context(), database.RemoveClassStaffParams{
+		ClassID:  targetClassID,
+		UserID:   targetUserID,
+		SchoolID: schoolID,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "could not remove user from class", "target_user_id", targetUserID, "class_id", targetClassID, "error", err)
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Unable to remove staff from class", err)
+		return
+	}
+	if rowsAffected == 0 {
+		helpers.RespondWithError(w, http.StatusNotFound, "Staff assignment not found within scope", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -2,10 +2,9 @@ package school_structure
 
 import (
 	"database/sql"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
 
@@ -13,6 +12,7 @@ import (
 	"github.com/5tuartw/droplet/internal/config"
 	"github.com/5tuartw/droplet/internal/database"
 	"github.com/5tuartw/droplet/internal/helpers"
+	"github.com/5tuartw/droplet/internal/models"
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 )
@@ -42,23 +42,12 @@ func CreateClass(dbq *database.Queries, w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	var requestBody struct {
-		ClassName   string `json:"class_name"`
-		YearGroupID int32  `json:"year_group_id"`
-	}
-
-	err := json.NewDecoder(r.Body).Decode(&requestBody)
-	defer r.Body.Close()
-	if err != nil {
-		helpers.RespondWithError(w, http.StatusBadRequest, "Could not decode request body", err)
-		return
-	}
-	if requestBody.ClassName == "" {
-		helpers.RespondWithError(w, http.StatusBadRequest, "Class name cannot be empty", nil)
+	requestBody := models.CreateClassRequest{}
+	if !helpers.DecodeJSON(w, r, &requestBody) {
 		return
 	}
 
-	err = CheckYearGroupID(dbq, r.Context(), schoolID, requestBody.YearGroupID)
+	err := CheckYearGroupID(dbq, r.Context(), schoolID, requestBody.YearGroupID)
 	if err != nil {
 		helpers.RespondWithError(w, http.StatusBadRequest, "Could not add class to year group", err)
 		return
@@ -84,8 +73,8 @@ func CreateClass(dbq *database.Queries, w http.ResponseWriter, r *http.Request)
 }
 
 func RenameClass(cfg *config.ApiConfig, dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
-	if cfg.IsDemoMode {
-		log.Println("Attempted class update in demo mode - Forbidden.")
+	if auth.DemoRestricted(cfg, dbq, r) {
+		slog.InfoContext(r.Context(), "blocked in demo mode", "action", "class update")
 		helpers.RespondWithError(w, http.StatusForbidden, "Class updating is disabled in demo mode", errors.New("demo mode restriction"))
 		return
 	}
@@ -106,18 +95,8 @@ func RenameClass(cfg *config.ApiConfig, dbq *database.Queries, w http.ResponseWr
 	}
 	targetClassID := int32(targetClassIDint)
 
-	var requestBody struct {
-		ClassName string `json:"class_name"`
-	}
-	err = json.NewDecoder(r.Body).Decode(&requestBody)
-	defer r.Body.Close()
-	if err != nil {
-		helpers.RespondWithError(w, http.StatusBadRequest, "Could not decode request body", err)
-		return
-	}
-
-	if requestBody.ClassName == "" {
-		helpers.RespondWithError(w, http.StatusBadRequest, "Class name cannot be empty", nil)
+	requestBody := models.RenameClassRequest{}
+	if !helpers.DecodeJSON(w, r, &requestBody) {
 		return
 	}
 
@@ -135,8 +114,8 @@ func RenameClass(cfg *config.ApiConfig, dbq *database.Queries, w http.ResponseWr
 }
 
 func MoveClass(cfg *config.ApiConfig, dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
-	if cfg.IsDemoMode {
-		log.Println("Attempted class update in demo mode - Forbidden.")
+	if auth.DemoRestricted(cfg, dbq, r) {
+		slog.InfoContext(r.Context(), "blocked in demo mode", "action", "class update")
 		helpers.RespondWithError(w, http.StatusForbidden, "Class updating is disabled in demo mode", errors.New("demo mode restriction"))
 		return
 	}
@@ -157,13 +136,8 @@ func MoveClass(cfg *config.ApiConfig, dbq *database.Queries, w http.ResponseWrit
 	}
 	targetClassID := int32(targetClassIDint)
 
-	var requestBody struct {
-		YearGroupID int32 `json:"year_group_id"`
-	}
-	err = json.NewDecoder(r.Body).Decode(&requestBody)
-	defer r.Body.Close()
-	if err != nil {
-		helpers.RespondWithError(w, http.StatusBadRequest, "Could not decode request body", err)
+	requestBody := models.MoveClassRequest{}
+	if !helpers.DecodeJSON(w, r, &requestBody) {
 		return
 	}
 	err = CheckYearGroupID(dbq, r.Context(), schoolID, requestBody.YearGroupID)
@@ -186,8 +160,8 @@ func MoveClass(cfg *config.ApiConfig, dbq *database.Queries, w http.ResponseWrit
 }
 
 func DeleteClass(cfg *config.ApiConfig, dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
-	if cfg.IsDemoMode {
-		log.Println("Attempted class deletion in demo mode - Forbidden.")
+	if auth.DemoRestricted(cfg, dbq, r) {
+		slog.InfoContext(r.Context(), "blocked in demo mode", "action", "class deletion")
 		helpers.RespondWithError(w, http.StatusForbidden, "Class deletion is disabled in demo mode", errors.New("demo mode restriction"))
 		return
 	}
@@ -214,12 +188,12 @@ func DeleteClass(cfg *config.ApiConfig, dbq *database.Queries, w http.ResponseWr
 		SchoolID: schoolID,
 	})
 	if err != nil {
-		log.Printf("Error deleting class %d from school %s: %v", targetClassID, schoolID, err)
+		slog.ErrorContext(r.Context(), "could not delete class", "class_id", targetClassID, "error", err)
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Failed to check for pupils", err)
 		return
 	}
 	if pupilCount > 0 {
-		log.Printf("Deletion forbidden: Class %d still has %d pupil(s) associated.", targetClassID, pupilCount)
+		slog.InfoContext(r.Context(), "class still has pupils", "class_id", targetClassID, "pupils", pupilCount)
 		helpers.RespondWithError(w, http.StatusConflict, fmt.Sprintf("Cannot delete class: %d pupil(s) still assigned.", pupilCount), errors.New("conflict: child records exist"))
 		return
 	}
@@ -229,7 +203,7 @@ func DeleteClass(cfg *config.ApiConfig, dbq *database.Queries, w http.ResponseWr
 		SchoolID: schoolID,
 	})
 	if err != nil {
-		log.Printf("Error deleting class %d: %v", targetClassID, err)
+		slog.ErrorContext(r.Context(), "could not delete class", "class_id", targetClassID, "error", err)
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Error deleting class", err)
 		return
 	}
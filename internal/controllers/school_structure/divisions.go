@@ -3,10 +3,9 @@ package school_structure
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
 
@@ -14,6 +13,7 @@ import (
 	"github.com/5tuartw/droplet/internal/config"
 	"github.com/5tuartw/droplet/internal/database"
 	"github.com/5tuartw/droplet/internal/helpers"
+	"github.com/5tuartw/droplet/internal/models"
 	"github.com/google/uuid"
 )
 
@@ -46,7 +46,7 @@ func CheckDivisionID(dbq *database.Queries, ctx context.Context, schoolID uuid.U
 		if errors.Is(err, sql.ErrNoRows) {
 			return fmt.Errorf("division ID %d not found in school %s", divisionID, schoolID)
 		}
-		log.Printf("DB error checking division ID %d for school %s: %v", divisionID, schoolID, err)
+		slog.ErrorContext(ctx, "could not check division", "division_id", divisionID, "error", err)
 		return fmt.Errorf("database error checking division ID")
 	}
 	return nil
@@ -61,18 +61,8 @@ func CreateDivision(dbq *database.Queries, w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	var requestBody struct {
-		DivisionName string `json:"division_name"`
-	}
-
-	err := json.NewDecoder(r.Body).Decode(&requestBody)
-	defer r.Body.Close()
-	if err != nil {
-		helpers.RespondWithError(w, http.StatusBadRequest, "Could not decode request body", err)
-		return
-	}
-	if requestBody.DivisionName == "" {
-		helpers.RespondWithError(w, http.StatusBadRequest, "Division name cannot be empty", nil)
+	requestBody := models.CreateDivisionRequest{}
+	if !helpers.DecodeJSON(w, r, &requestBody) {
 		return
 	}
 
@@ -89,8 +79,8 @@ func CreateDivision(dbq *database.Queries, w http.ResponseWriter, r *http.Reques
 }
 
 func RenameDivision(cfg *config.ApiConfig, dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
-	if cfg.IsDemoMode {
-		log.Println("Attempted division update in demo mode - Forbidden.")
+	if auth.DemoRestricted(cfg, dbq, r) {
+		slog.InfoContext(r.Context(), "blocked in demo mode", "action", "division update")
 		helpers.RespondWithError(w, http.StatusForbidden, "Division updating is disabled in demo mode", errors.New("demo mode restriction"))
 		return
 	}
@@ -111,17 +101,8 @@ func RenameDivision(cfg *config.ApiConfig, dbq *database.Queries, w http.Respons
 	}
 	targetDivisionID := int32(targetDivisionIDint)
 
-	var requestBody struct {
-		DivisionName string `json:"division_name"`
-	}
-	err = json.NewDecoder(r.Body).Decode(&requestBody)
-	defer r.Body.Close()
-	if err != nil {
-		helpers.RespondWithError(w, http.StatusBadRequest, "Could not decode request body", err)
-		return
-	}
-	if requestBody.DivisionName == "" {
-		helpers.RespondWithError(w, http.StatusBadRequest, "Division name cannot be empty", nil)
+	requestBody := models.RenameDivisionRequest{}
+	if !helpers.DecodeJSON(w, r, &requestBody) {
 		return
 	}
 
@@ -139,8 +120,8 @@ func RenameDivision(cfg *config.ApiConfig, dbq *database.Queries, w http.Respons
 }
 
 func DeleteDivision(cfg *config.ApiConfig, dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
-	if cfg.IsDemoMode {
-		log.Println("Attempted division deletion in demo mode - Forbidden.")
+	if auth.DemoRestricted(cfg, dbq, r) {
+		slog.InfoContext(r.Context(), "blocked in demo mode", "action", "division deletion")
 		helpers.RespondWithError(w, http.StatusForbidden, "Division deletion is disabled in demo mode", errors.New("demo mode restriction"))
 		return
 	}
@@ -167,12 +148,12 @@ func DeleteDivision(cfg *config.ApiConfig, dbq *database.Queries, w http.Respons
 		SchoolID:   schoolID,
 	})
 	if err != nil {
-		log.Printf("Error deleting division %d from school %s: %v", targetDivisionID, schoolID, err)
+		slog.ErrorContext(r.Context(), "could not delete division", "division_id", targetDivisionID, "error", err)
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Failed to check for year groups", err)
 		return
 	}
 	if yearGroupsCount > 0 {
-		log.Printf("Deletion forbidden: Division %d still has %d year group(s) associated.", targetDivisionID, yearGroupsCount)
+		slog.InfoContext(r.Context(), "division still has year groups", "division_id", targetDivisionID, "year_groups", yearGroupsCount)
 		helpers.RespondWithError(w, http.StatusConflict, fmt.Sprintf("Cannot delete division: %d year group(s) still assigned.", yearGroupsCount), errors.New("conflict: child records exist"))
 		return
 	}
@@ -182,7 +163,7 @@ func DeleteDivision(cfg *config.ApiConfig, dbq *database.Queries, w http.Respons
 		SchoolID: schoolID,
 	})
 	if err != nil {
-		log.Printf("Error deleting division %d: %v", targetDivisionID, err)
+		slog.ErrorContext(r.Context(), "could not delete division", "division_id", targetDivisionID, "error", err)
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Error deleting division", err)
 		return
 	}
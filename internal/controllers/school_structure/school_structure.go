@@ -51,13 +51,35 @@ func GetSchoolStructure(dbq *database.Queries, w http.ResponseWriter, r *http.Re
 		pupilCountsMap[pc.ClassID.Int32] = pc.Count
 	}
 
+	classStaff, err := dbq.GetClassStaffForSchool(r.Context(), schoolID)
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "could not look up class staff", err)
+		return
+	}
+
+	staffByClassID := make(map[int32][]models.ClassStaffInfo)
+	for _, cs := range classStaff {
+		staffByClassID[cs.ClassID] = append(staffByClassID[cs.ClassID], models.ClassStaffInfo{
+			ID:        cs.ID,
+			Title:     cs.Title,
+			FirstName: cs.FirstName,
+			Surname:   cs.Surname,
+			Role:      string(cs.Role),
+		})
+	}
+
 	classesByYearGroupID := make(map[int32][]models.ClassInfo)
 	for _, class := range classes {
 		pupilCount := pupilCountsMap[class.ID]
+		staff := staffByClassID[class.ID]
+		if staff == nil {
+			staff = []models.ClassStaffInfo{}
+		}
 		classInfo := models.ClassInfo{
 			ID:    class.ID,
 			Name:  class.ClassName,
 			PupilCount: pupilCount,
+			Staff: staff,
 		}
 		yearGroupID := class.YearGroupID.Int32
 		classesByYearGroupID[yearGroupID] = append(classesByYearGroupID[yearGroupID], classInfo)
@@ -120,12 +142,16 @@ func GetSchoolStructure(dbq *database.Queries, w http.ResponseWriter, r *http.Re
             {
               "id": 5, // Class ID (int32)
               "name": "3A",
-              "pupil_count": 15 // Added count
+              "pupil_count": 15, // Added count
+              "staff": [
+                { "id": "uuid-user", "title": "Mr", "first_name": "Sam", "surname": "Jones", "role": "tutor" }
+              ]
             },
             {
               "id": 6,
               "name": "3B",
-              "pupil_count": 18
+              "pupil_count": 18,
+              "staff": []
             }
           ]
         },
@@ -3,10 +3,9 @@ package school_structure
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
 
@@ -14,6 +13,7 @@ import (
 	"github.com/5tuartw/droplet/internal/config"
 	"github.com/5tuartw/droplet/internal/database"
 	"github.com/5tuartw/droplet/internal/helpers"
+	"github.com/5tuartw/droplet/internal/models"
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 )
@@ -42,7 +42,7 @@ func CheckYearGroupID(dbq *database.Queries, ctx context.Context, schoolID uuid.
 		if errors.Is(err, sql.ErrNoRows) {
 			return fmt.Errorf("year group ID %d not found in school %s", yearGroupID, schoolID)
 		}
-		log.Printf("DB error checking year group ID %d for school %s: %v", yearGroupID, schoolID, err)
+		slog.ErrorContext(ctx, "could not check year group", "year_group_id", yearGroupID, "error", err)
 		return fmt.Errorf("database error checking year group ID")
 	}
 	return nil
@@ -57,23 +57,12 @@ func CreateYearGroup(dbq *database.Queries, w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	var requestBody struct {
-		YearGroupName string `json:"year_group_name"`
-		DivisionID    int32  `json:"division_id"`
-	}
-
-	err := json.NewDecoder(r.Body).Decode(&requestBody)
-	defer r.Body.Close()
-	if err != nil {
-		helpers.RespondWithError(w, http.StatusBadRequest, "Could not decode request body", err)
-		return
-	}
-	if requestBody.YearGroupName == "" {
-		helpers.RespondWithError(w, http.StatusBadRequest, "Year group name cannot be empty", nil)
+	requestBody := models.CreateYearGroupRequest{}
+	if !helpers.DecodeJSON(w, r, &requestBody) {
 		return
 	}
 
-	err = CheckDivisionID(dbq, r.Context(), schoolID, requestBody.DivisionID)
+	err := CheckDivisionID(dbq, r.Context(), schoolID, requestBody.DivisionID)
 	if err != nil {
 		helpers.RespondWithError(w, http.StatusBadRequest, "Could not add year group to division", err)
 		return
@@ -99,8 +88,8 @@ func CreateYearGroup(dbq *database.Queries, w http.ResponseWriter, r *http.Reque
 }
 
 func RenameYearGroup(cfg *config.ApiConfig, dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
-	if cfg.IsDemoMode {
-		log.Println("Attempted year group update in demo mode - Forbidden.")
+	if auth.DemoRestricted(cfg, dbq, r) {
+		slog.InfoContext(r.Context(), "blocked in demo mode", "action", "year group update")
 		helpers.RespondWithError(w, http.StatusForbidden, "Year group updating is disabled in demo mode", errors.New("demo mode restriction"))
 		return
 	}
@@ -121,18 +110,8 @@ func RenameYearGroup(cfg *config.ApiConfig, dbq *database.Queries, w http.Respon
 	}
 	targetYearGroupID := int32(targetYearGroupIDint)
 
-	var requestBody struct {
-		YearGroupName string `json:"year_group_name"`
-	}
-	err = json.NewDecoder(r.Body).Decode(&requestBody)
-	defer r.Body.Close()
-	if err != nil {
-		helpers.RespondWithError(w, http.StatusBadRequest, "Could not decode request body", err)
-		return
-	}
-	
-	if requestBody.YearGroupName == "" {
-		helpers.RespondWithError(w, http.StatusBadRequest, "Year group name cannot be empty", nil)
+	requestBody := models.RenameYearGroupRequest{}
+	if !helpers.DecodeJSON(w, r, &requestBody) {
 		return
 	}
 
@@ -150,8 +129,8 @@ func RenameYearGroup(cfg *config.ApiConfig, dbq *database.Queries, w http.Respon
 }
 
 func MoveYearGroup(cfg *config.ApiConfig, dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
-	if cfg.IsDemoMode {
-		log.Println("Attempted year group update in demo mode - Forbidden.")
+	if auth.DemoRestricted(cfg, dbq, r) {
+		slog.InfoContext(r.Context(), "blocked in demo mode", "action", "year group update")
 		helpers.RespondWithError(w, http.StatusForbidden, "Year group updating is disabled in demo mode", errors.New("demo mode restriction"))
 		return
 	}
@@ -172,13 +151,8 @@ func MoveYearGroup(cfg *config.ApiConfig, dbq *database.Queries, w http.Response
 	}
 	targetYearGroupID := int32(targetYearGroupIDint)
 
-	var requestBody struct {
-		DivisionID    int32  `json:"division_id"`
-	}
-	err = json.NewDecoder(r.Body).Decode(&requestBody)
-	defer r.Body.Close()
-	if err != nil {
-		helpers.RespondWithError(w, http.StatusBadRequest, "Could not decode request body", err)
+	requestBody := models.MoveYearGroupRequest{}
+	if !helpers.DecodeJSON(w, r, &requestBody) {
 		return
 	}
 	err = CheckDivisionID(dbq, r.Context(), schoolID, requestBody.DivisionID)
@@ -201,8 +175,8 @@ func MoveYearGroup(cfg *config.ApiConfig, dbq *database.Queries, w http.Response
 }
 
 func DeleteYearGroup(cfg *config.ApiConfig, dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
-	if cfg.IsDemoMode {
-		log.Println("Attempted year group deletion in demo mode - Forbidden.")
+	if auth.DemoRestricted(cfg, dbq, r) {
+		slog.InfoContext(r.Context(), "blocked in demo mode", "action", "year group deletion")
 		helpers.RespondWithError(w, http.StatusForbidden, "Year group deletion is disabled in demo mode", errors.New("demo mode restriction"))
 		return
 	}
@@ -229,12 +203,12 @@ func DeleteYearGroup(cfg *config.ApiConfig, dbq *database.Queries, w http.Respon
 		SchoolID:    schoolID,
 	})
 	if err != nil {
-		log.Printf("Error deleting year group %d from school %s: %v", targetYearGroupID, schoolID, err)
+		slog.ErrorContext(r.Context(), "could not delete year group", "year_group_id", targetYearGroupID, "error", err)
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Failed to check for classes", err)
 		return
 	}
 	if classesCount > 0 {
-		log.Printf("Deletion forbidden: Year group %d still has %d class(es) associated.", targetYearGroupID, classesCount)
+		slog.InfoContext(r.Context(), "year group still has classes", "year_group_id", targetYearGroupID, "classes", classesCount)
 		helpers.RespondWithError(w, http.StatusConflict, fmt.Sprintf("Cannot delete year group: %d class(es) still assigned.", classesCount), errors.New("conflict: child records exist"))
 		return
 	}
@@ -244,7 +218,7 @@ func DeleteYearGroup(cfg *config.ApiConfig, dbq *database.Queries, w http.Respon
 		SchoolID: schoolID,
 	})
 	if err != nil {
-		log.Printf("Error deleting year group %d: %v", targetYearGroupID, err)
+		slog.ErrorContext(r.Context(), "could not delete year group", "year_group_id", targetYearGroupID, "error", err)
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Error deleting year group", err)
 		return
 	}
@@ -2,9 +2,8 @@ package settings
 
 import (
 	"database/sql"
-	"encoding/json"
 	"errors"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
 
@@ -27,14 +26,15 @@ func GetMySettings(dbq *database.Queries, w http.ResponseWriter, r *http.Request
 	schoolID, schoolOk := contextValueSchool.(uuid.UUID)
 
 	if !idOk || !schoolOk {
-		log.Println("Error: userID not found in context")
+		slog.ErrorContext(r.Context(), "user not found in request context")
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Internal Server Error (context error)", nil)
 		return
 	}
 
 	responsePayload := models.AllUserSettingsResponse{
-		Preferences:   models.UserSettingsPreferences{},
-		Subscriptions: make([]database.TargetInfo, 0),
+		Preferences:      models.UserSettingsPreferences{},
+		Subscriptions:    make([]database.TargetInfo, 0),
+		TagSubscriptions: make([]models.TagSubscription, 0),
 	}
 
 	getPreferences, err := dbq.GetUserSettings(r.Context(), database.GetUserSettingsParams{
@@ -60,13 +60,30 @@ func GetMySettings(dbq *database.Queries, w http.ResponseWriter, r *http.Request
 		SchoolID: schoolID,
 	})
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
-		log.Printf("Could not fetch subscriptions for user %s. %v", userID, err)
+		slog.ErrorContext(r.Context(), "could not fetch subscriptions", "error", err)
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not get user's subscriptions", err)
 		return
 	}
 
 	responsePayload.Subscriptions = database.MapSubscriptionsRowsToInfo(getSubscriptionRows)
 
+	tagSubscriptionRows, err := dbq.GetTagSubscriptionsForUser(r.Context(), database.GetTagSubscriptionsForUserParams{
+		UserID:   userID,
+		SchoolID: schoolID,
+	})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		slog.ErrorContext(r.Context(), "could not fetch tag subscriptions", "error", err)
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not get user's tag subscriptions", err)
+		return
+	}
+	for _, row := range tagSubscriptionRows {
+		responsePayload.TagSubscriptions = append(responsePayload.TagSubscriptions, models.TagSubscription{
+			TagID: row.TagID,
+			Name:  row.TagName,
+			Muted: row.Muted,
+		})
+	}
+
 	helpers.RespondWithJSON(w, http.StatusOK, responsePayload)
 }
 
@@ -82,17 +99,13 @@ func UpdateUserSettings(dbq *database.Queries, w http.ResponseWriter, r *http.Re
 	schoolID, schoolOk := contextValueSchool.(uuid.UUID)
 
 	if !idOk || !schoolOk {
-		log.Println("Error: userID not found in context")
+		slog.ErrorContext(r.Context(), "user not found in request context")
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Internal Server Error (context error)", nil)
 		return
 	}
 
 	requestBody := UpdatePreferencesRequest{}
-	decoder := json.NewDecoder(r.Body)
-	defer r.Body.Close()
-	err := decoder.Decode(&requestBody)
-	if err != nil {
-		helpers.RespondWithError(w, http.StatusBadRequest, "Error decoding settings json", err)
+	if !helpers.DecodeJSON(w, r, &requestBody) {
 		return
 	}
 
@@ -103,14 +116,14 @@ func UpdateUserSettings(dbq *database.Queries, w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	err = dbq.UpsertUserSettings(r.Context(), database.UpsertUserSettingsParams{
+	err := dbq.UpsertUserSettings(r.Context(), database.UpsertUserSettingsParams{
 		UserID:     userID,
 		SchoolID:   schoolID,
 		ColorTheme: requestBody.ColorTheme,
 		LayoutPref: requestBody.LayoutPref,
 	})
 	if err != nil {
-		log.Printf("Error upserting settings for user %s: %v", userID, err) // Log the error
+		slog.ErrorContext(r.Context(), "could not save user settings", "error", err)
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not update user settings", err)
 		return
 	}
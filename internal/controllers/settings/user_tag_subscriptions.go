@@ -0,0 +1,121 @@
+package settings
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/5tuartw/droplet/internal/auth"
+	"github.com/5tuartw/droplet/internal/controllers/tags"
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/helpers"
+	"github.com/google/uuid"
+)
+
+// Followed tags bring drops into /api/mydrops even when they are not targeted at the user;
+// muted tags hide drops from it (except urgent ones).
+type UpdateTagSubscriptionsRequest struct {
+	Followed []int32 `json:"followed"`
+	Muted    []int32 `json:"muted"`
+}
+
+func UpdateTagSubscriptions(db *sql.DB, dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+	contextValueID := r.Context().Value(auth.UserIDKey)
+	userID, idOk := contextValueID.(uuid.UUID)
+	contextValueSchool := r.Context().Value(auth.UserSchoolKey)
+	schoolID, schoolOk := contextValueSchool.(uuid.UUID)
+
+	if !idOk || !schoolOk {
+		slog.ErrorContext(r.Context(), "user not found in request context")
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Internal Server Error (context error)", nil)
+		return
+	}
+
+	requestBody := UpdateTagSubscriptionsRequest{}
+	if !helpers.DecodeJSON(w, r, &requestBody) {
+		return
+	}
+
+	muted := make(map[int32]bool)
+	for _, tagID := range requestBody.Muted {
+		muted[tagID] = true
+	}
+	followed := make(map[int32]bool)
+	for _, tagID := range requestBody.Followed {
+		if muted[tagID] {
+			helpers.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Tag %d cannot be both followed and muted", tagID), nil)
+			return
+		}
+		followed[tagID] = true
+	}
+
+	err := tags.ValidateTagsBelongToSchool(r.Context(), dbq, schoolID, append(requestBody.Followed, requestBody.Muted...))
+	if err != nil {
+		helpers.RespondWithProblem(w, http.StatusBadRequest, helpers.CodeTagInvalidForSchool, "Invalid tag(s) provided", err)
+		return
+	}
+
+	tx, err := db.BeginTx(r.Context(), nil)
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not start database transaction", err)
+		return
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			slog.ErrorContext(r.Context(), "panic in transaction, rolling back")
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			slog.WarnContext(r.Context(), "rolling back transaction", "error", err)
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+			if err != nil {
+				slog.ErrorContext(r.Context(), "could not commit transaction", "error", err)
+			} else {
+				slog.DebugContext(r.Context(), "transaction committed")
+			}
+		}
+	}()
+
+	qtx := dbq.WithTx(tx)
+
+	err = qtx.DeleteTagSubscriptions(r.Context(), database.DeleteTagSubscriptionsParams{
+		UserID:   userID,
+		SchoolID: schoolID,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "could not clear tag subscriptions", "error", err)
+		helpers.RespondWithError(w, http.StatusInternalServerError, "could not delete old tag subscriptions", err)
+		return
+	}
+
+	for tagID := range followed {
+		err = qtx.AddTagSubscription(r.Context(), database.AddTagSubscriptionParams{
+			UserID:   userID,
+			SchoolID: schoolID,
+			TagID:    tagID,
+			Muted:    false,
+		})
+		if err != nil {
+			helpers.RespondWithError(w, http.StatusInternalServerError, "could not follow tag", err)
+			return
+		}
+	}
+	for tagID := range muted {
+		err = qtx.AddTagSubscription(r.Context(), database.AddTagSubscriptionParams{
+			UserID:   userID,
+			SchoolID: schoolID,
+			TagID:    tagID,
+			Muted:    true,
+		})
+		if err != nil {
+			helpers.RespondWithError(w, http.StatusInternalServerError, "could not mute tag", err)
+			return
+		}
+	}
+
+	slog.InfoContext(r.Context(), "tag subscriptions updated")
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -2,9 +2,7 @@ package settings
 
 import (
 	"database/sql"
-	"encoding/json"
-	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 
 	"github.com/5tuartw/droplet/internal/auth"
@@ -26,24 +24,27 @@ func UpdateTargetSubscriptions(db *sql.DB, dbq *database.Queries, w http.Respons
 	schoolID, schoolOk := contextValueSchool.(uuid.UUID)
 
 	if !idOk || !schoolOk {
-		log.Println("Error: userID not found in context")
+		slog.ErrorContext(r.Context(), "user not found in request context")
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Internal Server Error (context error)", nil)
 		return
 	}
 
 	requestBody := UpdateSubscriptionsRequest{}
-	decoder := json.NewDecoder(r.Body)
-	defer r.Body.Close()
-	err := decoder.Decode(&requestBody)
-	if err != nil {
-		helpers.RespondWithError(w, http.StatusBadRequest, "Error decoding json data for update subscriptions", err)
+	if !helpers.DecodeJSON(w, r, &requestBody) {
 		return
 	}
 
-	err = targets.ValidateTargetsBelongToSchool(r.Context(), dbq, schoolID, requestBody.Targets)
+	for _, target := range requestBody.Targets {
+		if target.Type == "Lesson" {
+			helpers.RespondWithError(w, http.StatusBadRequest, "Lesson targets cannot be subscribed to", nil)
+			return
+		}
+	}
+
+	err := targets.ValidateTargetsBelongToSchool(r.Context(), dbq, schoolID, requestBody.Targets)
 	if err != nil {
-		log.Printf("Target validation failed for user %s school %s: %v", userID, schoolID, err)
-		helpers.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid target(s) provided: %v", err), err)
+		slog.InfoContext(r.Context(), "subscription targets rejected", "error", err)
+		helpers.RespondWithProblem(w, http.StatusBadRequest, helpers.CodeTargetInvalidForSchool, "Invalid target(s) provided", err)
 		return
 	}
 
@@ -55,19 +56,19 @@ func UpdateTargetSubscriptions(db *sql.DB, dbq *database.Queries, w http.Respons
 	}
 	defer func() {
 		if p := recover(); p != nil {
-			log.Println("Recovered from panic, rolling back transaction")
+			slog.ErrorContext(r.Context(), "panic in transaction, rolling back")
 			tx.Rollback() // Rollback on panic
 			panic(p)      // Re-panic after rollback attempt
 		} else if err != nil {
-			log.Printf("Error occurred, rolling back transaction: %v", err)
+			slog.WarnContext(r.Context(), "rolling back transaction", "error", err)
 			tx.Rollback() // Rollback on normal error
 		} else {
 			// No error, attempt to commit
 			err = tx.Commit()
 			if err != nil {
-				log.Printf("Failed to commit transaction: %v", err)
+				slog.ErrorContext(r.Context(), "could not commit transaction", "error", err)
 			} else {
-				log.Println("Transaction committed successfully.")
+				slog.DebugContext(r.Context(), "transaction committed")
 			}
 		}
 	}()
@@ -80,7 +81,7 @@ func UpdateTargetSubscriptions(db *sql.DB, dbq *database.Queries, w http.Respons
 		SchoolID: schoolID,
 	})
 	if err != nil {
-		log.Printf("TX Error deleting subscriptions for user %s: %v", userID, err)
+		slog.ErrorContext(r.Context(), "could not clear subscriptions", "error", err)
 		helpers.RespondWithError(w, http.StatusInternalServerError, "could not delete old subscriptions", err)
 		return
 	}
@@ -99,12 +100,12 @@ func UpdateTargetSubscriptions(db *sql.DB, dbq *database.Queries, w http.Respons
 			TargetID: nullTargetID.Int32,
 		})
 		if err != nil {
-			log.Printf("TX Error adding subscription %+v for user %s: %v", subscription, userID, err)
+			slog.ErrorContext(r.Context(), "could not add subscription", "target_type", subscription.Type, "target_id", subscription.ID, "error", err)
 			helpers.RespondWithError(w, http.StatusInternalServerError, "could not add new subscription", err)
 			return
 		}
 	}
 
-	log.Printf("User subscriptions successfully updated for %s.", userID)
+	slog.InfoContext(r.Context(), "subscriptions updated")
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -0,0 +1,198 @@
+package tags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/5tuartw/droplet/internal/auth"
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/helpers"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// GetTags lists the school's tag vocabulary
+func GetTags(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+	contextValueSchool := r.Context().Value(auth.UserSchoolKey)
+	schoolID, ok := contextValueSchool.(uuid.UUID)
+	if !ok {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "School id missing from context", nil)
+		return
+	}
+
+	tags, err := dbq.GetTagsForSchool(r.Context(), schoolID)
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "could not look up tags", err)
+		return
+	}
+	if tags == nil {
+		tags = make([]database.Tag, 0)
+	}
+	helpers.RespondWithJSON(w, http.StatusOK, tags)
+}
+
+type TagRequest struct {
+	Name string `json:"name"`
+}
+
+func CreateTag(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+	contextValueSchool := r.Context().Value(auth.UserSchoolKey)
+	schoolID, schoolOK := contextValueSchool.(uuid.UUID)
+	if !schoolOK {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Value missing from context", nil)
+		return
+	}
+
+	requestBody := TagRequest{}
+	if !helpers.DecodeJSON(w, r, &requestBody) {
+		return
+	}
+
+	name := strings.TrimSpace(requestBody.Name)
+	if name == "" {
+		helpers.RespondWithError(w, http.StatusBadRequest, "Tag name cannot be empty", nil)
+		return
+	}
+
+	newTag, err := dbq.CreateTag(r.Context(), database.CreateTagParams{
+		SchoolID: schoolID,
+		Name:     name,
+	})
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			helpers.RespondWithError(w, http.StatusConflict, "A tag with that name already exists", err)
+		} else {
+			helpers.RespondWithError(w, http.StatusInternalServerError, "Unable to create new tag", err)
+		}
+		return
+	}
+
+	helpers.RespondWithJSON(w, http.StatusCreated, newTag)
+}
+
+func RenameTag(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+	contextValueSchool := r.Context().Value(auth.UserSchoolKey)
+	schoolID, schoolOK := contextValueSchool.(uuid.UUID)
+	if !schoolOK {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Value missing from context", nil)
+		return
+	}
+
+	tagID, err := strconv.Atoi(r.PathValue("tagID"))
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusBadRequest, "Invalid tag ID format in path", err)
+		return
+	}
+
+	requestBody := TagRequest{}
+	if !helpers.DecodeJSON(w, r, &requestBody) {
+		return
+	}
+
+	name := strings.TrimSpace(requestBody.Name)
+	if name == "" {
+		helpers.RespondWithError(w, http.StatusBadRequest, "Tag name cannot be empty", nil)
+		return
+	}
+
+	rowsAffected, err := dbq.RenameTag(r.Context(), database.RenameTagParams{
+		ID:       int32(tagID),
+		SchoolID: schoolID,
+		Name:     name,
+	})
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			helpers.RespondWithError(w, http.StatusConflict, "A tag with that name already exists", err)
+		} else {
+			helpers.RespondWithError(w, http.StatusInternalServerError, "Unable to update tag", err)
+		}
+		return
+	}
+	if rowsAffected == 0 {
+		helpers.RespondWithError(w, http.StatusNotFound, "Tag not found", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteTag removes a tag from the vocabulary, along with its uses on drops and any subscriptions to it
+func DeleteTag(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+	contextValueSchool := r.Context().Value(auth.UserSchoolKey)
+	schoolID, schoolOK := contextValueSchool.(uuid.UUID)
+	if !schoolOK {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Value missing from context", nil)
+		return
+	}
+
+	tagID, err := strconv.Atoi(r.PathValue("tagID"))
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusBadRequest, "Invalid tag ID format in path", err)
+		return
+	}
+
+	rowsAffected, err := dbq.DeleteTag(r.Context(), database.DeleteTagParams{
+		ID:       int32(tagID),
+		SchoolID: schoolID,
+	})
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Unable to delete tag", err)
+		return
+	}
+	if rowsAffected == 0 {
+		helpers.RespondWithError(w, http.StatusNotFound, "Tag not found", nil)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ValidateTagsBelongToSchool checks every tag ID exists in the school's vocabulary
+func ValidateTagsBelongToSchool(ctx context.Context, dbq *database.Queries, schoolID uuid.UUID, tagIDs []int32) error {
+	if len(tagIDs) == 0 {
+		return nil // nothing to validate
+	}
+
+	unique := make(map[int32]bool)
+	for _, id := range tagIDs {
+		unique[id] = true
+	}
+	tagList := make([]int32, 0, len(unique))
+	for id := range unique {
+		tagList = append(tagList, id)
+	}
+
+	count, err := dbq.CountValidTagsForSchool(ctx, database.CountValidTagsForSchoolParams{
+		SchoolID: schoolID,
+		Column2:  tagList,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "could not validate tags", "error", err)
+		return fmt.Errorf("failed to validate tags")
+	}
+	if count != int64(len(tagList)) {
+		slog.InfoContext(ctx, "tag validation failed", "expected", len(tagList), "found", count)
+		return fmt.Errorf("one or more submitted tag IDs are invalid for this school")
+	}
+	return nil
+}
+
+// ParseTagFilter turns a ?tags=Sport,Trips query value into lower-cased tag names.
+// An empty value gives an empty (non-nil) list, which matches every drop.
+func ParseTagFilter(raw string) []string {
+	names := make([]string, 0)
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
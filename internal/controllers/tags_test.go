@@ -0,0 +1,196 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTagCRUD(t *testing.T) {
+	if testDB == nil {
+		t.Fatal("Test database connection pool (testDB) is nil")
+	}
+	server := newRouterTestServer(t)
+	_, adminToken := seedTestUserWithRole(t, "tags.admin@example.com", database.UserRoleAdmin)
+	_, userToken := seedTestUserWithRole(t, "tags.user@example.com", database.UserRoleUser)
+
+	create := func(name string) *database.Tag {
+		t.Helper()
+		rr := doJSON(t, server, http.MethodPost, "/api/tags", adminToken, map[string]string{"name": name})
+		if rr.Code != http.StatusCreated {
+			return nil
+		}
+		var tag database.Tag
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &tag))
+		return &tag
+	}
+	tagPath := func(id int32) string { return "/api/tags/" + strconv.Itoa(int(id)) }
+
+	crud := create("  CRUD Zebra  ")
+	require.NotNil(t, crud)
+	require.Equal(t, "CRUD Zebra", crud.Name, "names are trimmed")
+	require.Equal(t, testSchoolID, crud.SchoolID)
+	other := create("CRUD Aardvark")
+	require.NotNil(t, other)
+
+	rr := doJSON(t, server, http.MethodPost, "/api/tags", adminToken, map[string]string{"name": "CRUD Zebra"})
+	require.Equal(t, http.StatusConflict, rr.Code)
+	rr = doJSON(t, server, http.MethodPost, "/api/tags", adminToken, map[string]string{"name": "   "})
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+	rr = doJSON(t, server, http.MethodPost, "/api/tags", userToken, map[string]string{"name": "CRUD Staff"})
+	require.Equal(t, http.StatusForbidden, rr.Code, "only tags.manage can add tags")
+
+	// Everyone can list them, in name order
+	rr = doJSON(t, server, http.MethodGet, "/api/tags", userToken, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var listed []database.Tag
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &listed))
+	var names []string
+	for _, tag := range listed {
+		names = append(names, tag.Name)
+	}
+	require.NotEqual(t, -1, slices.Index(names, "CRUD Aardvark"))
+	require.Less(t, slices.Index(names, "CRUD Aardvark"), slices.Index(names, "CRUD Zebra"))
+
+	// Rename
+	rr = doJSON(t, server, http.MethodPut, tagPath(crud.ID), adminToken, map[string]string{"name": "CRUD Yak"})
+	require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+	rr = doJSON(t, server, http.MethodPut, tagPath(crud.ID), adminToken, map[string]string{"name": "CRUD Aardvark"})
+	require.Equal(t, http.StatusConflict, rr.Code)
+	rr = doJSON(t, server, http.MethodPut, tagPath(crud.ID), userToken, map[string]string{"name": "CRUD Staff"})
+	require.Equal(t, http.StatusForbidden, rr.Code)
+	rr = doJSON(t, server, http.MethodPut, tagPath(999999), adminToken, map[string]string{"name": "CRUD Ghost"})
+	require.Equal(t, http.StatusNotFound, rr.Code)
+	rr = doJSON(t, server, http.MethodPut, "/api/tags/yak", adminToken, map[string]string{"name": "CRUD Ghost"})
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+	var name string
+	require.NoError(t, testDB.QueryRow(`SELECT name FROM tags WHERE id = $1`, crud.ID).Scan(&name))
+	require.Equal(t, "CRUD Yak", name)
+
+	// Delete takes the tag off drops and out of users' subscriptions
+	authorID, _ := seedTestUserWithRole(t, "tags.author@example.com", database.UserRoleUser)
+	dropID := uuid.New()
+	_, err := testDB.Exec(`
+		INSERT INTO drops (id, user_id, school_id, title, content, created_at, updated_at, post_date)
+		VALUES ($1, $2, $3, 'Tagged', 'Seeded for TestTagCRUD', NOW(), NOW(), NOW())`,
+		dropID, authorID, testSchoolID)
+	require.NoError(t, err)
+	_, err = testDB.Exec(`INSERT INTO drop_tags (drop_id, tag_id, school_id) VALUES ($1, $2, $3)`, dropID, crud.ID, testSchoolID)
+	require.NoError(t, err)
+	_, err = testDB.Exec(`INSERT INTO tag_subscriptions (user_id, school_id, tag_id, muted) VALUES ($1, $2, $3, false)`, authorID, testSchoolID, crud.ID)
+	require.NoError(t, err)
+
+	rr = doJSON(t, server, http.MethodDelete, tagPath(crud.ID), userToken, nil)
+	require.Equal(t, http.StatusForbidden, rr.Code)
+	rr = doJSON(t, server, http.MethodDelete, tagPath(crud.ID), adminToken, nil)
+	require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+	rr = doJSON(t, server, http.MethodDelete, tagPath(crud.ID), adminToken, nil)
+	require.Equal(t, http.StatusNotFound, rr.Code)
+
+	var links int
+	require.NoError(t, testDB.QueryRow(`
+		SELECT (SELECT COUNT(*) FROM drop_tags WHERE tag_id = $1) + (SELECT COUNT(*) FROM tag_subscriptions WHERE tag_id = $1)`,
+		crud.ID).Scan(&links))
+	require.Zero(t, links)
+}
+
+// ?tags= narrows a list to drops carrying any of the tags; following a tag adds its drops to My Drops
+// and muting one hides them, except urgent drops
+func TestTagFilterAndSubscriptions(t *testing.T) {
+	if testDB == nil {
+		t.Fatal("Test database connection pool (testDB) is nil")
+	}
+	server := newRouterTestServer(t)
+	authorID, authorToken := seedTestUserWithRole(t, "tagged.author@example.com", database.UserRoleUser)
+	teacherID, teacherToken := seedTestUserWithRole(t, "tagged.teacher@example.com", database.UserRoleUser)
+	_, err := testDB.Exec(`INSERT INTO class_staff (class_id, user_id, school_id, role) VALUES (7, $1, $2, 'tutor')`, teacherID, testSchoolID)
+	require.NoError(t, err)
+	t.Cleanup(func() { testDB.Exec(`DELETE FROM class_staff WHERE class_id = 7 AND user_id = $1`, teacherID) })
+
+	tagID := func(name string) int32 {
+		t.Helper()
+		var id int32
+		err := testDB.QueryRow(`INSERT INTO tags (school_id, name) VALUES ($1, $2) RETURNING id`, testSchoolID, name).Scan(&id)
+		require.NoError(t, err)
+		return id
+	}
+	sport, trips := tagID("Filter Sport"), tagID("Filter Trips")
+
+	create := func(title string, classID int32, priority string, tagIDs ...int32) uuid.UUID {
+		t.Helper()
+		rr := doJSON(t, server, http.MethodPost, "/api/drops", authorToken, map[string]any{
+			"title":       title,
+			"content":     "Seeded for TestTagFilterAndSubscriptions",
+			"post_date":   time.Now().UTC().Format(time.DateOnly),
+			"expire_date": time.Now().Add(48 * time.Hour).UTC().Format(time.DateOnly),
+			"priority":    priority,
+			"targets":     []models.Target{{Type: "Class", ID: classID}},
+			"tag_ids":     tagIDs,
+		})
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		var drop database.Drop
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &drop))
+		return drop.ID
+	}
+	sportIn4A := create("Sport in 4A", 7, "normal", sport)
+	tripIn1A := create("Trip for 1A", 1, "normal", trips)
+	tripIn4A := create("Trip for 4A", 7, "normal", trips)
+	urgentTripIn4A := create("Trip cancelled", 7, "urgent", trips)
+	untagged := create("Untagged", 7, "normal")
+
+	list := func(path, token string) []uuid.UUID {
+		t.Helper()
+		rr := doJSON(t, server, http.MethodGet, path+"author="+authorID.String()+"&limit=200", token, nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var drops []database.DropWithTargets
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &drops))
+		ids := make([]uuid.UUID, len(drops))
+		for i, d := range drops {
+			ids[i] = d.ID
+		}
+		return ids
+	}
+
+	t.Run("filter", func(t *testing.T) {
+		require.ElementsMatch(t, []uuid.UUID{sportIn4A}, list("/api/drops?tags=filter%20sport&", teacherToken), "matching ignores case")
+		require.ElementsMatch(t, []uuid.UUID{sportIn4A, tripIn1A, tripIn4A, urgentTripIn4A},
+			list("/api/drops?tags=Filter%20Sport,%20FILTER%20TRIPS&", teacherToken), "any of the tags")
+		require.Empty(t, list("/api/drops?tags=Filter%20Nothing&", teacherToken))
+		require.ElementsMatch(t, []uuid.UUID{sportIn4A, tripIn4A, urgentTripIn4A},
+			list("/api/mydrops?tags=filter%20sport,filter%20trips&", teacherToken), "My Drops filters its own drops")
+	})
+
+	setTags := func(followed, muted []int32) int {
+		t.Helper()
+		rr := doJSON(t, server, http.MethodPut, "/api/settings/me/tags", teacherToken, map[string][]int32{"followed": followed, "muted": muted})
+		return rr.Code
+	}
+
+	t.Run("subscriptions", func(t *testing.T) {
+		require.ElementsMatch(t, []uuid.UUID{sportIn4A, tripIn4A, urgentTripIn4A, untagged}, list("/api/mydrops?", teacherToken))
+
+		require.Equal(t, http.StatusNoContent, setTags([]int32{trips}, nil))
+		require.ElementsMatch(t, []uuid.UUID{sportIn4A, tripIn1A, tripIn4A, urgentTripIn4A, untagged}, list("/api/mydrops?", teacherToken),
+			"following a tag adds its drops, even those not aimed at the teacher")
+
+		require.Equal(t, http.StatusNoContent, setTags(nil, []int32{trips}))
+		require.ElementsMatch(t, []uuid.UUID{sportIn4A, urgentTripIn4A, untagged}, list("/api/mydrops?", teacherToken),
+			"muting a tag hides its drops, except urgent ones")
+
+		require.Equal(t, http.StatusBadRequest, setTags([]int32{sport}, []int32{sport}), "a tag can't be followed and muted")
+		require.Equal(t, http.StatusBadRequest, setTags([]int32{999999}, nil), "tags must belong to the school")
+		require.ElementsMatch(t, []uuid.UUID{sportIn4A, urgentTripIn4A, untagged}, list("/api/mydrops?", teacherToken),
+			"a rejected change leaves the subscriptions alone")
+
+		require.Equal(t, http.StatusNoContent, setTags(nil, nil))
+		require.ElementsMatch(t, []uuid.UUID{sportIn4A, tripIn4A, urgentTripIn4A, untagged}, list("/api/mydrops?", teacherToken))
+	})
+}
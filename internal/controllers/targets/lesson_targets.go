@@ -0,0 +1,79 @@
+package targets
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/models"
+	"github.com/google/uuid"
+)
+
+// ResolveLessonTargets gives each Lesson target that describes a class or room, period and date the ID
+// of the matching lesson target, creating it the first time it is aimed at. Call it with the queries of
+// the transaction that saves the drop's targets, after ValidateTargetsBelongToSchool, so a drop that
+// fails to save doesn't leave lesson targets behind.
+func ResolveLessonTargets(ctx context.Context, dbq *database.Queries, schoolID uuid.UUID, targets []models.Target) error {
+	for i, target := range targets {
+		if target.Type != "Lesson" || target.Lesson == nil {
+			continue
+		}
+		lesson := target.Lesson
+
+		lessonDate, err := time.Parse(time.DateOnly, lesson.Date)
+		if err != nil {
+			return fmt.Errorf("invalid lesson date %q", lesson.Date)
+		}
+
+		id, err := dbq.UpsertLessonTarget(ctx, database.UpsertLessonTargetParams{
+			ClassID:    nullInt32(lesson.ClassID),
+			RoomID:     nullInt32(lesson.RoomID),
+			LessonDate: lessonDate,
+			PeriodID:   lesson.PeriodID,
+			SchoolID:   schoolID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("lesson class, room or period is invalid for this school")
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "could not resolve lesson target", "error", err)
+			return fmt.Errorf("failed to resolve lesson targets")
+		}
+		targets[i].ID = id
+	}
+	return nil
+}
+
+// validateDescribedLesson checks a Lesson target given as a class or room, period and date the way
+// ResolveLessonTargets would, without creating its lesson target
+func validateDescribedLesson(ctx context.Context, dbq *database.Queries, schoolID uuid.UUID, lesson *models.LessonTarget) error {
+	if _, err := time.Parse(time.DateOnly, lesson.Date); err != nil {
+		return fmt.Errorf("invalid lesson date %q", lesson.Date)
+	}
+
+	inSchool, err := dbq.IsLessonInSchool(ctx, database.IsLessonInSchoolParams{
+		PeriodID: lesson.PeriodID,
+		SchoolID: schoolID,
+		ClassID:  nullInt32(lesson.ClassID),
+		RoomID:   nullInt32(lesson.RoomID),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "could not validate targets", "target_type", "Lesson", "error", err)
+		return fmt.Errorf("failed to validate lesson targets")
+	}
+	if !inSchool {
+		return fmt.Errorf("lesson class, room or period is invalid for this school")
+	}
+	return nil
+}
+
+func nullInt32(v *int32) sql.NullInt32 {
+	if v == nil {
+		return sql.NullInt32{}
+	}
+	return sql.NullInt32{Int32: *v, Valid: true}
+}
@@ -3,7 +3,7 @@ package targets
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 
 	"github.com/5tuartw/droplet/internal/database"
 	"github.com/5tuartw/droplet/internal/models"
@@ -20,8 +20,17 @@ func ValidateTargetsBelongToSchool(ctx context.Context, dbq *database.Queries, s
 	yearGroupIDs := make(map[int32]bool)
 	divisionIDs := make(map[int32]bool)
 	pupilIDs := make(map[int32]bool)
+	lessonTargetIDs := make(map[int32]bool)
 
 	for _, target := range targets {
+		// Lessons described by class or room, period and date have no ID until they are resolved
+		if target.Type == "Lesson" && target.Lesson != nil {
+			if err := validateDescribedLesson(ctx, dbq, schoolID, target.Lesson); err != nil {
+				return err
+			}
+			continue
+		}
+
 		// ignore 'General'
 		if target.Type == "General" || target.ID == 0 {
 			continue
@@ -36,6 +45,8 @@ func ValidateTargetsBelongToSchool(ctx context.Context, dbq *database.Queries, s
 			divisionIDs[target.ID] = true
 		case "Student":
 			pupilIDs[target.ID] = true
+		case "Lesson":
+			lessonTargetIDs[target.ID] = true
 		default:
 			return fmt.Errorf("invalid target type submitted: %s", target.Type)
 		}
@@ -48,14 +59,13 @@ func ValidateTargetsBelongToSchool(ctx context.Context, dbq *database.Queries, s
 			Column2:  classList,
 		})
 		if err != nil {
-			log.Printf("DB error validating class targets for school %s: %v", schoolID, err)
+			slog.ErrorContext(ctx, "could not validate targets", "target_type", "Class", "error", err)
 			return fmt.Errorf("failed to validate class targets")
 		}
 		if count != int64(len(classList)) {
-			log.Printf("Validation failed: Class count mismatch for school %s. Expect %d, DB found %d", schoolID, len(classList), count)
+			slog.InfoContext(ctx, "target validation failed", "target_type", "Class", "expected", len(classList), "found", count)
 			return fmt.Errorf("one or more submitted Class IDs are invalid for this school")
 		}
-		log.Printf("Validated %d class targets for school %s.", count, schoolID)
 	}
 
 	yearGroupList := mapsToInt32Slice(yearGroupIDs)
@@ -65,14 +75,13 @@ func ValidateTargetsBelongToSchool(ctx context.Context, dbq *database.Queries, s
 			Column2:  yearGroupList,
 		})
 		if err != nil {
-			log.Printf("DB error validating Year Group targets for school %s: %v", schoolID, err)
+			slog.ErrorContext(ctx, "could not validate targets", "target_type", "YearGroup", "error", err)
 			return fmt.Errorf("failed to validate class targets")
 		}
 		if count != int64(len(yearGroupList)) {
-			log.Printf("Validation failed: Year Group count mismatch for school %s. Expect %d, DB found %d", schoolID, len(yearGroupList), count)
+			slog.InfoContext(ctx, "target validation failed", "target_type", "YearGroup", "expected", len(yearGroupList), "found", count)
 			return fmt.Errorf("one or more submitted Year Group IDs are invalid for this school")
 		}
-		log.Printf("Validated %d Year Group targets for school %s.", count, schoolID)
 	}
 
 	divisionList := mapsToInt32Slice(divisionIDs)
@@ -82,14 +91,13 @@ func ValidateTargetsBelongToSchool(ctx context.Context, dbq *database.Queries, s
 			Column2:  divisionList,
 		})
 		if err != nil {
-			log.Printf("DB error validating Division targets for school %s: %v", schoolID, err)
+			slog.ErrorContext(ctx, "could not validate targets", "target_type", "Division", "error", err)
 			return fmt.Errorf("failed to validate class targets")
 		}
 		if count != int64(len(divisionList)) {
-			log.Printf("Validation failed: Division count mismatch for school %s. Expect %d, DB found %d", schoolID, len(divisionList), count)
+			slog.InfoContext(ctx, "target validation failed", "target_type", "Division", "expected", len(divisionList), "found", count)
 			return fmt.Errorf("one or more submitted Division IDs are invalid for this school")
 		}
-		log.Printf("Validated %d Division targets for school %s.", count, schoolID)
 	}
 
 	pupilList := mapsToInt32Slice(pupilIDs)
@@ -99,17 +107,32 @@ func ValidateTargetsBelongToSchool(ctx context.Context, dbq *database.Queries, s
 			Column2:  pupilList,
 		})
 		if err != nil {
-			log.Printf("DB error validating Pupil targets for school %s: %v", schoolID, err)
+			slog.ErrorContext(ctx, "could not validate targets", "target_type", "Student", "error", err)
 			return fmt.Errorf("failed to validate class targets")
 		}
 		if count != int64(len(pupilList)) {
-			log.Printf("Validation failed: Pupil count mismatch for school %s. Expect %d, DB found %d", schoolID, len(pupilList), count)
+			slog.InfoContext(ctx, "target validation failed", "target_type", "Student", "expected", len(pupilList), "found", count)
 			return fmt.Errorf("one or more submitted Pupil IDs are invalid for this school")
 		}
-		log.Printf("Validated %d Pupil targets for school %s.", count, schoolID)
 	}
 
-	log.Printf("All submitted targets validated for school %s", schoolID)
+	lessonTargetList := mapsToInt32Slice(lessonTargetIDs)
+	if len(lessonTargetList) > 0 {
+		count, err := dbq.CountValidLessonTargetsForSchool(ctx, database.CountValidLessonTargetsForSchoolParams{
+			SchoolID: schoolID,
+			Column2:  lessonTargetList,
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "could not validate targets", "target_type", "Lesson", "error", err)
+			return fmt.Errorf("failed to validate lesson targets")
+		}
+		if count != int64(len(lessonTargetList)) {
+			slog.InfoContext(ctx, "target validation failed", "target_type", "Lesson", "expected", len(lessonTargetList), "found", count)
+			return fmt.Errorf("one or more submitted Lesson target IDs are invalid for this school")
+		}
+	}
+
+	slog.DebugContext(ctx, "targets validated")
 	return nil
 }
 
@@ -0,0 +1,76 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/models"
+	"github.com/5tuartw/droplet/internal/router"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// On a school's subdomain only that school's users can log in, refresh their session or use their token
+func TestTenantSubdomains(t *testing.T) {
+	if testDB == nil {
+		t.Fatal("Test database connection pool (testDB) is nil")
+	}
+	cfg := *testCfg
+	cfg.BaseDomain = "droplet.test"
+	server := router.NewRouter(&cfg, testDB, database.New(testDB))
+
+	_, err := testDB.Exec(`UPDATE schools SET subdomain = 'home' WHERE id = $1`, testSchoolID)
+	require.NoError(t, err)
+	t.Cleanup(func() { testDB.Exec(`UPDATE schools SET subdomain = NULL WHERE id = $1`, testSchoolID) })
+	otherSchoolID := uuid.New()
+	_, err = testDB.Exec(`INSERT INTO schools (id, name, subdomain, status) VALUES ($1, 'Elsewhere Test School', 'elsewhere', 'active')`, otherSchoolID)
+	require.NoError(t, err)
+	seedTestUser(t, testDB, "tenant.staff@example.com", "password123", testSchoolID, false)
+
+	send := func(host, method, path, token string, body any) *httptest.ResponseRecorder {
+		t.Helper()
+		var raw []byte
+		if body != nil {
+			raw, err = json.Marshal(body)
+			require.NoError(t, err)
+		}
+		req := httptest.NewRequest(method, path, bytes.NewReader(raw))
+		req.Host = host
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		server.ServeHTTP(rr, req)
+		return rr
+	}
+	login := func(host string) *httptest.ResponseRecorder {
+		t.Helper()
+		return send(host, http.MethodPost, "/api/login", "", map[string]string{"email": "tenant.staff@example.com", "password": "password123"})
+	}
+
+	require.Equal(t, http.StatusNotFound, login("nowhere.droplet.test").Code)
+	require.Equal(t, http.StatusUnauthorized, login("elsewhere.droplet.test").Code)
+	rr := login("home.droplet.test")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var session models.TokenUser
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &session))
+
+	for host, want := range map[string]int{
+		"home.droplet.test":      http.StatusOK,
+		"droplet.test":           http.StatusOK,
+		"elsewhere.droplet.test": http.StatusUnauthorized,
+	} {
+		rr = send(host, http.MethodGet, "/api/users/me", session.Token, nil)
+		require.Equal(t, want, rr.Code, "access token on %s: %s", host, rr.Body.String())
+	}
+
+	rr = send("elsewhere.droplet.test", http.MethodPost, "/api/token/refresh", session.RefreshToken, nil)
+	require.Equal(t, http.StatusUnauthorized, rr.Code, "refresh token on another school's subdomain")
+	rr = send("home.droplet.test", http.MethodPost, "/api/token/refresh", session.RefreshToken, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+}
@@ -0,0 +1,182 @@
+package timetable
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/5tuartw/droplet/internal/auth"
+	"github.com/5tuartw/droplet/internal/config"
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/helpers"
+	"github.com/5tuartw/droplet/internal/models"
+	"github.com/5tuartw/droplet/internal/timetable"
+	"github.com/google/uuid"
+)
+
+const maxTimetableBytes = 4 << 20
+
+// GetTimetable returns the school's day cycle, periods, rooms and lessons
+func GetTimetable(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+	contextValueSchool := r.Context().Value(auth.UserSchoolKey)
+	schoolID, schoolOK := contextValueSchool.(uuid.UUID)
+	if !schoolOK {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Value missing from context", nil)
+		return
+	}
+
+	cycle, err := dbq.GetTimetableCycle(r.Context(), schoolID)
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not look up day cycle", err)
+		return
+	}
+	periods, err := dbq.GetTimetablePeriods(r.Context(), schoolID)
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not look up periods", err)
+		return
+	}
+	rooms, err := dbq.GetRooms(r.Context(), schoolID)
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not look up rooms", err)
+		return
+	}
+	lessons, err := dbq.GetLessons(r.Context(), schoolID)
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not look up lessons", err)
+		return
+	}
+
+	response := models.Timetable{
+		TimetableCycle: models.TimetableCycle{CycleDays: cycle.CycleDays, StartsOn: cycle.StartsOn.Format(time.DateOnly)},
+		Periods:        make([]models.TimetableEntity, len(periods)),
+		Rooms:          make([]models.TimetableEntity, len(rooms)),
+		Lessons:        make([]models.Lesson, len(lessons)),
+	}
+	for i, p := range periods {
+		response.Periods[i] = models.TimetableEntity{ID: p.ID, Name: p.Name}
+	}
+	for i, room := range rooms {
+		response.Rooms[i] = models.TimetableEntity{ID: room.ID, Name: room.Name}
+	}
+	for i, l := range lessons {
+		lesson := models.Lesson{
+			ID:         l.ID,
+			CycleDay:   l.CycleDay,
+			PeriodID:   l.PeriodID,
+			PeriodName: l.PeriodName,
+			ClassID:    l.ClassID,
+			ClassName:  l.ClassName,
+			UserID:     l.UserID,
+			Title:      l.Title,
+			FirstName:  l.FirstName,
+			Surname:    l.Surname,
+		}
+		if l.RoomID.Valid {
+			lesson.RoomID = &l.RoomID.Int32
+			lesson.RoomName = &l.RoomName.String
+		}
+		response.Lessons[i] = lesson
+	}
+
+	helpers.RespondWithJSON(w, http.StatusOK, response)
+}
+
+// ImportTimetable replaces the school's lessons with those in the CSV request body
+func ImportTimetable(cfg *config.ApiConfig, db *sql.DB, dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+	if auth.DemoRestricted(cfg, dbq, r) {
+		slog.InfoContext(r.Context(), "blocked in demo mode", "action", "timetable import")
+		helpers.RespondWithError(w, http.StatusForbidden, "Timetable updating is disabled in demo mode", errors.New("demo mode restriction"))
+		return
+	}
+
+	contextValueSchool := r.Context().Value(auth.UserSchoolKey)
+	schoolID, schoolOK := contextValueSchool.(uuid.UUID)
+	if !schoolOK {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Value missing from context", nil)
+		return
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "text/csv" {
+		helpers.RespondWithProblem(w, http.StatusUnsupportedMediaType, helpers.CodeUnsupportedMedia, "Timetable must be uploaded as text/csv", nil)
+		return
+	}
+
+	defer r.Body.Close()
+	rows, err := timetable.ParseCSV(http.MaxBytesReader(w, r.Body, maxTimetableBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			helpers.RespondWithError(w, http.StatusRequestEntityTooLarge, "Timetable is too large", nil)
+			return
+		}
+		helpers.RespondWithError(w, http.StatusBadRequest, "Invalid timetable", err)
+		return
+	}
+
+	summary, err := timetable.Import(r.Context(), db, dbq, schoolID, rows)
+	if err != nil {
+		var invalid timetable.ValidationError
+		if errors.As(err, &invalid) {
+			helpers.RespondWithError(w, http.StatusBadRequest, "Invalid timetable", err)
+		} else {
+			slog.ErrorContext(r.Context(), "could not import timetable", "error", err)
+			helpers.RespondWithError(w, http.StatusInternalServerError, "Could not import timetable", err)
+		}
+		return
+	}
+
+	slog.InfoContext(r.Context(), "timetable imported", "lessons", summary.Lessons, "replaced", summary.Replaced)
+	helpers.RespondWithJSON(w, http.StatusOK, summary)
+}
+
+// SetTimetableCycle sets how many school days the timetable runs over before repeating, and when it
+// started. Every Lesson target's staff are worked out again for the new cycle.
+func SetTimetableCycle(cfg *config.ApiConfig, dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+	if auth.DemoRestricted(cfg, dbq, r) {
+		slog.InfoContext(r.Context(), "blocked in demo mode", "action", "timetable cycle update")
+		helpers.RespondWithError(w, http.StatusForbidden, "Timetable updating is disabled in demo mode", errors.New("demo mode restriction"))
+		return
+	}
+
+	contextValueSchool := r.Context().Value(auth.UserSchoolKey)
+	schoolID, schoolOK := contextValueSchool.(uuid.UUID)
+	if !schoolOK {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Value missing from context", nil)
+		return
+	}
+
+	requestBody := models.SetTimetableCycleRequest{}
+	if !helpers.DecodeJSON(w, r, &requestBody) {
+		return
+	}
+	startsOn, _ := time.Parse(time.DateOnly, requestBody.StartsOn) // checked by DecodeJSON
+
+	lessons, err := dbq.GetLessons(r.Context(), schoolID)
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not look up lessons", err)
+		return
+	}
+	for _, l := range lessons {
+		if l.CycleDay > requestBody.CycleDays {
+			helpers.RespondWithError(w, http.StatusConflict, fmt.Sprintf("The timetable has lessons on day %d; import a %d-day timetable first", l.CycleDay, requestBody.CycleDays), nil)
+			return
+		}
+	}
+
+	cycle, err := dbq.SetTimetableCycle(r.Context(), database.SetTimetableCycleParams{
+		SchoolID:  schoolID,
+		CycleDays: requestBody.CycleDays,
+		StartsOn:  startsOn,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "could not set timetable cycle", "error", err)
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Unable to set timetable cycle", err)
+		return
+	}
+
+	helpers.RespondWithJSON(w, http.StatusOK, models.TimetableCycle{CycleDays: cycle.CycleDays, StartsOn: cycle.StartsOn.Format(time.DateOnly)})
+}
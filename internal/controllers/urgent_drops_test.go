@@ -0,0 +1,185 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/5tuartw/droplet/internal/controllers/drops"
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/models"
+	"github.com/5tuartw/droplet/internal/notify"
+	"github.com/5tuartw/droplet/internal/router"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+// pushRecorder is a notification channel that counts the pushes for each drop
+type pushRecorder struct {
+	mu     sync.Mutex
+	pushes map[uuid.UUID]int
+}
+
+func (p *pushRecorder) Name() string { return "recorder" }
+
+func (p *pushRecorder) SendUrgent(ctx context.Context, drop notify.UrgentDrop) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pushes[drop.ID]++
+	return nil
+}
+
+func (p *pushRecorder) count(dropID uuid.UUID) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.pushes[dropID]
+}
+
+// Urgent drops are pushed once when they go live: straight away if they're live when saved,
+// otherwise by the scheduler once their post date arrives
+func TestUrgentDropsArePushedOnce(t *testing.T) {
+	if testDB == nil {
+		t.Fatal("Test database connection pool (testDB) is nil")
+	}
+	recorder := &pushRecorder{pushes: make(map[uuid.UUID]int)}
+	cfg := *testCfg
+	cfg.Notifier = notify.NewDispatcher(recorder)
+	dbq := database.New(testDB)
+	server := router.NewRouter(&cfg, testDB, dbq)
+
+	_, token := seedTestUserWithRole(t, "urgent.author@example.com", database.UserRoleUser)
+
+	// waitForPushes waits for background sends, so the counts are final
+	waitForPushes := func() {
+		t.Helper()
+		require.NoError(t, cfg.Notifier.Shutdown(context.Background()))
+	}
+	pushDue := func() {
+		t.Helper()
+		_, err := drops.PushDueUrgentDrops(context.Background(), &cfg, dbq)
+		require.NoError(t, err)
+		waitForPushes()
+	}
+	create := func(title, priority string, postDate time.Time) uuid.UUID {
+		t.Helper()
+		rr := doJSON(t, server, http.MethodPost, "/api/drops", token, map[string]any{
+			"title":       title,
+			"content":     "Seeded for TestUrgentDropsArePushedOnce",
+			"post_date":   postDate.UTC().Format(time.DateOnly),
+			"expire_date": postDate.Add(72 * time.Hour).UTC().Format(time.DateOnly),
+			"priority":    priority,
+			"targets":     []models.Target{{Type: "Class", ID: 7}},
+		})
+		require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+		var drop database.Drop
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &drop))
+		return drop.ID
+	}
+
+	// Live when saved: pushed straight away, and not again on edit or by the scheduler
+	liveID := create("Live now", "urgent", time.Now())
+	waitForPushes()
+	require.Equal(t, 1, recorder.count(liveID))
+
+	rr := doJSON(t, server, http.MethodPut, "/api/drops/"+liveID.String(), token, map[string]any{
+		"title":       "Live now (edited)",
+		"content":     "Seeded for TestUrgentDropsArePushedOnce",
+		"post_date":   time.Now().UTC().Format(time.DateOnly),
+		"expire_date": time.Now().Add(72 * time.Hour).UTC().Format(time.DateOnly),
+		"priority":    "urgent",
+		"targets":     []models.Target{{Type: "Class", ID: 7}},
+	})
+	require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+	pushDue()
+	require.Equal(t, 1, recorder.count(liveID))
+
+	// Future-dated: left for the scheduler
+	futureID := create("Tomorrow", "urgent", time.Now().Add(24*time.Hour))
+	normalID := create("Not urgent", "normal", time.Now())
+	pushDue()
+	require.Equal(t, 0, recorder.count(futureID), "not pushed before its post date")
+	require.Equal(t, 0, recorder.count(normalID))
+
+	_, err := testDB.Exec(`UPDATE drops SET post_date = NOW() - INTERVAL '1 minute' WHERE id = $1`, futureID)
+	require.NoError(t, err)
+	pushDue()
+	require.Equal(t, 1, recorder.count(futureID), "pushed once its post date arrives")
+	pushDue()
+	require.Equal(t, 1, recorder.count(futureID), "and only once")
+
+	var pushes int
+	require.NoError(t, testDB.QueryRow(`SELECT COUNT(*) FROM urgent_drop_pushes WHERE drop_id = ANY($1::uuid[])`,
+		pq.Array([]string{liveID.String(), futureID.String(), normalID.String()})).Scan(&pushes))
+	require.Equal(t, 2, pushes)
+}
+
+// A drop whose save fails at commit is not pushed, whether it was being created or edited to urgent
+func TestUrgentDropsAreNotPushedWhenTheSaveFails(t *testing.T) {
+	if testDB == nil {
+		t.Fatal("Test database connection pool (testDB) is nil")
+	}
+	recorder := &pushRecorder{pushes: make(map[uuid.UUID]int)}
+	cfg := *testCfg
+	cfg.Notifier = notify.NewDispatcher(recorder)
+	dbq := database.New(testDB)
+	server := router.NewRouter(&cfg, testDB, dbq)
+
+	_, token := seedTestUserWithRole(t, "urgent.rollback@example.com", database.UserRoleUser)
+
+	// A deferred constraint trigger only fires at COMMIT, after the handler has claimed the push
+	_, err := testDB.Exec(`
+		CREATE FUNCTION fail_commit_for_test() RETURNS trigger LANGUAGE plpgsql AS $$
+		BEGIN
+			RAISE EXCEPTION 'commit refused for test';
+		END $$;
+		CREATE CONSTRAINT TRIGGER fail_commit_for_test AFTER INSERT OR UPDATE ON drops
+			DEFERRABLE INITIALLY DEFERRED FOR EACH ROW
+			WHEN (NEW.title = 'Never saved') EXECUTE FUNCTION fail_commit_for_test();`)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		testDB.Exec(`DROP TRIGGER fail_commit_for_test ON drops; DROP FUNCTION fail_commit_for_test();`)
+	})
+
+	body := func(title, priority string) map[string]any {
+		return map[string]any{
+			"title":       title,
+			"content":     "Seeded for TestUrgentDropsAreNotPushedWhenTheSaveFails",
+			"post_date":   time.Now().UTC().Format(time.DateOnly),
+			"expire_date": time.Now().Add(72 * time.Hour).UTC().Format(time.DateOnly),
+			"priority":    priority,
+			"targets":     []models.Target{{Type: "Class", ID: 7}},
+		}
+	}
+	pushed := func() int {
+		t.Helper()
+		require.NoError(t, cfg.Notifier.Shutdown(context.Background()))
+		recorder.mu.Lock()
+		defer recorder.mu.Unlock()
+		return len(recorder.pushes)
+	}
+
+	rr := doJSON(t, server, http.MethodPost, "/api/drops", token, body("Never saved", "urgent"))
+	require.Equal(t, http.StatusInternalServerError, rr.Code, rr.Body.String())
+	require.Zero(t, pushed(), "a failed create is not pushed")
+
+	rr = doJSON(t, server, http.MethodPost, "/api/drops", token, body("Saved as normal", "normal"))
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	var drop database.Drop
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &drop))
+
+	rr = doJSON(t, server, http.MethodPut, "/api/drops/"+drop.ID.String(), token, body("Never saved", "urgent"))
+	require.Equal(t, http.StatusInternalServerError, rr.Code, rr.Body.String())
+	require.Zero(t, pushed(), "a failed edit to urgent is not pushed")
+
+	// Nothing was claimed either, and the drop stays as it was saved
+	_, err = drops.PushDueUrgentDrops(context.Background(), &cfg, dbq)
+	require.NoError(t, err)
+	require.Zero(t, pushed())
+	var claims int
+	require.NoError(t, testDB.QueryRow(`SELECT COUNT(*) FROM urgent_drop_pushes WHERE drop_id = $1`, drop.ID).Scan(&claims))
+	require.Zero(t, claims)
+}
@@ -1,68 +1,39 @@
 package users
 
 import (
-	"encoding/json"
-	"io"
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
 
 	"github.com/5tuartw/droplet/internal/auth"
 	"github.com/5tuartw/droplet/internal/database"
 	"github.com/5tuartw/droplet/internal/helpers"
 	"github.com/5tuartw/droplet/internal/models"
+	"github.com/5tuartw/droplet/internal/validate"
 	"github.com/google/uuid"
 )
 
 func CreateUser(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
 
-	// Get Requester's UserID from Context
-	contextValueID := r.Context().Value(auth.UserIDKey) // Use exported key
-	requesterUserID, ok := contextValueID.(uuid.UUID)
-	if !ok {
-		log.Println("Error: requester userID not found in context in CreateUser")
-		helpers.RespondWithError(w, http.StatusInternalServerError, "Internal Server Error (context error)", nil)
-		return
-	}
-
+	// Get Requester's school from Context
 	contextValueSchool := r.Context().Value(auth.UserSchoolKey)
 	requesterSchoolID, ok := contextValueSchool.(uuid.UUID)
 	if !ok {
-		log.Println("Error: requester schoolID not found in context in CreateUser")
+		slog.ErrorContext(r.Context(), "user not found in request context")
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Context error", nil)
 		return
 	}
 
-	var requestBody struct {
-		Email     string `json:"email"`
-		Password  string `json:"password"`
-		Role      string `json:"role"`
-		Title     string `json:"title"`
-		FirstName string `json:"first_name"`
-		Surname   string `json:"surname"`
-	}
-
-	// Read the raw request body
-	bodyBytes, err := io.ReadAll(r.Body)
-	if err != nil {
-		helpers.RespondWithError(w, http.StatusInternalServerError, "Error reading request body", err)
+	requestBody := models.CreateUserRequest{}
+	if !helpers.DecodeJSON(w, r, &requestBody) {
 		return
 	}
-	defer r.Body.Close()
 
-	// Decode the JSON into the requestBody struct
-	err = json.Unmarshal(bodyBytes, &requestBody)
-	if err != nil {
-		helpers.RespondWithError(w, http.StatusBadRequest, "Error decoding JSON data", err)
-		return
-	}
-
-	if requestBody.Email == "" {
-		helpers.RespondWithError(w, http.StatusBadRequest, "Email is required", err)
-		return
+	if requestBody.Role == "" {
+		requestBody.Role = string(database.UserRoleUser)
 	}
-
-	if requestBody.Password == "" {
-		helpers.RespondWithError(w, http.StatusBadRequest, "Password is required", err)
+	if !auth.IsValidRole(requestBody.Role) {
+		helpers.RespondWithValidationErrors(w, validate.Errors{{Field: "role", Code: validate.CodeNotAllowed, Message: fmt.Sprintf("unknown role %q", requestBody.Role)}})
 		return
 	}
 
@@ -85,7 +56,7 @@ func CreateUser(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not create new user", err)
 		return
 	}
-	log.Printf("Admin User %s created new user %s (ID: %s)", requesterUserID, newUser.Email, newUser.ID)
+	slog.InfoContext(r.Context(), "user created", "target_user_id", newUser.ID)
 
 	responsePayload := models.UserResponse{
 		ID:        newUser.ID,
@@ -4,13 +4,14 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 
 	"github.com/5tuartw/droplet/internal/auth"
 	"github.com/5tuartw/droplet/internal/database"
 	"github.com/5tuartw/droplet/internal/helpers"
 	"github.com/5tuartw/droplet/internal/models"
+	"github.com/5tuartw/droplet/internal/pagination"
 	"github.com/google/uuid"
 )
 
@@ -19,12 +20,41 @@ func GetUsers(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
 	requesterSchoolID, schoolOk := contextValueSchool.(uuid.UUID)
 
 	if !schoolOk {
-		log.Println("Error: one or more values not found in context")
+		slog.ErrorContext(r.Context(), "user not found in request context")
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Context error", nil)
 		return
 	}
 
-	users, err := dbq.GetUsers(r.Context(), requesterSchoolID)
+	// Staff are listed by name; ?role= narrows the list to one role
+	page, err := pagination.Parse(r.URL.Query(), "surname")
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusBadRequest, "Invalid user list query", err)
+		return
+	}
+	params := database.GetUsersParams{
+		SchoolID:  requesterSchoolID,
+		SortDesc:  page.Desc,
+		PageLimit: page.FetchLimit(),
+	}
+	if role := r.URL.Query().Get("role"); role != "" {
+		if !auth.IsValidRole(role) {
+			helpers.RespondWithError(w, http.StatusBadRequest, "Invalid user list query", fmt.Errorf("unknown role %q", role))
+			return
+		}
+		params.Role = database.NullUserRole{UserRole: database.UserRole(role), Valid: true}
+	}
+	if page.After != nil {
+		afterID, err := uuid.Parse(page.Key(2))
+		if err != nil {
+			helpers.RespondWithError(w, http.StatusBadRequest, "Invalid user list query", errors.New("cursor is not valid"))
+			return
+		}
+		params.AfterSurname = sql.NullString{String: page.Key(0), Valid: true}
+		params.AfterFirstName = page.Key(1)
+		params.AfterID = afterID
+	}
+
+	users, err := dbq.GetUsers(r.Context(), params)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) { // Assuming standard sql package error
 			helpers.RespondWithError(w, http.StatusNotFound, "User not found", err) // Corrected message & status
@@ -34,22 +64,22 @@ func GetUsers(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if len(users) > page.Limit {
+		users = users[:page.Limit]
+		last := users[len(users)-1]
+		pagination.SetNext(w, r, page, last.Surname, last.FirstName, last.ID.String())
+	}
+
 	responsePayload := mapDbUsersToUserResponses(users)
 	helpers.RespondWithJSON(w, http.StatusOK, responsePayload)
 }
 
+// GetUserById returns a single user; access is checked by RequirePermission(PermUsersManage)
 func GetUserById(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
-	contextRoleValue := r.Context().Value(auth.UserRoleKey) // Use your role key
-	requesterRole, ok := contextRoleValue.(string)
-	if !ok || requesterRole != "admin" { // Ensure only admins can fetch arbitrary users by ID
-		helpers.RespondWithError(w, http.StatusForbidden, "Forbidden: Admin access required", errors.New("admin required"))
-		return
-	}
-
 	contextValueSchool := r.Context().Value(auth.UserSchoolKey)
 	requesterSchoolID, ok := contextValueSchool.(uuid.UUID)
 	if !ok {
-		log.Println("Error: requester schoolID not found in context in CreateUser")
+		slog.ErrorContext(r.Context(), "user not found in request context")
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Context error", nil)
 		return
 	}
@@ -70,7 +100,7 @@ func GetUserById(dbq *database.Queries, w http.ResponseWriter, r *http.Request)
 			helpers.RespondWithError(w, http.StatusNotFound, "User not found", err)
 		} else {
 			// Other potential database errors
-			log.Printf("Database error fetching user %s: %v", id, err) // Log the error
+			slog.ErrorContext(r.Context(), "could not fetch user", "target_user_id", id, "error", err)
 			helpers.RespondWithError(w, http.StatusInternalServerError, "Could not fetch user data", err)
 		}
 		return
@@ -129,7 +159,7 @@ func GetMe(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
 	schoolID, okSchool := contextValueSchool.(uuid.UUID)
 
 	if !okID || !okSchool {
-		log.Println("Error: userID or schoolID not found in context for GetMe")
+		slog.ErrorContext(r.Context(), "user not found in request context")
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Internal Server Error (context error)", nil)
 		return
 	}
@@ -145,7 +175,7 @@ func GetMe(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
 			// Should not happen if token is valid and user exists, but handle defensively
 			helpers.RespondWithError(w, http.StatusNotFound, "Authenticated user not found in database", err)
 		} else {
-			log.Printf("Database error fetching own user data %s: %v", userID, err)
+			slog.ErrorContext(r.Context(), "could not fetch own user", "error", err)
 			helpers.RespondWithError(w, http.StatusInternalServerError, "Could not fetch user data", err)
 		}
 		return
@@ -2,10 +2,9 @@ package users
 
 import (
 	"database/sql"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 
 	"github.com/5tuartw/droplet/internal/auth"
@@ -13,13 +12,15 @@ import (
 	"github.com/5tuartw/droplet/internal/database"
 	"github.com/5tuartw/droplet/internal/helpers"
 	"github.com/5tuartw/droplet/internal/models"
+	"github.com/5tuartw/droplet/internal/schoolsettings"
+	"github.com/5tuartw/droplet/internal/validate"
 	"github.com/google/uuid"
 )
 
 func ChangePassword(c *config.ApiConfig, dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
 	// --- DEMO MODE CHECK ---
-	if c.IsDemoMode {
-		log.Println("Attempted admin password reset in demo mode - Forbidden.")
+	if auth.DemoRestricted(c, dbq, r) {
+		slog.InfoContext(r.Context(), "blocked in demo mode", "action", "admin password reset")
 		helpers.RespondWithError(w, http.StatusForbidden, "Password reset is disabled in demo mode", errors.New("demo mode restriction"))
 		return
 	}
@@ -30,44 +31,49 @@ func ChangePassword(c *config.ApiConfig, dbq *database.Queries, w http.ResponseW
 		return
 	}
 
-	var requestBody struct {
-		Password string `json:"password"`
-	}
-
-	decoder := json.NewDecoder(r.Body)
-	err = decoder.Decode(&requestBody)
-	if err != nil {
-		helpers.RespondWithError(w, http.StatusBadRequest, "Could not decode request", err)
+	requestBody := models.SetPasswordRequest{}
+	if !helpers.DecodeJSON(w, r, &requestBody) {
 		return
 	}
 
-	contextValueID := r.Context().Value(auth.UserIDKey)
-	editorUserID, editorOk := contextValueID.(uuid.UUID)
-
-	contextValueRole := r.Context().Value(auth.UserRoleKey)
-	editorRole, roleOk := contextValueRole.(string)
-
 	contextValueSchool := r.Context().Value(auth.UserSchoolKey)
 	schoolID, schoolOk := contextValueSchool.(uuid.UUID)
 
-	if !editorOk || !roleOk || !schoolOk {
-		log.Println("Error: one or more value missing from context")
+	if !schoolOk {
+		slog.ErrorContext(r.Context(), "user not found in request context")
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Internal Server Error", nil)
 		return
 	}
 
-	if editorRole != "admin" {
-		helpers.RespondWithError(w, http.StatusForbidden, "Forbidden", errors.New("Forbidden"))
-		log.Printf("Authorization failed: User %s (Role: %s) attempted to change password for user %s", editorUserID, editorRole, targetUserID)
+	if requestBody.Password == "" {
+		helpers.RespondWithError(w, http.StatusBadRequest, "Password field is required.", nil)
 		return
 	}
 
-	if requestBody.Password == "" {
-		helpers.RespondWithError(w, http.StatusBadRequest, "Password field is required.", nil)
+	target, err := dbq.GetUserById(r.Context(), database.GetUserByIdParams{
+		ID:       targetUserID,
+		SchoolID: schoolID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			helpers.RespondWithError(w, http.StatusNotFound, "User not found", err)
+		} else {
+			helpers.RespondWithError(w, http.StatusInternalServerError, "Could not get user from database", err)
+		}
+		return
+	}
+	if target.Role == database.UserRolePlatformAdmin {
+		helpers.RespondWithError(w, http.StatusForbidden, "Platform admin accounts can't be changed from a school", nil)
 		return
 	}
 
-	err = auth.ValidatePassword(requestBody.Password)
+	schoolSettings, err := schoolsettings.Load(r.Context(), dbq, schoolID)
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not load school settings", err)
+		return
+	}
+
+	err = auth.ValidatePassword(requestBody.Password, schoolSettings.Passwords)
 	if err != nil {
 		helpers.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid password: %v", err), err)
 		return
@@ -88,13 +94,14 @@ func ChangePassword(c *config.ApiConfig, dbq *database.Queries, w http.ResponseW
 		return
 	}
 
+	slog.InfoContext(r.Context(), "password reset", "target_user_id", targetUserID)
 	w.WriteHeader(http.StatusNoContent)
 }
 
 func ChangeMyPassword(c *config.ApiConfig, dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
 	// --- DEMO MODE CHECK ---
-	if c.IsDemoMode {
-		log.Println("Attempted password reset in demo mode - Forbidden.")
+	if auth.DemoRestricted(c, dbq, r) {
+		slog.InfoContext(r.Context(), "blocked in demo mode", "action", "password reset")
 		helpers.RespondWithError(w, http.StatusForbidden, "Password reset is disabled in demo mode", errors.New("demo mode restriction"))
 		return
 	}
@@ -105,20 +112,13 @@ func ChangeMyPassword(c *config.ApiConfig, dbq *database.Queries, w http.Respons
 	schoolID, schoolOk := contextValueSchoolID.(uuid.UUID)
 
 	if !idOk || !schoolOk {
-		log.Println("Error: one or more values not found in context")
+		slog.ErrorContext(r.Context(), "user not found in request context")
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Internal Server Error (context error)", nil)
 		return
 	}
 
-	var requestBody struct {
-		OldPassword string `json:"current_password"`
-		NewPassword string `json:"new_password"`
-	}
-
-	decoder := json.NewDecoder(r.Body)
-	err := decoder.Decode(&requestBody)
-	if err != nil {
-		helpers.RespondWithError(w, http.StatusBadRequest, "Could not decode request", err)
+	requestBody := models.ChangePasswordRequest{}
+	if !helpers.DecodeJSON(w, r, &requestBody) {
 		return
 	}
 
@@ -138,7 +138,13 @@ func ChangeMyPassword(c *config.ApiConfig, dbq *database.Queries, w http.Respons
 		return
 	}
 
-	err = auth.ValidatePassword(requestBody.NewPassword)
+	schoolSettings, err := schoolsettings.Load(r.Context(), dbq, schoolID)
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not load school settings", err)
+		return
+	}
+
+	err = auth.ValidatePassword(requestBody.NewPassword, schoolSettings.Passwords)
 	if err != nil {
 		helpers.RespondWithError(w, http.StatusBadRequest, "Invalid new password", err)
 		return
@@ -177,7 +183,7 @@ func ChangeRole(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
 	schoolID, schoolOk := contextValueSchool.(uuid.UUID)
 
 	if !editorOk || !schoolOk {
-		log.Println("Error: one or more value missing from context")
+		slog.ErrorContext(r.Context(), "user not found in request context")
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Internal Server Error", nil)
 	}
 
@@ -201,15 +207,19 @@ func ChangeRole(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
+	if user.Role == database.UserRolePlatformAdmin {
+		helpers.RespondWithError(w, http.StatusForbidden, "Platform admin accounts can't be changed from a school", nil)
+		return
+	}
 
 	// --- Decode Request Body ---
-	var requestBody struct {
-		Role database.UserRole `json:"role"` // Assuming UserRole is string or enum-like
+	requestBody := models.UpdateUserRoleRequest{}
+	if !helpers.DecodeJSON(w, r, &requestBody) {
+		return
 	}
-	decoder := json.NewDecoder(r.Body)
-	err = decoder.Decode(&requestBody)
-	if err != nil {
-		helpers.RespondWithError(w, http.StatusBadRequest, "Could not decode request body", err)
+
+	if !auth.IsValidRole(string(requestBody.Role)) {
+		helpers.RespondWithValidationErrors(w, validate.Errors{{Field: "role", Code: validate.CodeNotAllowed, Message: fmt.Sprintf("unknown role %q", requestBody.Role)}})
 		return
 	}
 
@@ -230,11 +240,11 @@ func ChangeRole(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
 			return // <<< Return on DB error
 		}
 		// Role successfully changed
-		log.Printf("User %s role changed to %s by admin %s", targetUserID, requestBody.Role, editorUserID)
+		slog.InfoContext(r.Context(), "user role changed", "target_user_id", targetUserID, "new_role", requestBody.Role)
 
 	} else {
 		// Role is the same, no DB update needed. Return No Content
-		log.Printf("Role for user %s not changed by admin %s (already %s)", targetUserID, editorUserID, requestBody.Role)
+		slog.InfoContext(r.Context(), "user role unchanged", "target_user_id", targetUserID, "new_role", requestBody.Role)
 		w.WriteHeader(http.StatusNoContent)
 		return
 	}
@@ -254,26 +264,30 @@ func ChangeName(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
 	schoolID, schoolOk := contextValueSchool.(uuid.UUID)
 
 	if !schoolOk {
-		log.Println("Error: one or more value missing from context")
+		slog.ErrorContext(r.Context(), "user not found in request context")
 		helpers.RespondWithError(w, http.StatusInternalServerError, "Internal Server", nil)
 		return
 	}
 
-	var requestBody struct {
-		Title     string `json:"title"`
-		FirstName string `json:"first_name"`
-		Surname   string `json:"surname"`
+	requestBody := models.UpdateUserNameRequest{}
+	if !helpers.DecodeJSON(w, r, &requestBody) {
+		return
 	}
 
-	decoder := json.NewDecoder(r.Body)
-	err = decoder.Decode(&requestBody)
+	target, err := dbq.GetUserById(r.Context(), database.GetUserByIdParams{
+		ID:       targetUserID,
+		SchoolID: schoolID,
+	})
 	if err != nil {
-		helpers.RespondWithError(w, http.StatusBadRequest, "Could not decode request", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			helpers.RespondWithError(w, http.StatusNotFound, "User not found", err)
+		} else {
+			helpers.RespondWithError(w, http.StatusInternalServerError, "Could not get user from database", err)
+		}
 		return
 	}
-
-	if requestBody.Title == "" || requestBody.FirstName == "" || requestBody.Surname == "" {
-		helpers.RespondWithError(w, http.StatusBadRequest, "Title, first name, and surname cannot be empty", errors.New("empty name field"))
+	if target.Role == database.UserRolePlatformAdmin {
+		helpers.RespondWithError(w, http.StatusForbidden, "Platform admin accounts can't be changed from a school", nil)
 		return
 	}
 
@@ -0,0 +1,158 @@
+package users
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/5tuartw/droplet/internal/auth"
+	"github.com/5tuartw/droplet/internal/controllers/targets"
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/helpers"
+	"github.com/5tuartw/droplet/internal/models"
+	"github.com/google/uuid"
+)
+
+// GetUserScopes lists the divisions, year groups and classes a user's role is scoped to
+func GetUserScopes(dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+	contextValueSchool := r.Context().Value(auth.UserSchoolKey)
+	schoolID, schoolOk := contextValueSchool.(uuid.UUID)
+	if !schoolOk {
+		slog.ErrorContext(r.Context(), "user not found in request context")
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Context error", nil)
+		return
+	}
+
+	targetUserID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusBadRequest, "Could not parse user ID in path", err)
+		return
+	}
+
+	scopeRows, err := dbq.GetRoleScopesForUser(r.Context(), database.GetRoleScopesForUserParams{
+		UserID:   targetUserID,
+		SchoolID: schoolID,
+	})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		slog.ErrorContext(r.Context(), "could not fetch role scopes", "target_user_id", targetUserID, "error", err)
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not get user's scopes", err)
+		return
+	}
+
+	responsePayload := make([]database.TargetInfo, 0, len(scopeRows))
+	for _, row := range scopeRows {
+		responsePayload = append(responsePayload, database.TargetInfo{
+			Type: string(row.TargetType),
+			ID:   row.TargetID,
+			Name: row.TargetName,
+		})
+	}
+
+	helpers.RespondWithJSON(w, http.StatusOK, responsePayload)
+}
+
+type UpdateUserScopesRequest struct {
+	Targets []models.Target `json:"targets"`
+}
+
+// UpdateUserScopes replaces the set of targets a user's role is scoped to
+func UpdateUserScopes(db *sql.DB, dbq *database.Queries, w http.ResponseWriter, r *http.Request) {
+	contextValueSchool := r.Context().Value(auth.UserSchoolKey)
+	schoolID, schoolOk := contextValueSchool.(uuid.UUID)
+
+	if !schoolOk {
+		slog.ErrorContext(r.Context(), "user not found in request context")
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Internal Server Error (context error)", nil)
+		return
+	}
+
+	targetUserID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusBadRequest, "Could not parse user ID in path", err)
+		return
+	}
+
+	requestBody := UpdateUserScopesRequest{}
+	if !helpers.DecodeJSON(w, r, &requestBody) {
+		return
+	}
+
+	for _, target := range requestBody.Targets {
+		if target.Type != "Division" && target.Type != "YearGroup" && target.Type != "Class" {
+			helpers.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Scopes must be a Division, YearGroup or Class, got %s", target.Type), nil)
+			return
+		}
+	}
+
+	err = targets.ValidateTargetsBelongToSchool(r.Context(), dbq, schoolID, requestBody.Targets)
+	if err != nil {
+		helpers.RespondWithProblem(w, http.StatusBadRequest, helpers.CodeTargetInvalidForSchool, "Invalid scope(s) provided", err)
+		return
+	}
+
+	_, err = dbq.GetUserById(r.Context(), database.GetUserByIdParams{
+		ID:       targetUserID,
+		SchoolID: schoolID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			helpers.RespondWithError(w, http.StatusNotFound, "User not found", err)
+		} else {
+			helpers.RespondWithError(w, http.StatusInternalServerError, "Could not get user from database", err)
+		}
+		return
+	}
+
+	tx, err := db.BeginTx(r.Context(), nil)
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "Could not start database transaction", err)
+		return
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			slog.ErrorContext(r.Context(), "panic in transaction, rolling back")
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			slog.WarnContext(r.Context(), "rolling back transaction", "error", err)
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+			if err != nil {
+				slog.ErrorContext(r.Context(), "could not commit transaction", "error", err)
+			} else {
+				slog.DebugContext(r.Context(), "transaction committed")
+			}
+		}
+	}()
+
+	qtx := dbq.WithTx(tx)
+
+	err = qtx.DeleteRoleScopes(r.Context(), database.DeleteRoleScopesParams{
+		UserID:   targetUserID,
+		SchoolID: schoolID,
+	})
+	if err != nil {
+		helpers.RespondWithError(w, http.StatusInternalServerError, "could not delete old scopes", err)
+		return
+	}
+
+	for _, target := range requestBody.Targets {
+		err = qtx.AddRoleScope(r.Context(), database.AddRoleScopeParams{
+			UserID:   targetUserID,
+			SchoolID: schoolID,
+			Type:     database.TargetType(target.Type),
+			TargetID: target.ID,
+		})
+		if err != nil {
+			slog.ErrorContext(r.Context(), "could not add role scope", "target_user_id", targetUserID, "target_type", target.Type, "target_id", target.ID, "error", err)
+			helpers.RespondWithError(w, http.StatusInternalServerError, "could not add new scope", err)
+			return
+		}
+	}
+
+	slog.InfoContext(r.Context(), "role scopes updated", "target_user_id", targetUserID)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -32,7 +32,7 @@ func (q *Queries) CountClassesInYearGroup(ctx context.Context, arg CountClassesI
 const createClass = `-- name: CreateClass :one
 INSERT INTO classes (class_name, year_group_id, school_id)
 VALUES ($1, $2, $3)
-RETURNING id, class_name, year_group_id, teacher_id, school_id
+RETURNING id, class_name, year_group_id, school_id
 `
 
 type CreateClassParams struct {
@@ -48,7 +48,6 @@ func (q *Queries) CreateClass(ctx context.Context, arg CreateClassParams) (Class
 		&i.ID,
 		&i.ClassName,
 		&i.YearGroupID,
-		&i.TeacherID,
 		&i.SchoolID,
 	)
 	return i, err
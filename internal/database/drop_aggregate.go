@@ -1,8 +1,9 @@
 package database
 
 import (
+	"database/sql"
+	"encoding/json"
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,270 +16,183 @@ type TargetInfo struct {
 	Name string `json:"name"`
 }
 
+// Represents a single tag attached to a drop
+type TagInfo struct {
+	ID   int32  `json:"id"`
+	Name string `json:"name"`
+}
+
+// Represents a file attached to a drop. The bytes are fetched from URL.
+type AttachmentInfo struct {
+	ID          int32  `json:"id"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	SizeBytes   int64  `json:"size_bytes"`
+	URL         string `json:"url"`
+}
+
 // Represents a drop with its associated targets
 type DropWithTargets struct {
-	ID         uuid.UUID `json:"id"`
-	UserID     uuid.UUID `json:"user_id"`
-	Title      string    `json:"title"`
-	Content    string    `json:"content"`
-	PostDate   time.Time `json:"post_date"`
-	ExpireDate time.Time `json:"expire_date"`
-	AuthorName string    `json:"author_name"`
-	EditorName string    `json:"editor_name"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID              uuid.UUID  `json:"id"`
+	UserID          uuid.UUID  `json:"user_id"`
+	Title           string     `json:"title"`
+	Content         string     `json:"content"`
+	PostDate        time.Time  `json:"post_date"`
+	ExpireDate      time.Time  `json:"expire_date"`
+	AuthorName      string     `json:"author_name"`
+	EditorName      string     `json:"editor_name"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	Priority        string     `json:"priority"`
+	PinnedUntil     *time.Time `json:"pinned_until,omitempty"`
+	Status          string     `json:"status,omitempty"`
+	RejectionReason string     `json:"rejection_reason,omitempty"` // set when an approver rejected the drop
 	// Maybe UserEmail string `json:"user_email,omitempty"` // At some point
 	// Add edited_by at some point
 	Targets []TargetInfo `json:"targets"`
-}
-
-// AggregateDropRows processes the flat rows from sqlc queries into a nested structure.
-// Ensure the input 'rows' slice matches the actual sqlc generated type (e.g., []GetActiveDropsWithTargetsRow)
-// Ensure the field names (row.TargetID, row.TargetType, row.TargetName) match the sqlc struct.
-func AggregateDropRows(rows []GetActiveDropsWithTargetsRow) []DropWithTargets {
-	dropsMap := make(map[uuid.UUID]*DropWithTargets)
-	orderedDropIDs := make([]uuid.UUID, 0)
-
-	for _, row := range rows {
-		drop, exists := dropsMap[row.DropID]
-		if !exists {
-			// First time seeing this drop, create the main structure
-			drop = &DropWithTargets{
-				ID:         row.DropID,
-				UserID:     row.DropUserID,
-				Title:      row.DropTitle,
-				Content:    row.DropContent,
-				PostDate:   row.DropPostDate,
-				ExpireDate: row.DropExpireDate,
-				AuthorName: row.AuthorName,
-				EditorName: row.EditorName,
-				UpdatedAt:  row.DropUpdatedDate,
-				Targets:    make([]TargetInfo, 0), // Initialize empty slice
-			}
-			dropsMap[row.DropID] = drop
-			orderedDropIDs = append(orderedDropIDs, row.DropID) // Record the order
-		}
-
-		// Add the target info if TargetType is valid (not NULL from LEFT JOIN)
-		// --- Adjust based on actual Nullable Type generated by sqlc for TargetType ---
-		var targetTypeStr string
-		if row.TargetType.Valid { // Example check for sqlc custom type or sql.NullString
-			targetTypeStr = string(row.TargetType.TargetType) // Adjust conversion based on actual type
-		} else {
-			// If TargetType itself is NULL, we likely skip adding any target info for this row
-			continue
-		}
-		// ---
+	Tags    []TagInfo    `json:"tags"`
 
-		var targetID int32
-		// Handle nullable TargetID (Use Int64 or Int32 based on sqlc generated type)
-		if row.TargetID.Valid {
-			targetID = row.TargetID.Int32
-		} else {
-			// Target ID is NULL. This might be valid for type 'General'.
-			// Assign 0, assuming 0 is not a real target ID. Adjust if needed.
-			if targetTypeStr == "General" {
-				targetID = 0
-			} else {
-				// If ID is NULL for a non-General type, maybe log a warning or skip?
-				log.Printf("Warning: Target type '%s' has NULL ID for drop %s. Assigning ID 0.", targetTypeStr, row.DropID)
-				targetID = 0 // Or 'continue' to skip this target entirely
-			}
-		}
-
-		targetName := row.TargetName
-		if targetName == "General" && targetTypeStr != "General" {
-			targetName = fmt.Sprintf("%s %d", targetTypeStr, targetID)
-		}
+	Attachments []AttachmentInfo `json:"attachments"`
+}
 
-		target := TargetInfo{
-			Type: targetTypeStr,
-			ID:   targetID,
-			Name: targetName,
-		}
-		drop.Targets = append(drop.Targets, target)
+// DropTargets is a drop's targets, which the drop queries return as a JSON array
+type DropTargets []TargetInfo
+
+// Scan implements the Scanner interface.
+func (t *DropTargets) Scan(src interface{}) error {
+	var dat []byte
+	switch s := src.(type) {
+	case []byte:
+		dat = s
+	case string:
+		dat = []byte(s)
+	case nil:
+		*t = DropTargets{}
+		return nil
+	default:
+		return fmt.Errorf("unsupported scan type for DropTargets: %T", src)
 	}
-
-	// Build the final slice in the original order
-	result := make([]DropWithTargets, len(orderedDropIDs))
-	for i, dropID := range orderedDropIDs {
-		result[i] = *dropsMap[dropID] // Dereference the pointer
+	targets := make(DropTargets, 0)
+	if err := json.Unmarshal(dat, &targets); err != nil {
+		return fmt.Errorf("decoding drop targets: %w", err)
 	}
-	return result
+	*t = targets
+	return nil
 }
 
-//duplicate function for upcoming drops
-func AggregateUpcomingDropRows(rows []GetUpcomingDropsWithTargetsRow) []DropWithTargets {
-	dropsMap := make(map[uuid.UUID]*DropWithTargets)
-	orderedDropIDs := make([]uuid.UUID, 0)
-
-	for _, row := range rows {
-		drop, exists := dropsMap[row.DropID]
-		if !exists {
-			drop = &DropWithTargets{
-				ID:         row.DropID,
-				UserID:     row.DropUserID,
-				Title:      row.DropTitle,
-				Content:    row.DropContent,
-				PostDate:   row.DropPostDate,
-				ExpireDate: row.DropExpireDate,
-				AuthorName: row.AuthorName,
-				EditorName: row.EditorName,
-				UpdatedAt:  row.DropUpdatedDate,
-				Targets:    make([]TargetInfo, 0),
-			}
-			dropsMap[row.DropID] = drop
-			orderedDropIDs = append(orderedDropIDs, row.DropID)
-		}
-
-		var targetTypeStr string
-		if row.TargetType.Valid {
-			targetTypeStr = string(row.TargetType.TargetType)
-		} else {
-			continue
-		}
-
-		var targetID int32
-		if row.TargetID.Valid {
-			targetID = row.TargetID.Int32
-		} else {
-			if targetTypeStr == "General" {
-				targetID = 0
-			} else {
-				log.Printf("Warning: Target type '%s' has NULL ID for drop %s. Assigning ID 0.", targetTypeStr, row.DropID)
-				targetID = 0
-			}
-		}
+// DropRow is the row type of any of the drop queries, which all return one row per drop
+// with the same columns
+type DropRow interface {
+	GetActiveDropsWithTargetsRow | GetDropsForUserWithTargetsRow | GetUpcomingDropsWithTargetsRow |
+		GetPendingDropsWithTargetsRow | GetDropWithTargetsByIDRow
+}
 
-		targetName := row.TargetName
-		if targetName == "General" && targetTypeStr != "General" {
-			targetName = fmt.Sprintf("%s %d", targetTypeStr, targetID)
-		}
+// dropRow has the fields every DropRow type shares, so any of them converts to it
+type dropRow struct {
+	DropID              uuid.UUID
+	DropUserID          uuid.UUID
+	DropTitle           string
+	DropContent         string
+	DropPostDate        time.Time
+	DropExpireDate      time.Time
+	DropUpdatedAt       time.Time
+	DropStatus          DropStatus
+	DropRejectionReason sql.NullString
+	DropPriority        DropPriority
+	DropPinnedUntil     sql.NullTime
+	AuthorName          string
+	EditorName          string
+	Targets             DropTargets
+}
 
-		target := TargetInfo{
-			Type: targetTypeStr,
-			ID:   targetID,
-			Name: targetName,
-		}
-		drop.Targets = append(drop.Targets, target)
+// NewDropWithTargets builds the API view of a drop from a drop query row.
+// Tags and attachments are filled in separately (AttachDropTags, AttachDropAttachments).
+func NewDropWithTargets[T DropRow](row T) DropWithTargets {
+	r := dropRow(row)
+	drop := DropWithTargets{
+		ID:          r.DropID,
+		UserID:      r.DropUserID,
+		Title:       r.DropTitle,
+		Content:     r.DropContent,
+		PostDate:    r.DropPostDate,
+		ExpireDate:  r.DropExpireDate,
+		AuthorName:  r.AuthorName,
+		EditorName:  r.EditorName,
+		UpdatedAt:   r.DropUpdatedAt,
+		Priority:    string(r.DropPriority),
+		PinnedUntil: nullTimePtr(r.DropPinnedUntil),
+		Status:      string(r.DropStatus),
+		Targets:     r.Targets,
 	}
-
-	result := make([]DropWithTargets, len(orderedDropIDs))
-	for i, dropID := range orderedDropIDs {
-		result[i] = *dropsMap[dropID]
+	if r.DropRejectionReason.Valid {
+		drop.RejectionReason = r.DropRejectionReason.String
+	}
+	if drop.Targets == nil {
+		drop.Targets = make([]TargetInfo, 0)
 	}
-	return result
+	return drop
 }
 
-// Duplicate function to handle the rows produced by the current user qery
-func AggregateCurrentUserDropRows(rows []GetDropsForUserWithTargetsRow) []DropWithTargets {
-	dropsMap := make(map[uuid.UUID]*DropWithTargets)
-	orderedDropIDs := make([]uuid.UUID, 0)
+// NewDropsWithTargets builds the API view of each row of a drop query, keeping their order
+func NewDropsWithTargets[T DropRow](rows []T) []DropWithTargets {
+	drops := make([]DropWithTargets, len(rows))
+	for i, row := range rows {
+		drops[i] = NewDropWithTargets(row)
+	}
+	return drops
+}
 
+// AttachDropTags fills in each drop's tags from the rows returned by GetTagsForDrops.
+// Drops without tags get an empty list rather than null.
+func AttachDropTags(drops []DropWithTargets, rows []GetTagsForDropsRow) {
+	tagsByDrop := make(map[uuid.UUID][]TagInfo)
 	for _, row := range rows {
-		drop, exists := dropsMap[row.DropID]
-		if !exists {
-			// First time seeing this drop, create the main structure
-			drop = &DropWithTargets{
-				ID:         row.DropID,
-				UserID:     row.DropUserID,
-				Title:      row.DropTitle,
-				Content:    row.DropContent,
-				PostDate:   row.DropPostDate,
-				ExpireDate: row.DropExpireDate,
-				AuthorName: row.AuthorName,
-				EditorName: row.EditorName,
-				UpdatedAt:  row.DropUpdatedAt,
-				Targets:    make([]TargetInfo, 0),
-			}
-			dropsMap[row.DropID] = drop
-			orderedDropIDs = append(orderedDropIDs, row.DropID) // Record the order
-		}
-
-		targetTypeStr := string(row.TargetType.TargetType)
-
-		var targetID int32
-		// Handle nullable TargetID (Use Int64 or Int32 based on sqlc generated type)
-		if row.TargetID.Valid {
-			targetID = row.TargetID.Int32
-		} else {
-			// Target ID is NULL. This might be valid for type 'General'.
-			// Assign 0, assuming 0 is not a real target ID. Adjust if needed.
-			if targetTypeStr == "General" {
-				targetID = 0
-			} else {
-				// If ID is NULL for a non-General type, maybe log a warning or skip?
-				log.Printf("Warning: Target type '%s' has NULL ID for drop %s. Assigning ID 0.", targetTypeStr, row.DropID)
-				targetID = 0 // Or 'continue' to skip this target entirely
-			}
-		}
-
-		targetName := row.TargetName
-		if targetName == "General" && targetTypeStr != "General" {
-			targetName = fmt.Sprintf("%s %d", targetTypeStr, targetID)
-		}
-
-		target := TargetInfo{
-			Type: targetTypeStr,
-			ID:   targetID,
-			Name: targetName,
-		}
-		drop.Targets = append(drop.Targets, target)
+		tagsByDrop[row.DropID] = append(tagsByDrop[row.DropID], TagInfo{
+			ID:   row.TagID,
+			Name: row.TagName,
+		})
 	}
 
-	// Build the final slice in the original order
-	result := make([]DropWithTargets, len(orderedDropIDs))
-	for i, dropID := range orderedDropIDs {
-		result[i] = *dropsMap[dropID] // Dereference the pointer
+	for i := range drops {
+		tags, ok := tagsByDrop[drops[i].ID]
+		if !ok {
+			tags = make([]TagInfo, 0)
+		}
+		drops[i].Tags = tags
 	}
-	return result
 }
 
-func AggregateDropAndTargetRows(rows []GetDropWithTargetsByIDRow) []DropWithTargets {
-	if len(rows) == 0 {
-		return []DropWithTargets{}
-	}
-
-	firstRow := rows[0]
-	finalDrop := DropWithTargets{
-		ID:         firstRow.DropID,
-		UserID:     firstRow.DropUserID,
-		Title:      firstRow.DropTitle,
-		Content:    firstRow.DropContent,
-		PostDate:   firstRow.DropPostDate,
-		ExpireDate: firstRow.DropExpireDate,
-		AuthorName: firstRow.AuthorName,
-		EditorName: firstRow.EditorName,
-		UpdatedAt:  firstRow.DropUpdatedAt,
-		Targets:    make([]TargetInfo, 0),
+// NewAttachmentInfo describes a stored attachment for API responses
+func NewAttachmentInfo(a DropAttachment) AttachmentInfo {
+	return AttachmentInfo{
+		ID:          a.ID,
+		Filename:    a.Filename,
+		ContentType: a.ContentType,
+		SizeBytes:   a.SizeBytes,
+		URL:         fmt.Sprintf("/api/drops/%s/attachments/%d", a.DropID, a.ID),
 	}
+}
 
+// AttachDropAttachments fills in each drop's attachments from the rows returned by GetAttachmentsForDrops.
+// Drops without attachments get an empty list rather than null.
+func AttachDropAttachments(drops []DropWithTargets, rows []DropAttachment) {
+	byDrop := make(map[uuid.UUID][]AttachmentInfo)
 	for _, row := range rows {
-		if row.TargetType.Valid {
-			targetTypeStr := string(row.TargetType.TargetType)
-
-			var targetID int32
-			if row.TargetID.Valid {
-				targetID = row.TargetID.Int32
-			} else {
-				if targetTypeStr == "General" {
-					targetID = 0
-				} else {
-					log.Printf("Warning: Target type '%s' has NULL ID for drop '%s'. Assigning ID 0.", targetTypeStr, row.DropID)
-					targetID = 0
-				}
-			}
-
-			targetName := row.TargetName
+		byDrop[row.DropID] = append(byDrop[row.DropID], NewAttachmentInfo(row))
+	}
 
-			target := TargetInfo{
-				Type: targetTypeStr,
-				ID:   targetID,
-				Name: targetName,
-			}
-			finalDrop.Targets = append(finalDrop.Targets, target)
+	for i := range drops {
+		attachments, ok := byDrop[drops[i].ID]
+		if !ok {
+			attachments = make([]AttachmentInfo, 0)
 		}
+		drops[i].Attachments = attachments
 	}
+}
 
-	return []DropWithTargets{finalDrop}
-
+// nullTimePtr converts a nullable timestamp to a pointer so it is omitted from JSON when unset
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
 }
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const addDropTarget = `-- name: AddDropTarget :one
@@ -64,68 +65,124 @@ func (q *Queries) DeleteAllTargetsForDrop(ctx context.Context, arg DeleteAllTarg
 }
 
 const getActiveDropsWithTargets = `-- name: GetActiveDropsWithTargets :many
+WITH page AS (
+    SELECT d.id, d.user_id, d.title, d.content, d.created_at, d.updated_at, d.post_date, d.expire_date, d.edited_by, d.school_id, d.status, d.reviewed_by, d.reviewed_at, d.rejection_reason, d.priority, d.pinned_until
+    FROM drops d
+    WHERE
+        (d.expire_date IS NULL OR d.expire_date > NOW()) AND d.post_date <= NOW() and d.school_id = $1
+        AND d.status = 'published'
+        -- Optional ?tags= filter (lower-cased tag names); an empty array matches every drop
+        AND (COALESCE(cardinality($2::text[]), 0) = 0 OR EXISTS (
+            SELECT 1 FROM drop_tags dtg JOIN tags t ON t.id = dtg.tag_id
+            WHERE dtg.drop_id = d.id AND LOWER(t.name) = ANY($2::text[])
+        ))
+        -- Optional ?author=, ?class= and ?year_group= filters
+        AND ($3::uuid IS NULL OR d.user_id = $3::uuid)
+        AND ($4::int IS NULL OR EXISTS (
+            SELECT 1 FROM drop_targets f WHERE f.drop_id = d.id AND f.type = 'Class' AND f.target_id = $4::int
+        ))
+        AND ($5::int IS NULL OR EXISTS (
+            SELECT 1 FROM drop_targets f WHERE f.drop_id = d.id AND f.type = 'YearGroup' AND f.target_id = $5::int
+        ))
+        -- Keyset: drops after the last (pinned now, priority, post_date, id) of the previous page. Pinned drops
+        -- come first, then by priority, whichever way post_date is sorted, so each page carries on in feed order
+        AND ($6::timestamp IS NULL
+            OR (d.pinned_until IS NOT NULL AND d.pinned_until > NOW()) < $7::boolean
+            OR ((d.pinned_until IS NOT NULL AND d.pinned_until > NOW()) = $7::boolean AND (
+                d.priority < $8::drop_priority
+                OR (d.priority = $8::drop_priority AND (
+                    ($9::boolean AND (d.post_date, d.id) < ($6::timestamp, $10::uuid))
+                    OR (NOT $9::boolean AND (d.post_date, d.id) > ($6::timestamp, $10::uuid)))))))
+    ORDER BY
+        (d.pinned_until IS NOT NULL AND d.pinned_until > NOW()) DESC,
+        d.priority DESC,
+        CASE WHEN $9::boolean THEN d.post_date END DESC,
+        CASE WHEN $9::boolean THEN d.id END DESC,
+        d.post_date, d.id
+    LIMIT $11
+)
 SELECT
     d.id AS drop_id,
     d.user_id AS drop_user_id,
     d.title AS drop_title,
     d.content AS drop_content,
     d.post_date AS drop_post_date,
-    d.updated_at AS drop_updated_date,
     d.expire_date AS drop_expire_date,
-    dt.type AS target_type,
-    dt.target_id AS target_id,
-    COALESCE(
-        cls.class_name,             -- Name if type is Class
-        yg.year_group_name,         -- Name if type is YearGroup
-        div.division_name,          -- Name if type is Division
-        p.surname || ', ' || p.first_name, -- Concatenated name if type is Student
-        'General'                   -- Fallback if type is General or name is NULL
-    ) AS target_name,
-    COALESCE(cls.class_name, yg.year_group_name, div.division_name, p.surname || ', ' || p.first_name, 'General') AS target_name,
+    d.updated_at AS drop_updated_at,
+    d.status AS drop_status,
+    d.rejection_reason AS drop_rejection_reason,
+    d.priority AS drop_priority,
+    d.pinned_until AS drop_pinned_until,
     -- Author Name (Concatenated, assumes author exists)
     COALESCE(CONCAT_WS(' ', author.first_name, author.surname), 'Unknown Author')::text AS author_name,
-    -- Editor Name (Concatenated, handles NULL editor via LEFT JOIN + COALESCE on final result)
-    COALESCE(CONCAT_WS(' ', editor.first_name,  editor.surname))::text AS editor_name -- This might be NULL if no editor
+    -- Editor Name (empty if the drop has not been edited)
+    CONCAT_WS(' ', editor.first_name, editor.surname)::text AS editor_name,
+    -- Targets as a JSON array of {"type", "id", "name"} objects, in target type order
+    COALESCE((
+        SELECT json_agg(json_build_object('type', tn.type, 'id', tn.target_id, 'name', tn.name) ORDER BY tn.type, tn.target_id)
+        FROM drop_target_names tn
+        WHERE tn.drop_id = d.id
+    ), '[]')::json AS targets
 FROM
-    drops d
-LEFT JOIN
-    drop_targets dt ON d.id = dt.drop_id
-LEFT JOIN
-    classes cls ON dt.type = 'Class' AND dt.target_id = cls.id
-LEFT JOIN
-    year_groups yg ON dt.type = 'YearGroup' AND dt.target_id = yg.id
-LEFT JOIN
-    divisions div ON dt.type = 'Division' AND dt.target_id = div.id
-LEFT JOIN
-    pupils p ON dt.type = 'Student' AND dt.target_id = p.id
+    page d
 LEFT JOIN
     users AS author ON d.user_id = author.id
 LEFT JOIN
-    users AS editor on d.edited_by = editor.id
-WHERE
-    (d.expire_date IS NULL OR d.expire_date > NOW()) AND d.post_date <= NOW() and d.school_id = $1
+    users AS editor ON d.edited_by = editor.id
 ORDER BY
-    d.post_date DESC, d.id, dt.type
+    -- The page's order: pinned drops first, then by priority (urgent > important > normal), then by post_date
+    (d.pinned_until IS NOT NULL AND d.pinned_until > NOW()) DESC,
+    d.priority DESC,
+    CASE WHEN $9::boolean THEN d.post_date END DESC,
+    CASE WHEN $9::boolean THEN d.id END DESC,
+    d.post_date, d.id
 `
 
+type GetActiveDropsWithTargetsParams struct {
+	SchoolID      uuid.UUID        `json:"school_id"`
+	Tags          []string         `json:"tags"`
+	AuthorID      uuid.NullUUID    `json:"author_id"`
+	ClassID       sql.NullInt32    `json:"class_id"`
+	YearGroupID   sql.NullInt32    `json:"year_group_id"`
+	AfterPostDate sql.NullTime     `json:"after_post_date"`
+	AfterPinned   bool             `json:"after_pinned"`
+	AfterPriority NullDropPriority `json:"after_priority"`
+	SortDesc      bool             `json:"sort_desc"`
+	AfterID       uuid.NullUUID    `json:"after_id"`
+	PageLimit     int32            `json:"page_limit"`
+}
+
 type GetActiveDropsWithTargetsRow struct {
-	DropID          uuid.UUID      `json:"drop_id"`
-	DropUserID      uuid.UUID      `json:"drop_user_id"`
-	DropTitle       string         `json:"drop_title"`
-	DropContent     string         `json:"drop_content"`
-	DropPostDate    time.Time      `json:"drop_post_date"`
-	DropUpdatedDate time.Time      `json:"drop_updated_date"`
-	DropExpireDate  time.Time      `json:"drop_expire_date"`
-	TargetType      NullTargetType `json:"target_type"`
-	TargetID        sql.NullInt32  `json:"target_id"`
-	TargetName      string         `json:"target_name"`
-	TargetName_2    string         `json:"target_name_2"`
-	AuthorName      string         `json:"author_name"`
-	EditorName      string         `json:"editor_name"`
+	DropID              uuid.UUID      `json:"drop_id"`
+	DropUserID          uuid.UUID      `json:"drop_user_id"`
+	DropTitle           string         `json:"drop_title"`
+	DropContent         string         `json:"drop_content"`
+	DropPostDate        time.Time      `json:"drop_post_date"`
+	DropExpireDate      time.Time      `json:"drop_expire_date"`
+	DropUpdatedAt       time.Time      `json:"drop_updated_at"`
+	DropStatus          DropStatus     `json:"drop_status"`
+	DropRejectionReason sql.NullString `json:"drop_rejection_reason"`
+	DropPriority        DropPriority   `json:"drop_priority"`
+	DropPinnedUntil     sql.NullTime   `json:"drop_pinned_until"`
+	AuthorName          string         `json:"author_name"`
+	EditorName          string         `json:"editor_name"`
+	Targets             DropTargets    `json:"targets"`
 }
 
-func (q *Queries) GetActiveDropsWithTargets(ctx context.Context, schoolID uuid.UUID) ([]GetActiveDropsWithTargetsRow, error) {
-	rows, err := q.db.QueryContext(ctx, getActiveDropsWithTargets, schoolID)
+func (q *Queries) GetActiveDropsWithTargets(ctx context.Context, arg GetActiveDropsWithTargetsParams) ([]GetActiveDropsWithTargetsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getActiveDropsWithTargets,
+		arg.SchoolID,
+		pq.Array(arg.Tags),
+		arg.AuthorID,
+		arg.ClassID,
+		arg.YearGroupID,
+		arg.AfterPostDate,
+		arg.AfterPinned,
+		arg.AfterPriority,
+		arg.SortDesc,
+		arg.AfterID,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -139,14 +196,15 @@ func (q *Queries) GetActiveDropsWithTargets(ctx context.Context, schoolID uuid.U
 			&i.DropTitle,
 			&i.DropContent,
 			&i.DropPostDate,
-			&i.DropUpdatedDate,
 			&i.DropExpireDate,
-			&i.TargetType,
-			&i.TargetID,
-			&i.TargetName,
-			&i.TargetName_2,
+			&i.DropUpdatedAt,
+			&i.DropStatus,
+			&i.DropRejectionReason,
+			&i.DropPriority,
+			&i.DropPinnedUntil,
 			&i.AuthorName,
 			&i.EditorName,
+			&i.Targets,
 		); err != nil {
 			return nil, err
 		}
@@ -161,45 +219,162 @@ func (q *Queries) GetActiveDropsWithTargets(ctx context.Context, schoolID uuid.U
 	return items, nil
 }
 
-const getDropsForCurrentUser = `-- name: GetDropsForCurrentUser :many
-SELECT d.id, d.user_id, d.title, d.content, d.created_at, d.updated_at, d.post_date, d.expire_date, d.edited_by, d.school_id
-FROM drops d
-JOIN drop_targets dt ON d.id = dt.drop_id
-WHERE
-    (dt.type = 'General'
-    OR (dt.type = 'Class' AND dt.target_id = (SELECT id FROM classes WHERE classes.teacher_id = $1))
-    OR (dt.type = 'YearGroup' AND dt.target_id = (SELECT year_group_id FROM classes WHERE classes.teacher_id = $1))
-    OR (dt.type = 'Division' AND dt.target_id = (SELECT division_id FROM year_groups WHERE year_groups.id = (SELECT year_group_id FROM classes WHERE classes.teacher_id = $1))))
-    AND d.expire_date > NOW()
-    AND d.school_id = $2
-ORDER BY d.post_date DESC
+const getDropsForUserWithTargets = `-- name: GetDropsForUserWithTargets :many
+WITH page AS (
+    SELECT d.id, d.user_id, d.title, d.content, d.created_at, d.updated_at, d.post_date, d.expire_date, d.edited_by, d.school_id, d.status, d.reviewed_by, d.reviewed_at, d.rejection_reason, d.priority, d.pinned_until
+    FROM drops d
+    -- Filter 3: Drop is visible to the user: its targets, the user's subscriptions and followed or muted tags,
+    -- as kept in drop_audience (see the drop_audience_expected view)
+    JOIN drop_audience da ON da.drop_id = d.id AND da.user_id = $1
+    WHERE
+        -- Filter 1: Drop is currently active
+        d.post_date <= NOW()
+        AND (d.expire_date IS NULL OR d.expire_date > NOW())
+
+        -- Filter 2: Drop belongs to school
+        AND d.school_id = $2
+
+        -- Filter 2b: Drop has been published (not awaiting approval or rejected)
+        AND d.status = 'published'
+
+        -- Filter 4: Optional ?tags= filter (lower-cased tag names); an empty array matches every drop
+        AND (COALESCE(cardinality($3::text[]), 0) = 0 OR EXISTS (
+            SELECT 1 FROM drop_tags dtg JOIN tags t ON t.id = dtg.tag_id
+            WHERE dtg.drop_id = d.id AND LOWER(t.name) = ANY($3::text[])
+        ))
+
+        -- Filter 5: Optional ?author=, ?class= and ?year_group= filters
+        AND ($4::uuid IS NULL OR d.user_id = $4::uuid)
+        AND ($5::int IS NULL OR EXISTS (
+            SELECT 1 FROM drop_targets f WHERE f.drop_id = d.id AND f.type = 'Class' AND f.target_id = $5::int
+        ))
+        AND ($6::int IS NULL OR EXISTS (
+            SELECT 1 FROM drop_targets f WHERE f.drop_id = d.id AND f.type = 'YearGroup' AND f.target_id = $6::int
+        ))
+
+        -- Keyset: drops after the last (pinned now, priority, post_date, id) of the previous page. Pinned drops
+        -- come first, then by priority, whichever way post_date is sorted, so each page carries on in feed order
+        AND ($7::timestamp IS NULL
+            OR (d.pinned_until IS NOT NULL AND d.pinned_until > NOW()) < $8::boolean
+            OR ((d.pinned_until IS NOT NULL AND d.pinned_until > NOW()) = $8::boolean AND (
+                d.priority < $9::drop_priority
+                OR (d.priority = $9::drop_priority AND (
+                    ($10::boolean AND (d.post_date, d.id) < ($7::timestamp, $11::uuid))
+                    OR (NOT $10::boolean AND (d.post_date, d.id) > ($7::timestamp, $11::uuid)))))))
+    ORDER BY
+        (d.pinned_until IS NOT NULL AND d.pinned_until > NOW()) DESC,
+        d.priority DESC,
+        CASE WHEN $10::boolean THEN d.post_date END DESC,
+        CASE WHEN $10::boolean THEN d.id END DESC,
+        d.post_date, d.id
+    LIMIT $12
+)
+SELECT
+    d.id AS drop_id,
+    d.user_id AS drop_user_id,
+    d.title AS drop_title,
+    d.content AS drop_content,
+    d.post_date AS drop_post_date,
+    d.expire_date AS drop_expire_date,
+    d.updated_at AS drop_updated_at,
+    d.status AS drop_status,
+    d.rejection_reason AS drop_rejection_reason,
+    d.priority AS drop_priority,
+    d.pinned_until AS drop_pinned_until,
+    -- Author Name (Concatenated, assumes author exists)
+    COALESCE(CONCAT_WS(' ', author.first_name, author.surname), 'Unknown Author')::text AS author_name,
+    -- Editor Name (empty if the drop has not been edited)
+    CONCAT_WS(' ', editor.first_name, editor.surname)::text AS editor_name,
+    -- Targets as a JSON array of {"type", "id", "name"} objects, in target type order
+    COALESCE((
+        SELECT json_agg(json_build_object('type', tn.type, 'id', tn.target_id, 'name', tn.name) ORDER BY tn.type, tn.target_id)
+        FROM drop_target_names tn
+        WHERE tn.drop_id = d.id
+    ), '[]')::json AS targets
+FROM
+    page d
+LEFT JOIN
+    users AS author ON d.user_id = author.id
+LEFT JOIN
+    users AS editor ON d.edited_by = editor.id
+ORDER BY
+    -- The page's order: pinned drops first, then by priority (urgent > important > normal), then by post_date
+    (d.pinned_until IS NOT NULL AND d.pinned_until > NOW()) DESC,
+    d.priority DESC,
+    CASE WHEN $10::boolean THEN d.post_date END DESC,
+    CASE WHEN $10::boolean THEN d.id END DESC,
+    d.post_date, d.id
 `
 
-type GetDropsForCurrentUserParams struct {
-	TeacherID uuid.NullUUID `json:"teacher_id"`
-	SchoolID  uuid.UUID     `json:"school_id"`
+type GetDropsForUserWithTargetsParams struct {
+	UserID        uuid.UUID        `json:"user_id"`
+	SchoolID      uuid.UUID        `json:"school_id"`
+	Tags          []string         `json:"tags"`
+	AuthorID      uuid.NullUUID    `json:"author_id"`
+	ClassID       sql.NullInt32    `json:"class_id"`
+	YearGroupID   sql.NullInt32    `json:"year_group_id"`
+	AfterPostDate sql.NullTime     `json:"after_post_date"`
+	AfterPinned   bool             `json:"after_pinned"`
+	AfterPriority NullDropPriority `json:"after_priority"`
+	SortDesc      bool             `json:"sort_desc"`
+	AfterID       uuid.NullUUID    `json:"after_id"`
+	PageLimit     int32            `json:"page_limit"`
+}
+
+type GetDropsForUserWithTargetsRow struct {
+	DropID              uuid.UUID      `json:"drop_id"`
+	DropUserID          uuid.UUID      `json:"drop_user_id"`
+	DropTitle           string         `json:"drop_title"`
+	DropContent         string         `json:"drop_content"`
+	DropPostDate        time.Time      `json:"drop_post_date"`
+	DropExpireDate      time.Time      `json:"drop_expire_date"`
+	DropUpdatedAt       time.Time      `json:"drop_updated_at"`
+	DropStatus          DropStatus     `json:"drop_status"`
+	DropRejectionReason sql.NullString `json:"drop_rejection_reason"`
+	DropPriority        DropPriority   `json:"drop_priority"`
+	DropPinnedUntil     sql.NullTime   `json:"drop_pinned_until"`
+	AuthorName          string         `json:"author_name"`
+	EditorName          string         `json:"editor_name"`
+	Targets             DropTargets    `json:"targets"`
 }
 
-func (q *Queries) GetDropsForCurrentUser(ctx context.Context, arg GetDropsForCurrentUserParams) ([]Drop, error) {
-	rows, err := q.db.QueryContext(ctx, getDropsForCurrentUser, arg.TeacherID, arg.SchoolID)
+func (q *Queries) GetDropsForUserWithTargets(ctx context.Context, arg GetDropsForUserWithTargetsParams) ([]GetDropsForUserWithTargetsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getDropsForUserWithTargets,
+		arg.UserID,
+		arg.SchoolID,
+		pq.Array(arg.Tags),
+		arg.AuthorID,
+		arg.ClassID,
+		arg.YearGroupID,
+		arg.AfterPostDate,
+		arg.AfterPinned,
+		arg.AfterPriority,
+		arg.SortDesc,
+		arg.AfterID,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Drop
+	var items []GetDropsForUserWithTargetsRow
 	for rows.Next() {
-		var i Drop
+		var i GetDropsForUserWithTargetsRow
 		if err := rows.Scan(
-			&i.ID,
-			&i.UserID,
-			&i.Title,
-			&i.Content,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.PostDate,
-			&i.ExpireDate,
-			&i.EditedBy,
-			&i.SchoolID,
+			&i.DropID,
+			&i.DropUserID,
+			&i.DropTitle,
+			&i.DropContent,
+			&i.DropPostDate,
+			&i.DropExpireDate,
+			&i.DropUpdatedAt,
+			&i.DropStatus,
+			&i.DropRejectionReason,
+			&i.DropPriority,
+			&i.DropPinnedUntil,
+			&i.AuthorName,
+			&i.EditorName,
+			&i.Targets,
 		); err != nil {
 			return nil, err
 		}
@@ -214,7 +389,7 @@ func (q *Queries) GetDropsForCurrentUser(ctx context.Context, arg GetDropsForCur
 	return items, nil
 }
 
-const getDropsForUserWithTargets = `-- name: GetDropsForUserWithTargets :many
+const getPendingDropsWithTargets = `-- name: GetPendingDropsWithTargets :many
 SELECT
     d.id AS drop_id,
     d.user_id AS drop_user_id,
@@ -222,111 +397,59 @@ SELECT
     d.content AS drop_content,
     d.post_date AS drop_post_date,
     d.expire_date AS drop_expire_date,
-    d.created_at AS drop_created_at,
     d.updated_at AS drop_updated_at,
-    d.edited_by AS drop_edited_by,
-    -- Target details
-    dt.type AS target_type,
-    dt.target_id AS target_id,
-    COALESCE(
-        cls_name.class_name,
-        yg_name.year_group_name,
-        div_name.division_name,
-        CONCAT_WS(', ', p_name.surname, p_name.first_name), -- Pupil Name included
-        'General'
-    )::text AS target_name,
-    -- Author and Editor Names
-    COALESCE(CONCAT_WS(' ', author.first_name, author.surname))::text AS author_name,
-    CONCAT_WS(' ', editor.first_name, editor.surname)::text AS editor_name
+    d.status AS drop_status,
+    d.rejection_reason AS drop_rejection_reason,
+    d.priority AS drop_priority,
+    d.pinned_until AS drop_pinned_until,
+    -- Author Name (Concatenated, assumes author exists)
+    COALESCE(CONCAT_WS(' ', author.first_name, author.surname), 'Unknown Author')::text AS author_name,
+    -- Editor Name (empty if the drop has not been edited)
+    CONCAT_WS(' ', editor.first_name, editor.surname)::text AS editor_name,
+    -- Targets as a JSON array of {"type", "id", "name"} objects, in target type order
+    COALESCE((
+        SELECT json_agg(json_build_object('type', tn.type, 'id', tn.target_id, 'name', tn.name) ORDER BY tn.type, tn.target_id)
+        FROM drop_target_names tn
+        WHERE tn.drop_id = d.id
+    ), '[]')::json AS targets
 FROM
     drops d
-LEFT JOIN users author ON d.user_id = author.id
-LEFT JOIN users editor ON d.edited_by = editor.id
-LEFT JOIN drop_targets dt ON d.id = dt.drop_id
-LEFT JOIN classes cls_name ON dt.type = 'Class' AND dt.target_id = cls_name.id
-LEFT JOIN year_groups yg_name ON dt.type = 'YearGroup' AND dt.target_id = yg_name.id
-LEFT JOIN divisions div_name ON dt.type = 'Division' AND dt.target_id = div_name.id
-LEFT JOIN pupils p_name ON dt.type = 'Student' AND dt.target_id = p_name.id
-
+LEFT JOIN
+    users AS author ON d.user_id = author.id
+LEFT JOIN
+    users AS editor ON d.edited_by = editor.id
 WHERE
-    -- Filter 1: Drop is currently active
-    d.post_date <= NOW()
-    AND (d.expire_date IS NULL OR d.expire_date > NOW())
-
-    -- Filter 2: Drop belongs to school
-    AND d.school_id = $2
-
-    -- Filter 3: Drop is visible to the user ($1 = logged_in_user_id)
-    AND EXISTS (
-        SELECT 1
-        FROM drop_targets dt_filter
-        WHERE dt_filter.drop_id = d.id
-          AND (
-            -- Condition A: Target is 'General'
-            dt_filter.type = 'General'
-
-            -- Condition B: Target is explicitly subscribed to by user $1
-            OR EXISTS (
-                SELECT 1 FROM target_subscriptions sub
-                WHERE sub.user_id = $1
-                  AND sub.type = dt_filter.type
-                  AND sub.target_id = dt_filter.target_id
-            )
-
-            -- Condition C: Target matches user's implicit groups based on user_id $1
-            -- (Verify these subqueries match how your user $1 links to classes)
-            OR (dt_filter.type = 'Class' AND dt_filter.target_id IN (SELECT classes.id FROM classes WHERE classes.teacher_id = $1))
-            OR (dt_filter.type = 'YearGroup' AND dt_filter.target_id IN (SELECT classes.year_group_id FROM classes WHERE classes.teacher_id = $1))
-            OR (dt_filter.type = 'Division' AND dt_filter.target_id IN (SELECT yg.division_id FROM year_groups yg JOIN classes cls ON yg.id = cls.year_group_id WHERE cls.teacher_id = $1))
-
-            -- *** Condition D: Target is a Student in one of the user's ($1) classes ***
-            OR (dt_filter.type = 'Student' AND dt_filter.target_id IN (
-                -- This subquery finds pupil IDs whose class_id matches a class taught by user $1
-                SELECT p_implicit.id
-                FROM pupils p_implicit
-                WHERE p_implicit.class_id IN (
-                    -- This subquery finds the class IDs taught by user $1
-                    SELECT cls_teacher.id FROM classes cls_teacher WHERE cls_teacher.teacher_id = $1
-                )
-            ))
-            -- *** End Condition D ***
-          )
-    )
+    d.status = 'pending_approval' AND d.school_id = $1
 ORDER BY
-    d.post_date DESC, d.id, dt.type
+    d.priority DESC, d.created_at ASC, d.id
 `
 
-type GetDropsForUserWithTargetsParams struct {
-	UserID   uuid.UUID `json:"user_id"`
-	SchoolID uuid.UUID `json:"school_id"`
-}
-
-type GetDropsForUserWithTargetsRow struct {
-	DropID         uuid.UUID      `json:"drop_id"`
-	DropUserID     uuid.UUID      `json:"drop_user_id"`
-	DropTitle      string         `json:"drop_title"`
-	DropContent    string         `json:"drop_content"`
-	DropPostDate   time.Time      `json:"drop_post_date"`
-	DropExpireDate time.Time      `json:"drop_expire_date"`
-	DropCreatedAt  time.Time      `json:"drop_created_at"`
-	DropUpdatedAt  time.Time      `json:"drop_updated_at"`
-	DropEditedBy   uuid.NullUUID  `json:"drop_edited_by"`
-	TargetType     NullTargetType `json:"target_type"`
-	TargetID       sql.NullInt32  `json:"target_id"`
-	TargetName     string         `json:"target_name"`
-	AuthorName     string         `json:"author_name"`
-	EditorName     string         `json:"editor_name"`
+type GetPendingDropsWithTargetsRow struct {
+	DropID              uuid.UUID      `json:"drop_id"`
+	DropUserID          uuid.UUID      `json:"drop_user_id"`
+	DropTitle           string         `json:"drop_title"`
+	DropContent         string         `json:"drop_content"`
+	DropPostDate        time.Time      `json:"drop_post_date"`
+	DropExpireDate      time.Time      `json:"drop_expire_date"`
+	DropUpdatedAt       time.Time      `json:"drop_updated_at"`
+	DropStatus          DropStatus     `json:"drop_status"`
+	DropRejectionReason sql.NullString `json:"drop_rejection_reason"`
+	DropPriority        DropPriority   `json:"drop_priority"`
+	DropPinnedUntil     sql.NullTime   `json:"drop_pinned_until"`
+	AuthorName          string         `json:"author_name"`
+	EditorName          string         `json:"editor_name"`
+	Targets             DropTargets    `json:"targets"`
 }
 
-func (q *Queries) GetDropsForUserWithTargets(ctx context.Context, arg GetDropsForUserWithTargetsParams) ([]GetDropsForUserWithTargetsRow, error) {
-	rows, err := q.db.QueryContext(ctx, getDropsForUserWithTargets, arg.UserID, arg.SchoolID)
+func (q *Queries) GetPendingDropsWithTargets(ctx context.Context, schoolID uuid.UUID) ([]GetPendingDropsWithTargetsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPendingDropsWithTargets, schoolID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetDropsForUserWithTargetsRow
+	var items []GetPendingDropsWithTargetsRow
 	for rows.Next() {
-		var i GetDropsForUserWithTargetsRow
+		var i GetPendingDropsWithTargetsRow
 		if err := rows.Scan(
 			&i.DropID,
 			&i.DropUserID,
@@ -334,14 +457,14 @@ func (q *Queries) GetDropsForUserWithTargets(ctx context.Context, arg GetDropsFo
 			&i.DropContent,
 			&i.DropPostDate,
 			&i.DropExpireDate,
-			&i.DropCreatedAt,
 			&i.DropUpdatedAt,
-			&i.DropEditedBy,
-			&i.TargetType,
-			&i.TargetID,
-			&i.TargetName,
+			&i.DropStatus,
+			&i.DropRejectionReason,
+			&i.DropPriority,
+			&i.DropPinnedUntil,
 			&i.AuthorName,
 			&i.EditorName,
+			&i.Targets,
 		); err != nil {
 			return nil, err
 		}
@@ -363,58 +486,50 @@ SELECT
     d.title AS drop_title,
     d.content AS drop_content,
     d.post_date AS drop_post_date,
-    d.updated_at AS drop_updated_date,
     d.expire_date AS drop_expire_date,
-    dt.type AS target_type,
-    dt.target_id AS target_id,
-    COALESCE(
-        cls.class_name,             -- Name if type is Class
-        yg.year_group_name,         -- Name if type is YearGroup
-        div.division_name,          -- Name if type is Division
-        p.surname || ', ' || p.first_name, -- Concatenated name if type is Student
-        'General'                   -- Fallback if type is General or name is NULL
-    ) AS target_name,
-    COALESCE(cls.class_name, yg.year_group_name, div.division_name, p.surname || ', ' || p.first_name, 'General') AS target_name,
+    d.updated_at AS drop_updated_at,
+    d.status AS drop_status,
+    d.rejection_reason AS drop_rejection_reason,
+    d.priority AS drop_priority,
+    d.pinned_until AS drop_pinned_until,
     -- Author Name (Concatenated, assumes author exists)
     COALESCE(CONCAT_WS(' ', author.first_name, author.surname), 'Unknown Author')::text AS author_name,
-    -- Editor Name (Concatenated, handles NULL editor via LEFT JOIN + COALESCE on final result)
-    COALESCE(CONCAT_WS(' ', editor.first_name,  editor.surname))::text AS editor_name -- This might be NULL if no editor
+    -- Editor Name (empty if the drop has not been edited)
+    CONCAT_WS(' ', editor.first_name, editor.surname)::text AS editor_name,
+    -- Targets as a JSON array of {"type", "id", "name"} objects, in target type order
+    COALESCE((
+        SELECT json_agg(json_build_object('type', tn.type, 'id', tn.target_id, 'name', tn.name) ORDER BY tn.type, tn.target_id)
+        FROM drop_target_names tn
+        WHERE tn.drop_id = d.id
+    ), '[]')::json AS targets
 FROM
     drops d
-LEFT JOIN
-    drop_targets dt ON d.id = dt.drop_id
-LEFT JOIN
-    classes cls ON dt.type = 'Class' AND dt.target_id = cls.id
-LEFT JOIN
-    year_groups yg ON dt.type = 'YearGroup' AND dt.target_id = yg.id
-LEFT JOIN
-    divisions div ON dt.type = 'Division' AND dt.target_id = div.id
-LEFT JOIN
-    pupils p ON dt.type = 'Student' AND dt.target_id = p.id
 LEFT JOIN
     users AS author ON d.user_id = author.id
 LEFT JOIN
-    users AS editor on d.edited_by = editor.id
+    users AS editor ON d.edited_by = editor.id
 WHERE
     d.post_date > NOW() and d.school_id = $1
+    AND d.status = 'published'
 ORDER BY
-    d.post_date DESC, d.id, dt.type
+    d.post_date DESC, d.id
 `
 
 type GetUpcomingDropsWithTargetsRow struct {
-	DropID          uuid.UUID      `json:"drop_id"`
-	DropUserID      uuid.UUID      `json:"drop_user_id"`
-	DropTitle       string         `json:"drop_title"`
-	DropContent     string         `json:"drop_content"`
-	DropPostDate    time.Time      `json:"drop_post_date"`
-	DropUpdatedDate time.Time      `json:"drop_updated_date"`
-	DropExpireDate  time.Time      `json:"drop_expire_date"`
-	TargetType      NullTargetType `json:"target_type"`
-	TargetID        sql.NullInt32  `json:"target_id"`
-	TargetName      string         `json:"target_name"`
-	TargetName_2    string         `json:"target_name_2"`
-	AuthorName      string         `json:"author_name"`
-	EditorName      string         `json:"editor_name"`
+	DropID              uuid.UUID      `json:"drop_id"`
+	DropUserID          uuid.UUID      `json:"drop_user_id"`
+	DropTitle           string         `json:"drop_title"`
+	DropContent         string         `json:"drop_content"`
+	DropPostDate        time.Time      `json:"drop_post_date"`
+	DropExpireDate      time.Time      `json:"drop_expire_date"`
+	DropUpdatedAt       time.Time      `json:"drop_updated_at"`
+	DropStatus          DropStatus     `json:"drop_status"`
+	DropRejectionReason sql.NullString `json:"drop_rejection_reason"`
+	DropPriority        DropPriority   `json:"drop_priority"`
+	DropPinnedUntil     sql.NullTime   `json:"drop_pinned_until"`
+	AuthorName          string         `json:"author_name"`
+	EditorName          string         `json:"editor_name"`
+	Targets             DropTargets    `json:"targets"`
 }
 
 func (q *Queries) GetUpcomingDropsWithTargets(ctx context.Context, schoolID uuid.UUID) ([]GetUpcomingDropsWithTargetsRow, error) {
@@ -432,14 +547,15 @@ func (q *Queries) GetUpcomingDropsWithTargets(ctx context.Context, schoolID uuid
 			&i.DropTitle,
 			&i.DropContent,
 			&i.DropPostDate,
-			&i.DropUpdatedDate,
 			&i.DropExpireDate,
-			&i.TargetType,
-			&i.TargetID,
-			&i.TargetName,
-			&i.TargetName_2,
+			&i.DropUpdatedAt,
+			&i.DropStatus,
+			&i.DropRejectionReason,
+			&i.DropPriority,
+			&i.DropPinnedUntil,
 			&i.AuthorName,
 			&i.EditorName,
+			&i.Targets,
 		); err != nil {
 			return nil, err
 		}
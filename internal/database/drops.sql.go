@@ -13,8 +13,103 @@ import (
 	"github.com/google/uuid"
 )
 
+const approveDrop = `-- name: ApproveDrop :execrows
+UPDATE drops
+SET status = 'published', reviewed_by = $3, reviewed_at = NOW(), rejection_reason = NULL
+WHERE id = $1 AND school_id = $2 AND status = 'pending_approval'
+`
+
+type ApproveDropParams struct {
+	ID         uuid.UUID     `json:"id"`
+	SchoolID   uuid.UUID     `json:"school_id"`
+	ReviewedBy uuid.NullUUID `json:"reviewed_by"`
+}
+
+func (q *Queries) ApproveDrop(ctx context.Context, arg ApproveDropParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, approveDrop, arg.ID, arg.SchoolID, arg.ReviewedBy)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const claimDueUrgentDropPushes = `-- name: ClaimDueUrgentDropPushes :many
+WITH claimed AS (
+    INSERT INTO urgent_drop_pushes (drop_id, school_id)
+    SELECT d.id, d.school_id FROM drops d
+    WHERE d.priority = 'urgent' AND d.status = 'published' AND d.post_date <= NOW() AND d.expire_date > NOW()
+        AND NOT EXISTS (SELECT 1 FROM urgent_drop_pushes p WHERE p.drop_id = d.id)
+    ON CONFLICT (drop_id) DO NOTHING
+    RETURNING drop_id
+)
+SELECT d.id, d.user_id, d.title, d.content, d.created_at, d.updated_at, d.post_date, d.expire_date, d.edited_by, d.school_id, d.status, d.reviewed_by, d.reviewed_at, d.rejection_reason, d.priority, d.pinned_until FROM drops d JOIN claimed c ON c.drop_id = d.id ORDER BY d.post_date
+`
+
+// Claims the push for every live urgent drop, in any school, that hasn't been pushed yet
+func (q *Queries) ClaimDueUrgentDropPushes(ctx context.Context) ([]Drop, error) {
+	rows, err := q.db.QueryContext(ctx, claimDueUrgentDropPushes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Drop
+	for rows.Next() {
+		var i Drop
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Title,
+			&i.Content,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.PostDate,
+			&i.ExpireDate,
+			&i.EditedBy,
+			&i.SchoolID,
+			&i.Status,
+			&i.ReviewedBy,
+			&i.ReviewedAt,
+			&i.RejectionReason,
+			&i.Priority,
+			&i.PinnedUntil,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const claimUrgentDropPush = `-- name: ClaimUrgentDropPush :execrows
+INSERT INTO urgent_drop_pushes (drop_id, school_id)
+SELECT id, school_id FROM drops
+WHERE id = $1 AND school_id = $2 AND priority = 'urgent' AND status = 'published'
+    AND post_date <= NOW() AND expire_date > NOW()
+ON CONFLICT (drop_id) DO NOTHING
+`
+
+type ClaimUrgentDropPushParams struct {
+	ID       uuid.UUID `json:"id"`
+	SchoolID uuid.UUID `json:"school_id"`
+}
+
+// Claims the push for a live urgent drop that hasn't been pushed yet. Whoever claims it sends it.
+func (q *Queries) ClaimUrgentDropPush(ctx context.Context, arg ClaimUrgentDropPushParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, claimUrgentDropPush, arg.ID, arg.SchoolID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const createDrop = `-- name: CreateDrop :one
-INSERT INTO drops (id, user_id, school_id, title, content, created_at, updated_at, post_date, expire_date)
+INSERT INTO drops (id, user_id, school_id, title, content, created_at, updated_at, post_date, expire_date, status, priority, pinned_until)
 VALUES (
     gen_random_uuid(),
     $1,
@@ -24,18 +119,24 @@ VALUES (
     NOW(),
     NOW(),
     $5,
-    $6   
+    $6,
+    $7,
+    $8,
+    $9
 )
-RETURNING id, user_id, title, content, created_at, updated_at, post_date, expire_date, edited_by, school_id
+RETURNING id, user_id, title, content, created_at, updated_at, post_date, expire_date, edited_by, school_id, status, reviewed_by, reviewed_at, rejection_reason, priority, pinned_until
 `
 
 type CreateDropParams struct {
-	UserID     uuid.UUID `json:"user_id"`
-	SchoolID   uuid.UUID `json:"school_id"`
-	Title      string    `json:"title"`
-	Content    string    `json:"content"`
-	PostDate   time.Time `json:"post_date"`
-	ExpireDate time.Time `json:"expire_date"`
+	UserID      uuid.UUID    `json:"user_id"`
+	SchoolID    uuid.UUID    `json:"school_id"`
+	Title       string       `json:"title"`
+	Content     string       `json:"content"`
+	PostDate    time.Time    `json:"post_date"`
+	ExpireDate  time.Time    `json:"expire_date"`
+	Status      DropStatus   `json:"status"`
+	Priority    DropPriority `json:"priority"`
+	PinnedUntil sql.NullTime `json:"pinned_until"`
 }
 
 func (q *Queries) CreateDrop(ctx context.Context, arg CreateDropParams) (Drop, error) {
@@ -46,6 +147,9 @@ func (q *Queries) CreateDrop(ctx context.Context, arg CreateDropParams) (Drop, e
 		arg.Content,
 		arg.PostDate,
 		arg.ExpireDate,
+		arg.Status,
+		arg.Priority,
+		arg.PinnedUntil,
 	)
 	var i Drop
 	err := row.Scan(
@@ -59,6 +163,12 @@ func (q *Queries) CreateDrop(ctx context.Context, arg CreateDropParams) (Drop, e
 		&i.ExpireDate,
 		&i.EditedBy,
 		&i.SchoolID,
+		&i.Status,
+		&i.ReviewedBy,
+		&i.ReviewedAt,
+		&i.RejectionReason,
+		&i.Priority,
+		&i.PinnedUntil,
 	)
 	return i, err
 }
@@ -78,7 +188,7 @@ func (q *Queries) DeleteDrop(ctx context.Context, arg DeleteDropParams) error {
 }
 
 const getActiveDrops = `-- name: GetActiveDrops :many
-SELECT id, user_id, title, content, created_at, updated_at, post_date, expire_date, edited_by, school_id FROM drops WHERE expire_date > NOW() AND school_id = $1 ORDER BY post_date DESC
+SELECT id, user_id, title, content, created_at, updated_at, post_date, expire_date, edited_by, school_id, status, reviewed_by, reviewed_at, rejection_reason, priority, pinned_until FROM drops WHERE expire_date > NOW() AND school_id = $1 AND status = 'published' ORDER BY post_date DESC
 `
 
 func (q *Queries) GetActiveDrops(ctx context.Context, schoolID uuid.UUID) ([]Drop, error) {
@@ -101,6 +211,12 @@ func (q *Queries) GetActiveDrops(ctx context.Context, schoolID uuid.UUID) ([]Dro
 			&i.ExpireDate,
 			&i.EditedBy,
 			&i.SchoolID,
+			&i.Status,
+			&i.ReviewedBy,
+			&i.ReviewedAt,
+			&i.RejectionReason,
+			&i.Priority,
+			&i.PinnedUntil,
 		); err != nil {
 			return nil, err
 		}
@@ -116,7 +232,7 @@ func (q *Queries) GetActiveDrops(ctx context.Context, schoolID uuid.UUID) ([]Dro
 }
 
 const getDropByID = `-- name: GetDropByID :one
-SELECT id, user_id, title, content, created_at, updated_at, post_date, expire_date, edited_by, school_id FROM drops WHERE id = $1 AND school_id = $2
+SELECT id, user_id, title, content, created_at, updated_at, post_date, expire_date, edited_by, school_id, status, reviewed_by, reviewed_at, rejection_reason, priority, pinned_until FROM drops WHERE id = $1 AND school_id = $2
 `
 
 type GetDropByIDParams struct {
@@ -138,11 +254,17 @@ func (q *Queries) GetDropByID(ctx context.Context, arg GetDropByIDParams) (Drop,
 		&i.ExpireDate,
 		&i.EditedBy,
 		&i.SchoolID,
+		&i.Status,
+		&i.ReviewedBy,
+		&i.ReviewedAt,
+		&i.RejectionReason,
+		&i.Priority,
+		&i.PinnedUntil,
 	)
 	return i, err
 }
 
-const getDropWithTargetsByID = `-- name: GetDropWithTargetsByID :many
+const getDropWithTargetsByID = `-- name: GetDropWithTargetsByID :one
 SELECT
     d.id AS drop_id,
     d.user_id AS drop_user_id,
@@ -150,46 +272,30 @@ SELECT
     d.content AS drop_content,
     d.post_date AS drop_post_date,
     d.expire_date AS drop_expire_date,
-    d.created_at AS drop_created_at,
     d.updated_at AS drop_updated_at,
-    d.edited_by AS drop_edited_by,
-    -- Target details (will be NULL if drop has no targets)
-    dt.type AS target_type,
-    dt.target_id AS target_id,
-    -- Target name (will be NULL if no target or name missing)
-    COALESCE(
-        cls.class_name,
-        yg.year_group_name,
-        div.division_name,
-        p.surname || ', ' || p.first_name,
-        'General' -- fallback
-    ) AS target_name,
-    COALESCE(cls.class_name, yg.year_group_name, div.division_name, p.surname || ', ' || p.first_name, 'General') AS target_name,
+    d.status AS drop_status,
+    d.rejection_reason AS drop_rejection_reason,
+    d.priority AS drop_priority,
+    d.pinned_until AS drop_pinned_until,
     -- Author Name (Concatenated, assumes author exists)
     COALESCE(CONCAT_WS(' ', author.first_name, author.surname), 'Unknown Author')::text AS author_name,
-    -- Editor Name (Concatenated, handles NULL editor via LEFT JOIN + COALESCE on final result)
-    COALESCE(CONCAT_WS(' ', editor.first_name,  editor.surname))::text AS editor_name -- This might be NULL if no editor
+    -- Editor Name (empty if the drop has not been edited)
+    CONCAT_WS(' ', editor.first_name, editor.surname)::text AS editor_name,
+    -- Targets as a JSON array of {"type", "id", "name"} objects, in target type order
+    COALESCE((
+        SELECT json_agg(json_build_object('type', tn.type, 'id', tn.target_id, 'name', tn.name) ORDER BY tn.type, tn.target_id)
+        FROM drop_target_names tn
+        WHERE tn.drop_id = d.id
+    ), '[]')::json AS targets
 FROM
     drops d
-LEFT JOIN
-    drop_targets dt ON d.id = dt.drop_id
-LEFT JOIN
-    classes cls ON dt.type = 'Class' AND dt.target_id = cls.id
-LEFT JOIN
-    year_groups yg ON dt.type = 'YearGroup' AND dt.target_id = yg.id
-LEFT JOIN
-    divisions div ON dt.type = 'Division' AND dt.target_id = div.id
-LEFT JOIN
-    pupils p ON dt.type = 'Student' AND dt.target_id = p.id
 LEFT JOIN
     users AS author ON d.user_id = author.id
 LEFT JOIN
-    users AS editor on d.edited_by = editor.id
+    users AS editor ON d.edited_by = editor.id
 WHERE
     d.id = $1 -- Filter for the specific drop ID
 AND d.school_id = $2
-ORDER BY
-    dt.type
 `
 
 type GetDropWithTargetsByIDParams struct {
@@ -198,60 +304,42 @@ type GetDropWithTargetsByIDParams struct {
 }
 
 type GetDropWithTargetsByIDRow struct {
-	DropID         uuid.UUID      `json:"drop_id"`
-	DropUserID     uuid.UUID      `json:"drop_user_id"`
-	DropTitle      string         `json:"drop_title"`
-	DropContent    string         `json:"drop_content"`
-	DropPostDate   time.Time      `json:"drop_post_date"`
-	DropExpireDate time.Time      `json:"drop_expire_date"`
-	DropCreatedAt  time.Time      `json:"drop_created_at"`
-	DropUpdatedAt  time.Time      `json:"drop_updated_at"`
-	DropEditedBy   uuid.NullUUID  `json:"drop_edited_by"`
-	TargetType     NullTargetType `json:"target_type"`
-	TargetID       sql.NullInt32  `json:"target_id"`
-	TargetName     string         `json:"target_name"`
-	TargetName_2   string         `json:"target_name_2"`
-	AuthorName     string         `json:"author_name"`
-	EditorName     string         `json:"editor_name"`
+	DropID              uuid.UUID      `json:"drop_id"`
+	DropUserID          uuid.UUID      `json:"drop_user_id"`
+	DropTitle           string         `json:"drop_title"`
+	DropContent         string         `json:"drop_content"`
+	DropPostDate        time.Time      `json:"drop_post_date"`
+	DropExpireDate      time.Time      `json:"drop_expire_date"`
+	DropUpdatedAt       time.Time      `json:"drop_updated_at"`
+	DropStatus          DropStatus     `json:"drop_status"`
+	DropRejectionReason sql.NullString `json:"drop_rejection_reason"`
+	DropPriority        DropPriority   `json:"drop_priority"`
+	DropPinnedUntil     sql.NullTime   `json:"drop_pinned_until"`
+	AuthorName          string         `json:"author_name"`
+	EditorName          string         `json:"editor_name"`
+	Targets             DropTargets    `json:"targets"`
 }
 
-func (q *Queries) GetDropWithTargetsByID(ctx context.Context, arg GetDropWithTargetsByIDParams) ([]GetDropWithTargetsByIDRow, error) {
-	rows, err := q.db.QueryContext(ctx, getDropWithTargetsByID, arg.ID, arg.SchoolID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []GetDropWithTargetsByIDRow
-	for rows.Next() {
-		var i GetDropWithTargetsByIDRow
-		if err := rows.Scan(
-			&i.DropID,
-			&i.DropUserID,
-			&i.DropTitle,
-			&i.DropContent,
-			&i.DropPostDate,
-			&i.DropExpireDate,
-			&i.DropCreatedAt,
-			&i.DropUpdatedAt,
-			&i.DropEditedBy,
-			&i.TargetType,
-			&i.TargetID,
-			&i.TargetName,
-			&i.TargetName_2,
-			&i.AuthorName,
-			&i.EditorName,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+func (q *Queries) GetDropWithTargetsByID(ctx context.Context, arg GetDropWithTargetsByIDParams) (GetDropWithTargetsByIDRow, error) {
+	row := q.db.QueryRowContext(ctx, getDropWithTargetsByID, arg.ID, arg.SchoolID)
+	var i GetDropWithTargetsByIDRow
+	err := row.Scan(
+		&i.DropID,
+		&i.DropUserID,
+		&i.DropTitle,
+		&i.DropContent,
+		&i.DropPostDate,
+		&i.DropExpireDate,
+		&i.DropUpdatedAt,
+		&i.DropStatus,
+		&i.DropRejectionReason,
+		&i.DropPriority,
+		&i.DropPinnedUntil,
+		&i.AuthorName,
+		&i.EditorName,
+		&i.Targets,
+	)
+	return i, err
 }
 
 const getUserIdFromDropID = `-- name: GetUserIdFromDropID :one
@@ -270,20 +358,98 @@ func (q *Queries) GetUserIdFromDropID(ctx context.Context, arg GetUserIdFromDrop
 	return user_id, err
 }
 
+const markLiveUrgentDropsPushed = `-- name: MarkLiveUrgentDropsPushed :exec
+INSERT INTO urgent_drop_pushes (drop_id, school_id)
+SELECT id, school_id FROM drops
+WHERE school_id = $1 AND priority = 'urgent' AND status = 'published' AND post_date <= NOW()
+ON CONFLICT (drop_id) DO NOTHING
+`
+
+// Marks a school's live urgent drops as pushed without sending them, e.g. after importing them
+func (q *Queries) MarkLiveUrgentDropsPushed(ctx context.Context, schoolID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, markLiveUrgentDropsPushed, schoolID)
+	return err
+}
+
+const rejectDrop = `-- name: RejectDrop :execrows
+UPDATE drops
+SET status = 'rejected', reviewed_by = $3, reviewed_at = NOW(), rejection_reason = $4
+WHERE id = $1 AND school_id = $2 AND status = 'pending_approval'
+`
+
+type RejectDropParams struct {
+	ID              uuid.UUID      `json:"id"`
+	SchoolID        uuid.UUID      `json:"school_id"`
+	ReviewedBy      uuid.NullUUID  `json:"reviewed_by"`
+	RejectionReason sql.NullString `json:"rejection_reason"`
+}
+
+func (q *Queries) RejectDrop(ctx context.Context, arg RejectDropParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, rejectDrop,
+		arg.ID,
+		arg.SchoolID,
+		arg.ReviewedBy,
+		arg.RejectionReason,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const resubmitDrop = `-- name: ResubmitDrop :execrows
+UPDATE drops
+SET status = 'pending_approval', reviewed_by = NULL, reviewed_at = NULL, rejection_reason = NULL
+WHERE id = $1 AND school_id = $2 AND status = 'rejected'
+`
+
+type ResubmitDropParams struct {
+	ID       uuid.UUID `json:"id"`
+	SchoolID uuid.UUID `json:"school_id"`
+}
+
+// Sends a rejected drop back to the approval queue
+func (q *Queries) ResubmitDrop(ctx context.Context, arg ResubmitDropParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, resubmitDrop, arg.ID, arg.SchoolID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setDropStatus = `-- name: SetDropStatus :exec
+UPDATE drops
+SET status = $3, reviewed_by = NULL, reviewed_at = NULL, rejection_reason = NULL
+WHERE id = $1 AND school_id = $2
+`
+
+type SetDropStatusParams struct {
+	ID       uuid.UUID  `json:"id"`
+	SchoolID uuid.UUID  `json:"school_id"`
+	Status   DropStatus `json:"status"`
+}
+
+func (q *Queries) SetDropStatus(ctx context.Context, arg SetDropStatusParams) error {
+	_, err := q.db.ExecContext(ctx, setDropStatus, arg.ID, arg.SchoolID, arg.Status)
+	return err
+}
+
 const updateDrop = `-- name: UpdateDrop :exec
 UPDATE drops
-SET title = $3, content = $4, post_date = $5, expire_date = $6, updated_at = NOW(), edited_by = $7
+SET title = $3, content = $4, post_date = $5, expire_date = $6, updated_at = NOW(), edited_by = $7, priority = $8, pinned_until = $9
 WHERE id = $1 and school_id = $2
 `
 
 type UpdateDropParams struct {
-	ID         uuid.UUID     `json:"id"`
-	SchoolID   uuid.UUID     `json:"school_id"`
-	Title      string        `json:"title"`
-	Content    string        `json:"content"`
-	PostDate   time.Time     `json:"post_date"`
-	ExpireDate time.Time     `json:"expire_date"`
-	EditedBy   uuid.NullUUID `json:"edited_by"`
+	ID          uuid.UUID     `json:"id"`
+	SchoolID    uuid.UUID     `json:"school_id"`
+	Title       string        `json:"title"`
+	Content     string        `json:"content"`
+	PostDate    time.Time     `json:"post_date"`
+	ExpireDate  time.Time     `json:"expire_date"`
+	EditedBy    uuid.NullUUID `json:"edited_by"`
+	Priority    DropPriority  `json:"priority"`
+	PinnedUntil sql.NullTime  `json:"pinned_until"`
 }
 
 func (q *Queries) UpdateDrop(ctx context.Context, arg UpdateDropParams) error {
@@ -295,6 +461,8 @@ func (q *Queries) UpdateDrop(ctx context.Context, arg UpdateDropParams) error {
 		arg.PostDate,
 		arg.ExpireDate,
 		arg.EditedBy,
+		arg.Priority,
+		arg.PinnedUntil,
 	)
 	return err
 }
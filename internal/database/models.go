@@ -14,6 +14,135 @@ import (
 	"github.com/sqlc-dev/pqtype"
 )
 
+type ClassStaffRole string
+
+const (
+	ClassStaffRoleTutor             ClassStaffRole = "tutor"
+	ClassStaffRoleSubjectTeacher    ClassStaffRole = "subject_teacher"
+	ClassStaffRoleTeachingAssistant ClassStaffRole = "teaching_assistant"
+)
+
+func (e *ClassStaffRole) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = ClassStaffRole(s)
+	case string:
+		*e = ClassStaffRole(s)
+	default:
+		return fmt.Errorf("unsupported scan type for ClassStaffRole: %T", src)
+	}
+	return nil
+}
+
+type NullClassStaffRole struct {
+	ClassStaffRole ClassStaffRole `json:"class_staff_role"`
+	Valid          bool           `json:"valid"` // Valid is true if ClassStaffRole is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullClassStaffRole) Scan(value interface{}) error {
+	if value == nil {
+		ns.ClassStaffRole, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.ClassStaffRole.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullClassStaffRole) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.ClassStaffRole), nil
+}
+
+type DropPriority string
+
+const (
+	DropPriorityNormal    DropPriority = "normal"
+	DropPriorityImportant DropPriority = "important"
+	DropPriorityUrgent    DropPriority = "urgent"
+)
+
+func (e *DropPriority) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = DropPriority(s)
+	case string:
+		*e = DropPriority(s)
+	default:
+		return fmt.Errorf("unsupported scan type for DropPriority: %T", src)
+	}
+	return nil
+}
+
+type NullDropPriority struct {
+	DropPriority DropPriority `json:"drop_priority"`
+	Valid        bool         `json:"valid"` // Valid is true if DropPriority is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullDropPriority) Scan(value interface{}) error {
+	if value == nil {
+		ns.DropPriority, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.DropPriority.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullDropPriority) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.DropPriority), nil
+}
+
+type DropStatus string
+
+const (
+	DropStatusPublished       DropStatus = "published"
+	DropStatusPendingApproval DropStatus = "pending_approval"
+	DropStatusRejected        DropStatus = "rejected"
+)
+
+func (e *DropStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = DropStatus(s)
+	case string:
+		*e = DropStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for DropStatus: %T", src)
+	}
+	return nil
+}
+
+type NullDropStatus struct {
+	DropStatus DropStatus `json:"drop_status"`
+	Valid      bool       `json:"valid"` // Valid is true if DropStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullDropStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.DropStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.DropStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullDropStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.DropStatus), nil
+}
+
 type TargetType string
 
 const (
@@ -23,6 +152,7 @@ const (
 	TargetTypeDivision    TargetType = "Division"
 	TargetTypeCustomGroup TargetType = "CustomGroup"
 	TargetTypeGeneral     TargetType = "General"
+	TargetTypeLesson      TargetType = "Lesson"
 )
 
 func (e *TargetType) Scan(src interface{}) error {
@@ -63,8 +193,13 @@ func (ns NullTargetType) Value() (driver.Value, error) {
 type UserRole string
 
 const (
-	UserRoleUser  UserRole = "user"
-	UserRoleAdmin UserRole = "admin"
+	UserRoleUser          UserRole = "user"
+	UserRoleAdmin         UserRole = "admin"
+	UserRoleHeadOfYear    UserRole = "head_of_year"
+	UserRoleOffice        UserRole = "office"
+	UserRoleReadOnly      UserRole = "read_only"
+	UserRoleTrainee       UserRole = "trainee"
+	UserRolePlatformAdmin UserRole = "platform_admin"
 )
 
 func (e *UserRole) Scan(src interface{}) error {
@@ -102,14 +237,26 @@ func (ns NullUserRole) Value() (driver.Value, error) {
 	return string(ns.UserRole), nil
 }
 
+type AttachmentDeletion struct {
+	StorageKey string    `json:"storage_key"`
+	DeletedAt  time.Time `json:"deleted_at"`
+}
+
 type Class struct {
 	ID          int32         `json:"id"`
 	ClassName   string        `json:"class_name"`
 	YearGroupID sql.NullInt32 `json:"year_group_id"`
-	TeacherID   uuid.NullUUID `json:"teacher_id"`
 	SchoolID    uuid.UUID     `json:"school_id"`
 }
 
+type ClassStaff struct {
+	ClassID   int32          `json:"class_id"`
+	UserID    uuid.UUID      `json:"user_id"`
+	SchoolID  uuid.UUID      `json:"school_id"`
+	Role      ClassStaffRole `json:"role"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
 type CustomGroup struct {
 	ID        int32         `json:"id"`
 	GroupName string        `json:"group_name"`
@@ -129,16 +276,44 @@ type Division struct {
 }
 
 type Drop struct {
-	ID         uuid.UUID     `json:"id"`
-	UserID     uuid.UUID     `json:"user_id"`
-	Title      string        `json:"title"`
-	Content    string        `json:"content"`
-	CreatedAt  time.Time     `json:"created_at"`
-	UpdatedAt  time.Time     `json:"updated_at"`
-	PostDate   time.Time     `json:"post_date"`
-	ExpireDate time.Time     `json:"expire_date"`
-	EditedBy   uuid.NullUUID `json:"edited_by"`
-	SchoolID   uuid.UUID     `json:"school_id"`
+	ID              uuid.UUID      `json:"id"`
+	UserID          uuid.UUID      `json:"user_id"`
+	Title           string         `json:"title"`
+	Content         string         `json:"content"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	PostDate        time.Time      `json:"post_date"`
+	ExpireDate      time.Time      `json:"expire_date"`
+	EditedBy        uuid.NullUUID  `json:"edited_by"`
+	SchoolID        uuid.UUID      `json:"school_id"`
+	Status          DropStatus     `json:"status"`
+	ReviewedBy      uuid.NullUUID  `json:"reviewed_by"`
+	ReviewedAt      sql.NullTime   `json:"reviewed_at"`
+	RejectionReason sql.NullString `json:"rejection_reason"`
+	Priority        DropPriority   `json:"priority"`
+	PinnedUntil     sql.NullTime   `json:"pinned_until"`
+}
+
+type DropAttachment struct {
+	ID          int32         `json:"id"`
+	DropID      uuid.UUID     `json:"drop_id"`
+	SchoolID    uuid.UUID     `json:"school_id"`
+	Filename    string        `json:"filename"`
+	ContentType string        `json:"content_type"`
+	SizeBytes   int64         `json:"size_bytes"`
+	StorageKey  string        `json:"storage_key"`
+	UploadedBy  uuid.NullUUID `json:"uploaded_by"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
+
+type DropAudience struct {
+	DropID uuid.UUID `json:"drop_id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+type DropAudienceExpected struct {
+	DropID uuid.UUID `json:"drop_id"`
+	UserID uuid.UUID `json:"user_id"`
 }
 
 type DropConfirmation struct {
@@ -149,6 +324,12 @@ type DropConfirmation struct {
 	SchoolID    uuid.UUID    `json:"school_id"`
 }
 
+type DropTag struct {
+	DropID   uuid.UUID `json:"drop_id"`
+	TagID    int32     `json:"tag_id"`
+	SchoolID uuid.UUID `json:"school_id"`
+}
+
 type DropTarget struct {
 	ID       int32         `json:"id"`
 	DropID   uuid.UUID     `json:"drop_id"`
@@ -157,6 +338,13 @@ type DropTarget struct {
 	SchoolID uuid.UUID     `json:"school_id"`
 }
 
+type DropTargetName struct {
+	DropID   uuid.UUID  `json:"drop_id"`
+	Type     TargetType `json:"type"`
+	TargetID int32      `json:"target_id"`
+	Name     string     `json:"name"`
+}
+
 type DropView struct {
 	DropID   uuid.UUID `json:"drop_id"`
 	UserID   uuid.UUID `json:"user_id"`
@@ -164,6 +352,25 @@ type DropView struct {
 	SchoolID uuid.UUID `json:"school_id"`
 }
 
+type Lesson struct {
+	ID       int32         `json:"id"`
+	SchoolID uuid.UUID     `json:"school_id"`
+	CycleDay int32         `json:"cycle_day"`
+	PeriodID int32         `json:"period_id"`
+	ClassID  int32         `json:"class_id"`
+	UserID   uuid.UUID     `json:"user_id"`
+	RoomID   sql.NullInt32 `json:"room_id"`
+}
+
+type LessonTarget struct {
+	ID         int32         `json:"id"`
+	SchoolID   uuid.UUID     `json:"school_id"`
+	ClassID    sql.NullInt32 `json:"class_id"`
+	RoomID     sql.NullInt32 `json:"room_id"`
+	PeriodID   int32         `json:"period_id"`
+	LessonDate time.Time     `json:"lesson_date"`
+}
+
 type Pupil struct {
 	ID        int32         `json:"id"`
 	FirstName string        `json:"first_name"`
@@ -172,15 +379,41 @@ type Pupil struct {
 	SchoolID  uuid.UUID     `json:"school_id"`
 }
 
+type PupilClassHistory struct {
+	ID        int32         `json:"id"`
+	PupilID   int32         `json:"pupil_id"`
+	SchoolID  uuid.UUID     `json:"school_id"`
+	ClassID   sql.NullInt32 `json:"class_id"`
+	ClassName string        `json:"class_name"`
+	StartedAt sql.NullTime  `json:"started_at"`
+	EndedAt   sql.NullTime  `json:"ended_at"`
+}
+
 type RefreshToken struct {
-	Token     string       `json:"token"`
-	CreatedAt time.Time    `json:"created_at"`
-	UpdatedAt time.Time    `json:"updated_at"`
-	UserID    uuid.UUID    `json:"user_id"`
-	ExpiresAt time.Time    `json:"expires_at"`
-	RevokedAt sql.NullTime `json:"revoked_at"`
-	Role      UserRole     `json:"role"`
-	SchoolID  uuid.UUID    `json:"school_id"`
+	Token            string       `json:"token"`
+	CreatedAt        time.Time    `json:"created_at"`
+	UpdatedAt        time.Time    `json:"updated_at"`
+	UserID           uuid.UUID    `json:"user_id"`
+	ExpiresAt        time.Time    `json:"expires_at"`
+	RevokedAt        sql.NullTime `json:"revoked_at"`
+	Role             UserRole     `json:"role"`
+	SchoolID         uuid.UUID    `json:"school_id"`
+	RememberMe       bool         `json:"remember_me"`
+	LastUsedAt       time.Time    `json:"last_used_at"`
+	SessionExpiresAt time.Time    `json:"session_expires_at"`
+}
+
+type RoleScope struct {
+	UserID   uuid.UUID  `json:"user_id"`
+	SchoolID uuid.UUID  `json:"school_id"`
+	Type     TargetType `json:"type"`
+	TargetID int32      `json:"target_id"`
+}
+
+type Room struct {
+	ID       int32     `json:"id"`
+	SchoolID uuid.UUID `json:"school_id"`
+	Name     string    `json:"name"`
 }
 
 type School struct {
@@ -193,10 +426,39 @@ type School struct {
 	ContactPhone sql.NullString        `json:"contact_phone"`
 	Subdomain    sql.NullString        `json:"subdomain"`
 	LogoUrl      sql.NullString        `json:"logo_url"`
-	Status       sql.NullString        `json:"status"`
+	Status       string                `json:"status"`
 	Settings     pqtype.NullRawMessage `json:"settings"`
 }
 
+type Tag struct {
+	ID        int32     `json:"id"`
+	SchoolID  uuid.UUID `json:"school_id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type TagSubscription struct {
+	UserID   uuid.UUID `json:"user_id"`
+	SchoolID uuid.UUID `json:"school_id"`
+	TagID    int32     `json:"tag_id"`
+	Muted    bool      `json:"muted"`
+}
+
+type TargetPupil struct {
+	SchoolID uuid.UUID     `json:"school_id"`
+	Type     TargetType    `json:"type"`
+	TargetID sql.NullInt32 `json:"target_id"`
+	PupilID  int32         `json:"pupil_id"`
+}
+
+type TargetStaff struct {
+	SchoolID uuid.UUID     `json:"school_id"`
+	Type     TargetType    `json:"type"`
+	TargetID sql.NullInt32 `json:"target_id"`
+	UserID   uuid.UUID     `json:"user_id"`
+}
+
 type TargetSubscription struct {
 	UserID   uuid.UUID  `json:"user_id"`
 	Type     TargetType `json:"type"`
@@ -204,17 +466,36 @@ type TargetSubscription struct {
 	SchoolID uuid.UUID  `json:"school_id"`
 }
 
+type TimetableCycle struct {
+	SchoolID  uuid.UUID `json:"school_id"`
+	CycleDays int32     `json:"cycle_days"`
+	StartsOn  time.Time `json:"starts_on"`
+}
+
+type TimetablePeriod struct {
+	ID       int32     `json:"id"`
+	SchoolID uuid.UUID `json:"school_id"`
+	Name     string    `json:"name"`
+}
+
+type UrgentDropPush struct {
+	DropID   uuid.UUID `json:"drop_id"`
+	SchoolID uuid.UUID `json:"school_id"`
+	PushedAt time.Time `json:"pushed_at"`
+}
+
 type User struct {
-	ID             uuid.UUID `json:"id"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
-	Email          string    `json:"email"`
-	HashedPassword string    `json:"hashed_password"`
-	Role           UserRole  `json:"role"`
-	Title          string    `json:"title"`
-	FirstName      string    `json:"first_name"`
-	Surname        string    `json:"surname"`
-	SchoolID       uuid.UUID `json:"school_id"`
+	ID             uuid.UUID    `json:"id"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+	Email          string       `json:"email"`
+	HashedPassword string       `json:"hashed_password"`
+	Role           UserRole     `json:"role"`
+	Title          string       `json:"title"`
+	FirstName      string       `json:"first_name"`
+	Surname        string       `json:"surname"`
+	SchoolID       uuid.UUID    `json:"school_id"`
+	ErasedAt       sql.NullTime `json:"erased_at"`
 }
 
 type UserSetting struct {
@@ -116,10 +116,37 @@ SELECT p.id, p.school_id, p.first_name, p.surname, p.class_id,
 COALESCE(c.class_name, 'Unassigned') AS class_name
 FROM pupils p
 LEFT JOIN classes c ON p.class_id = c.id
-WHERE p.school_id = $1
-ORDER BY c.class_name, p.surname, p.first_name
+LEFT JOIN LATERAL (
+    SELECT CASE WHEN $1::boolean THEN COALESCE(c.class_name, 'Unassigned') ELSE '' END AS sort_class
+) k ON true
+WHERE p.school_id = $2
+    AND ($3::int IS NULL OR p.class_id = $3::int)
+    AND ($4::int IS NULL OR c.year_group_id = $4::int)
+    AND ($5::text IS NULL
+        OR ($6::boolean AND (k.sort_class, p.surname, p.first_name, p.id) < ($7::text, $5::text, $8::text, $9::int))
+        OR (NOT $6::boolean AND (k.sort_class, p.surname, p.first_name, p.id) > ($7::text, $5::text, $8::text, $9::int)))
+ORDER BY
+    CASE WHEN $6::boolean THEN k.sort_class END DESC,
+    CASE WHEN $6::boolean THEN p.surname END DESC,
+    CASE WHEN $6::boolean THEN p.first_name END DESC,
+    CASE WHEN $6::boolean THEN p.id END DESC,
+    k.sort_class, p.surname, p.first_name, p.id
+LIMIT $10
 `
 
+type GetAllPupilsParams struct {
+	SortByClass    bool           `json:"sort_by_class"`
+	SchoolID       uuid.UUID      `json:"school_id"`
+	ClassID        sql.NullInt32  `json:"class_id"`
+	YearGroupID    sql.NullInt32  `json:"year_group_id"`
+	AfterSurname   sql.NullString `json:"after_surname"`
+	SortDesc       bool           `json:"sort_desc"`
+	AfterClass     string         `json:"after_class"`
+	AfterFirstName string         `json:"after_first_name"`
+	AfterID        int32          `json:"after_id"`
+	PageLimit      int32          `json:"page_limit"`
+}
+
 type GetAllPupilsRow struct {
 	ID        int32         `json:"id"`
 	SchoolID  uuid.UUID     `json:"school_id"`
@@ -129,8 +156,19 @@ type GetAllPupilsRow struct {
 	ClassName string        `json:"class_name"`
 }
 
-func (q *Queries) GetAllPupils(ctx context.Context, schoolID uuid.UUID) ([]GetAllPupilsRow, error) {
-	rows, err := q.db.QueryContext(ctx, getAllPupils, schoolID)
+func (q *Queries) GetAllPupils(ctx context.Context, arg GetAllPupilsParams) ([]GetAllPupilsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getAllPupils,
+		arg.SortByClass,
+		arg.SchoolID,
+		arg.ClassID,
+		arg.YearGroupID,
+		arg.AfterSurname,
+		arg.SortDesc,
+		arg.AfterClass,
+		arg.AfterFirstName,
+		arg.AfterID,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -160,7 +198,7 @@ func (q *Queries) GetAllPupils(ctx context.Context, schoolID uuid.UUID) ([]GetAl
 }
 
 const getPupil = `-- name: GetPupil :one
-SELECT pupils.id, first_name, surname, class_id, pupils.school_id, c.id, class_name, year_group_id, teacher_id, c.school_id FROM pupils LEFT JOIN classes c ON pupils.class_id = c.id
+SELECT pupils.id, first_name, surname, class_id, pupils.school_id, c.id, class_name, year_group_id, c.school_id FROM pupils LEFT JOIN classes c ON pupils.class_id = c.id
 WHERE pupils.id = $1 and pupils.school_id = $2
 `
 
@@ -178,7 +216,6 @@ type GetPupilRow struct {
 	ID_2        sql.NullInt32  `json:"id_2"`
 	ClassName   sql.NullString `json:"class_name"`
 	YearGroupID sql.NullInt32  `json:"year_group_id"`
-	TeacherID   uuid.NullUUID  `json:"teacher_id"`
 	SchoolID_2  uuid.NullUUID  `json:"school_id_2"`
 }
 
@@ -194,7 +231,6 @@ func (q *Queries) GetPupil(ctx context.Context, arg GetPupilParams) (GetPupilRow
 		&i.ID_2,
 		&i.ClassName,
 		&i.YearGroupID,
-		&i.TeacherID,
 		&i.SchoolID_2,
 	)
 	return i, err
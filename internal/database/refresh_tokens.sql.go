@@ -13,7 +13,7 @@ import (
 )
 
 const createRefreshToken = `-- name: CreateRefreshToken :one
-INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, school_id, role, expires_at, revoked_at)
+INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, school_id, role, expires_at, revoked_at, remember_me, last_used_at, session_expires_at)
 VALUES (
     $1,
     NOW(),
@@ -22,17 +22,23 @@ VALUES (
     $3,
     $4,
     $5,
-    NULL
+    NULL,
+    $6,
+    $7,
+    $8
 )
-RETURNING token, created_at, updated_at, user_id, expires_at, revoked_at, role, school_id
+RETURNING token, created_at, updated_at, user_id, expires_at, revoked_at, role, school_id, remember_me, last_used_at, session_expires_at
 `
 
 type CreateRefreshTokenParams struct {
-	Token     string    `json:"token"`
-	UserID    uuid.UUID `json:"user_id"`
-	SchoolID  uuid.UUID `json:"school_id"`
-	Role      UserRole  `json:"role"`
-	ExpiresAt time.Time `json:"expires_at"`
+	Token            string    `json:"token"`
+	UserID           uuid.UUID `json:"user_id"`
+	SchoolID         uuid.UUID `json:"school_id"`
+	Role             UserRole  `json:"role"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RememberMe       bool      `json:"remember_me"`
+	LastUsedAt       time.Time `json:"last_used_at"`
+	SessionExpiresAt time.Time `json:"session_expires_at"`
 }
 
 func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (RefreshToken, error) {
@@ -42,6 +48,9 @@ func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshToken
 		arg.SchoolID,
 		arg.Role,
 		arg.ExpiresAt,
+		arg.RememberMe,
+		arg.LastUsedAt,
+		arg.SessionExpiresAt,
 	)
 	var i RefreshToken
 	err := row.Scan(
@@ -53,12 +62,15 @@ func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshToken
 		&i.RevokedAt,
 		&i.Role,
 		&i.SchoolID,
+		&i.RememberMe,
+		&i.LastUsedAt,
+		&i.SessionExpiresAt,
 	)
 	return i, err
 }
 
 const getRefreshToken = `-- name: GetRefreshToken :one
-SELECT token, created_at, updated_at, user_id, expires_at, revoked_at, role, school_id FROM refresh_tokens
+SELECT token, created_at, updated_at, user_id, expires_at, revoked_at, role, school_id, remember_me, last_used_at, session_expires_at FROM refresh_tokens
 WHERE token = $1 and expires_at > NOW() and revoked_at IS NULL
 `
 
@@ -74,6 +86,9 @@ func (q *Queries) GetRefreshToken(ctx context.Context, token string) (RefreshTok
 		&i.RevokedAt,
 		&i.Role,
 		&i.SchoolID,
+		&i.RememberMe,
+		&i.LastUsedAt,
+		&i.SessionExpiresAt,
 	)
 	return i, err
 }
@@ -88,3 +103,20 @@ func (q *Queries) RevokeToken(ctx context.Context, token string) error {
 	_, err := q.db.ExecContext(ctx, revokeToken, token)
 	return err
 }
+
+const touchRefreshToken = `-- name: TouchRefreshToken :exec
+UPDATE refresh_tokens
+SET updated_at = NOW(), last_used_at = $2, expires_at = $3
+WHERE token = $1
+`
+
+type TouchRefreshTokenParams struct {
+	Token      string    `json:"token"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+func (q *Queries) TouchRefreshToken(ctx context.Context, arg TouchRefreshTokenParams) error {
+	_, err := q.db.ExecContext(ctx, touchRefreshToken, arg.Token, arg.LastUsedAt, arg.ExpiresAt)
+	return err
+}
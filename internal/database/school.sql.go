@@ -7,10 +7,102 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/sqlc-dev/pqtype"
 )
 
+const createSchool = `-- name: CreateSchool :one
+INSERT INTO schools (id, name, address, contact_email, contact_phone, subdomain, status)
+VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, 'active')
+RETURNING id, name, created_at, updated_at, address, contact_email, contact_phone, subdomain, logo_url, status, settings
+`
+
+type CreateSchoolParams struct {
+	Name         string         `json:"name"`
+	Address      sql.NullString `json:"address"`
+	ContactEmail sql.NullString `json:"contact_email"`
+	ContactPhone sql.NullString `json:"contact_phone"`
+	Subdomain    sql.NullString `json:"subdomain"`
+}
+
+func (q *Queries) CreateSchool(ctx context.Context, arg CreateSchoolParams) (School, error) {
+	row := q.db.QueryRowContext(ctx, createSchool,
+		arg.Name,
+		arg.Address,
+		arg.ContactEmail,
+		arg.ContactPhone,
+		arg.Subdomain,
+	)
+	var i School
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Address,
+		&i.ContactEmail,
+		&i.ContactPhone,
+		&i.Subdomain,
+		&i.LogoUrl,
+		&i.Status,
+		&i.Settings,
+	)
+	return i, err
+}
+
+const getSchoolBranding = `-- name: GetSchoolBranding :one
+SELECT name, logo_url, settings FROM schools WHERE id = $1
+`
+
+type GetSchoolBrandingRow struct {
+	Name     string                `json:"name"`
+	LogoUrl  sql.NullString        `json:"logo_url"`
+	Settings pqtype.NullRawMessage `json:"settings"`
+}
+
+func (q *Queries) GetSchoolBranding(ctx context.Context, id uuid.UUID) (GetSchoolBrandingRow, error) {
+	row := q.db.QueryRowContext(ctx, getSchoolBranding, id)
+	var i GetSchoolBrandingRow
+	err := row.Scan(&i.Name, &i.LogoUrl, &i.Settings)
+	return i, err
+}
+
+const getSchoolByID = `-- name: GetSchoolByID :one
+SELECT id, name, created_at, updated_at, address, contact_email, contact_phone, subdomain, logo_url, status, settings FROM schools WHERE id = $1
+`
+
+func (q *Queries) GetSchoolByID(ctx context.Context, id uuid.UUID) (School, error) {
+	row := q.db.QueryRowContext(ctx, getSchoolByID, id)
+	var i School
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Address,
+		&i.ContactEmail,
+		&i.ContactPhone,
+		&i.Subdomain,
+		&i.LogoUrl,
+		&i.Status,
+		&i.Settings,
+	)
+	return i, err
+}
+
+const getSchoolIDBySubdomain = `-- name: GetSchoolIDBySubdomain :one
+SELECT id FROM schools WHERE subdomain = $1
+`
+
+func (q *Queries) GetSchoolIDBySubdomain(ctx context.Context, subdomain sql.NullString) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, getSchoolIDBySubdomain, subdomain)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
 const getSchoolName = `-- name: GetSchoolName :one
 SELECT name FROM schools WHERE id = $1
 `
@@ -21,3 +113,107 @@ func (q *Queries) GetSchoolName(ctx context.Context, id uuid.UUID) (string, erro
 	err := row.Scan(&name)
 	return name, err
 }
+
+const getSchoolSettings = `-- name: GetSchoolSettings :one
+SELECT settings FROM schools WHERE id = $1
+`
+
+func (q *Queries) GetSchoolSettings(ctx context.Context, id uuid.UUID) (pqtype.NullRawMessage, error) {
+	row := q.db.QueryRowContext(ctx, getSchoolSettings, id)
+	var settings pqtype.NullRawMessage
+	err := row.Scan(&settings)
+	return settings, err
+}
+
+const getSchoolStatus = `-- name: GetSchoolStatus :one
+SELECT status FROM schools WHERE id = $1
+`
+
+func (q *Queries) GetSchoolStatus(ctx context.Context, id uuid.UUID) (string, error) {
+	row := q.db.QueryRowContext(ctx, getSchoolStatus, id)
+	var status string
+	err := row.Scan(&status)
+	return status, err
+}
+
+const getSchools = `-- name: GetSchools :many
+SELECT id, name, created_at, updated_at, address, contact_email, contact_phone, subdomain, logo_url, status, settings FROM schools ORDER BY name
+`
+
+func (q *Queries) GetSchools(ctx context.Context) ([]School, error) {
+	rows, err := q.db.QueryContext(ctx, getSchools)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []School
+	for rows.Next() {
+		var i School
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Address,
+			&i.ContactEmail,
+			&i.ContactPhone,
+			&i.Subdomain,
+			&i.LogoUrl,
+			&i.Status,
+			&i.Settings,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setSchoolSettings = `-- name: SetSchoolSettings :exec
+UPDATE schools SET settings = $2, updated_at = NOW() WHERE id = $1
+`
+
+type SetSchoolSettingsParams struct {
+	ID       uuid.UUID             `json:"id"`
+	Settings pqtype.NullRawMessage `json:"settings"`
+}
+
+func (q *Queries) SetSchoolSettings(ctx context.Context, arg SetSchoolSettingsParams) error {
+	_, err := q.db.ExecContext(ctx, setSchoolSettings, arg.ID, arg.Settings)
+	return err
+}
+
+const setSchoolStatus = `-- name: SetSchoolStatus :one
+UPDATE schools SET status = $2, updated_at = NOW() WHERE id = $1
+RETURNING id, name, created_at, updated_at, address, contact_email, contact_phone, subdomain, logo_url, status, settings
+`
+
+type SetSchoolStatusParams struct {
+	ID     uuid.UUID `json:"id"`
+	Status string    `json:"status"`
+}
+
+func (q *Queries) SetSchoolStatus(ctx context.Context, arg SetSchoolStatusParams) (School, error) {
+	row := q.db.QueryRowContext(ctx, setSchoolStatus, arg.ID, arg.Status)
+	var i School
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Address,
+		&i.ContactEmail,
+		&i.ContactPhone,
+		&i.Subdomain,
+		&i.LogoUrl,
+		&i.Status,
+		&i.Settings,
+	)
+	return i, err
+}
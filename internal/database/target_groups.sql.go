@@ -47,6 +47,23 @@ func (q *Queries) CountValidDivisionsForSchool(ctx context.Context, arg CountVal
 	return count, err
 }
 
+const countValidLessonTargetsForSchool = `-- name: CountValidLessonTargetsForSchool :one
+SELECT count(*) FROM lesson_targets
+WHERE school_id = $1 AND id = ANY($2::integer[])
+`
+
+type CountValidLessonTargetsForSchoolParams struct {
+	SchoolID uuid.UUID `json:"school_id"`
+	Column2  []int32   `json:"column_2"`
+}
+
+func (q *Queries) CountValidLessonTargetsForSchool(ctx context.Context, arg CountValidLessonTargetsForSchoolParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countValidLessonTargetsForSchool, arg.SchoolID, pq.Array(arg.Column2))
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const countValidPupilsForSchool = `-- name: CountValidPupilsForSchool :one
 SELECT count(*) FROM pupils
 WHERE school_id = $1 AND id = ANY($2::integer[])
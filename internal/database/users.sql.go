@@ -7,6 +7,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
@@ -60,7 +61,7 @@ VALUES (
     $6,
     $7
 )
-RETURNING id, created_at, updated_at, email, hashed_password, role, title, first_name, surname, school_id
+RETURNING id, created_at, updated_at, email, hashed_password, role, title, first_name, surname, school_id, erased_at
 `
 
 type CreateUserParams struct {
@@ -95,27 +96,11 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.FirstName,
 		&i.Surname,
 		&i.SchoolID,
+		&i.ErasedAt,
 	)
 	return i, err
 }
 
-const deleteUser = `-- name: DeleteUser :execrows
-DELETE FROM users WHERE id = $1 and school_id = $2
-`
-
-type DeleteUserParams struct {
-	ID       uuid.UUID `json:"id"`
-	SchoolID uuid.UUID `json:"school_id"`
-}
-
-func (q *Queries) DeleteUser(ctx context.Context, arg DeleteUserParams) (int64, error) {
-	result, err := q.db.ExecContext(ctx, deleteUser, arg.ID, arg.SchoolID)
-	if err != nil {
-		return 0, err
-	}
-	return result.RowsAffected()
-}
-
 const deleteUsers = `-- name: DeleteUsers :exec
 DELETE FROM users where school_id = $1
 `
@@ -245,9 +230,30 @@ func (q *Queries) GetUsercount(ctx context.Context, schoolID uuid.UUID) (int64,
 }
 
 const getUsers = `-- name: GetUsers :many
-SELECT id, email, role, title, first_name, surname FROM users WHERE school_id = $1
+SELECT id, email, role, title, first_name, surname FROM users
+WHERE school_id = $1 AND erased_at IS NULL
+    AND ($2::user_role IS NULL OR role = $2::user_role)
+    AND ($3::text IS NULL
+        OR ($4::boolean AND (surname, first_name, id) < ($3::text, $5::text, $6::uuid))
+        OR (NOT $4::boolean AND (surname, first_name, id) > ($3::text, $5::text, $6::uuid)))
+ORDER BY
+    CASE WHEN $4::boolean THEN surname END DESC,
+    CASE WHEN $4::boolean THEN first_name END DESC,
+    CASE WHEN $4::boolean THEN id END DESC,
+    surname, first_name, id
+LIMIT $7
 `
 
+type GetUsersParams struct {
+	SchoolID       uuid.UUID      `json:"school_id"`
+	Role           NullUserRole   `json:"role"`
+	AfterSurname   sql.NullString `json:"after_surname"`
+	SortDesc       bool           `json:"sort_desc"`
+	AfterFirstName string         `json:"after_first_name"`
+	AfterID        uuid.UUID      `json:"after_id"`
+	PageLimit      int32          `json:"page_limit"`
+}
+
 type GetUsersRow struct {
 	ID        uuid.UUID `json:"id"`
 	Email     string    `json:"email"`
@@ -257,8 +263,16 @@ type GetUsersRow struct {
 	Surname   string    `json:"surname"`
 }
 
-func (q *Queries) GetUsers(ctx context.Context, schoolID uuid.UUID) ([]GetUsersRow, error) {
-	rows, err := q.db.QueryContext(ctx, getUsers, schoolID)
+func (q *Queries) GetUsers(ctx context.Context, arg GetUsersParams) ([]GetUsersRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUsers,
+		arg.SchoolID,
+		arg.Role,
+		arg.AfterSurname,
+		arg.SortDesc,
+		arg.AfterFirstName,
+		arg.AfterID,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,146 @@
+// Package health serves the liveness (/healthz) and readiness (/readyz) endpoints used by orchestrators.
+package health
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/5tuartw/droplet/internal/helpers"
+)
+
+// Version and Commit identify the build. Set them when building:
+//
+//	go build -ldflags "-X github.com/5tuartw/droplet/internal/health.Version=1.4.0 -X github.com/5tuartw/droplet/internal/health.Commit=$(git rev-parse --short HEAD)" ./cmd/droplet
+//
+// If Commit isn't set, the VCS revision Go embeds in the binary is used where available.
+var (
+	Version = "dev"
+	Commit  = ""
+)
+
+// checkTimeout bounds each readiness check, so a hung dependency can't hang the probe
+const checkTimeout = 2 * time.Second
+
+const (
+	StatusOK          = "ok"
+	StatusDegraded    = "degraded"    // an optional dependency is failing; still serving
+	StatusUnavailable = "unavailable" // a critical dependency is failing; not ready
+	StatusFailing     = "failing"
+)
+
+// Check is one dependency the readiness probe looks at
+type Check struct {
+	Name string
+	// Critical dependencies failing make the server unready; others only mark it degraded
+	Critical bool
+	Run      func(ctx context.Context) error
+}
+
+type Checker struct {
+	checks  []Check
+	started time.Time
+}
+
+func NewChecker(checks ...Check) *Checker {
+	return &Checker{checks: checks, started: time.Now()}
+}
+
+type BuildInfo struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
+}
+
+type LivenessResponse struct {
+	Status        string    `json:"status"`
+	Build         BuildInfo `json:"build"`
+	UptimeSeconds int64     `json:"uptime_seconds"`
+}
+
+type CheckResult struct {
+	Status    string  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMS float64 `json:"latency_ms"`
+}
+
+type ReadinessResponse struct {
+	Status string                 `json:"status"`
+	Build  BuildInfo              `json:"build"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// Liveness only says the process is up and serving; it never touches dependencies,
+// so a database outage doesn't get every instance restarted.
+func (c *Checker) Liveness(w http.ResponseWriter, r *http.Request) {
+	helpers.RespondWithJSON(w, http.StatusOK, LivenessResponse{
+		Status:        StatusOK,
+		Build:         build(),
+		UptimeSeconds: int64(time.Since(c.started).Seconds()),
+	})
+}
+
+// Readiness runs every check concurrently. It answers 503 if a critical check fails and 200 otherwise,
+// with "degraded" status if an optional one fails. Errors are logged rather than returned, since the
+// endpoint is unauthenticated and errors can name internal hosts.
+func (c *Checker) Readiness(w http.ResponseWriter, r *http.Request) {
+	results := make([]CheckResult, len(c.checks))
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check.Run(ctx)
+			results[i] = CheckResult{
+				Status:    StatusOK,
+				Critical:  check.Critical,
+				LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				results[i].Status = StatusFailing
+				slog.WarnContext(r.Context(), "readiness check failed", "check", check.Name, "critical", check.Critical, "error", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	response := ReadinessResponse{Status: StatusOK, Build: build(), Checks: make(map[string]CheckResult, len(results))}
+	for i, result := range results {
+		response.Checks[c.checks[i].Name] = result
+		if result.Status == StatusOK {
+			continue
+		}
+		if result.Critical {
+			response.Status = StatusUnavailable
+		} else if response.Status == StatusOK {
+			response.Status = StatusDegraded
+		}
+	}
+
+	code := http.StatusOK
+	if response.Status == StatusUnavailable {
+		code = http.StatusServiceUnavailable
+	}
+	helpers.RespondWithJSON(w, code, response)
+}
+
+func build() BuildInfo {
+	commit := Commit
+	if commit == "" {
+		commit = "unknown"
+		if info, ok := debug.ReadBuildInfo(); ok {
+			for _, s := range info.Settings {
+				if s.Key == "vcs.revision" {
+					commit = s.Value
+				}
+			}
+		}
+	}
+	return BuildInfo{Version: Version, Commit: commit}
+}
@@ -0,0 +1,77 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/5tuartw/droplet/internal/health"
+	"github.com/stretchr/testify/require"
+)
+
+func ok(ctx context.Context) error { return nil }
+
+func failing(ctx context.Context) error { return errors.New("db.internal:5432 refused connection") }
+
+// hangs until the checker's timeout cancels it
+func hangs(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func readiness(t *testing.T, checks ...health.Check) (int, health.ReadinessResponse, string) {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	health.NewChecker(checks...).Readiness(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var body health.ReadinessResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	return rr.Code, body, rr.Body.String()
+}
+
+func TestReadiness(t *testing.T) {
+	code, body, _ := readiness(t,
+		health.Check{Name: "database", Critical: true, Run: ok},
+		health.Check{Name: "notifications", Run: ok},
+	)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, health.StatusOK, body.Status)
+	require.Equal(t, health.StatusOK, body.Checks["database"].Status)
+	require.Equal(t, "dev", body.Build.Version)
+
+	code, body, _ = readiness(t,
+		health.Check{Name: "database", Critical: true, Run: ok},
+		health.Check{Name: "notifications", Run: failing},
+	)
+	require.Equal(t, http.StatusOK, code, "optional dependencies failing doesn't take the server out of rotation")
+	require.Equal(t, health.StatusDegraded, body.Status)
+	require.Equal(t, health.StatusFailing, body.Checks["notifications"].Status)
+
+	code, body, raw := readiness(t,
+		health.Check{Name: "database", Critical: true, Run: failing},
+		health.Check{Name: "notifications", Run: failing},
+	)
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, health.StatusUnavailable, body.Status)
+	require.NotContains(t, raw, "db.internal", "errors are logged, not returned")
+}
+
+func TestReadinessTimesOut(t *testing.T) {
+	code, body, _ := readiness(t, health.Check{Name: "database", Critical: true, Run: hangs})
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, health.StatusFailing, body.Checks["database"].Status)
+}
+
+func TestLiveness(t *testing.T) {
+	rr := httptest.NewRecorder()
+	health.NewChecker(health.Check{Name: "database", Critical: true, Run: failing}).
+		Liveness(rr, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	require.Equal(t, http.StatusOK, rr.Code, "liveness ignores dependencies")
+
+	var body health.LivenessResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	require.Equal(t, health.StatusOK, body.Status)
+	require.NotEmpty(t, body.Build.Commit)
+}
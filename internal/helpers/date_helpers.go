@@ -1,6 +1,7 @@
 package helpers
 
 import (
+	"database/sql"
 	"fmt"
 	"time"
 )
@@ -20,9 +21,11 @@ func ParsePostDate(dateStrPtr *string) (time.Time, error) {
 	return time.Date(year, month, day, 0, 0, 0, 0, t.Location()), nil
 }
 
-func ParseExpireDate(dateStrPtr *string) (time.Time, error) {
+// ParseExpireDate parses an expiry date, ending at the close of that day.
+// An empty value expires defaultDays from today (the school's default drop duration).
+func ParseExpireDate(dateStrPtr *string, defaultDays int) (time.Time, error) {
 	if dateStrPtr == nil || *dateStrPtr == "" {
-		t := time.Now().AddDate(1, 0, 0)
+		t := time.Now().AddDate(0, 0, defaultDays)
 		year, month, day := t.Date()
 		return time.Date(year, month, day, 23, 59, 59, 999999999, t.Location()), nil
 	}
@@ -34,3 +37,22 @@ func ParseExpireDate(dateStrPtr *string) (time.Time, error) {
 	year, month, day := t.Date() //setting time to start of day
 	return time.Date(year, month, day, 23, 59, 59, 999999999, t.Location()), nil
 }
+
+// ParsePinnedUntil accepts either a full RFC 3339 timestamp or a YYYY-MM-DD date (pinned to the end of that day).
+// An empty value means the drop is not pinned.
+func ParsePinnedUntil(dateStrPtr *string) (sql.NullTime, error) {
+	if dateStrPtr == nil || *dateStrPtr == "" {
+		return sql.NullTime{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, *dateStrPtr); err == nil {
+		return sql.NullTime{Time: t, Valid: true}, nil
+	}
+
+	t, err := time.Parse(dateLayout, *dateStrPtr)
+	if err != nil {
+		return sql.NullTime{}, fmt.Errorf("invalid pinned_until format '%s': %w. Use YYYY-MM-DD or RFC 3339", *dateStrPtr, err)
+	}
+	year, month, day := t.Date()
+	return sql.NullTime{Time: time.Date(year, month, day, 23, 59, 59, 999999999, t.Location()), Valid: true}, nil
+}
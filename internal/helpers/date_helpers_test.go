@@ -0,0 +1,32 @@
+package helpers_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/5tuartw/droplet/internal/helpers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePinnedUntil(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	pinned, err := helpers.ParsePinnedUntil(nil)
+	require.NoError(t, err)
+	require.False(t, pinned.Valid, "no pin")
+	pinned, err = helpers.ParsePinnedUntil(str(""))
+	require.NoError(t, err)
+	require.False(t, pinned.Valid, "an empty pin clears it")
+
+	pinned, err = helpers.ParsePinnedUntil(str("2026-03-02T09:30:00Z"))
+	require.NoError(t, err)
+	require.True(t, pinned.Valid)
+	require.Equal(t, time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC), pinned.Time.UTC())
+
+	pinned, err = helpers.ParsePinnedUntil(str("2026-03-02"))
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2026, 3, 2, 23, 59, 59, 999999999, time.UTC), pinned.Time, "a date pins to the end of that day")
+
+	_, err = helpers.ParsePinnedUntil(str("next week"))
+	require.ErrorContains(t, err, "invalid pinned_until format")
+}
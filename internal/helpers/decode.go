@@ -0,0 +1,94 @@
+package helpers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/5tuartw/droplet/internal/validate"
+)
+
+// MaxJSONBodyBytes is the largest JSON request body DecodeJSON accepts
+const MaxJSONBodyBytes = 1 << 20
+
+// DecodeJSON reads the request's JSON body into dst and checks dst's validate rules.
+// The body may be at most MaxJSONBodyBytes, must hold exactly one JSON value, and may only
+// use fields dst declares. If anything is wrong it responds with a problem, listing field-level
+// errors where it can, and returns false; the handler should just return.
+func DecodeJSON(w http.ResponseWriter, r *http.Request, dst any) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, MaxJSONBodyBytes)
+	defer r.Body.Close()
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(dst)
+	if err == nil && decoder.Decode(&struct{}{}) != io.EOF {
+		err = errors.New("body must contain a single JSON value")
+	}
+	if err != nil {
+		respondWithDecodeError(w, err)
+		return false
+	}
+
+	if err := validate.Struct(dst); err != nil {
+		RespondWithValidationErrors(w, err.(validate.Errors))
+		return false
+	}
+	return true
+}
+
+// RespondWithValidationErrors rejects a request body, listing what is wrong with each field
+func RespondWithValidationErrors(w http.ResponseWriter, errs validate.Errors) {
+	problem := newProblem(w, http.StatusBadRequest, CodeValidationFailed, "The request body is invalid")
+	problem.Errors = errs
+	writeProblem(w, problem)
+}
+
+func respondWithDecodeError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	var typeErr *json.UnmarshalTypeError
+	var field string
+	switch {
+	case errors.As(err, &tooLarge):
+		RespondWithError(w, http.StatusRequestEntityTooLarge, "Request body is too large", nil)
+	case errors.Is(err, io.EOF):
+		RespondWithProblem(w, http.StatusBadRequest, CodeMalformedJSON, "Request body is empty", nil)
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		problem := newProblem(w, http.StatusBadRequest, CodeMalformedJSON, "Request body has a field of the wrong type")
+		problem.Errors = validate.Errors{{Field: typeErr.Field, Code: validate.CodeInvalidType, Message: "must be " + jsonType(typeErr.Type.Kind().String())}}
+		writeProblem(w, problem)
+	case unknownField(err, &field):
+		problem := newProblem(w, http.StatusBadRequest, CodeMalformedJSON, "Request body has an unknown field")
+		problem.Errors = validate.Errors{{Field: field, Code: validate.CodeUnknownField, Message: "is not a known field"}}
+		writeProblem(w, problem)
+	default:
+		RespondWithProblem(w, http.StatusBadRequest, CodeMalformedJSON, "Error decoding json data", err)
+	}
+}
+
+// unknownField picks the field name out of the error DisallowUnknownFields causes,
+// which encoding/json doesn't export a type for
+func unknownField(err error, field *string) bool {
+	const prefix = `json: unknown field "`
+	msg := err.Error()
+	if len(msg) <= len(prefix)+1 || msg[:len(prefix)] != prefix {
+		return false
+	}
+	*field = msg[len(prefix) : len(msg)-1]
+	return true
+}
+
+func jsonType(kind string) string {
+	switch kind {
+	case "string":
+		return "a string"
+	case "bool":
+		return "true or false"
+	case "slice", "array":
+		return "an array"
+	case "struct", "map":
+		return "an object"
+	}
+	return "a number"
+}
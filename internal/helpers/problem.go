@@ -0,0 +1,139 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/5tuartw/droplet/internal/validate"
+)
+
+// ProblemContentType is the media type of error responses (RFC 7807)
+const ProblemContentType = "application/problem+json"
+
+// Stable error codes. Clients branch on these rather than on the human-readable detail, so once
+// published a code keeps its meaning; add a new one instead of repurposing it.
+const (
+	// Generic codes, used when a handler doesn't give a more specific one
+	CodeBadRequest       = "request.invalid"
+	CodeUnauthorized     = "auth.unauthorized"
+	CodeForbidden        = "auth.forbidden"
+	CodeNotFound         = "resource.not_found"
+	CodeMethodNotAllowed = "request.method_not_allowed"
+	CodeConflict         = "resource.conflict"
+	CodeTooLarge         = "request.too_large"
+	CodeUnsupportedMedia = "request.unsupported_media_type"
+	CodeTooManyRequests  = "request.rate_limited"
+	CodeInternal         = "server.internal"
+	CodeUnavailable      = "server.unavailable"
+
+	CodeMalformedJSON    = "request.malformed_json"
+	CodeValidationFailed = "request.validation_failed"
+
+	CodeTokenMissing       = "auth.token_missing"
+	CodeTokenInvalid       = "auth.token_invalid"
+	CodeTokenExpired       = "auth.token_expired"
+	CodeInvalidCredentials = "auth.invalid_credentials"
+	CodeSessionExpired     = "auth.session_expired"
+	CodeSessionRevoked     = "auth.session_revoked"
+	CodeSessionTimedOut    = "auth.session_timed_out"
+	CodeOutOfScope         = "auth.out_of_scope"
+
+	CodeSchoolUnknown   = "school.unknown"
+	CodeSchoolSuspended = "school.suspended"
+
+	CodeDropNotFound           = "drop.not_found"
+	CodeAttachmentNotFound     = "attachment.not_found"
+	CodeTargetInvalidForSchool = "target.invalid_for_school"
+	CodeTagInvalidForSchool    = "tag.invalid_for_school"
+)
+
+// Problem is an RFC 7807 problem details body. Code is the stable identifier to branch on;
+// Detail is for people and may be reworded.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	Code      string `json:"code"`
+	RequestID string `json:"request_id,omitempty"`
+	// Errors lists the problems with each field of an invalid request body
+	Errors validate.Errors `json:"errors,omitempty"`
+	// Error repeats Detail for clients written before problem details
+	Error string `json:"error"`
+}
+
+// CodeForStatus is the generic code for an HTTP status
+func CodeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusRequestEntityTooLarge:
+		return CodeTooLarge
+	case http.StatusUnsupportedMediaType:
+		return CodeUnsupportedMedia
+	case http.StatusTooManyRequests:
+		return CodeTooManyRequests
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	}
+	if status >= 500 {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
+
+// RespondWithProblem sends an application/problem+json error with a specific code.
+// err is added to the detail for client errors, which usually explain what was wrong with the
+// request; for server errors it is only logged, so database and storage errors don't leak out.
+func RespondWithProblem(w http.ResponseWriter, status int, code, detail string, err error) {
+	if err != nil {
+		if status >= 500 {
+			logError(w, fmt.Errorf("%s: %w", detail, err))
+		} else {
+			detail += ": " + err.Error()
+		}
+	}
+	writeProblem(w, newProblem(w, status, code, detail))
+}
+
+func newProblem(w http.ResponseWriter, status int, code, detail string) Problem {
+	requestID := w.Header().Get("X-Request-ID")
+	problem := Problem{
+		Type:      "urn:droplet:problem:" + code,
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Code:      code,
+		RequestID: requestID,
+		Error:     detail,
+	}
+	if requestID != "" {
+		problem.Instance = "urn:droplet:request:" + requestID
+	}
+	return problem
+}
+
+func writeProblem(w http.ResponseWriter, problem Problem) {
+	dat, err := json.Marshal(problem)
+	if err != nil {
+		logError(w, fmt.Errorf("could not encode problem: %w", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(problem.Status)
+	w.Write(dat)
+}
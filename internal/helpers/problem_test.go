@@ -0,0 +1,93 @@
+package helpers_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/5tuartw/droplet/internal/helpers"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeProblem(t *testing.T, rec *httptest.ResponseRecorder) helpers.Problem {
+	t.Helper()
+	require.Equal(t, helpers.ProblemContentType, rec.Header().Get("Content-Type"))
+	var p helpers.Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+	return p
+}
+
+func TestRespondWithProblem(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rec.Header().Set("X-Request-ID", "req-1")
+	helpers.RespondWithProblem(rec, http.StatusNotFound, helpers.CodeDropNotFound, "Drop not found", nil)
+
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	p := decodeProblem(t, rec)
+	require.Equal(t, helpers.CodeDropNotFound, p.Code)
+	require.Equal(t, "urn:droplet:problem:drop.not_found", p.Type)
+	require.Equal(t, "Not Found", p.Title)
+	require.Equal(t, http.StatusNotFound, p.Status)
+	require.Equal(t, "Drop not found", p.Detail)
+	require.Equal(t, "req-1", p.RequestID)
+	require.Equal(t, p.Detail, p.Error, "the legacy error field stays for older clients")
+}
+
+func TestRespondWithErrorHidesServerErrors(t *testing.T) {
+	rec := httptest.NewRecorder()
+	helpers.RespondWithError(rec, http.StatusBadRequest, "Invalid Drop ID", errors.New("invalid UUID length: 3"))
+	p := decodeProblem(t, rec)
+	require.Equal(t, helpers.CodeBadRequest, p.Code)
+	require.Equal(t, "Invalid Drop ID: invalid UUID length: 3", p.Detail, "client errors explain what was wrong")
+
+	rec = httptest.NewRecorder()
+	helpers.RespondWithError(rec, http.StatusInternalServerError, "Could not get drops", errors.New("pq: connection refused"))
+	p = decodeProblem(t, rec)
+	require.Equal(t, helpers.CodeInternal, p.Code)
+	require.Equal(t, "Could not get drops", p.Detail, "server errors are logged, not returned")
+}
+
+func TestDecodeJSON(t *testing.T) {
+	type body struct {
+		Name  string `json:"name" validate:"required,max=5"`
+		Count int    `json:"count"`
+	}
+	decode := func(payload string) (*httptest.ResponseRecorder, bool) {
+		rec := httptest.NewRecorder()
+		var dst body
+		ok := helpers.DecodeJSON(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(payload)), &dst)
+		return rec, ok
+	}
+
+	_, ok := decode(`{"name": "Ada", "count": 2}`)
+	require.True(t, ok)
+
+	cases := []struct {
+		payload string
+		status  int
+		code    string
+		field   string
+	}{
+		{``, http.StatusBadRequest, helpers.CodeMalformedJSON, ""},
+		{`{"name": "Ada"`, http.StatusBadRequest, helpers.CodeMalformedJSON, ""},
+		{`{"name": "Ada"} {}`, http.StatusBadRequest, helpers.CodeMalformedJSON, ""},
+		{`{"name": "Ada", "admin": true}`, http.StatusBadRequest, helpers.CodeMalformedJSON, "admin"},
+		{`{"name": "Ada", "count": "two"}`, http.StatusBadRequest, helpers.CodeMalformedJSON, "count"},
+		{`{"name": "Augusta"}`, http.StatusBadRequest, helpers.CodeValidationFailed, "name"},
+		{`{"name": "` + strings.Repeat("a", helpers.MaxJSONBodyBytes) + `"}`, http.StatusRequestEntityTooLarge, helpers.CodeTooLarge, ""},
+	}
+	for _, tc := range cases {
+		rec, ok := decode(tc.payload)
+		require.False(t, ok)
+		require.Equal(t, tc.status, rec.Code, tc.payload)
+		p := decodeProblem(t, rec)
+		require.Equal(t, tc.code, p.Code, tc.payload)
+		if tc.field != "" {
+			require.Len(t, p.Errors, 1, tc.payload)
+			require.Equal(t, tc.field, p.Errors[0].Field)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package helpers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// QueryInt32 reads an optional whole-number query parameter, such as a ?class= filter.
+// An absent parameter reads as NULL (Valid false).
+func QueryInt32(r *http.Request, name string) (sql.NullInt32, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return sql.NullInt32{}, nil
+	}
+	n, err := strconv.ParseInt(raw, 10, 32)
+	if err != nil {
+		return sql.NullInt32{}, fmt.Errorf("%s must be a whole number", name)
+	}
+	return sql.NullInt32{Int32: int32(n), Valid: true}, nil
+}
+
+// QueryUUID reads an optional UUID query parameter, such as an ?author= filter
+func QueryUUID(r *http.Request, name string) (uuid.NullUUID, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return uuid.NullUUID{}, nil
+	}
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return uuid.NullUUID{}, fmt.Errorf("%s must be a UUID", name)
+	}
+	return uuid.NullUUID{UUID: id, Valid: true}, nil
+}
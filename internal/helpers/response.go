@@ -2,24 +2,24 @@ package helpers
 
 import (
 	"encoding/json"
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
+
+	"github.com/5tuartw/droplet/internal/logging"
 )
 
-// RespondWithError sends an error response with the given status code and message.
+// RespondWithError sends an application/problem+json error with the generic code for the status.
+// Use RespondWithProblem where clients need to tell this failure apart from others with the same status.
 func RespondWithError(w http.ResponseWriter, code int, msg string, err error) {
-	errorMsg := msg
-	if err != nil {
-		errorMsg += ": " + err.Error()
-	}
-	RespondWithJSON(w, code, map[string]string{"error": errorMsg})
+	RespondWithProblem(w, code, CodeForStatus(code), msg, err)
 }
 
 // RespondWithJSON sends a JSON response with the given status code and payload.
 func RespondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	dat, err := json.Marshal(payload)
 	if err != nil {
-		log.Printf("Error marshalling JSON: %v", err)
+		logError(w, fmt.Errorf("could not encode response: %w", err))
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
@@ -27,3 +27,10 @@ func RespondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	w.WriteHeader(code)
 	w.Write(dat)
 }
+
+// logError puts err on the request's access log line, so it carries the request ID and user
+func logError(w http.ResponseWriter, err error) {
+	if !logging.RecordError(w, err) {
+		slog.Error("request failed", "error", err)
+	}
+}
@@ -0,0 +1,140 @@
+// Package logging sets up droplet's structured logs. Every record logged with a request's context
+// carries its request ID and, once the caller has authenticated, who they are; every request gets
+// one access log line when it finishes.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// redactedKeys are attribute keys whose values never reach the logs
+var redactedKeys = []string{"password", "secret", "token", "authorization", "cookie", "hash"}
+
+// Setup makes slog's default logger write to w in the given format ("json" or "text", default json)
+// at the given level (debug, info, warn or error, default info). The standard log package is routed
+// through it too, so existing log.Printf calls come out in the same format.
+func Setup(w io.Writer, format, level string) error {
+	var lvl slog.Level
+	if level != "" {
+		if err := lvl.UnmarshalText([]byte(level)); err != nil {
+			return fmt.Errorf("LOG_LEVEL: %w", err)
+		}
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl, ReplaceAttr: redact}
+	var h slog.Handler
+	switch strings.ToLower(format) {
+	case "", "json":
+		h = slog.NewJSONHandler(w, opts)
+	case "text":
+		h = slog.NewTextHandler(w, opts)
+	default:
+		return fmt.Errorf("LOG_FORMAT must be json or text, not %q", format)
+	}
+
+	slog.SetDefault(slog.New(contextHandler{h}))
+	return nil
+}
+
+func redact(_ []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	for _, k := range redactedKeys {
+		if strings.Contains(key, k) {
+			return slog.String(a.Key, "[REDACTED]")
+		}
+	}
+	return a
+}
+
+// contextHandler adds the request's fields to every record logged with its context
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, rec slog.Record) error {
+	if info := fromContext(ctx); info != nil {
+		rec.AddAttrs(info.attrs()...)
+	}
+	return h.Handler.Handle(ctx, rec)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+type ctxKey struct{}
+
+// requestInfo is shared by pointer so fields set deeper in the chain (the user, the matched route)
+// are seen by the access log written by the outermost middleware.
+type requestInfo struct {
+	mu       sync.Mutex
+	id       string
+	route    string
+	userID   string
+	schoolID string
+	role     string
+}
+
+func fromContext(ctx context.Context) *requestInfo {
+	if ctx == nil {
+		return nil
+	}
+	info, _ := ctx.Value(ctxKey{}).(*requestInfo)
+	return info
+}
+
+func (i *requestInfo) attrs() []slog.Attr {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	attrs := []slog.Attr{slog.String("request_id", i.id)}
+	if i.userID != "" {
+		attrs = append(attrs,
+			slog.String("user_id", i.userID),
+			slog.String("school_id", i.schoolID),
+			slog.String("role", i.role))
+	}
+	return attrs
+}
+
+// RequestID returns the ID of the request ctx belongs to, or "" outside a request
+func RequestID(ctx context.Context) string {
+	if info := fromContext(ctx); info != nil {
+		return info.id
+	}
+	return ""
+}
+
+// Route returns the route pattern the request matched, once the mux has handled it
+func Route(ctx context.Context) string {
+	info := fromContext(ctx)
+	if info == nil {
+		return ""
+	}
+	info.mu.Lock()
+	defer info.mu.Unlock()
+	return info.route
+}
+
+// SetUser records who made the request, for every later log line from it
+func SetUser(ctx context.Context, userID, schoolID uuid.UUID, role string) {
+	info := fromContext(ctx)
+	if info == nil {
+		return
+	}
+	info.mu.Lock()
+	defer info.mu.Unlock()
+	info.userID = userID.String()
+	info.schoolID = schoolID.String()
+	info.role = role
+}
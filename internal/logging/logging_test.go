@@ -0,0 +1,122 @@
+package logging_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/5tuartw/droplet/internal/logging"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// setup captures JSON logs for the test and returns a function that decodes them
+func setup(t *testing.T) func() []map[string]any {
+	t.Helper()
+	previous := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	var buf bytes.Buffer
+	require.NoError(t, logging.Setup(&buf, "json", "debug"))
+	return func() []map[string]any {
+		var records []map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			var rec map[string]any
+			require.NoError(t, json.Unmarshal([]byte(line), &rec), line)
+			records = append(records, rec)
+		}
+		return records
+	}
+}
+
+func TestMiddlewareLogsRequestWithUser(t *testing.T) {
+	records := setup(t)
+	userID, schoolID := uuid.New(), uuid.New()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/drops/{dropID}", func(w http.ResponseWriter, r *http.Request) {
+		logging.SetUser(r.Context(), userID, schoolID, "teacher")
+		slog.InfoContext(r.Context(), "handler ran", "password", "hunter2")
+		w.WriteHeader(http.StatusTeapot)
+	})
+	handler := logging.Middleware(logging.RecordRoute(mux))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/drops/42?token=abc", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	id := rr.Header().Get(logging.RequestIDHeader)
+	require.NoError(t, uuid.Validate(id), "a request ID is generated when none is sent")
+
+	logs := records()
+	require.Len(t, logs, 2)
+	require.Equal(t, "[REDACTED]", logs[0]["password"])
+	require.Equal(t, id, logs[0]["request_id"])
+
+	access := logs[1]
+	require.Equal(t, "request", access["msg"])
+	require.Equal(t, "GET /api/drops/{dropID}", access["route"])
+	require.Equal(t, "/api/drops/42", access["path"], "the query string is never logged")
+	require.Equal(t, float64(http.StatusTeapot), access["status"])
+	require.Equal(t, userID.String(), access["user_id"])
+	require.Equal(t, schoolID.String(), access["school_id"])
+	require.Equal(t, "teacher", access["role"])
+	require.NotContains(t, access, "token")
+}
+
+func TestMiddlewareRequestIDFromProxy(t *testing.T) {
+	setup(t)
+	handler := logging.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(logging.RequestID(r.Context())))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(logging.RequestIDHeader, "edge-1234.abc")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	require.Equal(t, "edge-1234.abc", rr.Header().Get(logging.RequestIDHeader))
+	require.Equal(t, "edge-1234.abc", rr.Body.String())
+
+	req.Header.Set(logging.RequestIDHeader, "bad id\nwith newline")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	require.NoError(t, uuid.Validate(rr.Header().Get(logging.RequestIDHeader)), "unsafe IDs are replaced")
+}
+
+// wrappedWriter stands in for middleware that wraps the response writer, like metrics.Middleware
+type wrappedWriter struct {
+	http.ResponseWriter
+}
+
+func (w wrappedWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+func TestRecordErrorAddsToAccessLog(t *testing.T) {
+	records := setup(t)
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.True(t, logging.RecordError(w, errors.New("connection refused")))
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	handler := logging.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inner.ServeHTTP(wrappedWriter{w}, r)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	logs := records()
+	require.Len(t, logs, 1)
+	require.Equal(t, "ERROR", logs[0]["level"])
+	require.Equal(t, "connection refused", logs[0]["error"])
+	require.NotEmpty(t, logs[0]["request_id"])
+
+	require.False(t, logging.RecordError(httptest.NewRecorder(), errors.New("nowhere to go")))
+}
+
+func TestSetupRejectsBadValues(t *testing.T) {
+	setup(t)
+	require.Error(t, logging.Setup(&bytes.Buffer{}, "xml", ""))
+	require.Error(t, logging.Setup(&bytes.Buffer{}, "json", "loud"))
+}
@@ -0,0 +1,116 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID limits IDs accepted from clients or proxies, so they can't inject anything odd into the logs
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// Middleware gives every request an ID (keeping a sane one passed in X-Request-ID by a proxy), echoes it
+// in the response, and writes an access log line when the request finishes. Only the method, matched
+// route and path are logged: never the query string, headers or body, which can carry credentials.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = uuid.NewString()
+		}
+		info := &requestInfo{id: id}
+		ctx := context.WithValue(r.Context(), ctxKey{}, info)
+		w.Header().Set(RequestIDHeader, id)
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		route := Route(ctx)
+		if route == "" {
+			route = "unmatched"
+		}
+
+		level := slog.LevelInfo
+		if rec.status >= 500 {
+			level = slog.LevelError
+		}
+		attrs := []slog.Attr{
+			slog.String("method", r.Method),
+			slog.String("route", route),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.status),
+			slog.Int64("bytes", rec.bytes),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+		}
+		if rec.err != nil {
+			attrs = append(attrs, slog.String("error", rec.err.Error()))
+		}
+		slog.LogAttrs(ctx, level, "request", attrs...)
+	})
+}
+
+// RecordRoute notes the pattern mux matched, so the access log can group requests by route
+// rather than by path (which contains IDs).
+func RecordRoute(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.ServeHTTP(w, r)
+		if info := fromContext(r.Context()); info != nil && r.Pattern != "" {
+			info.mu.Lock()
+			info.route = r.Pattern
+			info.mu.Unlock()
+		}
+	})
+}
+
+// RecordError adds err to the access log line of the request w is answering. It's for code that
+// responds without the request's context, like the helpers that write error responses. It reports
+// false if w isn't inside Middleware, so the caller can log err some other way.
+func RecordError(w http.ResponseWriter, err error) bool {
+	for {
+		switch rw := w.(type) {
+		case *statusRecorder:
+			rw.err = err
+			return true
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = rw.Unwrap()
+		default:
+			return false
+		}
+	}
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+	err         error
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if !s.wroteHeader {
+		s.status = code
+		s.wroteHeader = true
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	s.wroteHeader = true
+	n, err := s.ResponseWriter.Write(b)
+	s.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer (for flushing, deadlines)
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
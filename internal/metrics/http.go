@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/5tuartw/droplet/internal/helpers"
+	"github.com/5tuartw/droplet/internal/logging"
+)
+
+// Middleware counts and times every request by its route pattern. It must sit inside logging.Middleware,
+// which records the pattern; requests no route matched are grouped under "unmatched" so stray paths
+// can't create new series.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		route := logging.Route(r.Context())
+		if route == "" {
+			route = "unmatched"
+		}
+		HTTPRequests.Inc(r.Method, route, strconv.Itoa(rec.status))
+		HTTPDuration.Observe(time.Since(start).Seconds(), r.Method, route)
+	})
+}
+
+// Handler serves every metric, plus db's connection pool stats if db is not nil.
+// If token is set, scrapers must send it as a bearer token.
+func Handler(db *sql.DB, token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token != "" {
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				helpers.RespondWithProblem(w, http.StatusUnauthorized, helpers.CodeTokenInvalid, "Unauthorized", nil)
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		for _, c := range collectors {
+			c.write(w)
+		}
+		if db != nil {
+			s := db.Stats()
+			writeGauge(w, "droplet_db_max_open_connections", "Maximum number of open connections to the database.", float64(s.MaxOpenConnections))
+			writeGauge(w, "droplet_db_open_connections", "Established connections to the database, in use and idle.", float64(s.OpenConnections))
+			writeGauge(w, "droplet_db_in_use_connections", "Connections currently in use.", float64(s.InUse))
+			writeGauge(w, "droplet_db_idle_connections", "Idle connections.", float64(s.Idle))
+			writeCounter(w, "droplet_db_wait_count_total", "Connections waited for.", float64(s.WaitCount))
+			writeCounter(w, "droplet_db_wait_duration_seconds_total", "Time spent waiting for a connection.", s.WaitDuration.Seconds())
+			writeCounter(w, "droplet_db_max_idle_closed_total", "Connections closed due to SetMaxIdleConns.", float64(s.MaxIdleClosed))
+			writeCounter(w, "droplet_db_max_lifetime_closed_total", "Connections closed due to SetConnMaxLifetime.", float64(s.MaxLifetimeClosed))
+		}
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if !s.wroteHeader {
+		s.status = code
+		s.wroteHeader = true
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	s.wroteHeader = true
+	return s.ResponseWriter.Write(b)
+}
+
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
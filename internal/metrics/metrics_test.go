@@ -0,0 +1,86 @@
+package metrics_test
+
+import (
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/5tuartw/droplet/internal/logging"
+	"github.com/5tuartw/droplet/internal/metrics"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+const token = "scrape-secret"
+
+func newServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	// sql.Open doesn't connect, but the pool still reports its stats
+	db, err := sql.Open("postgres", "postgres://localhost/droplet_metrics_test?sslmode=disable")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/drops/{dropID}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.Handle("GET /metrics", metrics.Handler(db, token))
+
+	srv := httptest.NewServer(logging.Middleware(metrics.Middleware(logging.RecordRoute(mux))))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func scrape(t *testing.T, srv *httptest.Server, bearer string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/metrics", nil)
+	require.NoError(t, err)
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(body)
+}
+
+func TestScrapeMetrics(t *testing.T) {
+	srv := newServer(t)
+
+	for _, path := range []string{"/api/drops/1", "/api/drops/2", "/nowhere"} {
+		resp, err := http.Get(srv.URL + path)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	metrics.Logins.Inc("success")
+	metrics.Logins.Inc("failure")
+	metrics.DropsCreated.Inc("7f1b6c3e-0000-4000-8000-000000000001")
+
+	status, body := scrape(t, srv, token)
+	require.Equal(t, http.StatusOK, status)
+
+	require.Contains(t, body, "# TYPE droplet_http_requests_total counter")
+	require.Contains(t, body, `droplet_http_requests_total{method="GET",route="GET /api/drops/{dropID}",status="404"} 2`)
+	require.Contains(t, body, `droplet_http_requests_total{method="GET",route="unmatched",status="404"} 1`, "paths no route matched share one series")
+	require.Contains(t, body, `droplet_http_request_duration_seconds_bucket{method="GET",route="GET /api/drops/{dropID}",le="+Inf"} 2`)
+	require.Contains(t, body, `droplet_http_request_duration_seconds_count{method="GET",route="GET /api/drops/{dropID}"} 2`)
+	require.Contains(t, body, `droplet_logins_total{result="success"} 1`)
+	require.Contains(t, body, `droplet_logins_total{result="failure"} 1`)
+	require.Contains(t, body, `droplet_drops_created_total{school_id="7f1b6c3e-0000-4000-8000-000000000001"} 1`)
+	require.Contains(t, body, "droplet_refresh_tokens_issued_total 0", "unlabelled counters are exposed before they are used")
+	require.Contains(t, body, "# TYPE droplet_db_open_connections gauge")
+	require.Contains(t, body, "droplet_db_wait_count_total 0")
+}
+
+func TestScrapeNeedsToken(t *testing.T) {
+	srv := newServer(t)
+
+	status, _ := scrape(t, srv, "")
+	require.Equal(t, http.StatusUnauthorized, status)
+	status, _ = scrape(t, srv, "wrong")
+	require.Equal(t, http.StatusUnauthorized, status)
+}
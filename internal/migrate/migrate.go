@@ -0,0 +1,296 @@
+// Package migrate applies the goose migrations in sql/schema from inside the droplet binary.
+// It keeps its history in goose's own goose_db_version table, so databases migrated with the
+// goose CLI carry on where they left off, and the goose CLI still works afterwards.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"time"
+)
+
+const versionTable = "goose_db_version"
+
+// lockID is the Postgres advisory lock held while migrating, so replicas starting together take turns
+const lockID int64 = 0x64726f706c6574 // "droplet"
+
+var ErrNoCurrentVersion = errors.New("no migrations have been applied")
+
+type Runner struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// Status is one migration and when it was applied (nil if it hasn't been)
+type Status struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time
+}
+
+func New(db *sql.DB, fsys fs.FS) (*Runner, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	if len(migrations) == 0 {
+		return nil, errors.New("no migrations found")
+	}
+	return &Runner{db: db, migrations: migrations}, nil
+}
+
+// Latest is the version the schema is at once every migration has been applied
+func (r *Runner) Latest() int64 {
+	return r.migrations[len(r.migrations)-1].Version
+}
+
+// Up applies every pending migration and returns how many it applied.
+func (r *Runner) Up(ctx context.Context) (int, error) {
+	count := 0
+	err := r.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := r.checkOrder(applied); err != nil {
+			return err
+		}
+		for _, m := range r.migrations {
+			if _, ok := applied[m.Version]; ok {
+				continue
+			}
+			if err := run(ctx, conn, m, true); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Down rolls back the most recently applied migration and returns it.
+func (r *Runner) Down(ctx context.Context) (Migration, error) {
+	var rolledBack Migration
+	err := r.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(r.migrations) - 1; i >= 0; i-- {
+			m := r.migrations[i]
+			if _, ok := applied[m.Version]; ok {
+				rolledBack = m
+				return run(ctx, conn, m, false)
+			}
+		}
+		return ErrNoCurrentVersion
+	})
+	return rolledBack, err
+}
+
+// Status lists every migration with when it was applied. It doesn't take the lock or change anything.
+func (r *Runner) Status(ctx context.Context) ([]Status, error) {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	applied, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, 0, len(r.migrations))
+	for _, m := range r.migrations {
+		s := Status{Version: m.Version, Name: m.Name}
+		if at, ok := applied[m.Version]; ok {
+			at := at
+			s.AppliedAt = &at
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses, nil
+}
+
+// Pending returns the migrations that haven't been applied yet
+func (r *Runner) Pending(ctx context.Context) ([]Migration, error) {
+	statuses, err := r.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for i, s := range statuses {
+		if s.AppliedAt == nil {
+			pending = append(pending, r.migrations[i])
+		}
+	}
+	return pending, nil
+}
+
+// checkOrder refuses to fill gaps: a pending migration older than an applied one usually means two
+// branches both added a migration, and applying it now would run it against a schema it wasn't written for.
+func (r *Runner) checkOrder(applied map[int64]time.Time) error {
+	var highest int64
+	for v := range applied {
+		if v > highest {
+			highest = v
+		}
+	}
+	for _, m := range r.migrations {
+		if _, ok := applied[m.Version]; !ok && m.Version < highest {
+			return fmt.Errorf("migration %s has not been applied but version %d has; apply it by hand or renumber it", m.Name, highest)
+		}
+	}
+	return nil
+}
+
+func (r *Runner) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockID); err != nil {
+		return fmt.Errorf("could not take migration lock: %w", err)
+	}
+	defer func() {
+		// Unlock even if ctx was cancelled, otherwise the lock lives as long as the pooled connection
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockID); err != nil {
+			log.Printf("Could not release migration lock: %v", err)
+		}
+	}()
+
+	if err := ensureVersionTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func ensureVersionTable(ctx context.Context, conn *sql.Conn) error {
+	exists, err := versionTableExists(ctx, conn)
+	if err != nil || exists {
+		return err
+	}
+
+	// Same layout goose creates, including its version 0 row
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, `CREATE TABLE `+versionTable+` (
+		id serial NOT NULL,
+		version_id bigint NOT NULL,
+		is_applied boolean NOT NULL,
+		tstamp timestamp NULL DEFAULT now(),
+		PRIMARY KEY(id)
+	)`); err != nil {
+		return fmt.Errorf("could not create %s: %w", versionTable, err)
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO `+versionTable+` (version_id, is_applied) VALUES (0, true)`); err != nil {
+		return fmt.Errorf("could not initialise %s: %w", versionTable, err)
+	}
+	return tx.Commit()
+}
+
+func versionTableExists(ctx context.Context, conn *sql.Conn) (bool, error) {
+	var exists bool
+	err := conn.QueryRowContext(ctx, "SELECT to_regclass($1) IS NOT NULL", versionTable).Scan(&exists)
+	return exists, err
+}
+
+// appliedVersions reads goose's history. The newest row for a version wins, which covers both
+// older goose releases (that record a down as is_applied = false) and newer ones (that delete the row).
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	applied := make(map[int64]time.Time)
+	exists, err := versionTableExists(ctx, conn)
+	if err != nil || !exists {
+		return applied, err
+	}
+
+	rows, err := conn.QueryContext(ctx, `SELECT version_id, is_applied, tstamp FROM `+versionTable+` ORDER BY id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", versionTable, err)
+	}
+	defer rows.Close()
+
+	seen := make(map[int64]bool)
+	for rows.Next() {
+		var version int64
+		var isApplied bool
+		var at sql.NullTime
+		if err := rows.Scan(&version, &isApplied, &at); err != nil {
+			return nil, err
+		}
+		if version == 0 || seen[version] {
+			continue
+		}
+		seen[version] = true
+		if isApplied {
+			applied[version] = at.Time
+		}
+	}
+	return applied, rows.Err()
+}
+
+func run(ctx context.Context, conn *sql.Conn, m Migration, up bool) error {
+	statements, direction := m.Up, "up"
+	if !up {
+		statements, direction = m.Down, "down"
+	}
+	start := time.Now()
+
+	var record func(execer) error
+	if up {
+		record = func(e execer) error {
+			_, err := e.ExecContext(ctx, `INSERT INTO `+versionTable+` (version_id, is_applied) VALUES ($1, true)`, m.Version)
+			return err
+		}
+	} else {
+		record = func(e execer) error {
+			_, err := e.ExecContext(ctx, `DELETE FROM `+versionTable+` WHERE version_id = $1`, m.Version)
+			return err
+		}
+	}
+
+	if m.NoTx {
+		for _, stmt := range statements {
+			if _, err := conn.ExecContext(ctx, stmt); err != nil {
+				return fmt.Errorf("%s (%s): %w", m.Name, direction, err)
+			}
+		}
+		if err := record(conn); err != nil {
+			return fmt.Errorf("%s (%s): could not record version: %w", m.Name, direction, err)
+		}
+	} else {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		for _, stmt := range statements {
+			if _, err := tx.ExecContext(ctx, stmt); err != nil {
+				return fmt.Errorf("%s (%s): %w", m.Name, direction, err)
+			}
+		}
+		if err := record(tx); err != nil {
+			return fmt.Errorf("%s (%s): could not record version: %w", m.Name, direction, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("%s (%s): %w", m.Name, direction, err)
+		}
+	}
+
+	log.Printf("OK   %s (%s, %s)", m.Name, direction, time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
@@ -0,0 +1,152 @@
+package migrate
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Migration is one goose SQL file, split into the statements to run in each direction.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      []string
+	Down    []string
+	// NoTx is set by "-- +goose NO TRANSACTION", for statements Postgres won't run in a transaction
+	NoTx bool
+}
+
+// Load reads every NNN_name.sql file in fsys, in version order.
+func Load(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []Migration
+	seen := make(map[int64]string)
+	for _, file := range files {
+		version, err := versionFromFilename(file)
+		if err != nil {
+			return nil, err
+		}
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("migrations %s and %s have the same version %d", other, file, version)
+		}
+		seen[version] = file
+
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		m, err := Parse(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		m.Version = version
+		m.Name = file
+		migrations = append(migrations, m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+func versionFromFilename(file string) (int64, error) {
+	base := path.Base(file)
+	prefix, _, ok := strings.Cut(base, "_")
+	if !ok {
+		return 0, fmt.Errorf("migration %s is not named NNN_description.sql", file)
+	}
+	version, err := strconv.ParseInt(prefix, 10, 64)
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("migration %s is not named NNN_description.sql", file)
+	}
+	return version, nil
+}
+
+// Parse splits a goose SQL file into statements the same way goose does: a statement ends at a line
+// ending in ";", except between StatementBegin and StatementEnd (used for function bodies).
+func Parse(src string) (Migration, error) {
+	var m Migration
+	var current *[]string
+	var buf strings.Builder
+	inBlock := false
+	sawUp := false
+
+	flush := func() {
+		stmt := strings.TrimSpace(buf.String())
+		buf.Reset()
+		if stmt != "" && current != nil && !onlyComments(stmt) {
+			*current = append(*current, stmt)
+		}
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(src))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if annotation, ok := strings.CutPrefix(trimmed, "-- +goose "); ok {
+			switch strings.ToUpper(strings.TrimSpace(annotation)) {
+			case "UP":
+				flush()
+				current, sawUp = &m.Up, true
+			case "DOWN":
+				flush()
+				current = &m.Down
+			case "STATEMENTBEGIN":
+				flush()
+				inBlock = true
+			case "STATEMENTEND":
+				inBlock = false
+				flush()
+			case "NO TRANSACTION":
+				m.NoTx = true
+			default:
+				return m, fmt.Errorf("unknown goose annotation %q", trimmed)
+			}
+			continue
+		}
+
+		if current == nil {
+			if trimmed != "" && !strings.HasPrefix(trimmed, "--") {
+				return m, fmt.Errorf("SQL before the -- +goose Up annotation")
+			}
+			continue
+		}
+
+		buf.WriteString(line)
+		buf.WriteString("\n")
+		if !inBlock && strings.HasSuffix(trimmed, ";") {
+			flush()
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return m, err
+	}
+	if inBlock {
+		return m, fmt.Errorf("StatementBegin without a matching StatementEnd")
+	}
+	flush()
+
+	if !sawUp {
+		return m, fmt.Errorf("missing -- +goose Up annotation")
+	}
+	return m, nil
+}
+
+func onlyComments(stmt string) bool {
+	for _, line := range strings.Split(stmt, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
+	}
+	return true
+}
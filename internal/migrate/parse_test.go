@@ -0,0 +1,70 @@
+package migrate_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/5tuartw/droplet/internal/migrate"
+	"github.com/5tuartw/droplet/sql/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSplitsStatements(t *testing.T) {
+	m, err := migrate.Parse(`-- +goose Up
+CREATE TABLE a (id INT);
+-- a comment on its own
+ALTER TABLE a
+    ADD COLUMN name TEXT;
+
+-- +goose StatementBegin
+CREATE FUNCTION f() RETURNS trigger AS $$
+BEGIN
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION f();
+DROP TABLE a`)
+	require.NoError(t, err)
+	require.Len(t, m.Up, 3)
+	require.Contains(t, m.Up[1], "ALTER TABLE a\n    ADD COLUMN name TEXT;")
+	require.Contains(t, m.Up[2], "END;\n$$ LANGUAGE plpgsql;", "function bodies stay in one statement")
+	require.Equal(t, []string{"DROP FUNCTION f();", "DROP TABLE a"}, m.Down)
+	require.False(t, m.NoTx)
+}
+
+func TestParseRejectsBadFiles(t *testing.T) {
+	_, err := migrate.Parse("CREATE TABLE a (id INT);")
+	require.Error(t, err, "no Up annotation")
+
+	_, err = migrate.Parse("-- +goose Up\n-- +goose StatementBegin\nSELECT 1;\n")
+	require.Error(t, err, "unterminated StatementBegin")
+}
+
+func TestLoadOrdersByVersion(t *testing.T) {
+	fsys := fstest.MapFS{
+		"010_b.sql": {Data: []byte("-- +goose Up\nSELECT 2;\n")},
+		"002_a.sql": {Data: []byte("-- +goose Up\n-- +goose NO TRANSACTION\nSELECT 1;\n")},
+	}
+	migrations, err := migrate.Load(fsys)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), migrations[0].Version)
+	require.True(t, migrations[0].NoTx)
+	require.Equal(t, int64(10), migrations[1].Version)
+
+	fsys["002_duplicate.sql"] = &fstest.MapFile{Data: []byte("-- +goose Up\nSELECT 1;\n")}
+	_, err = migrate.Load(fsys)
+	require.Error(t, err)
+}
+
+func TestEmbeddedSchemaParses(t *testing.T) {
+	migrations, err := migrate.Load(schema.FS)
+	require.NoError(t, err)
+	for i, m := range migrations {
+		require.Equal(t, int64(i+1), m.Version, "migrations are numbered without gaps")
+		require.NotEmpty(t, m.Up, m.Name)
+		require.NotEmpty(t, m.Down, m.Name)
+	}
+}
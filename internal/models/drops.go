@@ -1,9 +1,11 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/validate"
 	"github.com/google/uuid"
 )
 
@@ -19,11 +21,22 @@ type Drop struct {
 }
 
 type DropRequest struct { //renamed from UpdateDropRequest
-	Title      string              `json:"title"`
-	Content    string              `json:"content"`
-	PostDate   *string             `json:"post_date,omitempty"`
-	ExpireDate *string             `json:"expire_date,omitempty"`
-	Targets    []Target `json:"targets"`
+	Title       string   `json:"title" validate:"max=255"`
+	Content     string   `json:"content" validate:"max=10000"`
+	PostDate    *string  `json:"post_date,omitempty"`
+	ExpireDate  *string  `json:"expire_date,omitempty"`
+	Priority    *string  `json:"priority,omitempty" validate:"oneof=normal important urgent"` // normal (default), important or urgent
+	PinnedUntil *string  `json:"pinned_until,omitempty"`                                      // YYYY-MM-DD or RFC 3339
+	Targets     []Target `json:"targets" validate:"max=200"`
+	TagIDs      []int32  `json:"tag_ids,omitempty" validate:"max=50"` // on update, omit to keep the drop's current tags
+}
+
+// Check requires a title or content; a drop may have just one
+func (d *DropRequest) Check() validate.Errors {
+	if strings.TrimSpace(d.Title) == "" && strings.TrimSpace(d.Content) == "" {
+		return validate.Errors{{Field: "title", Code: validate.CodeRequired, Message: "title and content cannot both be empty"}}
+	}
+	return nil
 }
 
 type DropView struct {
@@ -42,6 +55,63 @@ type DropTarget struct {
 }
 
 type Target struct {
-	Type string `json:"type"`
-	ID   int32  `json:"id"`
+	Type   string        `json:"type" validate:"required,oneof=General Class YearGroup Division Student Lesson"`
+	ID     int32         `json:"id"`
+	Lesson *LessonTarget `json:"lesson,omitempty"` // a Lesson target without an ID yet
+}
+
+// Check requires a Lesson target to have an ID or a lesson to resolve to one
+func (t *Target) Check() validate.Errors {
+	if t.Lesson != nil && t.Type != "Lesson" {
+		return validate.Errors{{Field: "lesson", Code: validate.CodeNotAllowed, Message: "is only allowed on Lesson targets"}}
+	}
+	if t.Type == "Lesson" && t.ID == 0 && t.Lesson == nil {
+		return validate.Errors{{Field: "lesson", Code: validate.CodeRequired, Message: "is required for a Lesson target without an id"}}
+	}
+	return nil
+}
+
+// LessonTarget is whoever the timetable has teaching a class, or in a room, in a period on a date
+type LessonTarget struct {
+	ClassID  *int32 `json:"class_id,omitempty"`
+	RoomID   *int32 `json:"room_id,omitempty"`
+	PeriodID int32  `json:"period_id" validate:"required,min=1"`
+	Date     string `json:"date" validate:"required"` // YYYY-MM-DD
+}
+
+// Check requires exactly one of a class and a room, and a real date
+func (l *LessonTarget) Check() validate.Errors {
+	var errs validate.Errors
+	if (l.ClassID == nil) == (l.RoomID == nil) {
+		errs = append(errs, validate.FieldError{Field: "class_id", Code: validate.CodeInvalid, Message: "give either class_id or room_id"})
+	}
+	if l.Date != "" {
+		if _, err := time.Parse(time.DateOnly, l.Date); err != nil {
+			errs = append(errs, validate.FieldError{Field: "date", Code: validate.CodeInvalid, Message: "must be a date (YYYY-MM-DD)"})
+		}
+	}
+	return errs
+}
+
+// AudiencePreview is who a drop with a given set of targets would reach, before it is posted
+type AudiencePreview struct {
+	StaffCount int             `json:"staff_count"`
+	PupilCount int             `json:"pupil_count"`
+	Staff      []AudienceStaff `json:"staff"`
+	Pupils     []AudiencePupil `json:"pupils"`
+}
+
+type AudienceStaff struct {
+	ID        uuid.UUID `json:"id"`
+	Title     string    `json:"title"`
+	FirstName string    `json:"first_name"`
+	Surname   string    `json:"surname"`
+	Role      string    `json:"role"`
+}
+
+type AudiencePupil struct {
+	ID        int32  `json:"id"`
+	FirstName string `json:"first_name"`
+	Surname   string `json:"surname"`
+	ClassName string `json:"class_name"`
 }
@@ -10,3 +10,10 @@ type Pupil struct {
 	ClassID   int32     `json:"class_id"`
 	ClassName string    `json:"class_name"`
 }
+
+// PupilRequest creates or updates a pupil
+type PupilRequest struct {
+	FirstName string `json:"first_name" validate:"required,max=255"`
+	Surname   string `json:"surname" validate:"required,max=255"`
+	ClassID   int32  `json:"class_id" validate:"required,min=1"`
+}
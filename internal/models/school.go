@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SchoolResponse is a school as seen by platform admins
+type SchoolResponse struct {
+	ID           uuid.UUID `json:"id"`
+	Name         string    `json:"name"`
+	Status       string    `json:"status"`
+	Subdomain    string    `json:"subdomain,omitempty"`
+	Address      string    `json:"address,omitempty"`
+	ContactEmail string    `json:"contact_email,omitempty"`
+	ContactPhone string    `json:"contact_phone,omitempty"`
+	LogoURL      string    `json:"logo_url,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// NewSchoolAdmin is the first admin account created alongside a new school
+type NewSchoolAdmin struct {
+	Email     string `json:"email"`
+	Password  string `json:"password"`
+	Title     string `json:"title"`
+	FirstName string `json:"first_name"`
+	Surname   string `json:"surname"`
+}
+
+type CreateSchoolRequest struct {
+	Name         string          `json:"name"`
+	Subdomain    string          `json:"subdomain"`
+	Address      string          `json:"address"`
+	ContactEmail string          `json:"contact_email"`
+	ContactPhone string          `json:"contact_phone"`
+	Admin        *NewSchoolAdmin `json:"admin"`
+}
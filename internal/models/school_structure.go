@@ -3,9 +3,20 @@ package models
 import "github.com/google/uuid"
 
 type ClassInfo struct {
-	ID         int32  `json:"id"`
-	Name       string `json:"name"`
-	PupilCount int64  `json:"pupil_count"`
+	ID         int32            `json:"id"`
+	Name       string           `json:"name"`
+	PupilCount int64            `json:"pupil_count"`
+	Staff      []ClassStaffInfo `json:"staff"`
+}
+
+// ClassStaffInfo is a member of staff assigned to a class. Role is tutor, subject_teacher or
+// teaching_assistant.
+type ClassStaffInfo struct {
+	ID        uuid.UUID `json:"id"`
+	Title     string    `json:"title"`
+	FirstName string    `json:"first_name"`
+	Surname   string    `json:"surname"`
+	Role      string    `json:"role"`
 }
 
 type YearGroupInfo struct {
@@ -25,3 +36,41 @@ type SchoolStructureResponse struct {
 	Name      string         `json:"name"`
 	Divisions []DivisionInfo `json:"divisions"`
 }
+
+type CreateDivisionRequest struct {
+	DivisionName string `json:"division_name" validate:"required,max=255"`
+}
+
+type RenameDivisionRequest struct {
+	DivisionName string `json:"division_name" validate:"required,max=255"`
+}
+
+type CreateYearGroupRequest struct {
+	YearGroupName string `json:"year_group_name" validate:"required,max=255"`
+	DivisionID    int32  `json:"division_id" validate:"required,min=1"`
+}
+
+type RenameYearGroupRequest struct {
+	YearGroupName string `json:"year_group_name" validate:"required,max=255"`
+}
+
+type MoveYearGroupRequest struct {
+	DivisionID int32 `json:"division_id" validate:"required,min=1"`
+}
+
+type CreateClassRequest struct {
+	ClassName   string `json:"class_name" validate:"required,max=255"`
+	YearGroupID int32  `json:"year_group_id" validate:"required,min=1"`
+}
+
+type RenameClassRequest struct {
+	ClassName string `json:"class_name" validate:"required,max=255"`
+}
+
+type MoveClassRequest struct {
+	YearGroupID int32 `json:"year_group_id" validate:"required,min=1"`
+}
+
+type AssignClassStaffRequest struct {
+	Role string `json:"role" validate:"required,oneof=tutor subject_teacher teaching_assistant"`
+}
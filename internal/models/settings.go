@@ -7,8 +7,14 @@ type UserSettingsPreferences struct {
 	LayoutPref string `json:"layout_pref"`
 }
 
-type AllUserSettingsResponse struct {
-	Preferences   UserSettingsPreferences `json:"preferences"`
-	Subscriptions []database.TargetInfo   `json:"subscriptions"`
+type TagSubscription struct {
+	TagID int32  `json:"tag_id"`
+	Name  string `json:"name"`
+	Muted bool   `json:"muted"`
 }
 
+type AllUserSettingsResponse struct {
+	Preferences      UserSettingsPreferences `json:"preferences"`
+	Subscriptions    []database.TargetInfo   `json:"subscriptions"`
+	TagSubscriptions []TagSubscription       `json:"tag_subscriptions"`
+}
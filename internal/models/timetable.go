@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/5tuartw/droplet/internal/validate"
+	"github.com/google/uuid"
+)
+
+type Timetable struct {
+	TimetableCycle
+	Periods []TimetableEntity `json:"periods"`
+	Rooms   []TimetableEntity `json:"rooms"`
+	Lessons []Lesson          `json:"lessons"`
+}
+
+type TimetableCycle struct {
+	CycleDays int32  `json:"cycle_days"`
+	StartsOn  string `json:"starts_on"` // a date in week one of the cycle
+}
+
+// TimetableEntity is a period or a room
+type TimetableEntity struct {
+	ID   int32  `json:"id"`
+	Name string `json:"name"`
+}
+
+type Lesson struct {
+	ID         int32     `json:"id"`
+	CycleDay   int32     `json:"cycle_day"`
+	PeriodID   int32     `json:"period_id"`
+	PeriodName string    `json:"period_name"`
+	ClassID    int32     `json:"class_id"`
+	ClassName  string    `json:"class_name"`
+	UserID     uuid.UUID `json:"user_id"`
+	Title      string    `json:"title"`
+	FirstName  string    `json:"first_name"`
+	Surname    string    `json:"surname"`
+	RoomID     *int32    `json:"room_id"`
+	RoomName   *string   `json:"room_name"`
+}
+
+type SetTimetableCycleRequest struct {
+	CycleDays int32  `json:"cycle_days" validate:"required,min=1,max=20"`
+	StartsOn  string `json:"starts_on" validate:"required"` // YYYY-MM-DD, a weekday in week one of the cycle
+}
+
+// Check requires starts_on to be a weekday, since weekends are not part of the cycle
+func (c *SetTimetableCycleRequest) Check() validate.Errors {
+	if c.StartsOn == "" {
+		return nil
+	}
+	startsOn, err := time.Parse(time.DateOnly, c.StartsOn)
+	if err != nil {
+		return validate.Errors{{Field: "starts_on", Code: validate.CodeInvalid, Message: "must be a date (YYYY-MM-DD)"}}
+	}
+	if startsOn.Weekday() == time.Saturday || startsOn.Weekday() == time.Sunday {
+		return validate.Errors{{Field: "starts_on", Code: validate.CodeInvalid, Message: "must be a weekday"}}
+	}
+	return nil
+}
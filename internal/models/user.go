@@ -45,3 +45,34 @@ type UserResponse struct {
 	FirstName string            `json:"first_name,omitempty"`
 	Surname   string            `json:"surname,omitempty"`
 }
+
+// CreateUserRequest adds a user to the admin's school. Role defaults to user.
+type CreateUserRequest struct {
+	Email     string `json:"email" validate:"required,email,max=255"`
+	Password  string `json:"password" validate:"required"`
+	Role      string `json:"role"`
+	Title     string `json:"title" validate:"max=255"`
+	FirstName string `json:"first_name" validate:"max=255"`
+	Surname   string `json:"surname" validate:"max=255"`
+}
+
+type UpdateUserNameRequest struct {
+	Title     string `json:"title" validate:"required,max=255"`
+	FirstName string `json:"first_name" validate:"required,max=255"`
+	Surname   string `json:"surname" validate:"required,max=255"`
+}
+
+type UpdateUserRoleRequest struct {
+	Role database.UserRole `json:"role" validate:"required"`
+}
+
+// SetPasswordRequest is an admin resetting someone else's password
+type SetPasswordRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// ChangePasswordRequest is a user changing their own password
+type ChangePasswordRequest struct {
+	OldPassword string `json:"current_password" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required"`
+}
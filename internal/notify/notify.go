@@ -0,0 +1,129 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// sendTimeout bounds how long a background dispatch may take across all channels
+const sendTimeout = 30 * time.Second
+
+// UrgentDrop is the payload pushed to notification channels when an urgent drop goes live
+type UrgentDrop struct {
+	ID         uuid.UUID `json:"id"`
+	SchoolID   uuid.UUID `json:"school_id"`
+	AuthorID   uuid.UUID `json:"author_id"`
+	Title      string    `json:"title"`
+	Content    string    `json:"content"`
+	PostDate   time.Time `json:"post_date"`
+	ExpireDate time.Time `json:"expire_date"`
+}
+
+// Channel is a single way of pushing a notification out (webhook, mail, ...)
+type Channel interface {
+	Name() string
+	SendUrgent(ctx context.Context, drop UrgentDrop) error
+}
+
+// Dispatcher fans urgent drops out to every configured channel.
+// A nil *Dispatcher is valid and sends nothing.
+type Dispatcher struct {
+	channels []Channel
+
+	mu      sync.Mutex
+	lastErr map[string]error // each channel's most recent send error, nil once it succeeds again
+
+	inFlight sync.WaitGroup // background sends, waited for on shutdown
+}
+
+func NewDispatcher(channels ...Channel) *Dispatcher {
+	return &Dispatcher{channels: channels, lastErr: make(map[string]error)}
+}
+
+// Channels returns the names of the configured channels
+func (d *Dispatcher) Channels() []string {
+	if d == nil {
+		return nil
+	}
+	names := make([]string, 0, len(d.channels))
+	for _, c := range d.channels {
+		names = append(names, c.Name())
+	}
+	return names
+}
+
+// SendUrgent pushes drop to every channel, returning all channel errors joined
+func (d *Dispatcher) SendUrgent(ctx context.Context, drop UrgentDrop) error {
+	if d == nil {
+		return nil
+	}
+	var errs []error
+	for _, c := range d.channels {
+		err := c.SendUrgent(ctx, drop)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", c.Name(), err))
+		}
+		d.mu.Lock()
+		d.lastErr[c.Name()] = err
+		d.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+// Check reports the channels whose most recent send failed. Channels aren't probed, since that
+// would mean sending something, so a channel is only known to be broken once a real send fails.
+func (d *Dispatcher) Check(ctx context.Context) error {
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var errs []error
+	for _, c := range d.channels {
+		if err := d.lastErr[c.Name()]; err != nil {
+			errs = append(errs, fmt.Errorf("%s: last send failed: %w", c.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// SendUrgentAsync dispatches in the background so handlers are not held up by slow channels.
+// ctx is only used for its values (the request ID); the send carries on after the request finishes.
+func (d *Dispatcher) SendUrgentAsync(ctx context.Context, drop UrgentDrop) {
+	if d == nil || len(d.channels) == 0 {
+		return
+	}
+	d.inFlight.Add(1)
+	go func() {
+		defer d.inFlight.Done()
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), sendTimeout)
+		defer cancel()
+		if err := d.SendUrgent(ctx, drop); err != nil {
+			slog.ErrorContext(ctx, "could not push urgent drop", "drop_id", drop.ID, "error", err)
+		}
+	}()
+}
+
+// Shutdown waits for background sends to finish, or for ctx to be done
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	if d == nil {
+		return nil
+	}
+	done := make(chan struct{})
+	go func() {
+		d.inFlight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("urgent drop pushes still running: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,131 @@
+package notify_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/5tuartw/droplet/internal/logging"
+	"github.com/5tuartw/droplet/internal/notify"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingChannel remembers what it was sent, failing with err if set
+type recordingChannel struct {
+	name string
+	err  error
+
+	mu   sync.Mutex
+	sent []notify.UrgentDrop
+}
+
+func (c *recordingChannel) Name() string { return c.name }
+
+func (c *recordingChannel) SendUrgent(ctx context.Context, drop notify.UrgentDrop) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sent = append(c.sent, drop)
+	return c.err
+}
+
+func (c *recordingChannel) Sent() []notify.UrgentDrop {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]notify.UrgentDrop(nil), c.sent...)
+}
+
+func testDrop() notify.UrgentDrop {
+	now := time.Now().UTC().Truncate(time.Second)
+	return notify.UrgentDrop{
+		ID:         uuid.New(),
+		SchoolID:   uuid.New(),
+		AuthorID:   uuid.New(),
+		Title:      "Fire drill",
+		Content:    "Leave by the nearest exit",
+		PostDate:   now,
+		ExpireDate: now.Add(time.Hour),
+	}
+}
+
+// requestContext returns a context carrying a request ID, as handlers see it
+func requestContext(t *testing.T, id string) context.Context {
+	t.Helper()
+	var ctx context.Context
+	handler := logging.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { ctx = r.Context() }))
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set(logging.RequestIDHeader, id)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	return ctx
+}
+
+func TestWebhookChannel(t *testing.T) {
+	drop := testDrop()
+	status := http.StatusNoContent
+	var received notify.UrgentDrop
+	var contentType, requestID string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		requestID = r.Header.Get(logging.RequestIDHeader)
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	channel := notify.NewWebhookChannel(srv.URL)
+	require.NoError(t, channel.SendUrgent(requestContext(t, "req-42"), drop))
+	require.Equal(t, "application/json", contentType)
+	require.Equal(t, "req-42", requestID, "the request ID is passed on so the push can be traced")
+	require.Equal(t, drop.ID, received.ID)
+	require.Equal(t, drop.Title, received.Title)
+	require.True(t, drop.PostDate.Equal(received.PostDate))
+
+	status = http.StatusBadGateway
+	require.ErrorContains(t, channel.SendUrgent(context.Background(), drop), "status 502")
+}
+
+func TestDispatcher(t *testing.T) {
+	ok := &recordingChannel{name: "ok"}
+	broken := &recordingChannel{name: "broken", err: errors.New("connection refused")}
+	d := notify.NewDispatcher(ok, broken)
+	require.Equal(t, []string{"ok", "broken"}, d.Channels())
+	require.NoError(t, d.Check(context.Background()), "nothing is known to be broken before the first send")
+
+	drop := testDrop()
+	err := d.SendUrgent(context.Background(), drop)
+	require.ErrorContains(t, err, "broken: connection refused")
+	require.Equal(t, []notify.UrgentDrop{drop}, ok.Sent(), "one channel failing doesn't stop the others")
+	require.ErrorContains(t, d.Check(context.Background()), "broken: last send failed")
+
+	broken.err = nil
+	require.NoError(t, d.SendUrgent(context.Background(), drop))
+	require.NoError(t, d.Check(context.Background()), "a channel recovers once a send succeeds")
+}
+
+func TestDispatcherSendUrgentAsync(t *testing.T) {
+	channel := &recordingChannel{name: "ok"}
+	d := notify.NewDispatcher(channel)
+
+	// The request finishing doesn't cancel the send
+	ctx, cancel := context.WithCancel(context.Background())
+	drop := testDrop()
+	d.SendUrgentAsync(ctx, drop)
+	cancel()
+
+	require.NoError(t, d.Shutdown(context.Background()))
+	require.Equal(t, []notify.UrgentDrop{drop}, channel.Sent())
+}
+
+func TestNilDispatcher(t *testing.T) {
+	var d *notify.Dispatcher
+	require.Nil(t, d.Channels())
+	require.NoError(t, d.SendUrgent(context.Background(), testDrop()))
+	d.SendUrgentAsync(context.Background(), testDrop())
+	require.NoError(t, d.Check(context.Background()))
+	require.NoError(t, d.Shutdown(context.Background()))
+}
@@ -0,0 +1,147 @@
+// Package pagination pages list endpoints by cursor (keyset) rather than offset, so pages stay
+// cheap and stable however many rows a school has and however many are added while a client reads.
+//
+// A request may send:
+//
+//	limit=N     rows per page, DefaultLimit if absent and at most MaxLimit
+//	sort=key    the endpoint's sort key, prefixed with "-" for descending (sort=-post_date)
+//	cursor=c    the cursor from the previous page's Link header, to read the next page
+//
+// A response with more rows after it carries a `Link: <url>; rel="next"` header and the bare
+// cursor in X-Next-Cursor. The last page has neither. Cursors are opaque to clients.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 200
+)
+
+// NextCursorHeader carries the next page's cursor alongside the Link header
+const NextCursorHeader = "X-Next-Cursor"
+
+// Params are the paging options of one list request
+type Params struct {
+	Limit int
+	// Sort is the sort key without its direction, e.g. "post_date"
+	Sort string
+	Desc bool
+	// After is the last row of the previous page, nil on the first page
+	After *Cursor
+}
+
+// Cursor is the sort key of the last row of a page; the next page starts after it
+type Cursor struct {
+	Sort string   `json:"s"`
+	Keys []string `json:"k"`
+}
+
+// Parse reads limit, sort and cursor from q. sorts lists the sort keys the endpoint allows,
+// the first being its default, written as they would be sent (e.g. "-post_date").
+// The ascending or descending form of any listed key is accepted.
+func Parse(q url.Values, sorts ...string) (Params, error) {
+	p := Params{Limit: DefaultLimit}
+
+	if raw := q.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return p, errors.New("limit must be a positive whole number")
+		}
+		p.Limit = min(limit, MaxLimit)
+	}
+
+	sort := q.Get("sort")
+	if sort == "" {
+		sort = sorts[0]
+	}
+	p.Sort, p.Desc = strings.TrimPrefix(sort, "-"), strings.HasPrefix(sort, "-")
+	if !allowed(p.Sort, sorts) {
+		keys := make([]string, len(sorts))
+		for i, s := range sorts {
+			keys[i] = strings.TrimPrefix(s, "-")
+		}
+		return p, fmt.Errorf("sort must be one of %s, optionally prefixed with -", strings.Join(keys, ", "))
+	}
+
+	if raw := q.Get("cursor"); raw != "" {
+		cursor, err := decode(raw)
+		if err != nil {
+			return p, errors.New("cursor is not valid")
+		}
+		if cursor.Sort != p.sortParam() {
+			return p, errors.New("cursor belongs to a different sort; start again without it")
+		}
+		p.After = cursor
+	}
+	return p, nil
+}
+
+// Key is the i'th key of the cursor, or "" on the first page
+func (p Params) Key(i int) string {
+	if p.After == nil || i >= len(p.After.Keys) {
+		return ""
+	}
+	return p.After.Keys[i]
+}
+
+// FetchLimit is how many rows to ask the database for: one more than a page,
+// so the extra row shows whether there is a next page
+func (p Params) FetchLimit() int32 {
+	return int32(p.Limit + 1)
+}
+
+// SetNext advertises the page after the row with the given sort keys in the Link and
+// X-Next-Cursor headers. Call it before writing the body, and only if there is a next page.
+func SetNext(w http.ResponseWriter, r *http.Request, p Params, keys ...string) {
+	cursor := encode(Cursor{Sort: p.sortParam(), Keys: keys})
+
+	q := r.URL.Query()
+	q.Set("cursor", cursor)
+	next := url.URL{Path: r.URL.Path, RawQuery: q.Encode()}
+
+	w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.String()))
+	w.Header().Set(NextCursorHeader, cursor)
+}
+
+func (p Params) sortParam() string {
+	if p.Desc {
+		return "-" + p.Sort
+	}
+	return p.Sort
+}
+
+func allowed(key string, sorts []string) bool {
+	for _, s := range sorts {
+		if strings.TrimPrefix(s, "-") == key {
+			return true
+		}
+	}
+	return false
+}
+
+func encode(c Cursor) string {
+	dat, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(dat)
+}
+
+func decode(raw string) (*Cursor, error) {
+	dat, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, err
+	}
+	var c Cursor
+	if err := json.Unmarshal(dat, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
@@ -0,0 +1,62 @@
+package pagination_test
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/5tuartw/droplet/internal/pagination"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDefaults(t *testing.T) {
+	p, err := pagination.Parse(url.Values{}, "-post_date")
+	require.NoError(t, err)
+	require.Equal(t, pagination.Params{Limit: pagination.DefaultLimit, Sort: "post_date", Desc: true}, p)
+
+	p, err = pagination.Parse(url.Values{"limit": {"1000"}, "sort": {"post_date"}}, "-post_date")
+	require.NoError(t, err)
+	require.Equal(t, pagination.MaxLimit, p.Limit, "limits are capped, not rejected")
+	require.False(t, p.Desc)
+
+	for _, q := range []url.Values{
+		{"limit": {"0"}},
+		{"limit": {"ten"}},
+		{"sort": {"title"}},
+		{"cursor": {"not a cursor"}},
+	} {
+		_, err := pagination.Parse(q, "-post_date")
+		require.Error(t, err, q.Encode())
+	}
+}
+
+func TestNextPageRoundTrip(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/users?role=office&limit=2", nil)
+	p, err := pagination.Parse(r.URL.Query(), "surname")
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	pagination.SetNext(rec, r, p, "Lovelace", "Ada", "42")
+	cursor := rec.Header().Get(pagination.NextCursorHeader)
+	require.NotEmpty(t, cursor)
+
+	link := rec.Header().Get("Link")
+	require.True(t, strings.HasPrefix(link, "<") && strings.HasSuffix(link, `>; rel="next"`), link)
+	next, err := url.Parse(strings.TrimSuffix(strings.TrimPrefix(link, "<"), `>; rel="next"`))
+	require.NoError(t, err)
+	require.Equal(t, "/api/users", next.Path)
+	require.Equal(t, "office", next.Query().Get("role"), "filters carry over to the next page")
+	require.Equal(t, cursor, next.Query().Get("cursor"))
+
+	p, err = pagination.Parse(next.Query(), "surname")
+	require.NoError(t, err)
+	require.Equal(t, 2, p.Limit)
+	require.Equal(t, []string{"Lovelace", "Ada", "42"}, p.After.Keys)
+	require.Equal(t, "Ada", p.Key(1))
+
+	q := next.Query()
+	q.Set("sort", "-surname")
+	_, err = pagination.Parse(q, "surname")
+	require.Error(t, err, "a cursor only continues the sort it came from")
+}
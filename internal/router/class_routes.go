@@ -18,33 +18,54 @@ func registerClassRoutes(mux *http.ServeMux, cfg *config.ApiConfig, db *sql.DB,
 	}
 	mux.HandleFunc("GET /api/classes", auth.RequireAuth(cfg, getClassesHandler))
 
-	// All Admin only
+	// Structure management (admin only by default)
 	// POST /api/classes
 	addClassHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
 		school_structure.CreateClass(dbq, w, r)
 	}
-	addYearGroupChain := auth.RequireAuth(cfg, auth.RequireAdmin(cfg, addClassHandlerFunc))
+	addYearGroupChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermStructureManage, addClassHandlerFunc))
 	mux.HandleFunc("POST /api/classes", addYearGroupChain)
 
 	// PATCH /api/classes/{classID}/name
 	renameClassHandler := func(w http.ResponseWriter, r *http.Request) {
 		school_structure.RenameClass(cfg, dbq, w, r)
 	}
-	renameClassChain := auth.RequireAuth(cfg, auth.RequireAdmin(cfg, renameClassHandler))
+	renameClassChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermStructureManage, renameClassHandler))
 	mux.HandleFunc("PATCH /api/classes/{classID}/name", renameClassChain)
 
 	// PATCH /api/classes/{classID}/yeargroup
 	moveClassHandler := func(w http.ResponseWriter, r *http.Request) {
 		school_structure.MoveClass(cfg, dbq, w, r)
 	}
-	moveClassChain := auth.RequireAuth(cfg, auth.RequireAdmin(cfg, moveClassHandler))
+	moveClassChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermStructureManage, moveClassHandler))
 	mux.HandleFunc("PATCH /api/classes/{classID}/yeargroup", moveClassChain)
 
 	// DELETE /api/classes/{classID}
 	deleteClassHandler := func(w http.ResponseWriter, r *http.Request) {
 		school_structure.DeleteClass(cfg, dbq, w, r)
 	}
-	deleteClassChain := auth.RequireAuth(cfg, auth.RequireAdmin(cfg, deleteClassHandler))
+	deleteClassChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermStructureManage, deleteClassHandler))
 	mux.HandleFunc("DELETE /api/classes/{classID}", deleteClassChain)
 
+	// GET /api/classes/{classID}/staff
+	getClassStaffHandler := func(w http.ResponseWriter, r *http.Request) {
+		school_structure.GetClassStaff(dbq, w, r)
+	}
+	getClassStaffChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermStructureView, getClassStaffHandler))
+	mux.HandleFunc("GET /api/classes/{classID}/staff", getClassStaffChain)
+
+	// PUT /api/classes/{classID}/staff/{userID}
+	assignClassStaffHandler := func(w http.ResponseWriter, r *http.Request) {
+		school_structure.AssignClassStaff(cfg, dbq, w, r)
+	}
+	assignClassStaffChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermStructureManage, assignClassStaffHandler))
+	mux.HandleFunc("PUT /api/classes/{classID}/staff/{userID}", assignClassStaffChain)
+
+	// DELETE /api/classes/{classID}/staff/{userID}
+	removeClassStaffHandler := func(w http.ResponseWriter, r *http.Request) {
+		school_structure.RemoveClassStaff(cfg, dbq, w, r)
+	}
+	removeClassStaffChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermStructureManage, removeClassStaffHandler))
+	mux.HandleFunc("DELETE /api/classes/{classID}/staff/{userID}", removeClassStaffChain)
+
 }
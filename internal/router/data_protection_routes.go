@@ -0,0 +1,42 @@
+package router
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/5tuartw/droplet/internal/auth"
+	"github.com/5tuartw/droplet/internal/config"
+	"github.com/5tuartw/droplet/internal/controllers/dataprotection"
+	"github.com/5tuartw/droplet/internal/database"
+)
+
+func registerDataProtectionRoutes(mux *http.ServeMux, cfg *config.ApiConfig, db *sql.DB, dbq *database.Queries) {
+
+	// GET /api/pupils/{pupilID}/export (requires pupils.manage)
+	exportPupilHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
+		dataprotection.ExportPupil(dbq, w, r)
+	}
+	exportPupilChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermPupilsManage, exportPupilHandlerFunc))
+	mux.HandleFunc("GET /api/pupils/{pupilID}/export", exportPupilChain)
+
+	// POST /api/pupils/{pupilID}/erase (requires pupils.manage)
+	erasePupilHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
+		dataprotection.ErasePupil(cfg, dbq, w, r)
+	}
+	erasePupilChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermPupilsManage, erasePupilHandlerFunc))
+	mux.HandleFunc("POST /api/pupils/{pupilID}/erase", erasePupilChain)
+
+	// GET /api/users/{userID}/export (requires users.manage)
+	exportUserHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
+		dataprotection.ExportUser(cfg, dbq, w, r)
+	}
+	exportUserChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermUsersManage, exportUserHandlerFunc))
+	mux.HandleFunc("GET /api/users/{userID}/export", exportUserChain)
+
+	// POST /api/users/{userID}/erase (requires users.manage)
+	eraseUserHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
+		dataprotection.EraseUser(cfg, db, dbq, w, r)
+	}
+	eraseUserChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermUsersManage, eraseUserHandlerFunc))
+	mux.HandleFunc("POST /api/users/{userID}/erase", eraseUserChain)
+}
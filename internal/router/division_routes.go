@@ -16,28 +16,28 @@ func registerDivisionRoutes(mux *http.ServeMux, cfg *config.ApiConfig, db *sql.D
 	getDivisionsHandler := func(w http.ResponseWriter, r *http.Request) {
 		school_structure.GetDivisions(dbq, w, r)
 	}
-	mux.HandleFunc("GET /api/divisions", auth.RequireAuth(cfg, getDivisionsHandler)) // <<< No RequireAdmin here
+	mux.HandleFunc("GET /api/divisions", auth.RequireAuth(cfg, getDivisionsHandler)) // <<< No permission check here
 
-	// All Admin only
+	// Structure management (admin only by default)
 	// POST /api/divisions
 	addDivisionHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
 		school_structure.CreateDivision(dbq, w, r)
 	}
-	addDivisionChain := auth.RequireAuth(cfg, auth.RequireAdmin(cfg, addDivisionHandlerFunc))
+	addDivisionChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermStructureManage, addDivisionHandlerFunc))
 	mux.HandleFunc("POST /api/divisions", addDivisionChain)
 
 	// PATCH /api/divisions/{divisionID}/name
 	renameDivisionHandler := func(w http.ResponseWriter, r *http.Request) {
 		school_structure.RenameDivision(cfg, dbq, w, r)
 	}
-	renameDivisionChain := auth.RequireAuth(cfg, auth.RequireAdmin(cfg, renameDivisionHandler))
+	renameDivisionChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermStructureManage, renameDivisionHandler))
 	mux.HandleFunc("PATCH /api/divisions/{divisionID}/name", renameDivisionChain)
 
 	// DELETE /api/divisions/{divisionID}
 	deleteDivisionHandler := func(w http.ResponseWriter, r *http.Request) {
 		school_structure.DeleteDivision(cfg, dbq, w, r)
 	}
-	deleteDivisionChain := auth.RequireAuth(cfg, auth.RequireAdmin(cfg, deleteDivisionHandler))
+	deleteDivisionChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermStructureManage, deleteDivisionHandler))
 	mux.HandleFunc("DELETE /api/divisions/{divisionID}", deleteDivisionChain)
 
 }
@@ -14,21 +14,31 @@ func registerDropRoutes(mux *http.ServeMux, cfg *config.ApiConfig, db *sql.DB, d
 
 	// POST /api/drops (CreateDrop)
 	createDropHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
-		drops.CreateDrop(db, dbq, w, r)
+		drops.CreateDrop(cfg, db, dbq, w, r)
 	}
-	mux.HandleFunc("POST /api/drops", auth.RequireAuth(cfg, createDropHandlerFunc))
+	createDropChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermDropsCreate, createDropHandlerFunc))
+	mux.HandleFunc("POST /api/drops", createDropChain)
+
+	// POST /api/drops/preview-audience (PreviewAudience) - who a set of targets would reach
+	previewAudienceHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
+		drops.PreviewAudience(dbq, w, r)
+	}
+	previewAudienceChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermDropsCreate, previewAudienceHandlerFunc))
+	mux.HandleFunc("POST /api/drops/preview-audience", previewAudienceChain)
 
 	// DELETE /api/drops/{dropID} (DeleteDrop)
 	deleteDropHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
-		drops.DeleteDrop(dbq, w, r)
+		drops.DeleteDrop(cfg, dbq, w, r)
 	}
-	mux.HandleFunc("DELETE /api/drops/{dropID}", auth.RequireAuth(cfg, deleteDropHandlerFunc))
+	deleteDropChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermDropsManage, deleteDropHandlerFunc))
+	mux.HandleFunc("DELETE /api/drops/{dropID}", deleteDropChain)
 
 	// PUT /api/drops{dropID} (UpdateDrop)
 	updateDropHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
-		drops.UpdateDrop(db, dbq, w, r)
+		drops.UpdateDrop(cfg, db, dbq, w, r)
 	}
-	mux.HandleFunc("PUT /api/drops/{dropID}", auth.RequireAuth(cfg, updateDropHandlerFunc))
+	updateDropChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermDropsManage, updateDropHandlerFunc))
+	mux.HandleFunc("PUT /api/drops/{dropID}", updateDropChain)
 
 	// GET /api/drops (GetActiveDrops) - Assuming this needs auth
 	getActiveDropsHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
@@ -54,10 +64,59 @@ func registerDropRoutes(mux *http.ServeMux, cfg *config.ApiConfig, db *sql.DB, d
 	}
 	mux.HandleFunc("GET /api/drops/{dropID}", auth.RequireAuth(cfg, getDropAndTargetsHandlerFunc))
 
+	// GET /api/drops/pending (GetPendingDrops) - approval queue
+	getPendingDropsHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
+		drops.GetPendingDrops(dbq, w, r)
+	}
+	getPendingDropsChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermDropsApprove, getPendingDropsHandlerFunc))
+	mux.HandleFunc("GET /api/drops/pending", getPendingDropsChain)
+
+	// POST /api/drops/{dropID}/approve (ApproveDrop)
+	approveDropHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
+		drops.ApproveDrop(cfg, dbq, w, r)
+	}
+	approveDropChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermDropsApprove, approveDropHandlerFunc))
+	mux.HandleFunc("POST /api/drops/{dropID}/approve", approveDropChain)
+
+	// POST /api/drops/{dropID}/reject (RejectDrop)
+	rejectDropHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
+		drops.RejectDrop(dbq, w, r)
+	}
+	rejectDropChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermDropsApprove, rejectDropHandlerFunc))
+	mux.HandleFunc("POST /api/drops/{dropID}/reject", rejectDropChain)
+
+	// POST /api/drops/{dropID}/resubmit (ResubmitDrop)
+	resubmitDropHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
+		drops.ResubmitDrop(dbq, w, r)
+	}
+	resubmitDropChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermDropsManage, resubmitDropHandlerFunc))
+	mux.HandleFunc("POST /api/drops/{dropID}/resubmit", resubmitDropChain)
+
+	// POST /api/drops/{dropID}/attachments (UploadAttachment)
+	uploadAttachmentHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
+		drops.UploadAttachment(cfg, dbq, w, r)
+	}
+	uploadAttachmentChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermDropsManage, uploadAttachmentHandlerFunc))
+	mux.HandleFunc("POST /api/drops/{dropID}/attachments", uploadAttachmentChain)
+
+	// GET /api/drops/{dropID}/attachments/{attachmentID} (GetAttachment) - visibility follows the drop
+	getAttachmentHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
+		drops.GetAttachment(cfg, dbq, w, r)
+	}
+	mux.HandleFunc("GET /api/drops/{dropID}/attachments/{attachmentID}", auth.RequireAuth(cfg, getAttachmentHandlerFunc))
+
+	// DELETE /api/drops/{dropID}/attachments/{attachmentID} (DeleteAttachment)
+	deleteAttachmentHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
+		drops.DeleteAttachment(cfg, dbq, w, r)
+	}
+	deleteAttachmentChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermDropsManage, deleteAttachmentHandlerFunc))
+	mux.HandleFunc("DELETE /api/drops/{dropID}/attachments/{attachmentID}", deleteAttachmentChain)
+
 	// POST /api/droptargets (AddDropTarget)
 	addDropTargetHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
-		drops.AddDropTarget(dbq, w, r)
+		drops.AddDropTarget(db, dbq, w, r)
 	}
-	mux.HandleFunc("POST /api/droptargets", auth.RequireAuth(cfg, addDropTargetHandlerFunc))
+	addDropTargetChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermDropsManage, addDropTargetHandlerFunc))
+	mux.HandleFunc("POST /api/droptargets", addDropTargetChain)
 
 }
@@ -0,0 +1,52 @@
+package router
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/5tuartw/droplet/internal/config"
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/health"
+	"github.com/5tuartw/droplet/internal/migrate"
+	"github.com/5tuartw/droplet/internal/storage"
+	"github.com/5tuartw/droplet/sql/schema"
+)
+
+func registerHealthRoutes(mux *http.ServeMux, cfg *config.ApiConfig, db *sql.DB, dbq *database.Queries) {
+	checks := []health.Check{
+		{Name: "database", Critical: true, Run: db.PingContext},
+	}
+
+	runner, err := migrate.New(db, schema.FS)
+	if err != nil {
+		slog.Warn("could not load migrations, /readyz will not check the schema version", "error", err)
+	} else {
+		checks = append(checks, health.Check{Name: "schema", Critical: true, Run: func(ctx context.Context) error {
+			pending, err := runner.Pending(ctx)
+			if err != nil {
+				return err
+			}
+			if len(pending) > 0 {
+				return fmt.Errorf("%d migration(s) pending, starting with %s", len(pending), pending[0].Name)
+			}
+			return nil
+		}})
+	}
+
+	if checker, ok := cfg.Attachments.(storage.Checker); ok {
+		checks = append(checks, health.Check{Name: "attachments", Run: checker.Check})
+	}
+	if len(cfg.Notifier.Channels()) > 0 {
+		checks = append(checks, health.Check{Name: "notifications", Run: cfg.Notifier.Check})
+	}
+
+	checker := health.NewChecker(checks...)
+
+	// GET /healthz (no auth) - liveness: the process is up
+	mux.HandleFunc("GET /healthz", checker.Liveness)
+	// GET /readyz (no auth) - readiness: the database is reachable and the schema current
+	mux.HandleFunc("GET /readyz", checker.Readiness)
+}
@@ -0,0 +1,71 @@
+package router
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/5tuartw/droplet/internal/auth"
+	"github.com/5tuartw/droplet/internal/config"
+	"github.com/5tuartw/droplet/internal/controllers/platform"
+	"github.com/5tuartw/droplet/internal/database"
+)
+
+func registerPlatformRoutes(mux *http.ServeMux, cfg *config.ApiConfig, db *sql.DB, dbq *database.Queries) {
+
+	// GET /api/platform/schools (requires platform.manage)
+	getSchoolsHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
+		platform.GetSchools(dbq, w, r)
+	}
+	getSchoolsChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermPlatformManage, getSchoolsHandlerFunc))
+	mux.HandleFunc("GET /api/platform/schools", getSchoolsChain)
+
+	// POST /api/platform/schools (requires platform.manage)
+	createSchoolHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
+		platform.CreateSchool(db, dbq, w, r)
+	}
+	createSchoolChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermPlatformManage, createSchoolHandlerFunc))
+	mux.HandleFunc("POST /api/platform/schools", createSchoolChain)
+
+	// POST /api/platform/schools/import (requires platform.manage)
+	importSchoolHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
+		platform.ImportSchool(db, dbq, w, r)
+	}
+	importSchoolChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermPlatformManage, importSchoolHandlerFunc))
+	mux.HandleFunc("POST /api/platform/schools/import", importSchoolChain)
+
+	// GET /api/platform/schools/{schoolID} (requires platform.manage)
+	getSchoolHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
+		platform.GetSchool(dbq, w, r)
+	}
+	getSchoolChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermPlatformManage, getSchoolHandlerFunc))
+	mux.HandleFunc("GET /api/platform/schools/{schoolID}", getSchoolChain)
+
+	// POST /api/platform/schools/{schoolID}/suspend (requires platform.manage)
+	suspendSchoolHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
+		platform.SuspendSchool(dbq, w, r)
+	}
+	suspendSchoolChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermPlatformManage, suspendSchoolHandlerFunc))
+	mux.HandleFunc("POST /api/platform/schools/{schoolID}/suspend", suspendSchoolChain)
+
+	// POST /api/platform/schools/{schoolID}/reactivate (requires platform.manage)
+	reactivateSchoolHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
+		platform.ReactivateSchool(dbq, w, r)
+	}
+	reactivateSchoolChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermPlatformManage, reactivateSchoolHandlerFunc))
+	mux.HandleFunc("POST /api/platform/schools/{schoolID}/reactivate", reactivateSchoolChain)
+
+	// POST /api/platform/schools/{schoolID}/admins (requires platform.manage)
+	createSchoolAdminHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
+		platform.CreateSchoolAdmin(dbq, w, r)
+	}
+	createSchoolAdminChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermPlatformManage, createSchoolAdminHandlerFunc))
+	mux.HandleFunc("POST /api/platform/schools/{schoolID}/admins", createSchoolAdminChain)
+
+	// PUT /api/platform/schools/{schoolID}/demo (requires platform.manage)
+	setSchoolDemoHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
+		platform.SetSchoolDemo(dbq, w, r)
+	}
+	setSchoolDemoChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermPlatformManage, setSchoolDemoHandlerFunc))
+	mux.HandleFunc("PUT /api/platform/schools/{schoolID}/demo", setSchoolDemoChain)
+
+}
@@ -18,25 +18,25 @@ func registerPupilRoutes(mux *http.ServeMux, cfg *config.ApiConfig, db *sql.DB,
 	}
 	mux.HandleFunc("GET /api/pupils", auth.RequireAuth(cfg, getPupilsHandlerFunc))
 
-	// PUT /api/pupils/{pupilID} (Admin only)
+	// PUT /api/pupils/{pupilID} (requires pupils.manage)
 	updatePupilHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
 		pupils.UpdatePupil(cfg, dbq, w, r)
 	}
-	updatePupilChain := auth.RequireAuth(cfg, auth.RequireAdmin(cfg, updatePupilHandlerFunc))
+	updatePupilChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermPupilsManage, updatePupilHandlerFunc))
 	mux.HandleFunc("PUT /api/pupils/{pupilID}", updatePupilChain)
 
-	// DELETE /api/pupils/{pupilID} (Admin only)
+	// DELETE /api/pupils/{pupilID} (requires pupils.manage)
 	deletePupilHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
-		pupils.DeletePupil(cfg, db, dbq, w, r)
+		pupils.DeletePupil(cfg, dbq, w, r)
 	}
-	deletePupilChain := auth.RequireAuth(cfg, auth.RequireAdmin(cfg, deletePupilHandlerFunc))
+	deletePupilChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermPupilsManage, deletePupilHandlerFunc))
 	mux.HandleFunc("DELETE /api/pupils/{pupilID}", deletePupilChain)
 
-	// POST /api/pupils (Admin only)
+	// POST /api/pupils (requires pupils.manage)
 	addPupilHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
 		pupils.AddPupil(dbq, w, r)
 	}
-	addPupilChain := auth.RequireAuth(cfg, auth.RequireAdmin(cfg, addPupilHandlerFunc))
+	addPupilChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermPupilsManage, addPupilHandlerFunc))
 	mux.HandleFunc("POST /api/pupils", addPupilChain)
 
 	// GET /api/pupils/{pupilID} (single)
@@ -6,10 +6,15 @@ import (
 
 	"github.com/5tuartw/droplet/internal/config"
 	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/logging"
+	"github.com/5tuartw/droplet/internal/metrics"
+	"github.com/5tuartw/droplet/internal/tenant"
 )
 
-// NewRouter creates and configures the main application router
-func NewRouter(cfg *config.ApiConfig, db *sql.DB, dbq *database.Queries) *http.ServeMux {
+// NewRouter creates and configures the main application router.
+// Every request is first given a request ID, access logged and counted, then goes through tenant resolution,
+// so handlers can see which school's subdomain it came in on.
+func NewRouter(cfg *config.ApiConfig, db *sql.DB, dbq *database.Queries) http.Handler {
 	mux := http.NewServeMux()
 
 	registerAuthRoutes(mux, cfg, db, dbq)         // Handles /api/login, /api/token/*, /api/status
@@ -23,6 +28,17 @@ func NewRouter(cfg *config.ApiConfig, db *sql.DB, dbq *database.Queries) *http.S
 	registerYearGroupRoutes(mux, cfg, db, dbq)
 	registerDivisionRoutes(mux, cfg, db, dbq)
 	registerSchoolStructureRoutesmux(mux, cfg, db, dbq)
+	registerSchoolRoutes(mux, cfg, db, dbq)         // Handles /api/school/* policies
+	registerTagRoutes(mux, cfg, db, dbq)            // Handles /api/tags/*
+	registerTimetableRoutes(mux, cfg, db, dbq)      // Handles /api/timetable/*
+	registerPlatformRoutes(mux, cfg, db, dbq)       // Handles /api/platform/* (platform admins only)
+	registerDataProtectionRoutes(mux, cfg, db, dbq) // Handles pupil/staff export and erasure
+	registerHealthRoutes(mux, cfg, db, dbq)         // Handles /healthz, /readyz
 
-	return mux
+	// GET /metrics (requires METRICS_TOKEN); with METRICS_ADDR set it is served on its own listener instead
+	if cfg.MetricsAddr == "" && cfg.MetricsToken != "" {
+		mux.Handle("GET /metrics", metrics.Handler(db, cfg.MetricsToken))
+	}
+
+	return logging.Middleware(metrics.Middleware(tenant.NewResolver(dbq, cfg.BaseDomain).Middleware(logging.RecordRoute(mux))))
 }
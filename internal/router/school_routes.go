@@ -0,0 +1,42 @@
+package router
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/5tuartw/droplet/internal/auth"
+	"github.com/5tuartw/droplet/internal/config"
+	"github.com/5tuartw/droplet/internal/controllers/school"
+	"github.com/5tuartw/droplet/internal/database"
+)
+
+func registerSchoolRoutes(mux *http.ServeMux, cfg *config.ApiConfig, db *sql.DB, dbq *database.Queries) {
+
+	// GET /api/school/settings (requires school.manage)
+	getSchoolSettingsHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
+		school.GetSchoolSettings(dbq, w, r)
+	}
+	getSchoolSettingsChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermSchoolManage, getSchoolSettingsHandlerFunc))
+	mux.HandleFunc("GET /api/school/settings", getSchoolSettingsChain)
+
+	// PUT /api/school/settings (requires school.manage)
+	updateSchoolSettingsHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
+		school.UpdateSchoolSettings(dbq, w, r)
+	}
+	updateSchoolSettingsChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermSchoolManage, updateSchoolSettingsHandlerFunc))
+	mux.HandleFunc("PUT /api/school/settings", updateSchoolSettingsChain)
+
+	// GET /api/school/export (requires school.manage)
+	exportSchoolHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
+		school.ExportSchool(cfg, dbq, w, r)
+	}
+	exportSchoolChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermSchoolManage, exportSchoolHandlerFunc))
+	mux.HandleFunc("GET /api/school/export", exportSchoolChain)
+
+	// GET /api/school/branding (public, used by the login page)
+	getBrandingHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
+		school.GetBranding(cfg, dbq, w, r)
+	}
+	mux.HandleFunc("GET /api/school/branding", getBrandingHandlerFunc)
+
+}
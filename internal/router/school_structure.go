@@ -11,10 +11,10 @@ import (
 )
 
 func registerSchoolStructureRoutesmux(mux *http.ServeMux, cfg *config.ApiConfig, db *sql.DB, dbq *database.Queries) {
-	// get /api/school-structure (admin only)
+	// get /api/school-structure (requires structure.view)
 	getSchoolStructureHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
 		school_structure.GetSchoolStructure(dbq, w, r)
 	}
-	getSchoolStructureChain := auth.RequireAuth(cfg, auth.RequireAdmin(cfg, getSchoolStructureHandlerFunc))
+	getSchoolStructureChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermStructureView, getSchoolStructureHandlerFunc))
 	mux.HandleFunc("GET /api/school-structure", getSchoolStructureChain)
 }
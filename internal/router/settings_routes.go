@@ -30,4 +30,10 @@ func registerSettingsRoutes(mux *http.ServeMux, cfg *config.ApiConfig, db *sql.D
 	}
 	mux.HandleFunc("PUT /api/settings/me/subscriptions", auth.RequireAuth(cfg, updateTargetSubscriptions))
 
+	// PUT /api/settings/me/tags
+	updateTagSubscriptions := func(w http.ResponseWriter, r *http.Request) {
+		settings.UpdateTagSubscriptions(db, dbq, w, r)
+	}
+	mux.HandleFunc("PUT /api/settings/me/tags", auth.RequireAuth(cfg, updateTagSubscriptions))
+
 }
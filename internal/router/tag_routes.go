@@ -0,0 +1,42 @@
+package router
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/5tuartw/droplet/internal/auth"
+	"github.com/5tuartw/droplet/internal/config"
+	"github.com/5tuartw/droplet/internal/controllers/tags"
+	"github.com/5tuartw/droplet/internal/database"
+)
+
+func registerTagRoutes(mux *http.ServeMux, cfg *config.ApiConfig, db *sql.DB, dbq *database.Queries) {
+
+	// GET /api/tags
+	getTagsHandler := func(w http.ResponseWriter, r *http.Request) {
+		tags.GetTags(dbq, w, r)
+	}
+	mux.HandleFunc("GET /api/tags", auth.RequireAuth(cfg, getTagsHandler))
+
+	// Tag vocabulary management (admin only by default)
+	// POST /api/tags
+	createTagHandler := func(w http.ResponseWriter, r *http.Request) {
+		tags.CreateTag(dbq, w, r)
+	}
+	createTagChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermTagsManage, createTagHandler))
+	mux.HandleFunc("POST /api/tags", createTagChain)
+
+	// PUT /api/tags/{tagID}
+	renameTagHandler := func(w http.ResponseWriter, r *http.Request) {
+		tags.RenameTag(dbq, w, r)
+	}
+	renameTagChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermTagsManage, renameTagHandler))
+	mux.HandleFunc("PUT /api/tags/{tagID}", renameTagChain)
+
+	// DELETE /api/tags/{tagID}
+	deleteTagHandler := func(w http.ResponseWriter, r *http.Request) {
+		tags.DeleteTag(dbq, w, r)
+	}
+	deleteTagChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermTagsManage, deleteTagHandler))
+	mux.HandleFunc("DELETE /api/tags/{tagID}", deleteTagChain)
+}
@@ -0,0 +1,35 @@
+package router
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/5tuartw/droplet/internal/auth"
+	"github.com/5tuartw/droplet/internal/config"
+	"github.com/5tuartw/droplet/internal/controllers/timetable"
+	"github.com/5tuartw/droplet/internal/database"
+)
+
+func registerTimetableRoutes(mux *http.ServeMux, cfg *config.ApiConfig, db *sql.DB, dbq *database.Queries) {
+
+	// GET /api/timetable
+	getTimetableHandler := func(w http.ResponseWriter, r *http.Request) {
+		timetable.GetTimetable(dbq, w, r)
+	}
+	mux.HandleFunc("GET /api/timetable", auth.RequireAuth(cfg, getTimetableHandler))
+
+	// PUT /api/timetable (text/csv)
+	importTimetableHandler := func(w http.ResponseWriter, r *http.Request) {
+		timetable.ImportTimetable(cfg, db, dbq, w, r)
+	}
+	importTimetableChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermStructureManage, importTimetableHandler))
+	mux.HandleFunc("PUT /api/timetable", importTimetableChain)
+
+	// PUT /api/timetable/cycle
+	setTimetableCycleHandler := func(w http.ResponseWriter, r *http.Request) {
+		timetable.SetTimetableCycle(cfg, dbq, w, r)
+	}
+	setTimetableCycleChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermStructureManage, setTimetableCycleHandler))
+	mux.HandleFunc("PUT /api/timetable/cycle", setTimetableCycleChain)
+
+}
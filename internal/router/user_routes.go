@@ -40,21 +40,21 @@ func registerUserRoutes(mux *http.ServeMux, cfg *config.ApiConfig, db *sql.DB, d
 	changePasswordHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
 		users.ChangePassword(cfg, dbq, w, r)
 	}
-	changePasswordChain := auth.RequireAuth(cfg, auth.RequireAdmin(cfg, changePasswordHandlerFunc))
+	changePasswordChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermUsersManage, changePasswordHandlerFunc))
 	mux.HandleFunc("PUT /api/users/{userID}/password", changePasswordChain)
 
 	// PATCH /api/users/{userID}/role (ChangeRole)
 	changeRoleHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
 		users.ChangeRole(dbq, w, r)
 	}
-	changeRoleChain := auth.RequireAuth(cfg, auth.RequireAdmin(cfg, changeRoleHandlerFunc))
+	changeRoleChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermUsersManage, changeRoleHandlerFunc))
 	mux.HandleFunc("PATCH /api/users/{userID}/role", changeRoleChain)
 
 	// PATCH /api/users/{userID}/name
 	updateUserNameHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
 		users.ChangeName(dbq, w, r)
 	}
-	updateNameChain := auth.RequireAuth(cfg, auth.RequireAdmin(cfg, updateUserNameHandlerFunc))
+	updateNameChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermUsersManage, updateUserNameHandlerFunc))
 	mux.HandleFunc("PATCH /api/users/{userID}/name", updateNameChain)
 
 	// DELETE /api/users (DeleteAllUsers)
@@ -67,7 +67,21 @@ func registerUserRoutes(mux *http.ServeMux, cfg *config.ApiConfig, db *sql.DB, d
 	createUserHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
 		users.CreateUser(dbq, w, r)
 	}
-	createUserChain := auth.RequireAuth(cfg, auth.RequireAdmin(cfg, createUserHandlerFunc))
+	createUserChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermUsersManage, createUserHandlerFunc))
 	mux.HandleFunc("POST /api/users", createUserChain)
 
+	// GET /api/users/{userID}/scopes
+	getUserScopesHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
+		users.GetUserScopes(dbq, w, r)
+	}
+	getUserScopesChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermUsersManage, getUserScopesHandlerFunc))
+	mux.HandleFunc("GET /api/users/{userID}/scopes", getUserScopesChain)
+
+	// PUT /api/users/{userID}/scopes
+	updateUserScopesHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
+		users.UpdateUserScopes(db, dbq, w, r)
+	}
+	updateUserScopesChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermUsersManage, updateUserScopesHandlerFunc))
+	mux.HandleFunc("PUT /api/users/{userID}/scopes", updateUserScopesChain)
+
 }
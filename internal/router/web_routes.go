@@ -2,11 +2,12 @@ package router
 
 import (
 	"database/sql" // db is passed down, but might not be needed directly here
-	"log"          // For logging errors
+	"log/slog"
 	"net/http"
 
 	"github.com/5tuartw/droplet/internal/config"
 	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/helpers"
 )
 
 func registerWebRoutes(mux *http.ServeMux, cfg *config.ApiConfig, db *sql.DB, dbq *database.Queries) {
@@ -18,7 +19,7 @@ func registerWebRoutes(mux *http.ServeMux, cfg *config.ApiConfig, db *sql.DB, db
 	const publicDir = "./public"
 	fs := http.FileServer(http.Dir(publicDir))
 	mux.Handle("/static/", http.StripPrefix("/static/", fs))
-	log.Println("Serving static files from:", publicDir)
+	slog.Info("serving static files", "dir", publicDir)
 
 	// --- Root Path (Public) ---
 	rootHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -40,6 +41,11 @@ func registerWebRoutes(mux *http.ServeMux, cfg *config.ApiConfig, db *sql.DB, db
 	// Register using Handle
 	mux.Handle("/", rootHandler)
 
+	// Unmatched API paths answer with a problem rather than falling through to the login page
+	mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		helpers.RespondWithProblem(w, http.StatusNotFound, helpers.CodeNotFound, "No API endpoint matches this method and path", nil)
+	})
+
 	// Drops Page
 	dropsPageHandler := func(w http.ResponseWriter, r *http.Request) {
 		http.ServeFile(w, r, publicDir+"/drops.html")
@@ -18,33 +18,33 @@ func registerYearGroupRoutes(mux *http.ServeMux, cfg *config.ApiConfig, db *sql.
 	}
 	mux.HandleFunc("GET /api/yeargroups", auth.RequireAuth(cfg, getYearGroupsHandler))
 
-	// All Admin only
+	// Structure management (admin only by default)
 	// POST /api/yeargroups
 	addYearGroupHandlerFunc := func(w http.ResponseWriter, r *http.Request) {
 		school_structure.CreateYearGroup(dbq, w, r)
 	}
-	addYearGroupChain := auth.RequireAuth(cfg, auth.RequireAdmin(cfg, addYearGroupHandlerFunc))
+	addYearGroupChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermStructureManage, addYearGroupHandlerFunc))
 	mux.HandleFunc("POST /api/yeargroups", addYearGroupChain)
 
 	// PATCH /api/yeargroups/{yeargroupID}/name
 	renameYearGroupHandler := func(w http.ResponseWriter, r *http.Request) {
 		school_structure.RenameYearGroup(cfg, dbq, w, r)
 	}
-	renameYearGroupChain := auth.RequireAuth(cfg, auth.RequireAdmin(cfg, renameYearGroupHandler))
+	renameYearGroupChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermStructureManage, renameYearGroupHandler))
 	mux.HandleFunc("PATCH /api/yeargroups/{yeargroupID}/name", renameYearGroupChain)
 
 	// PATCH /api/yeargroups/{yeargroupID}/division
 	moveYearGroupHandler := func(w http.ResponseWriter, r *http.Request) {
 		school_structure.MoveYearGroup(cfg, dbq, w, r)
 	}
-	moveYearGroupChain := auth.RequireAuth(cfg, auth.RequireAdmin(cfg, moveYearGroupHandler))
+	moveYearGroupChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermStructureManage, moveYearGroupHandler))
 	mux.HandleFunc("PATCH /api/yeargroups/{yeargroupID}/division", moveYearGroupChain)
 
 	// DELETE /api/yeargroups/{yeargroupID}
 	deleteYearGroupHandler := func(w http.ResponseWriter, r *http.Request) {
 		school_structure.DeleteYearGroup(cfg, dbq, w, r)
 	}
-	deleteYearGroupChain := auth.RequireAuth(cfg, auth.RequireAdmin(cfg, deleteYearGroupHandler))
+	deleteYearGroupChain := auth.RequireAuth(cfg, auth.RequirePermission(cfg, dbq, auth.PermStructureManage, deleteYearGroupHandler))
 	mux.HandleFunc("DELETE /api/yeargroups/{yeargroupID}", deleteYearGroupChain)
 
 }
@@ -0,0 +1,189 @@
+// Package schoolsettings is the typed form of the schools.settings JSONB document.
+// Schools that have never saved settings (or only saved some) get the defaults for everything missing.
+package schoolsettings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/validate"
+	"github.com/google/uuid"
+)
+
+// CurrentVersion is written into every saved document. Bump it, and upgrade older documents in Parse,
+// whenever a field changes meaning.
+const CurrentVersion = 1
+
+type Settings struct {
+	Version   int            `json:"version"`
+	Drops     DropSettings   `json:"drops"`
+	Passwords PasswordPolicy `json:"passwords"`
+	Demo      DemoSettings   `json:"demo"`
+	Branding  Branding       `json:"branding"`
+	Sessions  SessionPolicy  `json:"sessions"`
+}
+
+type DropSettings struct {
+	DefaultDurationDays int `json:"default_duration_days"` // expiry for drops posted without an expire_date
+	// School-wide drops from roles without drops.publish_wide wait for approval before they are shown
+	RequireApproval bool `json:"require_approval"`
+}
+
+type PasswordPolicy struct {
+	MinLength     int  `json:"min_length"`
+	RequireUpper  bool `json:"require_upper"`
+	RequireLower  bool `json:"require_lower"`
+	RequireNumber bool `json:"require_number"`
+}
+
+// DemoSettings lets a single school behave as a demo school (no password resets, user deletion or
+// structure and pupil edits) without putting the whole server into DEMO_MODE
+type DemoSettings struct {
+	Restricted bool `json:"restricted"`
+}
+
+// SessionPolicy controls how long staff stay logged in. Sessions slide: each token refresh extends the
+// session by its lifetime again, until the absolute lifetime since login is reached.
+type SessionPolicy struct {
+	AccessTokenMinutes int `json:"access_token_minutes"` // 0 uses the server's ACCESS_TOKEN_TTL
+	// Lifetime of sessions started without "remember me", e.g. on shared staffroom PCs
+	SessionHours int `json:"session_hours"`
+	// Lifetime of sessions started with "remember me"; 0 uses the server's REFRESH_TOKEN_TTL
+	RememberMeDays       int `json:"remember_me_days"`
+	AbsoluteLifetimeDays int `json:"absolute_lifetime_days"` // however often it is refreshed, a session ends this long after login
+	// Sessions without "remember me" end if not refreshed for this long; 0 disables.
+	// It can't be shorter than the access token lifetime, since clients only refresh when their access token expires.
+	IdleTimeoutMinutes int `json:"idle_timeout_minutes"`
+}
+
+type Branding struct {
+	PrimaryColour string `json:"primary_colour,omitempty"`
+	AccentColour  string `json:"accent_colour,omitempty"`
+}
+
+// Limits for validation
+const (
+	MinPasswordLength      = 8
+	MaxPasswordLength      = 72 // = max for bcrypt
+	maxDefaultDurationDays = 3650
+
+	minAccessTokenMinutes = 5
+	maxAccessTokenMinutes = 24 * 60
+	maxSessionHours       = 30 * 24
+	maxSessionDays        = 365
+	minIdleTimeoutMinutes = 5
+	maxIdleTimeoutMinutes = 7 * 24 * 60
+)
+
+var hexColourPattern = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// Default returns the settings a school starts with, matching Droplet's behaviour before settings existed
+func Default() Settings {
+	return Settings{
+		Version: CurrentVersion,
+		Drops: DropSettings{
+			DefaultDurationDays: 365,
+		},
+		Passwords: PasswordPolicy{
+			MinLength:     MinPasswordLength,
+			RequireUpper:  true,
+			RequireLower:  true,
+			RequireNumber: true,
+		},
+		Sessions: SessionPolicy{
+			SessionHours:         12,
+			AbsoluteLifetimeDays: 90,
+		},
+	}
+}
+
+// ValidHexColour reports whether colour is a #rgb or #rrggbb value
+func ValidHexColour(colour string) bool {
+	return hexColourPattern.MatchString(colour)
+}
+
+// Parse reads a stored document over the defaults. An empty document gives the defaults.
+func Parse(raw []byte) (Settings, error) {
+	settings := Default()
+	if len(raw) == 0 {
+		return settings, nil
+	}
+	if err := json.Unmarshal(raw, &settings); err != nil {
+		return Default(), fmt.Errorf("could not read school settings: %w", err)
+	}
+
+	switch settings.Version {
+	case 0:
+		// Written before settings were versioned (only ever held branding), which is compatible with version 1
+		settings.Version = CurrentVersion
+	case CurrentVersion:
+	default:
+		return Default(), fmt.Errorf("unsupported school settings version %d", settings.Version)
+	}
+	return settings, nil
+}
+
+// Validate returns every problem with the settings at once, by field, so an admin can fix them in one go
+func (s Settings) Validate() validate.Errors {
+	var errs validate.Errors
+	if s.Version != CurrentVersion {
+		errs = append(errs, validate.FieldError{Field: "version", Code: validate.CodeNotAllowed, Message: fmt.Sprintf("must be %d", CurrentVersion)})
+	}
+	if s.Drops.DefaultDurationDays < 1 || s.Drops.DefaultDurationDays > maxDefaultDurationDays {
+		errs = append(errs, rangeError("drops.default_duration_days", s.Drops.DefaultDurationDays, 1, fmt.Sprintf("must be between 1 and %d", maxDefaultDurationDays)))
+	}
+	if s.Passwords.MinLength < MinPasswordLength || s.Passwords.MinLength > MaxPasswordLength {
+		errs = append(errs, rangeError("passwords.min_length", s.Passwords.MinLength, MinPasswordLength, fmt.Sprintf("must be between %d and %d", MinPasswordLength, MaxPasswordLength)))
+	}
+	if s.Branding.PrimaryColour != "" && !ValidHexColour(s.Branding.PrimaryColour) {
+		errs = append(errs, validate.FieldError{Field: "branding.primary_colour", Code: validate.CodeInvalid, Message: "must be a hex colour such as #203171"})
+	}
+	if s.Branding.AccentColour != "" && !ValidHexColour(s.Branding.AccentColour) {
+		errs = append(errs, validate.FieldError{Field: "branding.accent_colour", Code: validate.CodeInvalid, Message: "must be a hex colour such as #1976D2"})
+	}
+	return append(errs, s.Sessions.errors()...)
+}
+
+func (p SessionPolicy) errors() validate.Errors {
+	var errs validate.Errors
+	if p.AccessTokenMinutes != 0 && (p.AccessTokenMinutes < minAccessTokenMinutes || p.AccessTokenMinutes > maxAccessTokenMinutes) {
+		errs = append(errs, rangeError("sessions.access_token_minutes", p.AccessTokenMinutes, minAccessTokenMinutes, fmt.Sprintf("must be 0 (server default) or between %d and %d", minAccessTokenMinutes, maxAccessTokenMinutes)))
+	}
+	if p.SessionHours < 1 || p.SessionHours > maxSessionHours {
+		errs = append(errs, rangeError("sessions.session_hours", p.SessionHours, 1, fmt.Sprintf("must be between 1 and %d", maxSessionHours)))
+	}
+	if p.RememberMeDays < 0 || p.RememberMeDays > maxSessionDays {
+		errs = append(errs, rangeError("sessions.remember_me_days", p.RememberMeDays, 0, fmt.Sprintf("must be 0 (server default) or between 1 and %d", maxSessionDays)))
+	}
+	if p.AbsoluteLifetimeDays < 1 || p.AbsoluteLifetimeDays > maxSessionDays {
+		errs = append(errs, rangeError("sessions.absolute_lifetime_days", p.AbsoluteLifetimeDays, 1, fmt.Sprintf("must be between 1 and %d", maxSessionDays)))
+	}
+	if p.IdleTimeoutMinutes != 0 && (p.IdleTimeoutMinutes < minIdleTimeoutMinutes || p.IdleTimeoutMinutes > maxIdleTimeoutMinutes) {
+		errs = append(errs, rangeError("sessions.idle_timeout_minutes", p.IdleTimeoutMinutes, minIdleTimeoutMinutes, fmt.Sprintf("must be 0 (off) or between %d and %d", minIdleTimeoutMinutes, maxIdleTimeoutMinutes)))
+	}
+	return errs
+}
+
+// rangeError reports a number outside its allowed range as too small or too large
+func rangeError(field string, value, min int, message string) validate.FieldError {
+	code := validate.CodeTooLarge
+	if value < min {
+		code = validate.CodeTooSmall
+	}
+	return validate.FieldError{Field: field, Code: code, Message: message}
+}
+
+// Load returns a school's settings with defaults filled in
+func Load(ctx context.Context, dbq *database.Queries, schoolID uuid.UUID) (Settings, error) {
+	raw, err := dbq.GetSchoolSettings(ctx, schoolID)
+	if err != nil {
+		return Default(), err
+	}
+	if !raw.Valid {
+		return Default(), nil
+	}
+	return Parse(raw.RawMessage)
+}
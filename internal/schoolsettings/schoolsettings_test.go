@@ -0,0 +1,50 @@
+package schoolsettings_test
+
+import (
+	"testing"
+
+	"github.com/5tuartw/droplet/internal/schoolsettings"
+	"github.com/5tuartw/droplet/internal/validate"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFillsDefaults(t *testing.T) {
+	// Documents saved before versioning only held branding
+	settings, err := schoolsettings.Parse([]byte(`{"branding": {"primary_colour": "#0b6e4f"}}`))
+	require.NoError(t, err)
+	require.Equal(t, schoolsettings.CurrentVersion, settings.Version)
+	require.Equal(t, "#0b6e4f", settings.Branding.PrimaryColour)
+	require.Equal(t, schoolsettings.Default().Drops, settings.Drops)
+	require.Equal(t, schoolsettings.Default().Passwords, settings.Passwords)
+
+	settings, err = schoolsettings.Parse([]byte(`{"version": 1, "passwords": {"min_length": 12}}`))
+	require.NoError(t, err)
+	require.Equal(t, 12, settings.Passwords.MinLength)
+	require.True(t, settings.Passwords.RequireUpper, "fields left out keep their defaults")
+
+	_, err = schoolsettings.Parse([]byte(`{"version": 99}`))
+	require.Error(t, err)
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	require.Empty(t, schoolsettings.Default().Validate())
+
+	settings := schoolsettings.Default()
+	settings.Drops.DefaultDurationDays = 0
+	settings.Passwords.MinLength = 4
+	settings.Branding.AccentColour = "red; background: url(x)"
+	settings.Sessions.IdleTimeoutMinutes = 1
+	settings.Sessions.SessionHours = 10000
+
+	fields := map[string]string{}
+	for _, fe := range settings.Validate() {
+		fields[fe.Field] = fe.Code
+	}
+	require.Equal(t, map[string]string{
+		"drops.default_duration_days":   validate.CodeTooSmall,
+		"passwords.min_length":          validate.CodeTooSmall,
+		"branding.accent_colour":        validate.CodeInvalid,
+		"sessions.session_hours":        validate.CodeTooLarge,
+		"sessions.idle_timeout_minutes": validate.CodeTooSmall,
+	}, fields)
+}
@@ -43,20 +43,43 @@ VALUES ('Year 1', 2, '4adc3aaf-8f42-4ef8-a800-46ab05dfaf58'),
 /*CREATE TABLE classes (
     id SERIAL PRIMARY KEY,
     class_name VARCHAR(255) UNIQUE NOT NULL,
-    year_group_id INT REFERENCES year_groups(id) ON DELETE SET NULL,
-    teacher_id UUID REFERENCES users(id) ON DELETE SET NULL
+    year_group_id INT REFERENCES year_groups(id) ON DELETE SET NULL
 );*/
-INSERT INTO classes (class_name, year_group_id, teacher_id, school_id)
-VALUES ('1A', 1, (SELECT id FROM users WHERE email = 'emily.carter@dropletschool.co.uk'), '4adc3aaf-8f42-4ef8-a800-46ab05dfaf58'),
-       ('1B', 1, (SELECT id FROM users WHERE email = 'david.rodriguez@dropletschool.co.uk'), '4adc3aaf-8f42-4ef8-a800-46ab05dfaf58'),
-       ('2A', 2, (SELECT id FROM users WHERE email = 'sarah.thompson@dropletschool.co.uk'), '4adc3aaf-8f42-4ef8-a800-46ab05dfaf58'),
-       ('2B', 2, (SELECT id FROM users WHERE email = 'john.wilson@dropletschool.co.uk'), '4adc3aaf-8f42-4ef8-a800-46ab05dfaf58'),
-       ('3A', 3, (SELECT id FROM users WHERE email = 'jessica.perez@dropletschool.co.uk'), '4adc3aaf-8f42-4ef8-a800-46ab05dfaf58'),
-       ('3B', 3, (SELECT id FROM users WHERE email = 'michael.davis@dropletschool.co.uk'), '4adc3aaf-8f42-4ef8-a800-46ab05dfaf58'),
-       ('4A', 4, (SELECT id FROM users WHERE email = 'amanda.garcia@dropletschool.co.uk'), '4adc3aaf-8f42-4ef8-a800-46ab05dfaf58'),
-       ('4B', 4, (SELECT id FROM users WHERE email = 'christopher.martinez@dropletschool.co.uk'), '4adc3aaf-8f42-4ef8-a800-46ab05dfaf58'),
-       ('5A', 5, (SELECT id FROM users WHERE email = 'stephanie.anderson@dropletschool.co.uk'), '4adc3aaf-8f42-4ef8-a800-46ab05dfaf58'),
-       ('5B', 5, (SELECT id FROM users WHERE email = 'brian.taylor@dropletschool.co.uk'), '4adc3aaf-8f42-4ef8-a800-46ab05dfaf58'),
-       ('6A', 6, (SELECT id FROM users WHERE email = 'nicole.moore@dropletschool.co.uk'), '4adc3aaf-8f42-4ef8-a800-46ab05dfaf58'),
-       ('6B', 6, (SELECT id FROM users WHERE email = 'kevin.jackson@dropletschool.co.uk'), '4adc3aaf-8f42-4ef8-a800-46ab05dfaf58');
+INSERT INTO classes (class_name, year_group_id, school_id)
+VALUES ('1A', 1, '4adc3aaf-8f42-4ef8-a800-46ab05dfaf58'),
+       ('1B', 1, '4adc3aaf-8f42-4ef8-a800-46ab05dfaf58'),
+       ('2A', 2, '4adc3aaf-8f42-4ef8-a800-46ab05dfaf58'),
+       ('2B', 2, '4adc3aaf-8f42-4ef8-a800-46ab05dfaf58'),
+       ('3A', 3, '4adc3aaf-8f42-4ef8-a800-46ab05dfaf58'),
+       ('3B', 3, '4adc3aaf-8f42-4ef8-a800-46ab05dfaf58'),
+       ('4A', 4, '4adc3aaf-8f42-4ef8-a800-46ab05dfaf58'),
+       ('4B', 4, '4adc3aaf-8f42-4ef8-a800-46ab05dfaf58'),
+       ('5A', 5, '4adc3aaf-8f42-4ef8-a800-46ab05dfaf58'),
+       ('5B', 5, '4adc3aaf-8f42-4ef8-a800-46ab05dfaf58'),
+       ('6A', 6, '4adc3aaf-8f42-4ef8-a800-46ab05dfaf58'),
+       ('6B', 6, '4adc3aaf-8f42-4ef8-a800-46ab05dfaf58');
+
+/*CREATE TABLE class_staff (
+    class_id INT NOT NULL REFERENCES classes(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    school_id UUID NOT NULL REFERENCES schools(id) ON DELETE CASCADE,
+    role class_staff_role NOT NULL DEFAULT 'tutor',
+    PRIMARY KEY (class_id, user_id)
+);*/
+INSERT INTO class_staff (class_id, user_id, school_id, role)
+SELECT cls.id, u.id, cls.school_id, 'tutor'
+FROM (VALUES ('1A', 'emily.carter@dropletschool.co.uk'),
+             ('1B', 'david.rodriguez@dropletschool.co.uk'),
+             ('2A', 'sarah.thompson@dropletschool.co.uk'),
+             ('2B', 'john.wilson@dropletschool.co.uk'),
+             ('3A', 'jessica.perez@dropletschool.co.uk'),
+             ('3B', 'michael.davis@dropletschool.co.uk'),
+             ('4A', 'amanda.garcia@dropletschool.co.uk'),
+             ('4B', 'christopher.martinez@dropletschool.co.uk'),
+             ('5A', 'stephanie.anderson@dropletschool.co.uk'),
+             ('5B', 'brian.taylor@dropletschool.co.uk'),
+             ('6A', 'nicole.moore@dropletschool.co.uk'),
+             ('6B', 'kevin.jackson@dropletschool.co.uk')) AS t(class_name, email)
+JOIN classes cls ON cls.class_name = t.class_name AND cls.school_id = '4adc3aaf-8f42-4ef8-a800-46ab05dfaf58'
+JOIN users u ON u.email = t.email;
     
\ No newline at end of file
@@ -28,8 +28,7 @@ VALUES (1, 'Year 1', 2, '4adc3aaf-8f42-4ef8-a800-46ab05dfaf58'),
 /*CREATE TABLE classes (
     id SERIAL PRIMARY KEY,
     class_name VARCHAR(255) UNIQUE NOT NULL,
-    year_group_id INT REFERENCES year_groups(id) ON DELETE SET NULL,
-    teacher_id UUID REFERENCES users(id) ON DELETE SET NULL
+    year_group_id INT REFERENCES year_groups(id) ON DELETE SET NULL
 );*/
 INSERT INTO classes (id, class_name, year_group_id, school_id)
 VALUES (1, '1A', 1, '4adc3aaf-8f42-4ef8-a800-46ab05dfaf58'),
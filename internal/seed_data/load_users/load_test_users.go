@@ -14,13 +14,25 @@ import (
 	"github.com/5tuartw/droplet/internal/database"
 	"github.com/5tuartw/droplet/internal/models"
 	"github.com/google/uuid"
+	"github.com/joho/godotenv"
 )
 
 var testSchoolID = uuid.MustParse("4adc3aaf-8f42-4ef8-a800-46ab05dfaf58")
 
 func main() {
-	_, dbq, db := config.LoadConfig()
+	if err := godotenv.Load(); err != nil {
+		log.Printf("Info: No .env file found or error loading: %v. Relying on system environment variables.", err)
+	}
+	dbConfig, err := config.LoadDatabase(os.Getenv("CONFIG_FILE"), os.LookupEnv)
+	if err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+	db, err := config.OpenDatabase(dbConfig)
+	if err != nil {
+		log.Fatal(err)
+	}
 	defer db.Close()
+	dbq := database.New(db)
 
 	users, err := loadUsersFromCSV("internal/seed_data/test_teachers.csv")
 	if err != nil {
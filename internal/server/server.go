@@ -0,0 +1,172 @@
+// Package server runs droplet's HTTP servers and background jobs and shuts them down cleanly: on SIGTERM it
+// stops accepting connections, lets in-flight requests finish, stops the jobs, then runs shutdown hooks
+// (background workers, the database).
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/5tuartw/droplet/internal/config"
+)
+
+// New builds an http.Server for handler with the timeouts and header limit from cfg
+func New(cfg config.HTTPConfig, addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+	}
+}
+
+// Hook is run after the servers have stopped, e.g. to wait for background work or close the database
+type Hook struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Job is work repeated while the servers are up, e.g. sending drops whose post date has arrived
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Runner serves one or more servers until its context is cancelled
+type Runner struct {
+	ShutdownTimeout time.Duration
+
+	servers   []*http.Server
+	listeners []net.Listener
+	jobs      []Job
+	hooks     []Hook
+}
+
+// Listen binds srv.Addr now, so a port already in use is reported before anything is served
+func (r *Runner) Listen(srv *http.Server) error {
+	ln, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		return err
+	}
+	r.Serve(srv, ln)
+	return nil
+}
+
+// Serve adds srv, to be served on ln
+func (r *Runner) Serve(srv *http.Server, ln net.Listener) {
+	r.servers = append(r.servers, srv)
+	r.listeners = append(r.listeners, ln)
+}
+
+// Every adds a job, run as soon as Run starts and then every interval. Errors are logged and the job
+// carries on. Its context is cancelled at shutdown, once requests have drained.
+func (r *Runner) Every(name string, interval time.Duration, fn func(ctx context.Context) error) {
+	r.jobs = append(r.jobs, Job{Name: name, Interval: interval, Run: fn})
+}
+
+// OnShutdown adds a hook. Hooks run in the order they were added.
+func (r *Runner) OnShutdown(name string, fn func(ctx context.Context) error) {
+	r.hooks = append(r.hooks, Hook{Name: name, Run: fn})
+}
+
+// Run serves until ctx is done or a server fails, then shuts down within ShutdownTimeout:
+// every server stops accepting and drains its in-flight requests, the jobs stop, then the hooks run.
+// It returns the server failure, if any, joined with anything that didn't shut down cleanly.
+func (r *Runner) Run(ctx context.Context) error {
+	serveErrs := make(chan error, len(r.servers))
+	for i, srv := range r.servers {
+		ln := r.listeners[i]
+		go func() {
+			log.Printf("Server listening on %s", ln.Addr())
+			if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+				serveErrs <- fmt.Errorf("server on %s: %w", ln.Addr(), err)
+			}
+		}()
+	}
+
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	var jobs sync.WaitGroup
+	for _, job := range r.jobs {
+		jobs.Add(1)
+		go func() {
+			defer jobs.Done()
+			runJob(jobsCtx, job)
+		}()
+	}
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+		log.Printf("Shutting down: draining requests for up to %s", r.ShutdownTimeout)
+	case runErr = <-serveErrs:
+		log.Printf("Shutting down after server error: %v", runErr)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), r.ShutdownTimeout)
+	defer cancel()
+
+	errs := []error{runErr}
+	shutdownErrs := make(chan error, len(r.servers))
+	for _, srv := range r.servers {
+		go func() {
+			err := srv.Shutdown(shutdownCtx)
+			if err != nil {
+				srv.Close() // out of time: cut off whatever is left
+			}
+			shutdownErrs <- err
+		}()
+	}
+	for range r.servers {
+		if err := <-shutdownErrs; err != nil {
+			errs = append(errs, fmt.Errorf("draining requests: %w", err))
+		}
+	}
+
+	stopJobs()
+	jobsDone := make(chan struct{})
+	go func() {
+		jobs.Wait()
+		close(jobsDone)
+	}()
+	select {
+	case <-jobsDone:
+	case <-shutdownCtx.Done():
+		errs = append(errs, fmt.Errorf("stopping jobs: %w", shutdownCtx.Err()))
+	}
+
+	for _, hook := range r.hooks {
+		if err := hook.Run(shutdownCtx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", hook.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// runJob runs job now and then every job.Interval until ctx is done
+func runJob(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+	for {
+		if err := job.Run(ctx); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "background job failed", "job", job.Name, "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
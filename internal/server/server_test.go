@@ -0,0 +1,158 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/5tuartw/droplet/internal/config"
+	"github.com/5tuartw/droplet/internal/server"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunDrainsRequestsThenRunsHooks(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.Write([]byte("finished"))
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	runner := &server.Runner{ShutdownTimeout: 5 * time.Second}
+	runner.Serve(server.New(config.HTTPConfig{ReadHeaderTimeout: time.Second}, "", handler), ln)
+
+	var order []string
+	runner.OnShutdown("workers", func(ctx context.Context) error {
+		order = append(order, "workers")
+		return nil
+	})
+	runner.OnShutdown("database", func(ctx context.Context) error {
+		order = append(order, "database")
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() { runErr <- runner.Run(ctx) }()
+
+	type result struct {
+		body string
+		err  error
+	}
+	response := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String())
+		if err != nil {
+			response <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		response <- result{string(body), err}
+	}()
+
+	<-started
+	cancel() // as if SIGTERM arrived mid-request
+
+	select {
+	case <-runErr:
+		t.Fatal("Run returned before the in-flight request finished")
+	case <-time.After(100 * time.Millisecond):
+	}
+	require.Empty(t, order, "hooks wait for requests to drain")
+
+	close(release)
+	res := <-response
+	require.NoError(t, res.err)
+	require.Equal(t, "finished", res.body)
+	require.NoError(t, <-runErr)
+	require.Equal(t, []string{"workers", "database"}, order)
+
+	_, err = net.Dial("tcp", ln.Addr().String())
+	require.Error(t, err, "no longer accepting connections")
+}
+
+func TestRunGivesUpAfterShutdownTimeout(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	runner := &server.Runner{ShutdownTimeout: 50 * time.Millisecond}
+	runner.Serve(server.New(config.HTTPConfig{}, "", handler), ln)
+
+	hookRan := false
+	runner.OnShutdown("database", func(ctx context.Context) error {
+		hookRan = true
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() { runErr <- runner.Run(ctx) }()
+
+	go http.Get("http://" + ln.Addr().String())
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	err = <-runErr
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.True(t, hookRan, "hooks still run when draining times out")
+}
+
+func TestEveryRunsJobsUntilShutdown(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	runner := &server.Runner{ShutdownTimeout: 5 * time.Second}
+	runner.Serve(server.New(config.HTTPConfig{}, "", http.NotFoundHandler()), ln)
+
+	var mu sync.Mutex
+	var order []string
+	runs := 0
+	thirdRun := make(chan struct{})
+	runner.Every("ticker", 10*time.Millisecond, func(ctx context.Context) error {
+		mu.Lock()
+		runs++
+		n := runs
+		mu.Unlock()
+		if n < 3 {
+			return errors.New("failures are logged and the job carries on")
+		}
+		if n == 3 {
+			close(thirdRun)
+			<-ctx.Done() // still running when shutdown starts
+			mu.Lock()
+			order = append(order, "job stopped")
+			mu.Unlock()
+		}
+		return nil
+	})
+	runner.OnShutdown("database", func(ctx context.Context) error {
+		mu.Lock()
+		order = append(order, "database")
+		mu.Unlock()
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() { runErr <- runner.Run(ctx) }()
+
+	select {
+	case <-thirdRun:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job didn't run every interval")
+	}
+	cancel()
+	require.NoError(t, <-runErr)
+	require.Equal(t, []string{"job stopped", "database"}, order, "hooks wait for jobs to stop")
+	require.Equal(t, 3, runs, "no runs after shutdown")
+}
@@ -0,0 +1,27 @@
+// Package storage holds drop attachment files. Keys are opaque paths chosen by the caller
+// (e.g. "<school_id>/<drop_id>/<uuid>"); implementations must not interpret them beyond that.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrNotFound = errors.New("storage: object not found")
+
+// Store is where attachment bytes live. The database keeps the metadata.
+type Store interface {
+	Name() string
+	// Put streams body to key. size may be -1 if unknown.
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get opens key for reading. It returns ErrNotFound if nothing is stored there.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes key. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// Checker is implemented by stores that can report whether they are reachable, for readiness checks
+type Checker interface {
+	Check(ctx context.Context) error
+}
@@ -0,0 +1,149 @@
+package storage_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/5tuartw/droplet/internal/storage"
+	"github.com/stretchr/testify/require"
+)
+
+// s3Stub is a minimal in-memory stand-in for an S3/MinIO endpoint: just enough of
+// PUT/HEAD/GET/DELETE on path-style object URLs for S3Store. Signatures are not checked.
+type s3Stub struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+}
+
+func newS3Stub() *s3Stub {
+	return &s3Stub{objects: make(map[string][]byte), types: make(map[string]string)}
+}
+
+func (s *s3Stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := r.URL.Path
+	switch r.Method {
+	case http.MethodPut:
+		body, err := readS3Body(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.objects[key] = body
+		s.types[key] = r.Header.Get("Content-Type")
+		w.Header().Set("ETag", `"stub"`)
+		w.WriteHeader(http.StatusOK)
+	case http.MethodHead, http.MethodGet:
+		body, ok := s.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("ETag", `"stub"`)
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		w.Header().Set("Content-Type", s.types[key])
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			w.Write(body)
+		}
+	case http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+// readS3Body undoes the aws-chunked encoding clients use for streaming-signed uploads over plain HTTP
+func readS3Body(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+	var out bytes.Buffer
+	reader := bufio.NewReader(r.Body)
+	for {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(header), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad chunk header %q: %w", header, err)
+		}
+		if size == 0 {
+			return out.Bytes(), nil
+		}
+		if _, err := io.CopyN(&out, reader, size); err != nil {
+			return nil, err
+		}
+		if _, err := reader.Discard(2); err != nil { // trailing CRLF
+			return nil, err
+		}
+	}
+}
+
+func exerciseStore(t *testing.T, store storage.Store) {
+	ctx := context.Background()
+	key := "school-a/drop-1/letter.pdf"
+	content := []byte("%PDF-1.4 trip letter")
+
+	require.NoError(t, store.Put(ctx, key, bytes.NewReader(content), int64(len(content)), "application/pdf"))
+
+	rc, err := store.Get(ctx, key)
+	require.NoError(t, err)
+	got, err := io.ReadAll(rc)
+	rc.Close()
+	require.NoError(t, err)
+	require.Equal(t, content, got)
+
+	require.NoError(t, store.Delete(ctx, key))
+	_, err = store.Get(ctx, key)
+	require.True(t, errors.Is(err, storage.ErrNotFound), "expected ErrNotFound after delete, got %v", err)
+
+	// Deleting again is not an error
+	require.NoError(t, store.Delete(ctx, key))
+}
+
+func TestLocalStore(t *testing.T) {
+	store, err := storage.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	exerciseStore(t, store)
+
+	err = store.Put(context.Background(), "../escape", strings.NewReader("x"), 1, "text/plain")
+	require.Error(t, err, "keys must not escape the root directory")
+}
+
+func TestS3StoreAgainstStub(t *testing.T) {
+	server := httptest.NewServer(newS3Stub())
+	defer server.Close()
+
+	endpoint, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	store, err := storage.NewS3Store(storage.S3Config{
+		Endpoint:  endpoint.Host,
+		AccessKey: "test-access",
+		SecretKey: "test-secret",
+		Bucket:    "attachments",
+		Region:    "us-east-1",
+	})
+	require.NoError(t, err)
+	exerciseStore(t, store)
+}
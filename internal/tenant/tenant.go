@@ -0,0 +1,153 @@
+// Package tenant works out which school a request is for from the host it was sent to,
+// e.g. greenfield.droplet.example.org is the school whose subdomain is "greenfield".
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/helpers"
+	"github.com/google/uuid"
+)
+
+type contextKey string
+
+const schoolKey contextKey = "tenantSchoolID"
+
+// Subdomain lookups are cached briefly so static files don't each cost a query. Only schools that
+// were found are cached, and there are never more than maxCacheEntries of them.
+const (
+	cacheTTL        = time.Minute
+	maxCacheEntries = 1024
+)
+
+type cacheEntry struct {
+	schoolID uuid.UUID
+	expires  time.Time
+}
+
+// schoolFinder is the query the Resolver needs; *database.Queries satisfies it
+type schoolFinder interface {
+	GetSchoolIDBySubdomain(ctx context.Context, subdomain sql.NullString) (uuid.UUID, error)
+}
+
+// Resolver maps subdomains of BaseDomain to schools
+type Resolver struct {
+	dbq        schoolFinder
+	baseDomain string
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+}
+
+// NewResolver returns a Resolver for hosts under baseDomain. An empty baseDomain turns resolution off.
+func NewResolver(dbq *database.Queries, baseDomain string) *Resolver {
+	return &Resolver{
+		dbq:        dbq,
+		baseDomain: strings.ToLower(strings.Trim(baseDomain, ".")),
+		cache:      make(map[string]cacheEntry),
+	}
+}
+
+// FromContext returns the school resolved from the request host, if there was one
+func FromContext(ctx context.Context) (uuid.UUID, bool) {
+	schoolID, ok := ctx.Value(schoolKey).(uuid.UUID)
+	return schoolID, ok
+}
+
+// Middleware adds the school for the request's subdomain to the context. Requests to the bare
+// domain (or www) carry no tenant; requests to a subdomain no school has claimed get a 404.
+func (res *Resolver) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		subdomain := res.subdomain(r.Host)
+		if subdomain == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		schoolID, found, err := res.lookup(r.Context(), subdomain)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "could not resolve tenant", "host", r.Host, "error", err)
+			helpers.RespondWithError(w, http.StatusInternalServerError, "Could not resolve school", nil)
+			return
+		}
+		if !found {
+			helpers.RespondWithProblem(w, http.StatusNotFound, helpers.CodeSchoolUnknown, "Unknown school", nil)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), schoolKey, schoolID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// subdomain returns the single label in front of the base domain, or "" if the host has none
+func (res *Resolver) subdomain(host string) string {
+	if res.baseDomain == "" {
+		return ""
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	subdomain, ok := strings.CutSuffix(host, "."+res.baseDomain)
+	if !ok || subdomain == "www" {
+		return ""
+	}
+	return subdomain
+}
+
+func (res *Resolver) lookup(ctx context.Context, subdomain string) (uuid.UUID, bool, error) {
+	res.mu.Lock()
+	entry, ok := res.cache[subdomain]
+	res.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.schoolID, true, nil
+	}
+
+	schoolID, err := res.dbq.GetSchoolIDBySubdomain(ctx, sql.NullString{String: subdomain, Valid: true})
+	if errors.Is(err, sql.ErrNoRows) {
+		// Misses aren't cached, so made-up subdomains can't fill the cache
+		res.mu.Lock()
+		delete(res.cache, subdomain)
+		res.mu.Unlock()
+		return uuid.Nil, false, nil
+	}
+	if err != nil {
+		return uuid.Nil, false, err
+	}
+
+	res.mu.Lock()
+	defer res.mu.Unlock()
+	now := time.Now()
+	if _, ok := res.cache[subdomain]; !ok && len(res.cache) >= maxCacheEntries {
+		res.evict(now)
+	}
+	res.cache[subdomain] = cacheEntry{schoolID: schoolID, expires: now.Add(cacheTTL)}
+	return schoolID, true, nil
+}
+
+// evict makes room in a full cache: expired entries go first, and if none have expired an arbitrary one does.
+// The caller must hold res.mu.
+func (res *Resolver) evict(now time.Time) {
+	for subdomain, entry := range res.cache {
+		if !now.Before(entry.expires) {
+			delete(res.cache, subdomain)
+		}
+	}
+	for subdomain := range res.cache {
+		if len(res.cache) < maxCacheEntries {
+			return
+		}
+		delete(res.cache, subdomain)
+	}
+}
@@ -0,0 +1,148 @@
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSchools answers subdomain lookups from a map and counts the queries
+type fakeSchools struct {
+	mu      sync.Mutex
+	schools map[string]uuid.UUID
+	err     error
+	queries int
+}
+
+func (f *fakeSchools) GetSchoolIDBySubdomain(ctx context.Context, subdomain sql.NullString) (uuid.UUID, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.queries++
+	if f.err != nil {
+		return uuid.Nil, f.err
+	}
+	schoolID, ok := f.schools[subdomain.String]
+	if !ok {
+		return uuid.Nil, sql.ErrNoRows
+	}
+	return schoolID, nil
+}
+
+func newTestResolver(schools map[string]uuid.UUID) (*Resolver, *fakeSchools) {
+	fake := &fakeSchools{schools: schools}
+	return &Resolver{dbq: fake, baseDomain: "droplet.example.org", cache: make(map[string]cacheEntry)}, fake
+}
+
+func TestSubdomain(t *testing.T) {
+	res, _ := newTestResolver(nil)
+	cases := map[string]string{
+		"greenfield.droplet.example.org":      "greenfield",
+		"Greenfield.Droplet.Example.org.":     "greenfield",
+		"greenfield.droplet.example.org:8080": "greenfield",
+		"droplet.example.org":                 "",
+		"www.droplet.example.org":             "",
+		"greenfield.example.com":              "",
+		"evildroplet.example.org":             "",
+	}
+	for host, want := range cases {
+		require.Equal(t, want, res.subdomain(host), host)
+	}
+
+	off := NewResolver(nil, "")
+	require.Empty(t, off.subdomain("greenfield.droplet.example.org"), "no base domain, no tenants")
+}
+
+func TestLookupCachesSchoolsButNotMisses(t *testing.T) {
+	greenfield := uuid.New()
+	res, fake := newTestResolver(map[string]uuid.UUID{"greenfield": greenfield})
+	ctx := context.Background()
+
+	for range 3 {
+		schoolID, found, err := res.lookup(ctx, "greenfield")
+		require.NoError(t, err)
+		require.True(t, found)
+		require.Equal(t, greenfield, schoolID)
+	}
+	require.Equal(t, 1, fake.queries)
+
+	for range 3 {
+		_, found, err := res.lookup(ctx, "nowhere")
+		require.NoError(t, err)
+		require.False(t, found)
+	}
+	require.Equal(t, 4, fake.queries, "every miss is looked up")
+	require.Len(t, res.cache, 1)
+
+	// Entries expire
+	res.cache["greenfield"] = cacheEntry{schoolID: greenfield, expires: time.Now().Add(-time.Second)}
+	_, _, err := res.lookup(ctx, "greenfield")
+	require.NoError(t, err)
+	require.Equal(t, 5, fake.queries)
+
+	// Errors are returned, not cached
+	fake.err = errors.New("connection refused")
+	_, _, err = res.lookup(ctx, "other")
+	require.ErrorContains(t, err, "connection refused")
+	require.Len(t, res.cache, 1)
+}
+
+func TestLookupCacheIsBounded(t *testing.T) {
+	schools := make(map[string]uuid.UUID)
+	for i := range maxCacheEntries + 10 {
+		schools[fmt.Sprintf("school%d", i)] = uuid.New()
+	}
+	res, _ := newTestResolver(schools)
+	ctx := context.Background()
+
+	// Expired entries are swept first
+	res.cache["stale"] = cacheEntry{schoolID: uuid.New(), expires: time.Now().Add(-time.Second)}
+	for subdomain := range schools {
+		_, found, err := res.lookup(ctx, subdomain)
+		require.NoError(t, err)
+		require.True(t, found)
+		require.LessOrEqual(t, len(res.cache), maxCacheEntries)
+	}
+	require.Len(t, res.cache, maxCacheEntries)
+	require.NotContains(t, res.cache, "stale")
+}
+
+func TestMiddleware(t *testing.T) {
+	greenfield := uuid.New()
+	res, fake := newTestResolver(map[string]uuid.UUID{"greenfield": greenfield})
+
+	var gotSchool uuid.UUID
+	var gotTenant bool
+	handler := res.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSchool, gotTenant = FromContext(r.Context())
+	}))
+	get := func(host string) int {
+		t.Helper()
+		gotSchool, gotTenant = uuid.Nil, false
+		req := httptest.NewRequest(http.MethodGet, "/api/health", nil)
+		req.Host = host
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	require.Equal(t, http.StatusOK, get("greenfield.droplet.example.org"))
+	require.True(t, gotTenant)
+	require.Equal(t, greenfield, gotSchool)
+
+	require.Equal(t, http.StatusOK, get("droplet.example.org"))
+	require.False(t, gotTenant, "the bare domain has no tenant")
+
+	require.Equal(t, http.StatusNotFound, get("nowhere.droplet.example.org"))
+
+	fake.err = errors.New("connection refused")
+	require.Equal(t, http.StatusInternalServerError, get("other.droplet.example.org"))
+}
@@ -0,0 +1,227 @@
+// Package tenantarchive exports a whole school to a self-contained archive and imports it again,
+// possibly on a different Droplet instance. Serial IDs are only meaningful inside an archive: the
+// importer gives every record a fresh ID and rewrites the references between them.
+package tenantarchive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FormatVersion is written into every archive. Bump it whenever the layout changes in a way older
+// importers can't read, and teach Read to upgrade older archives.
+const FormatVersion = 1
+
+var ErrUnsupportedVersion = errors.New("unsupported archive version")
+
+type Archive struct {
+	Version      int           `json:"version"`
+	ExportedAt   time.Time     `json:"exported_at"`
+	School       School        `json:"school"`
+	Divisions    []Division    `json:"divisions"`
+	YearGroups   []YearGroup   `json:"year_groups"`
+	Classes      []Class       `json:"classes"`
+	Pupils       []Pupil       `json:"pupils"`
+	CustomGroups []CustomGroup `json:"custom_groups"`
+	Users        []User        `json:"users"`
+	Tags         []Tag         `json:"tags"`
+	Timetable    *Timetable    `json:"timetable,omitempty"`
+	Drops        []Drop        `json:"drops"`
+}
+
+type School struct {
+	Name         string          `json:"name"`
+	Address      string          `json:"address,omitempty"`
+	ContactEmail string          `json:"contact_email,omitempty"`
+	ContactPhone string          `json:"contact_phone,omitempty"`
+	Subdomain    string          `json:"subdomain,omitempty"`
+	LogoURL      string          `json:"logo_url,omitempty"`
+	Settings     json.RawMessage `json:"settings,omitempty"`
+	// Archives written before the approval policy moved into settings (as drops.require_approval) carry it here
+	RequireDropApproval bool `json:"require_drop_approval,omitempty"`
+}
+
+type Division struct {
+	ID   int32  `json:"id"`
+	Name string `json:"name"`
+}
+
+type YearGroup struct {
+	ID         int32  `json:"id"`
+	Name       string `json:"name"`
+	DivisionID *int32 `json:"division_id,omitempty"`
+}
+
+// Class lists the staff assigned to it. TeacherID is only found in archives written before classes
+// could have several staff; it imports as the class's tutor.
+type Class struct {
+	ID          int32        `json:"id"`
+	Name        string       `json:"name"`
+	YearGroupID *int32       `json:"year_group_id,omitempty"`
+	Staff       []ClassStaff `json:"staff,omitempty"`
+	TeacherID   *uuid.UUID   `json:"teacher_id,omitempty"`
+}
+
+type ClassStaff struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Pupil struct {
+	ID        int32  `json:"id"`
+	FirstName string `json:"first_name"`
+	Surname   string `json:"surname"`
+	ClassID   *int32 `json:"class_id,omitempty"`
+}
+
+type CustomGroup struct {
+	ID        int32      `json:"id"`
+	Name      string     `json:"name"`
+	TeacherID *uuid.UUID `json:"teacher_id,omitempty"`
+	PupilIDs  []int32    `json:"pupil_ids"`
+}
+
+// User is a staff account with everything hanging off it. PasswordHash is only filled in when the
+// export asked for it; users imported without one have to have their password reset.
+type User struct {
+	ID               uuid.UUID         `json:"id"`
+	Email            string            `json:"email"`
+	PasswordHash     string            `json:"password_hash,omitempty"`
+	Role             string            `json:"role"`
+	Title            string            `json:"title"`
+	FirstName        string            `json:"first_name"`
+	Surname          string            `json:"surname"`
+	CreatedAt        time.Time         `json:"created_at"`
+	UpdatedAt        time.Time         `json:"updated_at"`
+	ErasedAt         *time.Time        `json:"erased_at,omitempty"`
+	Settings         *UserSettings     `json:"settings,omitempty"`
+	RoleScopes       []Target          `json:"role_scopes"`
+	Subscriptions    []Target          `json:"subscriptions"`
+	TagSubscriptions []TagSubscription `json:"tag_subscriptions"`
+}
+
+type UserSettings struct {
+	ColorTheme string    `json:"color_theme"`
+	LayoutPref string    `json:"layout_pref"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Timetable is only included for schools that have set one up
+type Timetable struct {
+	Cycle         *TimetableCycle `json:"cycle,omitempty"`
+	Periods       []Named         `json:"periods"`
+	Rooms         []Named         `json:"rooms"`
+	Lessons       []Lesson        `json:"lessons"`
+	LessonTargets []LessonTarget  `json:"lesson_targets"`
+}
+
+type TimetableCycle struct {
+	CycleDays int32  `json:"cycle_days"`
+	StartsOn  string `json:"starts_on"` // YYYY-MM-DD
+}
+
+// Named is a timetable period or room
+type Named struct {
+	ID   int32  `json:"id"`
+	Name string `json:"name"`
+}
+
+type Lesson struct {
+	CycleDay int32     `json:"cycle_day"`
+	PeriodID int32     `json:"period_id"`
+	ClassID  int32     `json:"class_id"`
+	UserID   uuid.UUID `json:"user_id"`
+	RoomID   *int32    `json:"room_id,omitempty"`
+}
+
+// LessonTarget is the class or room, period and date a Lesson drop target points at
+type LessonTarget struct {
+	ID       int32  `json:"id"`
+	ClassID  *int32 `json:"class_id,omitempty"`
+	RoomID   *int32 `json:"room_id,omitempty"`
+	PeriodID int32  `json:"period_id"`
+	Date     string `json:"date"` // YYYY-MM-DD
+}
+
+// Target points at a division, year group, class, pupil, custom group or lesson target by its archive ID.
+// General targets have no ID.
+type Target struct {
+	Type string `json:"type"`
+	ID   *int32 `json:"id,omitempty"`
+}
+
+type TagSubscription struct {
+	TagID int32 `json:"tag_id"`
+	Muted bool  `json:"muted"`
+}
+
+type Tag struct {
+	ID        int32     `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type Drop struct {
+	ID              uuid.UUID  `json:"id"`
+	AuthorID        uuid.UUID  `json:"author_id"`
+	Title           string     `json:"title"`
+	Content         string     `json:"content"`
+	Status          string     `json:"status"`
+	Priority        string     `json:"priority"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	PostDate        time.Time  `json:"post_date"`
+	ExpireDate      time.Time  `json:"expire_date"`
+	PinnedUntil     *time.Time `json:"pinned_until,omitempty"`
+	EditedBy        *uuid.UUID `json:"edited_by,omitempty"`
+	ReviewedBy      *uuid.UUID `json:"reviewed_by,omitempty"`
+	ReviewedAt      *time.Time `json:"reviewed_at,omitempty"`
+	RejectionReason string     `json:"rejection_reason,omitempty"`
+	Targets         []Target   `json:"targets"`
+	TagIDs          []int32    `json:"tag_ids"`
+}
+
+// Write stores the archive as gzipped JSON.
+func Write(w io.Writer, archive *Archive) error {
+	zw := gzip.NewWriter(w)
+	enc := json.NewEncoder(zw)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(archive); err != nil {
+		zw.Close()
+		return fmt.Errorf("could not write archive: %w", err)
+	}
+	return zw.Close()
+}
+
+// Read loads an archive written by Write. Plain (uncompressed) JSON is accepted too, so an archive
+// can be unpacked and edited by hand before importing.
+func Read(r io.Reader) (*Archive, error) {
+	br := bufio.NewReader(r)
+	var src io.Reader = br
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("could not read archive: %w", err)
+		}
+		defer zr.Close()
+		src = zr
+	}
+
+	var archive Archive
+	if err := json.NewDecoder(src).Decode(&archive); err != nil {
+		return nil, fmt.Errorf("could not read archive: %w", err)
+	}
+	if archive.Version != FormatVersion {
+		return nil, fmt.Errorf("%w %d (this version of Droplet reads version %d)", ErrUnsupportedVersion, archive.Version, FormatVersion)
+	}
+	return &archive, nil
+}
@@ -0,0 +1,48 @@
+package tenantarchive_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/5tuartw/droplet/internal/tenantarchive"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteReadRoundTrip(t *testing.T) {
+	classID := int32(4)
+	archive := &tenantarchive.Archive{
+		Version: tenantarchive.FormatVersion,
+		School:  tenantarchive.School{Name: "Example Primary", Subdomain: "example"},
+		Classes: []tenantarchive.Class{{ID: classID, Name: "5B"}},
+		Pupils:  []tenantarchive.Pupil{{ID: 9, FirstName: "Sam", Surname: "Smith", ClassID: &classID}},
+		Drops: []tenantarchive.Drop{{
+			ID:      uuid.New(),
+			Title:   "Trip",
+			Targets: []tenantarchive.Target{{Type: "General"}, {Type: "Class", ID: &classID}},
+		}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, tenantarchive.Write(&buf, archive))
+	require.Equal(t, []byte{0x1f, 0x8b}, buf.Bytes()[:2], "archives are gzipped")
+
+	got, err := tenantarchive.Read(&buf)
+	require.NoError(t, err)
+	require.Equal(t, archive.School, got.School)
+	require.Equal(t, archive.Pupils, got.Pupils)
+	require.Equal(t, archive.Drops[0].Targets, got.Drops[0].Targets)
+}
+
+func TestReadAcceptsPlainJSON(t *testing.T) {
+	got, err := tenantarchive.Read(strings.NewReader(`{"version": 1, "school": {"name": "Example Primary"}}`))
+	require.NoError(t, err)
+	require.Equal(t, "Example Primary", got.School.Name)
+}
+
+func TestReadRejectsUnknownVersion(t *testing.T) {
+	_, err := tenantarchive.Read(strings.NewReader(`{"version": 99, "school": {"name": "Example Primary"}}`))
+	require.True(t, errors.Is(err, tenantarchive.ErrUnsupportedVersion))
+}
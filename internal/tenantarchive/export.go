@@ -0,0 +1,325 @@
+package tenantarchive
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/google/uuid"
+)
+
+type ExportOptions struct {
+	// IncludePasswordHashes keeps users' bcrypt hashes so they can log in on the new instance
+	// with their existing passwords. Leave it off for backups that aren't stored securely.
+	// Platform admins' hashes are never included.
+	IncludePasswordHashes bool
+}
+
+// Export reads everything belonging to a school into an archive.
+// Attachment files, sessions and read receipts are not included.
+func Export(ctx context.Context, dbq *database.Queries, schoolID uuid.UUID, opts ExportOptions) (*Archive, error) {
+	school, err := dbq.GetSchoolByID(ctx, schoolID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get school: %w", err)
+	}
+
+	archive := &Archive{
+		Version:    FormatVersion,
+		ExportedAt: time.Now().UTC(),
+		School: School{
+			Name:         school.Name,
+			Address:      school.Address.String,
+			ContactEmail: school.ContactEmail.String,
+			ContactPhone: school.ContactPhone.String,
+			Subdomain:    school.Subdomain.String,
+			LogoURL:      school.LogoUrl.String,
+		},
+		Divisions:    []Division{},
+		YearGroups:   []YearGroup{},
+		Classes:      []Class{},
+		Pupils:       []Pupil{},
+		CustomGroups: []CustomGroup{},
+		Users:        []User{},
+		Tags:         []Tag{},
+		Drops:        []Drop{},
+	}
+	if school.Settings.Valid {
+		archive.School.Settings = school.Settings.RawMessage
+	}
+
+	divisions, err := dbq.ExportDivisions(ctx, schoolID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get divisions: %w", err)
+	}
+	for _, d := range divisions {
+		archive.Divisions = append(archive.Divisions, Division{ID: d.ID, Name: d.DivisionName})
+	}
+
+	yearGroups, err := dbq.ExportYearGroups(ctx, schoolID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get year groups: %w", err)
+	}
+	for _, yg := range yearGroups {
+		archive.YearGroups = append(archive.YearGroups, YearGroup{ID: yg.ID, Name: yg.YearGroupName, DivisionID: int32Ptr(yg.DivisionID)})
+	}
+
+	classes, err := dbq.ExportClasses(ctx, schoolID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get classes: %w", err)
+	}
+	classStaff, err := dbq.ExportClassStaff(ctx, schoolID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get class staff: %w", err)
+	}
+	staffByClass := make(map[int32][]ClassStaff)
+	for _, cs := range classStaff {
+		staffByClass[cs.ClassID] = append(staffByClass[cs.ClassID], ClassStaff{UserID: cs.UserID, Role: string(cs.Role), CreatedAt: cs.CreatedAt})
+	}
+	for _, c := range classes {
+		archive.Classes = append(archive.Classes, Class{ID: c.ID, Name: c.ClassName, YearGroupID: int32Ptr(c.YearGroupID), Staff: staffByClass[c.ID]})
+	}
+
+	pupils, err := dbq.ExportPupils(ctx, schoolID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get pupils: %w", err)
+	}
+	for _, p := range pupils {
+		archive.Pupils = append(archive.Pupils, Pupil{ID: p.ID, FirstName: p.FirstName, Surname: p.Surname, ClassID: int32Ptr(p.ClassID)})
+	}
+
+	groups, err := dbq.ExportCustomGroups(ctx, schoolID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get custom groups: %w", err)
+	}
+	members, err := dbq.ExportCustomGroupMembers(ctx, schoolID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get custom group members: %w", err)
+	}
+	groupPupils := make(map[int32][]int32)
+	for _, m := range members {
+		groupPupils[m.GroupID] = append(groupPupils[m.GroupID], m.PupilID)
+	}
+	for _, g := range groups {
+		pupilIDs := groupPupils[g.ID]
+		if pupilIDs == nil {
+			pupilIDs = []int32{}
+		}
+		archive.CustomGroups = append(archive.CustomGroups, CustomGroup{ID: g.ID, Name: g.GroupName, TeacherID: uuidPtr(g.TeacherID), PupilIDs: pupilIDs})
+	}
+
+	if err := exportUsers(ctx, dbq, schoolID, opts, archive); err != nil {
+		return nil, err
+	}
+
+	tags, err := dbq.ExportTags(ctx, schoolID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get tags: %w", err)
+	}
+	for _, t := range tags {
+		archive.Tags = append(archive.Tags, Tag{ID: t.ID, Name: t.Name, CreatedAt: t.CreatedAt, UpdatedAt: t.UpdatedAt})
+	}
+
+	if err := exportTimetable(ctx, dbq, schoolID, archive); err != nil {
+		return nil, err
+	}
+
+	if err := exportDrops(ctx, dbq, schoolID, archive); err != nil {
+		return nil, err
+	}
+	return archive, nil
+}
+
+func exportTimetable(ctx context.Context, dbq *database.Queries, schoolID uuid.UUID, archive *Archive) error {
+	cycles, err := dbq.ExportTimetableCycles(ctx, schoolID)
+	if err != nil {
+		return fmt.Errorf("could not get timetable cycle: %w", err)
+	}
+	periods, err := dbq.ExportTimetablePeriods(ctx, schoolID)
+	if err != nil {
+		return fmt.Errorf("could not get timetable periods: %w", err)
+	}
+	rooms, err := dbq.ExportRooms(ctx, schoolID)
+	if err != nil {
+		return fmt.Errorf("could not get rooms: %w", err)
+	}
+	lessons, err := dbq.ExportLessons(ctx, schoolID)
+	if err != nil {
+		return fmt.Errorf("could not get lessons: %w", err)
+	}
+	lessonTargets, err := dbq.ExportLessonTargets(ctx, schoolID)
+	if err != nil {
+		return fmt.Errorf("could not get lesson targets: %w", err)
+	}
+	if len(cycles) == 0 && len(periods) == 0 && len(rooms) == 0 {
+		return nil
+	}
+
+	timetable := &Timetable{Periods: []Named{}, Rooms: []Named{}, Lessons: []Lesson{}, LessonTargets: []LessonTarget{}}
+	for _, c := range cycles {
+		timetable.Cycle = &TimetableCycle{CycleDays: c.CycleDays, StartsOn: c.StartsOn.Format(time.DateOnly)}
+	}
+	for _, p := range periods {
+		timetable.Periods = append(timetable.Periods, Named{ID: p.ID, Name: p.Name})
+	}
+	for _, r := range rooms {
+		timetable.Rooms = append(timetable.Rooms, Named{ID: r.ID, Name: r.Name})
+	}
+	for _, l := range lessons {
+		timetable.Lessons = append(timetable.Lessons, Lesson{CycleDay: l.CycleDay, PeriodID: l.PeriodID, ClassID: l.ClassID, UserID: l.UserID, RoomID: int32Ptr(l.RoomID)})
+	}
+	for _, lt := range lessonTargets {
+		timetable.LessonTargets = append(timetable.LessonTargets, LessonTarget{
+			ID:       lt.ID,
+			ClassID:  int32Ptr(lt.ClassID),
+			RoomID:   int32Ptr(lt.RoomID),
+			PeriodID: lt.PeriodID,
+			Date:     lt.LessonDate.Format(time.DateOnly),
+		})
+	}
+	archive.Timetable = timetable
+	return nil
+}
+
+func exportUsers(ctx context.Context, dbq *database.Queries, schoolID uuid.UUID, opts ExportOptions, archive *Archive) error {
+	users, err := dbq.ExportUsers(ctx, schoolID)
+	if err != nil {
+		return fmt.Errorf("could not get users: %w", err)
+	}
+	settings, err := dbq.ExportUserSettings(ctx, schoolID)
+	if err != nil {
+		return fmt.Errorf("could not get user settings: %w", err)
+	}
+	scopes, err := dbq.ExportRoleScopes(ctx, schoolID)
+	if err != nil {
+		return fmt.Errorf("could not get role scopes: %w", err)
+	}
+	subscriptions, err := dbq.ExportTargetSubscriptions(ctx, schoolID)
+	if err != nil {
+		return fmt.Errorf("could not get subscriptions: %w", err)
+	}
+	tagSubscriptions, err := dbq.ExportTagSubscriptions(ctx, schoolID)
+	if err != nil {
+		return fmt.Errorf("could not get tag subscriptions: %w", err)
+	}
+
+	index := make(map[uuid.UUID]int, len(users))
+	for _, u := range users {
+		user := User{
+			ID:               u.ID,
+			Email:            u.Email,
+			Role:             string(u.Role),
+			Title:            u.Title,
+			FirstName:        u.FirstName,
+			Surname:          u.Surname,
+			CreatedAt:        u.CreatedAt,
+			UpdatedAt:        u.UpdatedAt,
+			ErasedAt:         timePtr(u.ErasedAt),
+			RoleScopes:       []Target{},
+			Subscriptions:    []Target{},
+			TagSubscriptions: []TagSubscription{},
+		}
+		// Platform admins' passwords guard the whole instance, so they never leave it
+		if opts.IncludePasswordHashes && u.Role != database.UserRolePlatformAdmin {
+			user.PasswordHash = u.HashedPassword
+		}
+		index[u.ID] = len(archive.Users)
+		archive.Users = append(archive.Users, user)
+	}
+
+	for _, s := range settings {
+		if i, ok := index[s.UserID]; ok {
+			archive.Users[i].Settings = &UserSettings{ColorTheme: s.ColorTheme, LayoutPref: s.LayoutPref, UpdatedAt: s.UpdatedAt}
+		}
+	}
+	for _, s := range scopes {
+		if i, ok := index[s.UserID]; ok {
+			id := s.TargetID
+			archive.Users[i].RoleScopes = append(archive.Users[i].RoleScopes, Target{Type: string(s.Type), ID: &id})
+		}
+	}
+	for _, s := range subscriptions {
+		if i, ok := index[s.UserID]; ok {
+			id := s.TargetID
+			archive.Users[i].Subscriptions = append(archive.Users[i].Subscriptions, Target{Type: string(s.Type), ID: &id})
+		}
+	}
+	for _, s := range tagSubscriptions {
+		if i, ok := index[s.UserID]; ok {
+			archive.Users[i].TagSubscriptions = append(archive.Users[i].TagSubscriptions, TagSubscription{TagID: s.TagID, Muted: s.Muted})
+		}
+	}
+	return nil
+}
+
+func exportDrops(ctx context.Context, dbq *database.Queries, schoolID uuid.UUID, archive *Archive) error {
+	drops, err := dbq.ExportDrops(ctx, schoolID)
+	if err != nil {
+		return fmt.Errorf("could not get drops: %w", err)
+	}
+	targets, err := dbq.ExportDropTargets(ctx, schoolID)
+	if err != nil {
+		return fmt.Errorf("could not get drop targets: %w", err)
+	}
+	dropTags, err := dbq.ExportDropTags(ctx, schoolID)
+	if err != nil {
+		return fmt.Errorf("could not get drop tags: %w", err)
+	}
+
+	index := make(map[uuid.UUID]int, len(drops))
+	for _, d := range drops {
+		index[d.ID] = len(archive.Drops)
+		archive.Drops = append(archive.Drops, Drop{
+			ID:              d.ID,
+			AuthorID:        d.UserID,
+			Title:           d.Title,
+			Content:         d.Content,
+			Status:          string(d.Status),
+			Priority:        string(d.Priority),
+			CreatedAt:       d.CreatedAt,
+			UpdatedAt:       d.UpdatedAt,
+			PostDate:        d.PostDate,
+			ExpireDate:      d.ExpireDate,
+			PinnedUntil:     timePtr(d.PinnedUntil),
+			EditedBy:        uuidPtr(d.EditedBy),
+			ReviewedBy:      uuidPtr(d.ReviewedBy),
+			ReviewedAt:      timePtr(d.ReviewedAt),
+			RejectionReason: d.RejectionReason.String,
+			Targets:         []Target{},
+			TagIDs:          []int32{},
+		})
+	}
+	for _, t := range targets {
+		if i, ok := index[t.DropID]; ok {
+			archive.Drops[i].Targets = append(archive.Drops[i].Targets, Target{Type: string(t.Type), ID: int32Ptr(t.TargetID)})
+		}
+	}
+	for _, t := range dropTags {
+		if i, ok := index[t.DropID]; ok {
+			archive.Drops[i].TagIDs = append(archive.Drops[i].TagIDs, t.TagID)
+		}
+	}
+	return nil
+}
+
+func int32Ptr(v sql.NullInt32) *int32 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Int32
+}
+
+func timePtr(v sql.NullTime) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Time
+}
+
+func uuidPtr(v uuid.NullUUID) *uuid.UUID {
+	if !v.Valid {
+		return nil
+	}
+	return &v.UUID
+}
@@ -0,0 +1,535 @@
+package tenantarchive
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/5tuartw/droplet/internal/helpers"
+	"github.com/5tuartw/droplet/internal/schoolsettings"
+	"github.com/google/uuid"
+	"github.com/sqlc-dev/pqtype"
+)
+
+// lockedPassword is not a bcrypt hash, so no password matches it
+const lockedPassword = "unset"
+
+type ImportOptions struct {
+	// Name and Subdomain replace the archived values, e.g. when restoring a copy alongside the original
+	Name      string
+	Subdomain string
+}
+
+// Import creates a new school from an archive in a single transaction and returns it.
+// Nothing is written if any record fails to import.
+func Import(ctx context.Context, db *sql.DB, dbq *database.Queries, archive *Archive, opts ImportOptions) (school database.School, err error) {
+	if archive.Version != FormatVersion {
+		return school, fmt.Errorf("%w %d", ErrUnsupportedVersion, archive.Version)
+	}
+
+	name := archive.School.Name
+	if opts.Name != "" {
+		name = opts.Name
+	}
+	subdomain := archive.School.Subdomain
+	if opts.Subdomain != "" {
+		subdomain = opts.Subdomain
+	}
+
+	// Upgrades settings written by an older Droplet and rejects ones from a newer one
+	settings, err := schoolsettings.Parse(archive.School.Settings)
+	if err != nil {
+		return school, err
+	}
+	if archive.School.RequireDropApproval {
+		settings.Drops.RequireApproval = true
+	}
+	rawSettings, err := json.Marshal(settings)
+	if err != nil {
+		return school, err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return school, fmt.Errorf("could not start transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	qtx := dbq.WithTx(tx)
+
+	school, err = qtx.ImportSchool(ctx, database.ImportSchoolParams{
+		Name:         name,
+		Address:      helpers.NullStringFromString(archive.School.Address),
+		ContactEmail: helpers.NullStringFromString(archive.School.ContactEmail),
+		ContactPhone: helpers.NullStringFromString(archive.School.ContactPhone),
+		Subdomain:    helpers.NullStringFromString(subdomain),
+		LogoUrl:      helpers.NullStringFromString(archive.School.LogoURL),
+		Settings:     pqtype.NullRawMessage{RawMessage: rawSettings, Valid: true},
+	})
+	if err != nil {
+		return school, fmt.Errorf("could not create school: %w", err)
+	}
+
+	im := &importer{
+		ctx:           ctx,
+		qtx:           qtx,
+		schoolID:      school.ID,
+		users:         make(map[uuid.UUID]uuid.UUID),
+		divisions:     make(map[int32]int32),
+		yearGroups:    make(map[int32]int32),
+		classes:       make(map[int32]int32),
+		pupils:        make(map[int32]int32),
+		customGroups:  make(map[int32]int32),
+		tags:          make(map[int32]int32),
+		periods:       make(map[int32]int32),
+		rooms:         make(map[int32]int32),
+		lessonTargets: make(map[int32]int32),
+	}
+
+	// Users come first as everything else can refer to them; their scopes and subscriptions
+	// point at structure and tags, so those are added once the rest is in place
+	steps := []func(*Archive) error{
+		im.importUsers,
+		im.importStructure,
+		im.importPupils,
+		im.importCustomGroups,
+		im.importTags,
+		im.importTimetable,
+		im.importUserLinks,
+		im.importDrops,
+	}
+	for _, step := range steps {
+		if err = step(archive); err != nil {
+			return school, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return school, fmt.Errorf("could not commit import: %w", err)
+	}
+	return school, nil
+}
+
+// importer maps the IDs in an archive to the IDs of the records created for them
+type importer struct {
+	ctx      context.Context
+	qtx      *database.Queries
+	schoolID uuid.UUID
+
+	users         map[uuid.UUID]uuid.UUID
+	divisions     map[int32]int32
+	yearGroups    map[int32]int32
+	classes       map[int32]int32
+	pupils        map[int32]int32
+	customGroups  map[int32]int32
+	tags          map[int32]int32
+	periods       map[int32]int32
+	rooms         map[int32]int32
+	lessonTargets map[int32]int32
+}
+
+func (im *importer) importUsers(archive *Archive) error {
+	for _, u := range archive.Users {
+		role := database.UserRole(u.Role)
+		// Platform roles belong to the instance, not the school, so they never travel with it
+		if role == database.UserRolePlatformAdmin {
+			role = database.UserRoleReadOnly
+		}
+		password := u.PasswordHash
+		if password == "" {
+			password = lockedPassword
+		}
+
+		id, err := im.qtx.ImportUser(im.ctx, database.ImportUserParams{
+			SchoolID:       im.schoolID,
+			CreatedAt:      u.CreatedAt,
+			UpdatedAt:      u.UpdatedAt,
+			Email:          u.Email,
+			HashedPassword: password,
+			Role:           role,
+			Title:          u.Title,
+			FirstName:      u.FirstName,
+			Surname:        u.Surname,
+			ErasedAt:       nullTime(u.ErasedAt),
+		})
+		if err != nil {
+			return fmt.Errorf("could not import user %s: %w", u.Email, err)
+		}
+		im.users[u.ID] = id
+
+		if u.Settings != nil {
+			err = im.qtx.ImportUserSettings(im.ctx, database.ImportUserSettingsParams{
+				UserID:     id,
+				SchoolID:   im.schoolID,
+				ColorTheme: u.Settings.ColorTheme,
+				LayoutPref: u.Settings.LayoutPref,
+				UpdatedAt:  u.Settings.UpdatedAt,
+			})
+			if err != nil {
+				return fmt.Errorf("could not import settings for user %s: %w", u.Email, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (im *importer) importStructure(archive *Archive) error {
+	for _, d := range archive.Divisions {
+		id, err := im.qtx.ImportDivision(im.ctx, database.ImportDivisionParams{SchoolID: im.schoolID, DivisionName: d.Name})
+		if err != nil {
+			return fmt.Errorf("could not import division %q: %w", d.Name, err)
+		}
+		im.divisions[d.ID] = id
+	}
+
+	for _, yg := range archive.YearGroups {
+		divisionID, err := mapOptional(im.divisions, yg.DivisionID, "division")
+		if err != nil {
+			return fmt.Errorf("year group %q: %w", yg.Name, err)
+		}
+		id, err := im.qtx.ImportYearGroup(im.ctx, database.ImportYearGroupParams{SchoolID: im.schoolID, YearGroupName: yg.Name, DivisionID: divisionID})
+		if err != nil {
+			return fmt.Errorf("could not import year group %q: %w", yg.Name, err)
+		}
+		im.yearGroups[yg.ID] = id
+	}
+
+	for _, c := range archive.Classes {
+		yearGroupID, err := mapOptional(im.yearGroups, c.YearGroupID, "year group")
+		if err != nil {
+			return fmt.Errorf("class %q: %w", c.Name, err)
+		}
+		id, err := im.qtx.ImportClass(im.ctx, database.ImportClassParams{SchoolID: im.schoolID, ClassName: c.Name, YearGroupID: yearGroupID})
+		if err != nil {
+			return fmt.Errorf("could not import class %q: %w", c.Name, err)
+		}
+		im.classes[c.ID] = id
+
+		staff := c.Staff
+		if len(staff) == 0 && c.TeacherID != nil {
+			staff = []ClassStaff{{UserID: *c.TeacherID, Role: string(database.ClassStaffRoleTutor), CreatedAt: time.Now()}}
+		}
+		for _, cs := range staff {
+			userID, ok := im.users[cs.UserID]
+			if !ok {
+				return fmt.Errorf("class %q: unknown user %s", c.Name, cs.UserID)
+			}
+			err := im.qtx.ImportClassStaff(im.ctx, database.ImportClassStaffParams{ClassID: id, UserID: userID, SchoolID: im.schoolID, Role: database.ClassStaffRole(cs.Role), CreatedAt: cs.CreatedAt})
+			if err != nil {
+				return fmt.Errorf("could not import staff for class %q: %w", c.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (im *importer) importPupils(archive *Archive) error {
+	for _, p := range archive.Pupils {
+		classID, err := mapOptional(im.classes, p.ClassID, "class")
+		if err != nil {
+			return fmt.Errorf("pupil %d: %w", p.ID, err)
+		}
+		id, err := im.qtx.ImportPupil(im.ctx, database.ImportPupilParams{SchoolID: im.schoolID, FirstName: p.FirstName, Surname: p.Surname, ClassID: classID})
+		if err != nil {
+			return fmt.Errorf("could not import pupil %d: %w", p.ID, err)
+		}
+		im.pupils[p.ID] = id
+	}
+	return nil
+}
+
+func (im *importer) importCustomGroups(archive *Archive) error {
+	for _, g := range archive.CustomGroups {
+		teacherID, err := im.user(g.TeacherID)
+		if err != nil {
+			return fmt.Errorf("custom group %q: %w", g.Name, err)
+		}
+		id, err := im.qtx.ImportCustomGroup(im.ctx, database.ImportCustomGroupParams{SchoolID: im.schoolID, GroupName: g.Name, TeacherID: teacherID})
+		if err != nil {
+			return fmt.Errorf("could not import custom group %q: %w", g.Name, err)
+		}
+		im.customGroups[g.ID] = id
+
+		for _, pupilID := range g.PupilIDs {
+			newPupilID, ok := im.pupils[pupilID]
+			if !ok {
+				return fmt.Errorf("custom group %q: unknown pupil %d", g.Name, pupilID)
+			}
+			if err := im.qtx.ImportCustomGroupMember(im.ctx, database.ImportCustomGroupMemberParams{GroupID: id, PupilID: newPupilID}); err != nil {
+				return fmt.Errorf("could not import custom group %q: %w", g.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (im *importer) importTags(archive *Archive) error {
+	for _, t := range archive.Tags {
+		id, err := im.qtx.ImportTag(im.ctx, database.ImportTagParams{SchoolID: im.schoolID, Name: t.Name, CreatedAt: t.CreatedAt, UpdatedAt: t.UpdatedAt})
+		if err != nil {
+			return fmt.Errorf("could not import tag %q: %w", t.Name, err)
+		}
+		im.tags[t.ID] = id
+	}
+	return nil
+}
+
+func (im *importer) importTimetable(archive *Archive) error {
+	timetable := archive.Timetable
+	if timetable == nil {
+		return nil
+	}
+
+	if timetable.Cycle != nil {
+		startsOn, err := time.Parse(time.DateOnly, timetable.Cycle.StartsOn)
+		if err != nil {
+			return fmt.Errorf("timetable cycle: invalid start date %q", timetable.Cycle.StartsOn)
+		}
+		_, err = im.qtx.SetTimetableCycle(im.ctx, database.SetTimetableCycleParams{SchoolID: im.schoolID, CycleDays: timetable.Cycle.CycleDays, StartsOn: startsOn})
+		if err != nil {
+			return fmt.Errorf("could not import timetable cycle: %w", err)
+		}
+	}
+
+	for _, p := range timetable.Periods {
+		id, err := im.qtx.UpsertTimetablePeriod(im.ctx, database.UpsertTimetablePeriodParams{SchoolID: im.schoolID, Name: p.Name})
+		if err != nil {
+			return fmt.Errorf("could not import period %q: %w", p.Name, err)
+		}
+		im.periods[p.ID] = id
+	}
+
+	for _, r := range timetable.Rooms {
+		id, err := im.qtx.UpsertRoom(im.ctx, database.UpsertRoomParams{SchoolID: im.schoolID, Name: r.Name})
+		if err != nil {
+			return fmt.Errorf("could not import room %q: %w", r.Name, err)
+		}
+		im.rooms[r.ID] = id
+	}
+
+	lessons := database.CreateLessonsParams{SchoolID: im.schoolID}
+	for i, l := range timetable.Lessons {
+		periodID, ok := im.periods[l.PeriodID]
+		if !ok {
+			return fmt.Errorf("lesson %d: unknown period %d", i, l.PeriodID)
+		}
+		classID, ok := im.classes[l.ClassID]
+		if !ok {
+			return fmt.Errorf("lesson %d: unknown class %d", i, l.ClassID)
+		}
+		userID, ok := im.users[l.UserID]
+		if !ok {
+			return fmt.Errorf("lesson %d: unknown user %s", i, l.UserID)
+		}
+		roomID, err := mapOptional(im.rooms, l.RoomID, "room")
+		if err != nil {
+			return fmt.Errorf("lesson %d: %w", i, err)
+		}
+		lessons.CycleDays = append(lessons.CycleDays, l.CycleDay)
+		lessons.PeriodIds = append(lessons.PeriodIds, periodID)
+		lessons.ClassIds = append(lessons.ClassIds, classID)
+		lessons.UserIds = append(lessons.UserIds, userID)
+		lessons.RoomIds = append(lessons.RoomIds, roomID.Int32) // 0 for no room
+	}
+	if len(lessons.CycleDays) > 0 {
+		if _, err := im.qtx.CreateLessons(im.ctx, lessons); err != nil {
+			return fmt.Errorf("could not import lessons: %w", err)
+		}
+	}
+
+	for _, lt := range timetable.LessonTargets {
+		periodID, ok := im.periods[lt.PeriodID]
+		if !ok {
+			return fmt.Errorf("lesson target %d: unknown period %d", lt.ID, lt.PeriodID)
+		}
+		classID, err := mapOptional(im.classes, lt.ClassID, "class")
+		if err != nil {
+			return fmt.Errorf("lesson target %d: %w", lt.ID, err)
+		}
+		roomID, err := mapOptional(im.rooms, lt.RoomID, "room")
+		if err != nil {
+			return fmt.Errorf("lesson target %d: %w", lt.ID, err)
+		}
+		lessonDate, err := time.Parse(time.DateOnly, lt.Date)
+		if err != nil {
+			return fmt.Errorf("lesson target %d: invalid date %q", lt.ID, lt.Date)
+		}
+		id, err := im.qtx.ImportLessonTarget(im.ctx, database.ImportLessonTargetParams{SchoolID: im.schoolID, ClassID: classID, RoomID: roomID, PeriodID: periodID, LessonDate: lessonDate})
+		if err != nil {
+			return fmt.Errorf("could not import lesson target %d: %w", lt.ID, err)
+		}
+		im.lessonTargets[lt.ID] = id
+	}
+	return nil
+}
+
+func (im *importer) importUserLinks(archive *Archive) error {
+	for _, u := range archive.Users {
+		userID := im.users[u.ID]
+
+		for _, s := range u.RoleScopes {
+			targetID, err := im.target(s)
+			if err != nil || !targetID.Valid {
+				return fmt.Errorf("role scope for user %s: %w", u.Email, errOrMissingID(err, s))
+			}
+			err = im.qtx.ImportRoleScope(im.ctx, database.ImportRoleScopeParams{UserID: userID, SchoolID: im.schoolID, Type: database.TargetType(s.Type), TargetID: targetID.Int32})
+			if err != nil {
+				return fmt.Errorf("could not import role scope for user %s: %w", u.Email, err)
+			}
+		}
+
+		for _, s := range u.Subscriptions {
+			targetID, err := im.target(s)
+			if err != nil || !targetID.Valid {
+				return fmt.Errorf("subscription for user %s: %w", u.Email, errOrMissingID(err, s))
+			}
+			err = im.qtx.ImportTargetSubscription(im.ctx, database.ImportTargetSubscriptionParams{UserID: userID, SchoolID: im.schoolID, Type: database.TargetType(s.Type), TargetID: targetID.Int32})
+			if err != nil {
+				return fmt.Errorf("could not import subscription for user %s: %w", u.Email, err)
+			}
+		}
+
+		for _, s := range u.TagSubscriptions {
+			tagID, ok := im.tags[s.TagID]
+			if !ok {
+				return fmt.Errorf("tag subscription for user %s: unknown tag %d", u.Email, s.TagID)
+			}
+			err := im.qtx.ImportTagSubscription(im.ctx, database.ImportTagSubscriptionParams{UserID: userID, SchoolID: im.schoolID, TagID: tagID, Muted: s.Muted})
+			if err != nil {
+				return fmt.Errorf("could not import tag subscription for user %s: %w", u.Email, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (im *importer) importDrops(archive *Archive) error {
+	for _, d := range archive.Drops {
+		authorID, ok := im.users[d.AuthorID]
+		if !ok {
+			return fmt.Errorf("drop %s: unknown author %s", d.ID, d.AuthorID)
+		}
+		editedBy, err := im.user(d.EditedBy)
+		if err != nil {
+			return fmt.Errorf("drop %s: %w", d.ID, err)
+		}
+		reviewedBy, err := im.user(d.ReviewedBy)
+		if err != nil {
+			return fmt.Errorf("drop %s: %w", d.ID, err)
+		}
+
+		dropID, err := im.qtx.ImportDrop(im.ctx, database.ImportDropParams{
+			SchoolID:        im.schoolID,
+			UserID:          authorID,
+			Title:           d.Title,
+			Content:         d.Content,
+			CreatedAt:       d.CreatedAt,
+			UpdatedAt:       d.UpdatedAt,
+			PostDate:        d.PostDate,
+			ExpireDate:      d.ExpireDate,
+			EditedBy:        editedBy,
+			Status:          database.DropStatus(d.Status),
+			ReviewedBy:      reviewedBy,
+			ReviewedAt:      nullTime(d.ReviewedAt),
+			RejectionReason: helpers.NullStringFromString(d.RejectionReason),
+			Priority:        database.DropPriority(d.Priority),
+			PinnedUntil:     nullTime(d.PinnedUntil),
+		})
+		if err != nil {
+			return fmt.Errorf("could not import drop %s: %w", d.ID, err)
+		}
+
+		for _, t := range d.Targets {
+			targetID, err := im.target(t)
+			if err != nil {
+				return fmt.Errorf("drop %s: %w", d.ID, err)
+			}
+			err = im.qtx.ImportDropTarget(im.ctx, database.ImportDropTargetParams{SchoolID: im.schoolID, DropID: dropID, Type: database.TargetType(t.Type), TargetID: targetID})
+			if err != nil {
+				return fmt.Errorf("could not import target for drop %s: %w", d.ID, err)
+			}
+		}
+
+		for _, tagID := range d.TagIDs {
+			newTagID, ok := im.tags[tagID]
+			if !ok {
+				return fmt.Errorf("drop %s: unknown tag %d", d.ID, tagID)
+			}
+			if err := im.qtx.ImportDropTag(im.ctx, database.ImportDropTagParams{SchoolID: im.schoolID, DropID: dropID, TagID: newTagID}); err != nil {
+				return fmt.Errorf("could not import tag for drop %s: %w", d.ID, err)
+			}
+		}
+	}
+
+	// Urgent drops that are already live were pushed by the school they came from
+	if err := im.qtx.MarkLiveUrgentDropsPushed(im.ctx, im.schoolID); err != nil {
+		return fmt.Errorf("could not record urgent drop pushes: %w", err)
+	}
+	return nil
+}
+
+// target maps a target's archive ID to the new ID of the same type. General targets have no ID.
+func (im *importer) target(t Target) (sql.NullInt32, error) {
+	var ids map[int32]int32
+	switch database.TargetType(t.Type) {
+	case database.TargetTypeGeneral:
+		return sql.NullInt32{}, nil
+	case database.TargetTypeDivision:
+		ids = im.divisions
+	case database.TargetTypeYearGroup:
+		ids = im.yearGroups
+	case database.TargetTypeClass:
+		ids = im.classes
+	case database.TargetTypeStudent:
+		ids = im.pupils
+	case database.TargetTypeCustomGroup:
+		ids = im.customGroups
+	case database.TargetTypeLesson:
+		ids = im.lessonTargets
+	default:
+		return sql.NullInt32{}, fmt.Errorf("unknown target type %q", t.Type)
+	}
+	return mapOptional(ids, t.ID, t.Type)
+}
+
+func (im *importer) user(id *uuid.UUID) (uuid.NullUUID, error) {
+	if id == nil {
+		return uuid.NullUUID{}, nil
+	}
+	newID, ok := im.users[*id]
+	if !ok {
+		return uuid.NullUUID{}, fmt.Errorf("unknown user %s", *id)
+	}
+	return uuid.NullUUID{UUID: newID, Valid: true}, nil
+}
+
+func mapOptional(ids map[int32]int32, id *int32, kind string) (sql.NullInt32, error) {
+	if id == nil {
+		return sql.NullInt32{}, nil
+	}
+	newID, ok := ids[*id]
+	if !ok {
+		return sql.NullInt32{}, fmt.Errorf("unknown %s %d", kind, *id)
+	}
+	return sql.NullInt32{Int32: newID, Valid: true}, nil
+}
+
+func errOrMissingID(err error, t Target) error {
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("%s target needs an id", t.Type)
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
@@ -0,0 +1,220 @@
+// Package timetable imports a school's lesson timetable from CSV. Lessons say who teaches which class,
+// and where, in each period of each day of the school's day cycle; Lesson targets use them to reach
+// whoever is teaching a class or room at a given time.
+package timetable
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/5tuartw/droplet/internal/database"
+	"github.com/google/uuid"
+)
+
+// Header is the expected first line of a timetable CSV. The room column may be left empty.
+var Header = []string{"day", "period", "class", "staff_email", "room"}
+
+// Row is one lesson from a timetable CSV
+type Row struct {
+	Line       int // line number in the CSV, for error messages
+	Day        int32
+	Period     string
+	Class      string
+	StaffEmail string
+	Room       string
+}
+
+// Summary reports what an import did
+type Summary struct {
+	Lessons  int64 `json:"lessons"`
+	Replaced int64 `json:"replaced"` // lessons from the previous timetable
+	Periods  int   `json:"periods"`
+	Rooms    int   `json:"rooms"`
+}
+
+// ParseCSV reads a timetable CSV. Every problem found is reported, each with its line number.
+func ParseCSV(r io.Reader) ([]Row, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("timetable is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading header: %w", err)
+	}
+	if len(header) != len(Header) {
+		return nil, fmt.Errorf("header must be %q", strings.Join(Header, ","))
+	}
+	for i, name := range header {
+		if strings.ToLower(strings.TrimSpace(name)) != Header[i] {
+			return nil, fmt.Errorf("header must be %q", strings.Join(Header, ","))
+		}
+	}
+
+	var rows []Row
+	var problems []string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line, _ := reader.FieldPos(0)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		row := Row{
+			Line:       line,
+			Period:     strings.TrimSpace(record[1]),
+			Class:      strings.TrimSpace(record[2]),
+			StaffEmail: strings.ToLower(strings.TrimSpace(record[3])),
+			Room:       strings.TrimSpace(record[4]),
+		}
+		day, err := strconv.Atoi(strings.TrimSpace(record[0]))
+		if err != nil || day < 1 {
+			problems = append(problems, fmt.Sprintf("line %d: day must be a positive number", line))
+		}
+		row.Day = int32(day)
+		if row.Period == "" || row.Class == "" || row.StaffEmail == "" {
+			problems = append(problems, fmt.Sprintf("line %d: period, class and staff_email are required", line))
+		}
+		rows = append(rows, row)
+	}
+
+	if len(problems) > 0 {
+		return nil, ValidationError(problems)
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("timetable has no lessons")
+	}
+	return rows, nil
+}
+
+// ValidationError lists every row of a timetable that can't be imported as it stands
+type ValidationError []string
+
+func (e ValidationError) Error() string {
+	return strings.Join(e, "; ")
+}
+
+// Import replaces a school's lessons with the given rows in a single transaction. Classes and staff
+// must already exist; periods and rooms are created as needed. Nothing is written if any row fails.
+func Import(ctx context.Context, db *sql.DB, dbq *database.Queries, schoolID uuid.UUID, rows []Row) (summary Summary, err error) {
+	classes, err := dbq.GetClasses(ctx, schoolID)
+	if err != nil {
+		return summary, fmt.Errorf("could not look up classes: %w", err)
+	}
+	classIDs := make(map[string]int32, len(classes))
+	for _, c := range classes {
+		classIDs[strings.ToLower(c.ClassName)] = c.ID
+	}
+
+	staff, err := dbq.GetTimetableStaff(ctx, schoolID)
+	if err != nil {
+		return summary, fmt.Errorf("could not look up staff: %w", err)
+	}
+	userIDs := make(map[string]uuid.UUID, len(staff))
+	for _, s := range staff {
+		userIDs[strings.ToLower(s.Email)] = s.ID
+	}
+
+	cycle, err := dbq.GetTimetableCycle(ctx, schoolID)
+	if err != nil {
+		return summary, fmt.Errorf("could not look up day cycle: %w", err)
+	}
+
+	var problems []string
+	for _, row := range rows {
+		if row.Day > cycle.CycleDays {
+			problems = append(problems, fmt.Sprintf("line %d: day %d is outside the %d-day cycle", row.Line, row.Day, cycle.CycleDays))
+		}
+		if _, ok := classIDs[strings.ToLower(row.Class)]; !ok {
+			problems = append(problems, fmt.Sprintf("line %d: class %q not found", row.Line, row.Class))
+		}
+		if _, ok := userIDs[row.StaffEmail]; !ok {
+			problems = append(problems, fmt.Sprintf("line %d: staff member %q not found", row.Line, row.StaffEmail))
+		}
+	}
+	if len(problems) > 0 {
+		return summary, ValidationError(problems)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return summary, fmt.Errorf("could not start transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	qtx := dbq.WithTx(tx)
+
+	periodIDs := make(map[string]int32)
+	roomIDs := make(map[string]int32)
+	type lessonKey struct {
+		day, period, class int32
+		user               uuid.UUID
+	}
+	seen := make(map[lessonKey]bool, len(rows))
+	params := database.CreateLessonsParams{SchoolID: schoolID}
+
+	for _, row := range rows {
+		periodID, ok := periodIDs[row.Period]
+		if !ok {
+			periodID, err = qtx.UpsertTimetablePeriod(ctx, database.UpsertTimetablePeriodParams{SchoolID: schoolID, Name: row.Period})
+			if err != nil {
+				return summary, fmt.Errorf("line %d: could not save period %q: %w", row.Line, row.Period, err)
+			}
+			periodIDs[row.Period] = periodID
+		}
+
+		var roomID int32 // 0 for no room
+		if row.Room != "" {
+			roomID, ok = roomIDs[row.Room]
+			if !ok {
+				roomID, err = qtx.UpsertRoom(ctx, database.UpsertRoomParams{SchoolID: schoolID, Name: row.Room})
+				if err != nil {
+					return summary, fmt.Errorf("line %d: could not save room %q: %w", row.Line, row.Room, err)
+				}
+				roomIDs[row.Room] = roomID
+			}
+		}
+
+		key := lessonKey{row.Day, periodID, classIDs[strings.ToLower(row.Class)], userIDs[row.StaffEmail]}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		params.CycleDays = append(params.CycleDays, key.day)
+		params.PeriodIds = append(params.PeriodIds, key.period)
+		params.ClassIds = append(params.ClassIds, key.class)
+		params.UserIds = append(params.UserIds, key.user)
+		params.RoomIds = append(params.RoomIds, roomID)
+	}
+
+	summary.Replaced, err = qtx.DeleteLessons(ctx, schoolID)
+	if err != nil {
+		return summary, fmt.Errorf("could not remove previous timetable: %w", err)
+	}
+	summary.Lessons, err = qtx.CreateLessons(ctx, params)
+	if err != nil {
+		return summary, fmt.Errorf("could not save lessons: %w", err)
+	}
+	summary.Periods = len(periodIDs)
+	summary.Rooms = len(roomIDs)
+
+	if err = tx.Commit(); err != nil {
+		return summary, fmt.Errorf("could not commit timetable: %w", err)
+	}
+	return summary, nil
+}
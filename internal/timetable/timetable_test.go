@@ -0,0 +1,38 @@
+package timetable_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/5tuartw/droplet/internal/timetable"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCSV(t *testing.T) {
+	rows, err := timetable.ParseCSV(strings.NewReader("Day,Period,Class,Staff_Email,Room\n" +
+		"1,P1,Ash,Teacher@Example.com,Lab 2\n" +
+		"2, P3 ,Oak,ta@example.com,\n"))
+	require.NoError(t, err)
+	require.Equal(t, []timetable.Row{
+		{Line: 2, Day: 1, Period: "P1", Class: "Ash", StaffEmail: "teacher@example.com", Room: "Lab 2"},
+		{Line: 3, Day: 2, Period: "P3", Class: "Oak", StaffEmail: "ta@example.com"},
+	}, rows)
+}
+
+func TestParseCSVReportsEveryBadLine(t *testing.T) {
+	_, err := timetable.ParseCSV(strings.NewReader("day,period,class,staff_email,room\n" +
+		"0,P1,Ash,teacher@example.com,\n" +
+		"1,P1,Ash,teacher@example.com,\n" +
+		"1,,Ash,teacher@example.com,\n"))
+	require.ErrorContains(t, err, "line 2: day")
+	require.ErrorContains(t, err, "line 4: period")
+	require.NotContains(t, err.Error(), "line 3")
+	var invalid timetable.ValidationError
+	require.ErrorAs(t, err, &invalid)
+	require.Len(t, invalid, 2)
+
+	for _, csv := range []string{"", "day,period,class\n", "day,period,class,staff_email,room\n", "day,period,class,staff_email,room\n1,P1\n"} {
+		_, err := timetable.ParseCSV(strings.NewReader(csv))
+		require.Error(t, err, csv)
+	}
+}
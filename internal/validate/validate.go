@@ -0,0 +1,252 @@
+// Package validate checks request payloads against rules declared in `validate` struct tags,
+// reporting every problem by its JSON field name so clients can show it next to the right input.
+//
+// Rules are comma separated:
+//
+//	required      the value is set: a non-blank string, non-empty slice, non-nil pointer or non-zero number
+//	min=N, max=N  string length in characters, slice length, or numeric value
+//	oneof=a b c   the string is one of the listed values
+//	email         the string looks like an email address
+//
+// Rules other than required only apply to values that are set: a nil pointer or empty string passes them.
+// Pointers are checked through.
+// Struct fields and slices of structs are validated recursively, with paths like targets[2].type.
+// A struct can add rules that involve several fields by implementing Checker.
+package validate
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Field error codes
+const (
+	CodeRequired     = "required"
+	CodeTooShort     = "too_short"
+	CodeTooLong      = "too_long"
+	CodeTooSmall     = "too_small"
+	CodeTooLarge     = "too_large"
+	CodeNotAllowed   = "not_allowed"
+	CodeInvalidEmail = "invalid_email"
+	CodeInvalidType  = "invalid_type"
+	CodeUnknownField = "unknown_field"
+	CodeInvalid      = "invalid"
+)
+
+// FieldError is one problem with one field of a payload
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Errors is every problem found with a payload
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Field + ": " + fe.Message
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Checker is implemented by payloads with rules a tag can't express, such as "title or content"
+type Checker interface {
+	Check() Errors
+}
+
+// Struct checks v, a struct, a slice of structs or a pointer to either, and returns Errors if any rule fails.
+// The fields of a slice's elements are reported as "[i].field".
+func Struct(v any) error {
+	var errs Errors
+	checkNested(reflect.ValueOf(v), "", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func checkStruct(v reflect.Value, prefix string, errs *Errors) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name := fieldName(sf)
+		if name == "-" {
+			continue
+		}
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+		fv := v.Field(i)
+		if !checkField(fv, path, sf.Tag.Get("validate"), errs) {
+			continue
+		}
+		checkNested(fv, path, errs)
+	}
+
+	var c Checker
+	if v.CanAddr() {
+		c, _ = v.Addr().Interface().(Checker)
+	} else {
+		c, _ = v.Interface().(Checker)
+	}
+	if c != nil {
+		for _, fe := range c.Check() {
+			if prefix != "" {
+				fe.Field = prefix + "." + fe.Field
+			}
+			*errs = append(*errs, fe)
+		}
+	}
+}
+
+// checkNested validates structs inside a field that passed its own rules
+func checkNested(v reflect.Value, path string, errs *Errors) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		checkStruct(v, path, errs)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			checkNested(v.Index(i), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	}
+}
+
+// checkField applies the rules in tag to v, reporting the first that fails. It returns false if one did.
+func checkField(v reflect.Value, path, tag string, errs *Errors) bool {
+	if tag == "" {
+		return true
+	}
+	fail := func(code, format string, args ...any) bool {
+		*errs = append(*errs, FieldError{Field: path, Code: code, Message: fmt.Sprintf(format, args...)})
+		return false
+	}
+
+	rules := strings.Split(tag, ",")
+	for _, rule := range rules {
+		if rule == "required" && isZero(v) {
+			return fail(CodeRequired, "is required")
+		}
+	}
+
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return true
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.String && v.String() == "" {
+		return true
+	}
+
+	for _, rule := range rules {
+		name, arg, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+		case "min", "max":
+			n, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				panic(fmt.Sprintf("validate: bad %s rule %q on %s", name, rule, path))
+			}
+			size, unit, ok := measure(v)
+			if !ok {
+				panic(fmt.Sprintf("validate: %s rule on %s, which has no size", name, path))
+			}
+			if name == "min" && size < n {
+				if unit == "" {
+					return fail(CodeTooSmall, "must be at least %s", arg)
+				}
+				return fail(CodeTooShort, "must be at least %s %s", arg, unit)
+			}
+			if name == "max" && size > n {
+				if unit == "" {
+					return fail(CodeTooLarge, "must be at most %s", arg)
+				}
+				return fail(CodeTooLong, "must be at most %s %s", arg, unit)
+			}
+		case "oneof":
+			allowed := strings.Fields(arg)
+			s := fmt.Sprint(v.Interface())
+			if !contains(allowed, s) {
+				return fail(CodeNotAllowed, "must be one of %s", strings.Join(allowed, ", "))
+			}
+		case "email":
+			s := v.String()
+			if addr, err := mail.ParseAddress(s); err != nil || addr.Address != s {
+				return fail(CodeInvalidEmail, "must be an email address")
+			}
+		default:
+			panic(fmt.Sprintf("validate: unknown rule %q on %s", rule, path))
+		}
+	}
+	return true
+}
+
+// measure is the size min and max compare against, and its unit for messages ("" for numbers)
+func measure(v reflect.Value) (float64, string, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), "characters", true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), "items", true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), "", true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), "", true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), "", true
+	}
+	return 0, "", false
+}
+
+func isZero(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(v.String()) == ""
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+func fieldName(sf reflect.StructField) string {
+	name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	if name == "" {
+		return sf.Name
+	}
+	return name
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
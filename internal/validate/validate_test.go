@@ -0,0 +1,65 @@
+package validate_test
+
+import (
+	"testing"
+
+	"github.com/5tuartw/droplet/internal/models"
+	"github.com/5tuartw/droplet/internal/validate"
+	"github.com/stretchr/testify/require"
+)
+
+func fieldErrors(t *testing.T, v any) validate.Errors {
+	t.Helper()
+	err := validate.Struct(v)
+	if err == nil {
+		return nil
+	}
+	errs, ok := err.(validate.Errors)
+	require.True(t, ok, "Struct returns validate.Errors")
+	return errs
+}
+
+func TestDropRequest(t *testing.T) {
+	urgent, loud := "urgent", "loud"
+	require.Empty(t, fieldErrors(t, &models.DropRequest{Title: "Trip", Priority: &urgent}))
+	require.Empty(t, fieldErrors(t, &models.DropRequest{Content: "A drop can have just content"}))
+
+	errs := fieldErrors(t, &models.DropRequest{
+		Title:    string(make([]rune, 256)),
+		Priority: &loud,
+		Targets:  []models.Target{{Type: "Class", ID: 3}, {Type: "Planet", ID: 1}, {ID: 2}},
+	})
+	require.Equal(t, validate.Errors{
+		{Field: "title", Code: validate.CodeTooLong, Message: "must be at most 255 characters"},
+		{Field: "priority", Code: validate.CodeNotAllowed, Message: "must be one of normal, important, urgent"},
+		{Field: "targets[1].type", Code: validate.CodeNotAllowed, Message: "must be one of General, Class, YearGroup, Division, Student, Lesson"},
+		{Field: "targets[2].type", Code: validate.CodeRequired, Message: "is required"},
+	}, errs)
+
+	errs = fieldErrors(t, &models.DropRequest{Title: "  "})
+	require.Equal(t, []string{"title"}, fields(errs), "a drop needs a title or content")
+
+	errs = fieldErrors(t, &[]models.Target{{Type: "General"}, {Type: "Planet", ID: 1}})
+	require.Equal(t, []string{"[1].type"}, fields(errs), "a bare list of targets is checked element by element")
+}
+
+func TestUserAndStructureRequests(t *testing.T) {
+	errs := fieldErrors(t, &models.CreateUserRequest{Email: "not an email", Password: ""})
+	require.Equal(t, []string{"email", "password"}, fields(errs))
+	require.Equal(t, validate.CodeInvalidEmail, errs[0].Code)
+	require.Empty(t, fieldErrors(t, &models.CreateUserRequest{Email: "head@school.example", Password: "hunter22"}))
+
+	errs = fieldErrors(t, &models.CreateClassRequest{ClassName: "", YearGroupID: -4})
+	require.Equal(t, []string{"class_name", "year_group_id"}, fields(errs))
+	require.Equal(t, validate.CodeTooSmall, errs[1].Code)
+
+	require.Empty(t, fieldErrors(t, &models.PupilRequest{FirstName: "Ada", Surname: "Lovelace", ClassID: 1}))
+}
+
+func fields(errs validate.Errors) []string {
+	var names []string
+	for _, fe := range errs {
+		names = append(names, fe.Field)
+	}
+	return names
+}
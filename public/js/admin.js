@@ -21,7 +21,7 @@ document.addEventListener('DOMContentLoaded', async () => {
         // --- Initial Auth & Admin Check ---
         // Call an ADMIN-ONLY endpoint. fetchApi handles tokens & 401.
         // GET /api/users handler should return 403 if user is authenticated but not admin.
-        await fetchApi('/api/users');
+        await fetchApi('/api/users?limit=1');
         console.log("Admin access verified via API.");
 
         // --- Fetch Demo Mode Status (only if admin access verified) ---
@@ -201,7 +201,7 @@ async function loadTeachers() {
 
     container.innerHTML = '<p>Loading teachers...</p>';
     try {
-        const users = await fetchApi('/api/users');
+        const users = await fetchAllPages('/api/users');
         if (!users || !Array.isArray(users)) throw new Error("Invalid user list response.");
 
         container.innerHTML = ''; // Clear loading/previous content
@@ -338,7 +338,7 @@ async function loadPupils() {
 
     container.innerHTML = '<p>Loading pupils...</p>';
     try {
-        const pupils = await fetchApi('/api/pupils');
+        const pupils = await fetchAllPages('/api/pupils');
         if (!pupils || !Array.isArray(pupils)) throw new Error("Invalid pupil list response.");
 
         container.innerHTML = ''; // Clear loading
@@ -1461,9 +1461,9 @@ function showEditModalError(message, errorDivElement, submitButtonElement) { /*
 async function handleDeleteUserClick(userId, userName) { 
     console.log(`Delete Teacher button clicked for ID: ${userId}`);
     if (isDemoMode) { alert("User deletion is disabled in demo mode."); return; }
-    if (confirm(`Are you sure you want to permanently delete user ${userName}?`)) {
+    if (confirm(`Are you sure you want to permanently delete user ${userName}? Their account is erased, but drops they wrote stay.`)) {
         try {
-            await fetchApi(`/api/users/${userId}`, { method: 'DELETE' });
+            await fetchApi(`/api/users/${userId}/erase`, { method: 'POST' });
             console.log(`User ${userId} deleted successfully.`);
             loadTeachers();
         } catch (error) { console.error(`Failed to delete user ${userId}:`, error); alert(`Error deleting user: ${error.message}`); }
@@ -0,0 +1,44 @@
+// --- public/js/branding.js ---
+// Applies the school's name, logo and colours from /api/school/branding.
+// The school comes from the subdomain (before login) or the access token (after login).
+
+async function applyBranding() {
+    const headers = {};
+    const token = sessionStorage.getItem('accessToken');
+    if (token) headers['Authorization'] = `Bearer ${token}`;
+
+    let branding;
+    try {
+        const response = await fetch('/api/school/branding', { headers });
+        if (!response.ok) return;
+        branding = await response.json();
+    } catch (error) {
+        console.error("Could not load school branding:", error);
+        return;
+    }
+
+    // Colours are validated as hex values by the server
+    const rootStyle = document.documentElement.style;
+    if (branding.colours?.primary) {
+        rootStyle.setProperty('--color-primary', branding.colours.primary);
+        rootStyle.setProperty('--color-primary-hover', branding.colours.primary);
+    }
+    if (branding.colours?.accent) {
+        rootStyle.setProperty('--color-accent-blue', branding.colours.accent);
+    }
+
+    if (branding.name) {
+        document.querySelectorAll('.logo').forEach(el => { el.textContent = branding.name; });
+        const loginHeading = document.querySelector('.login-container h1');
+        if (loginHeading) loginHeading.textContent = `${branding.name} Login`;
+        document.title = document.title.replace('Droplet', branding.name);
+    }
+
+    const loginLogo = document.querySelector('.login-container img');
+    if (loginLogo && branding.logo_url) {
+        loginLogo.src = branding.logo_url;
+        loginLogo.alt = `${branding.name || 'School'} Logo`;
+    }
+}
+
+document.addEventListener('DOMContentLoaded', applyBranding);
@@ -148,6 +148,8 @@ function applySettings(settings) {
 // API Fetch Function Helper
 // Automatically adds Authorization header and handles common responses/errors
 async function fetchApi(url, options = {}) {
+    // onResponse, if given, sees the raw response (e.g. to read headers) before the body is parsed
+    const { onResponse, ...fetchOptions } = options;
     const token = sessionStorage.getItem('accessToken');
 
     const defaultHeaders = {
@@ -156,7 +158,7 @@ async function fetchApi(url, options = {}) {
     };
 
     // Add Authorization header only if token exists
-    const headers = { ...defaultHeaders, ...options.headers };
+    const headers = { ...defaultHeaders, ...fetchOptions.headers };
     if (token) {
          headers['Authorization'] = `Bearer ${token}`;
     } else {
@@ -168,7 +170,7 @@ async function fetchApi(url, options = {}) {
     }
 
     const config = {
-        ...options, // Add/override method, body etc.
+        ...fetchOptions, // Add/override method, body etc.
         headers: headers,
     };
 
@@ -192,10 +194,15 @@ async function fetchApi(url, options = {}) {
         try {
             // Try to parse JSON error body from backend
             const contentType = response.headers.get("content-type");
-            if (contentType && contentType.indexOf("application/json") !== -1) {
+            // Errors come back as application/problem+json
+            if (contentType && contentType.indexOf("json") !== -1) {
                 const errData = await response.json();
-                if (errData && errData.error) {
-                     errorDetail += `: ${errData.error}`; // Add backend error message
+                if (errData && (errData.detail || errData.error)) {
+                     errorDetail += `: ${errData.detail || errData.error}`; // Add backend error message
+                }
+                if (errData && Array.isArray(errData.errors) && errData.errors.length > 0) {
+                     // Field-level problems, e.g. "title must be at most 255 characters"
+                     errorDetail += ` (${errData.errors.map(e => `${e.field} ${e.message}`).join('; ')})`;
                 }
             } else {
                  // If no JSON, maybe get text? But often status is enough.
@@ -205,6 +212,10 @@ async function fetchApi(url, options = {}) {
         throw new Error(errorDetail); // Throw error to be caught by caller
     }
 
+    if (onResponse) {
+        onResponse(response);
+    }
+
     // Handle successful responses with no content
     if (response.status === 204) {
         return undefined; // Indicate success with no data
@@ -225,6 +236,36 @@ async function fetchApi(url, options = {}) {
 }
 // --- End of fetchApi function definition ---
 
+// Fetches one page of a paged list endpoint. Returns its rows and the URL of the next page
+// from the Link rel="next" header, or null for the last page.
+async function fetchPage(url) {
+    let link = null;
+    const rows = await fetchApi(url, { onResponse: response => { link = response.headers.get('Link'); } });
+    const match = link && link.match(/<([^>]+)>;\s*rel="next"/);
+    return { rows, next: match ? match[1] : null };
+}
+
+// Fetches every page of a paged list endpoint (GET /api/users, /api/pupils, ...) and returns
+// the rows as one array, following the Link rel="next" header until the last page.
+async function fetchAllPages(url) {
+    const pageUrl = new URL(url, window.location.origin);
+    if (!pageUrl.searchParams.has('limit')) {
+        pageUrl.searchParams.set('limit', '200'); // The most the server returns per page
+    }
+
+    const rows = [];
+    let next = pageUrl.pathname + pageUrl.search;
+    while (next) {
+        const page = await fetchPage(next);
+        if (!Array.isArray(page.rows)) {
+            return page.rows; // Not a list; let the caller report it
+        }
+        rows.push(...page.rows);
+        next = page.next;
+    }
+    return rows;
+}
+
 // This function runs automatically when common.js loads (via initializeCommon)
 async function loadAndApplySettings() {
     console.log("Common: Attempting to load settings...");
@@ -1,6 +1,7 @@
 // --- STATE VARIABLES ---
 // Defined globally within this script's scope
 let currentView = 'my'; // Default view: 'my', 'all', 'upcoming'
+let schoolTags = []; // Tag vocabulary for the user's school, loaded from /api/tags
 let selectedTargets = []; // Array to hold target objects {type, id, name} added in the modal form
 let isEditMode = false; // Flag to track if the modal is for creating or editing
 let currentlyEditingDropId = null; // Store the ID of the drop being edited
@@ -145,7 +146,9 @@ function setupDropsListListeners(listDiv) {
         listDiv.addEventListener('click', (event) => { /* ... handle edit/delete clicks using closest() ... */
              const editButton = event.target.closest('.edit-btn');
              const deleteButton = event.target.closest('.delete-btn');
-             if (editButton) { const dropId = editButton.dataset.dropId; if (dropId) handleEditClick(dropId); }
+             const attachmentLink = event.target.closest('.attachment-link');
+             if (attachmentLink) { event.preventDefault(); downloadAttachment(attachmentLink.dataset.url, attachmentLink.dataset.filename); }
+             else if (editButton) { const dropId = editButton.dataset.dropId; if (dropId) handleEditClick(dropId); }
              else if (deleteButton) { const dropId = deleteButton.dataset.dropId; if (dropId) deleteDrop(dropId); }
         });
     } else { console.warn("Drops list container (#drops-list) not found for event delegation."); }
@@ -202,6 +205,128 @@ function updateSelectedTargetsUI() {
     }
 }
 
+// Builds the list item for one drop in the drops list
+function renderDropItem(drop, userInfo) {
+    const li = document.createElement('li');
+    // Use esc() helper for all potentially unsafe content
+    const title = esc(drop.title) || 'Untitled Drop';
+    const content = esc(drop.content) || '';
+    // Format dates nicely, handle potential null or zero dates from Go
+    const postDateStr = formatIsoDateForInput(drop.post_date) ? new Date(drop.post_date).toLocaleDateString() : 'Now';
+    const expireDateStr = formatIsoDateForInput(drop.expire_date) ? new Date(drop.expire_date).toLocaleDateString() : 'Never';
+
+    // Generate Target Badges HTML
+    let targetsHtml = '';
+    if (drop.targets && Array.isArray(drop.targets) && drop.targets.length > 0) {
+        targetsHtml = drop.targets.map(target => {
+            let badgeClass = 'target-general';
+            const typeLower = target.type ? target.type.toLowerCase() : 'general';
+            // Map types to CSS classes
+            if (typeLower === 'division') badgeClass = 'target-division';
+            else if (typeLower === 'yeargroup') badgeClass = 'target-yeargroup';
+            else if (typeLower === 'class') badgeClass = 'target-class';
+            else if (typeLower === 'student' || typeLower === 'pupil') badgeClass = 'target-student';
+            // Add other types like 'custom' if needed
+            // Use target.name provided by API (JOINed in backend), fallback to type
+            const targetDisplay = target.name || target.type || 'Target';
+            return `<span class="target-badge ${badgeClass}">${esc(targetDisplay)}</span>`;
+        }).join('');
+    }
+
+    // Determine if Edit/Delete Actions should be shown
+    let showActions = false;
+    if (userInfo) {
+        if (userInfo.role && userInfo.role.toLowerCase() === 'admin') {
+            showActions = true;
+        } else if (userInfo.id === drop.user_id) { // Assumes API sends drop.user_id (lowercase/snake)
+            showActions = true;
+        }
+    }
+    // Generate actions HTML with data attributes for delegation
+    const actionsHtml = showActions ? `
+        <div class="drop-actions">
+            <button class="edit-btn" data-drop-id="${esc(drop.id)}" title="Edit Drop">✏️</button>
+            <button class="delete-btn" data-drop-id="${esc(drop.id)}" title="Delete Drop">🗑️</button>
+        </div>` : '';
+
+    // Creator Info (Show in 'all' view if available)
+    // Assumes API sends drop.author_name (lowercase/snake)
+    const creatorInfo = (currentView === 'all' && drop.author_name) ? ` | By: ${esc(drop.author_name)}` : '';
+
+    // Tooltip Info
+    let tooltipText = '';
+    if (drop.author_name) {
+        tooltipText += `Added by: ${esc(drop.author_name)}`;
+        if (drop.created_at && !drop.created_at.startsWith('0001-01-01')) {
+            tooltipText += ` on ${new Date(drop.created_at).toLocaleString()}`;
+        }
+    } else if (drop.user_id) { // Fallback to user ID
+        tooltipText += `Added by: User ID ${esc(drop.user_id)}`;
+    }
+    // Add editor info if present (assuming drop.editor_name from API)
+    if (drop.editor_name) {
+        tooltipText += `\nLast edited by: ${esc(drop.editor_name)}`;
+        if (drop.updated_at && !drop.updated_at.startsWith('0001-01-01')) {
+            tooltipText += ` on ${new Date(drop.updated_at).toLocaleString()}`;
+        }
+    }
+    li.setAttribute('title', tooltipText.trim()); // Set tooltip on the list item
+
+    // Priority / pinned markers
+    let priorityHtml = '';
+    if (drop.pinned_until && new Date(drop.pinned_until) > new Date()) {
+        priorityHtml += '<span class="priority-badge priority-pinned" title="Pinned">📌</span>';
+    }
+    if (drop.priority === 'urgent' || drop.priority === 'important') {
+        priorityHtml += `<span class="priority-badge priority-${drop.priority}">${drop.priority === 'urgent' ? 'Urgent' : 'Important'}</span>`;
+        li.classList.add(`drop-${drop.priority}`);
+    }
+
+    // Tag chips
+    const tagsHtml = (drop.tags || []).map(tag => `<span class="tag-chip">${esc(tag.name)}</span>`).join('');
+
+    // Attachment links (downloaded with the auth header, see downloadAttachment)
+    const attachmentsHtml = (drop.attachments || []).map(attachment =>
+        `<a href="#" class="attachment-link" data-url="${esc(attachment.url)}" data-filename="${esc(attachment.filename)}">📎 ${esc(attachment.filename)}</a>`).join('');
+
+    // Construct Full List Item HTML
+    li.innerHTML = `
+        <div class="drop-header">
+            <div>${priorityHtml}<span class="drop-title">${title}</span>${targetsHtml}</div>
+            
+        </div>
+        <div class="drop-content">${content}</div>
+        ${tagsHtml ? `<div class="drop-tags">${tagsHtml}</div>` : ''}
+        ${attachmentsHtml ? `<div class="drop-attachments">${attachmentsHtml}</div>` : ''}
+        <div class="drop-footer">
+            <div class="drop-meta">Posted: ${postDateStr} | Expires: ${expireDateStr}${creatorInfo}</div>
+        ${actionsHtml}</div>`;
+    return li;
+}
+
+// Adds a "Load more" button under a drop list while there are more pages, fetching one page per click
+function appendLoadMoreButton(container, ul, next, userInfo) {
+    if (!next) return;
+    const button = document.createElement('button');
+    button.className = 'action-button load-more-button';
+    button.textContent = 'Load more';
+    button.addEventListener('click', async () => {
+        button.disabled = true;
+        try {
+            const page = await fetchPage(next);
+            (page.rows || []).forEach(drop => ul.appendChild(renderDropItem(drop, userInfo)));
+            button.remove();
+            appendLoadMoreButton(container, ul, page.next, userInfo);
+        } catch (error) {
+            console.error(`Error loading more ${currentView} drops:`, error);
+            const errorMessageDiv = document.getElementById('error-message');
+            if (errorMessageDiv) errorMessageDiv.textContent = `Failed to load more drops: ${error.message}`;
+            button.disabled = false;
+        }
+    });
+    container.appendChild(button);
+}
+
 // --- Main Data Fetching and Display ---
 // --- Main Data Fetching and Display ---
 async function fetchAndDisplayDrops() {
@@ -231,11 +356,17 @@ async function fetchAndDisplayDrops() {
         return;
     }
 
+    // The tag filter applies to the 'my' and 'all' views
+    const tagFilter = document.getElementById('tag-filter')?.value;
+    if (tagFilter && currentView !== 'upcoming') {
+        apiUrl += `?tags=${encodeURIComponent(tagFilter)}`;
+    }
+
     console.log(`Workspaceing ${apiUrl} (View: ${currentView})...`);
 
     try {
-        // Use fetchApi for authenticated request
-        const drops = await fetchApi(apiUrl);
+        // Drop lists are paged, pinned and urgent drops first; show the first page and load the rest on request
+        const { rows: drops, next } = await fetchPage(apiUrl);
 
         // Validate response format
         if (!drops || !Array.isArray(drops)) {
@@ -251,86 +382,10 @@ async function fetchAndDisplayDrops() {
             // Get current user info ONCE before the loop for efficiency
             const userInfo = getUserInfo(); // From common.js
 
-            drops.forEach(drop => {
-                const li = document.createElement('li');
-                // Use esc() helper for all potentially unsafe content
-                const title = esc(drop.title) || 'Untitled Drop';
-                const content = esc(drop.content) || '';
-                // Format dates nicely, handle potential null or zero dates from Go
-                const postDateStr = formatIsoDateForInput(drop.post_date) ? new Date(drop.post_date).toLocaleDateString() : 'Now';
-                const expireDateStr = formatIsoDateForInput(drop.expire_date) ? new Date(drop.expire_date).toLocaleDateString() : 'Never';
-
-                // Generate Target Badges HTML
-                let targetsHtml = '';
-                if (drop.targets && Array.isArray(drop.targets) && drop.targets.length > 0) {
-                    targetsHtml = drop.targets.map(target => {
-                        let badgeClass = 'target-general';
-                        const typeLower = target.type ? target.type.toLowerCase() : 'general';
-                        // Map types to CSS classes
-                        if (typeLower === 'division') badgeClass = 'target-division';
-                        else if (typeLower === 'yeargroup') badgeClass = 'target-yeargroup';
-                        else if (typeLower === 'class') badgeClass = 'target-class';
-                        else if (typeLower === 'student' || typeLower === 'pupil') badgeClass = 'target-student';
-                        // Add other types like 'custom' if needed
-                        // Use target.name provided by API (JOINed in backend), fallback to type
-                        const targetDisplay = target.name || target.type || 'Target';
-                        return `<span class="target-badge ${badgeClass}">${esc(targetDisplay)}</span>`;
-                    }).join('');
-                }
-
-                // Determine if Edit/Delete Actions should be shown
-                let showActions = false;
-                if (userInfo) {
-                    if (userInfo.role && userInfo.role.toLowerCase() === 'admin') {
-                        showActions = true;
-                    } else if (userInfo.id === drop.user_id) { // Assumes API sends drop.user_id (lowercase/snake)
-                        showActions = true;
-                    }
-                }
-                // Generate actions HTML with data attributes for delegation
-                const actionsHtml = showActions ? `
-                    <div class="drop-actions">
-                        <button class="edit-btn" data-drop-id="${esc(drop.id)}" title="Edit Drop">✏️</button>
-                        <button class="delete-btn" data-drop-id="${esc(drop.id)}" title="Delete Drop">🗑️</button>
-                    </div>` : '';
-
-                // Creator Info (Show in 'all' view if available)
-                // Assumes API sends drop.author_name (lowercase/snake)
-                const creatorInfo = (currentView === 'all' && drop.author_name) ? ` | By: ${esc(drop.author_name)}` : '';
-
-                // Tooltip Info
-                let tooltipText = '';
-                if (drop.author_name) {
-                    tooltipText += `Added by: ${esc(drop.author_name)}`;
-                    if (drop.created_at && !drop.created_at.startsWith('0001-01-01')) {
-                        tooltipText += ` on ${new Date(drop.created_at).toLocaleString()}`;
-                    }
-                } else if (drop.user_id) { // Fallback to user ID
-                    tooltipText += `Added by: User ID ${esc(drop.user_id)}`;
-                }
-                // Add editor info if present (assuming drop.editor_name from API)
-                if (drop.editor_name) {
-                    tooltipText += `\nLast edited by: ${esc(drop.editor_name)}`;
-                    if (drop.updated_at && !drop.updated_at.startsWith('0001-01-01')) {
-                        tooltipText += ` on ${new Date(drop.updated_at).toLocaleString()}`;
-                    }
-                }
-                li.setAttribute('title', tooltipText.trim()); // Set tooltip on the list item
-
-                // Construct Full List Item HTML
-                li.innerHTML = `
-                    <div class="drop-header">
-                        <div><span class="drop-title">${title}</span>${targetsHtml}</div>
-                        
-                    </div>
-                    <div class="drop-content">${content}</div>
-                    <div class="drop-footer">
-                        <div class="drop-meta">Posted: ${postDateStr} | Expires: ${expireDateStr}${creatorInfo}</div>
-                    ${actionsHtml}</div>`;
-                ul.appendChild(li);
-            }); // End drops.forEach
+            drops.forEach(drop => ul.appendChild(renderDropItem(drop, userInfo)));
 
             dropsListDiv.appendChild(ul);
+            appendLoadMoreButton(dropsListDiv, ul, next, userInfo);
             // NOTE: No need to call setupDropsListListeners here if it was called once
             // in initializeDropsPage attaching listener to dropsListDiv (event delegation).
         } else {
@@ -433,6 +488,12 @@ function showCreateDropModal(dropDataToEdit = null) {
         dropContentInput.value = dropDataToEdit.content || '';
         postDateInput.value = formatIsoDateForInput(dropDataToEdit.post_date); // Use formatter
         expireDateInput.value = formatIsoDateForInput(dropDataToEdit.expire_date); // Use formatter
+        document.getElementById('drop-priority').value = dropDataToEdit.priority || 'normal';
+        document.getElementById('drop-pinned-until').value = formatIsoDateForInput(dropDataToEdit.pinned_until);
+        const dropTagIds = new Set((dropDataToEdit.tags || []).map(tag => tag.id));
+        document.querySelectorAll('.drop-tag-checkbox').forEach(checkbox => {
+            checkbox.checked = dropTagIds.has(Number(checkbox.value));
+        });
 
         // Populate the selectedTargets array from fetched data
         // Map structure must match {type, id, name} used by updateSelectedTargetsUI etc.
@@ -505,6 +566,8 @@ async function handleCreateOrUpdateDropSubmit(event) {
         const content = document.getElementById('drop-content').value.trim();
         const postDateValue = document.getElementById('drop-post-date').value; // Gets YYYY-MM-DD string or empty
         const expireDateValue = document.getElementById('drop-expire-date').value; // Gets YYYY-MM-DD string or empty
+        const priorityValue = document.getElementById('drop-priority').value;
+        const pinnedUntilValue = document.getElementById('drop-pinned-until').value; // Gets YYYY-MM-DD string or empty
         // 'selectedTargets' array is already populated globally by handleAddTarget/handleRemoveTarget
 
         // --- 2. Client-Side Validation ---
@@ -532,6 +595,13 @@ async function handleCreateOrUpdateDropSubmit(event) {
         if (expireDateValue) {
             payload.expire_date = expireDateValue;
         }
+        if (priorityValue) {
+            payload.priority = priorityValue;
+        }
+        if (pinnedUntilValue) {
+            payload.pinned_until = pinnedUntilValue;
+        }
+        payload.tag_ids = Array.from(document.querySelectorAll('.drop-tag-checkbox:checked')).map(checkbox => Number(checkbox.value));
         console.log("Submitting payload:", payload); // Log for debugging
 
         // --- 4. Determine Method and URL ---
@@ -541,14 +611,27 @@ async function handleCreateOrUpdateDropSubmit(event) {
 
         // --- 5. Make the API Call ---
         // Use fetchApi, which handles auth header and basic error responses (like 401 redirect)
-        await fetchApi(apiUrl, {
+        const result = await fetchApi(apiUrl, {
             method: method,
             body: JSON.stringify(payload)
             // fetchApi should automatically set 'Content-Type': 'application/json'
         });
 
-        // --- 6. Handle Success ---
+        // --- 6. Upload any attachments against the saved drop ---
+        const fileInput = document.getElementById('drop-attachments');
+        const dropId = isEditMode ? currentlyEditingDropId : result?.id;
+        if (fileInput && fileInput.files.length > 0 && dropId) {
+            submitButton.textContent = 'Uploading...';
+            for (const file of fileInput.files) {
+                await uploadAttachment(dropId, file);
+            }
+        }
+
+        // --- 7. Handle Success ---
         console.log(`Drop ${isEditMode ? 'updated' : 'created'} successfully!`);
+        if (result && result.status === 'pending_approval') {
+            alert('Your drop has been submitted for approval and will appear once it has been signed off.');
+        }
         closeCreateDropModal();    // Close the modal (also resets edit flags)
         fetchAndDisplayDrops();    // Refresh the main drop list to show changes
 
@@ -568,7 +651,80 @@ async function handleCreateOrUpdateDropSubmit(event) {
     }
 }
 
+// --- Attachments ---
+// fetchApi always sends JSON, so multipart uploads and file downloads call fetch directly
+async function uploadAttachment(dropId, file) {
+    const formData = new FormData();
+    formData.append('file', file);
+    const response = await fetch(`/api/drops/${dropId}/attachments`, {
+        method: 'POST',
+        headers: { 'Authorization': `Bearer ${sessionStorage.getItem('accessToken')}` },
+        body: formData
+    });
+    if (!response.ok) {
+        let message = `Could not upload ${file.name} (Status: ${response.status})`;
+        try {
+            const errData = await response.json();
+            if (errData && (errData.detail || errData.error)) message += `: ${errData.detail || errData.error}`;
+        } catch (e) { /* Ignore parsing errors */ }
+        throw new Error(message);
+    }
+}
+
+async function downloadAttachment(url, filename) {
+    try {
+        const response = await fetch(url, {
+            headers: { 'Authorization': `Bearer ${sessionStorage.getItem('accessToken')}` }
+        });
+        if (!response.ok) throw new Error(`Download failed (Status: ${response.status})`);
+        const blobUrl = URL.createObjectURL(await response.blob());
+        const link = document.createElement('a');
+        link.href = blobUrl;
+        link.download = filename || 'attachment';
+        document.body.appendChild(link);
+        link.click();
+        link.remove();
+        URL.revokeObjectURL(blobUrl);
+    } catch (error) {
+        console.error("Error downloading attachment:", error);
+        const errorMessageDiv = document.getElementById('error-message');
+        if (errorMessageDiv) errorMessageDiv.textContent = `Could not download attachment: ${error.message}`;
+    }
+}
+
 // --- PAGE INITIALIZATION (AFTER AUTH CHECK) ---
+// --- Tags ---
+// Loads the school's tags into the filter dropdown and the create/edit form checkboxes
+async function loadSchoolTags() {
+    try {
+        schoolTags = await fetchApi('/api/tags') || [];
+    } catch (error) {
+        console.error("Error loading tags:", error);
+        schoolTags = [];
+    }
+
+    const filterSelect = document.getElementById('tag-filter');
+    if (filterSelect) {
+        schoolTags.forEach(tag => {
+            const option = document.createElement('option');
+            option.value = tag.name;
+            option.textContent = tag.name;
+            filterSelect.appendChild(option);
+        });
+        filterSelect.addEventListener('change', () => fetchAndDisplayDrops());
+    }
+
+    const tagsDiv = document.getElementById('drop-tags');
+    if (tagsDiv) {
+        tagsDiv.innerHTML = schoolTags.length === 0 ? '<small>No tags have been set up for your school.</small>' : '';
+        schoolTags.forEach(tag => {
+            const label = document.createElement('label');
+            label.innerHTML = `<input type="checkbox" class="drop-tag-checkbox" value="${tag.id}"> ${esc(tag.name)}`;
+            tagsDiv.appendChild(label);
+        });
+    }
+}
+
 // This function contains the setup logic that runs ONLY if the initial auth check passes
 function initializeDropsPage() {
     console.log("===> START Initializing drops page UI and listeners... ===");
@@ -631,6 +787,7 @@ function initializeDropsPage() {
     console.log("Setting initial view and loading drops...");
     setActiveView(currentView); // Set initial button state and title ('my')
     fetchAndDisplayDrops();     // Fetch initial data ('my' drops)
+    loadSchoolTags();           // Tag filter and form checkboxes
 
     console.log("===> END Initializing drops page UI and listeners. ===");
 }
@@ -14,6 +14,10 @@ document.addEventListener('DOMContentLoaded', () => {
     const yeargroupSubsDiv = document.getElementById('yeargroup-subscriptions');
     const classSubsDiv = document.getElementById('class-subscriptions');
     const subsMessageArea = document.getElementById('subs-message-area');
+    // Tag Subscriptions Form
+    const tagSubscriptionsForm = document.getElementById('tag-subscriptions-form');
+    const tagSubsDiv = document.getElementById('tag-subscriptions');
+    const tagsMessageArea = document.getElementById('tags-message-area');
     // Common Navbar Elements (if needed here, but displayUserInfo/logout handled by common.js)
     // const userEmailDisplay = document.getElementById('user-email-display');
     // const logoutButton = document.getElementById('logout-button');
@@ -97,6 +101,33 @@ document.addEventListener('DOMContentLoaded', () => {
         });
     }
 
+    function populateTagSubscriptions(tags, currentTagSubscriptions) {
+        if (!tagSubsDiv) return;
+        tagSubsDiv.innerHTML = '';
+        if (!tags || tags.length === 0) { tagSubsDiv.innerHTML = '<p>Your school has not set up any tags yet.</p>'; return; }
+
+        const stateByTag = new Map();
+        (currentTagSubscriptions || []).forEach(sub => {
+            stateByTag.set(sub.tag_id, sub.muted ? 'muted' : 'followed');
+        });
+
+        tags.forEach(tag => {
+            const selectId = `tag-sub-${tag.id}`;
+            const state = stateByTag.get(tag.id) || '';
+            const row = document.createElement('div');
+            row.classList.add('tag-subscription-row');
+            row.innerHTML = `
+                <label for="${selectId}">${escapeHtml(tag.name)}</label>
+                <select id="${selectId}" class="tag-subscription-select" data-tag-id="${tag.id}">
+                    <option value="">No preference</option>
+                    <option value="followed">Follow</option>
+                    <option value="muted">Mute</option>
+                </select>`;
+            row.querySelector('select').value = state;
+            tagSubsDiv.appendChild(row);
+        });
+    }
+
     // --- Function to Load All Initial Data for Settings Form ---
     async function loadInitialSettingsDataForForm() {
         console.log("Settings Page: Loading initial data...");
@@ -109,17 +140,19 @@ document.addEventListener('DOMContentLoaded', () => {
 
         try {
             // Assumes fetchApi helper is in common.js
-            const [settingsData, divisions, yearGroups, classes] = await Promise.all([
+            const [settingsData, divisions, yearGroups, classes, tags] = await Promise.all([
                 fetchApi('/api/settings/me'),
                 fetchApi('/api/divisions'),
                 fetchApi('/api/yeargroups'),
-                fetchApi('/api/classes')
+                fetchApi('/api/classes'),
+                fetchApi('/api/tags')
             ]);
 
             populatePreferencesForm(settingsData?.preferences);
             populateSubscriptionCheckboxes('Division', divisions, settingsData?.subscriptions, divisionSubsDiv);
             populateSubscriptionCheckboxes('YearGroup', yearGroups, settingsData?.subscriptions, yeargroupSubsDiv);
             populateSubscriptionCheckboxes('Class', classes, settingsData?.subscriptions, classSubsDiv);
+            populateTagSubscriptions(tags, settingsData?.tag_subscriptions);
 
         } catch (error) {
             console.error("Failed to load initial settings page data:", error);
@@ -203,6 +236,36 @@ document.addEventListener('DOMContentLoaded', () => {
         }
     }
 
+    async function handleSaveTagSubscriptions(event) {
+        event.preventDefault();
+        const submitButton = tagSubscriptionsForm?.querySelector('button[type="submit"]');
+        if (!tagsMessageArea) return;
+        tagsMessageArea.textContent = '';
+        tagsMessageArea.classList.remove('success-message', 'error-message');
+        if (submitButton) submitButton.disabled = true;
+
+        try {
+            const payload = { followed: [], muted: [] };
+            tagSubscriptionsForm.querySelectorAll('.tag-subscription-select').forEach(select => {
+                const tagId = parseInt(select.dataset.tagId, 10);
+                if (select.value === 'followed') payload.followed.push(tagId);
+                else if (select.value === 'muted') payload.muted.push(tagId);
+            });
+
+            console.log("Saving tag subscriptions:", payload);
+            await fetchApi('/api/settings/me/tags', { method: 'PUT', body: JSON.stringify(payload) });
+            tagsMessageArea.textContent = 'Tags saved!';
+            tagsMessageArea.classList.add('success-message');
+
+        } catch (error) {
+            console.error("Error saving tag subscriptions:", error);
+            tagsMessageArea.textContent = `Error: ${error.message}`;
+            tagsMessageArea.classList.add('error-message');
+        } finally {
+            if (submitButton) submitButton.disabled = false;
+        }
+    }
+
     // --- 1. Define Password Requirements (Mirror Backend) ---
     // Adjust these values based on auth.ValidatePassword function
     const minPasswordLength = 8;
@@ -324,6 +387,7 @@ document.addEventListener('DOMContentLoaded', () => {
     // --- Event Listeners Setup ---
     if (preferencesForm) { preferencesForm.addEventListener('submit', handleSavePreferences); }
     if (subscriptionsForm) { subscriptionsForm.addEventListener('submit', handleSaveSubscriptions); }
+    if (tagSubscriptionsForm) { tagSubscriptionsForm.addEventListener('submit', handleSaveTagSubscriptions); }
     // Note: Logout listener should be handled by common.js
 
     // --- Initial Page Load Actions ---
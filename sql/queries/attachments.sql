@@ -0,0 +1,25 @@
+-- name: CreateAttachment :one
+INSERT INTO drop_attachments (drop_id, school_id, filename, content_type, size_bytes, storage_key, uploaded_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetAttachmentByID :one
+SELECT * FROM drop_attachments WHERE id = $1 AND drop_id = $2 AND school_id = $3;
+
+-- name: GetAttachmentsForDrops :many
+SELECT * FROM drop_attachments
+WHERE school_id = $1 AND drop_id = ANY($2::uuid[])
+ORDER BY created_at, id;
+
+-- name: GetAttachmentKeysForDrop :many
+SELECT storage_key FROM drop_attachments WHERE drop_id = $1 AND school_id = $2;
+
+-- name: DeleteAttachment :exec
+DELETE FROM drop_attachments WHERE id = $1 AND drop_id = $2 AND school_id = $3;
+
+-- Storage keys of deleted attachments whose files may still need deleting, oldest first
+-- name: GetAttachmentDeletions :many
+SELECT storage_key FROM attachment_deletions ORDER BY deleted_at, storage_key LIMIT $1;
+
+-- name: ForgetAttachmentDeletions :exec
+DELETE FROM attachment_deletions WHERE storage_key = ANY(@storage_keys::text[]);
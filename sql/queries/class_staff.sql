@@ -0,0 +1,28 @@
+-- Staff assigned to a class, tutors first
+-- name: GetClassStaff :many
+SELECT u.id, u.title, u.first_name, u.surname, cs.role
+FROM class_staff cs
+JOIN users u ON u.id = cs.user_id
+WHERE cs.class_id = $1 AND cs.school_id = $2
+ORDER BY cs.role, u.surname, u.first_name, u.id;
+
+-- name: GetClassStaffForSchool :many
+SELECT cs.class_id, u.id, u.title, u.first_name, u.surname, cs.role
+FROM class_staff cs
+JOIN users u ON u.id = cs.user_id
+WHERE cs.school_id = $1
+ORDER BY cs.class_id, cs.role, u.surname, u.first_name, u.id;
+
+-- Assigns a user to a class or changes their role there. Returns no rows when the class or
+-- user isn't in the school, or the user has been erased.
+-- name: AssignClassStaff :one
+INSERT INTO class_staff (class_id, user_id, school_id, role)
+SELECT cls.id, u.id, cls.school_id, @role::class_staff_role
+FROM classes cls
+JOIN users u ON u.school_id = cls.school_id AND u.erased_at IS NULL
+WHERE cls.id = @class_id AND u.id = @user_id AND cls.school_id = @school_id
+ON CONFLICT (class_id, user_id) DO UPDATE SET role = EXCLUDED.role
+RETURNING *;
+
+-- name: RemoveClassStaff :execrows
+DELETE FROM class_staff WHERE class_id = $1 AND user_id = $2 AND school_id = $3;
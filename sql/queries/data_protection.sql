@@ -0,0 +1,59 @@
+-- name: GetPupilClassHistory :many
+SELECT class_id, class_name, started_at, ended_at FROM pupil_class_history
+WHERE pupil_id = $1 AND school_id = $2
+ORDER BY started_at NULLS FIRST, id;
+
+-- name: GetDropsTargetingPupil :many
+SELECT d.* FROM drops d
+JOIN drop_targets dt ON dt.drop_id = d.id
+WHERE dt.school_id = $1 AND dt.type = 'Student' AND dt.target_id = $2
+ORDER BY d.created_at;
+
+-- name: GetDropsByUser :many
+SELECT * FROM drops
+WHERE school_id = $1 AND (user_id = $2 OR edited_by = $2 OR reviewed_by = $2)
+ORDER BY created_at;
+
+-- name: GetAttachmentsUploadedByUser :many
+SELECT * FROM drop_attachments WHERE school_id = $1 AND uploaded_by = $2
+ORDER BY created_at;
+
+-- name: GetSessionsForUser :many
+SELECT created_at, expires_at, revoked_at FROM refresh_tokens
+WHERE user_id = $1 AND school_id = $2
+ORDER BY created_at DESC;
+
+-- Erased accounts keep their id so authored drops stay, but nothing that identifies the person
+-- name: AnonymiseUser :execrows
+UPDATE users
+SET email = 'erased-' || id || '@erased.invalid',
+    hashed_password = 'unset',
+    role = 'read_only',
+    title = '',
+    first_name = 'Former',
+    surname = 'staff member',
+    erased_at = NOW(),
+    updated_at = NOW()
+WHERE id = $1 AND school_id = $2 AND erased_at IS NULL;
+
+-- name: DeleteUserPersonalData :exec
+WITH deleted_settings AS (DELETE FROM user_settings WHERE user_settings.user_id = $1),
+    deleted_target_subscriptions AS (DELETE FROM target_subscriptions WHERE target_subscriptions.user_id = $1),
+    deleted_tag_subscriptions AS (DELETE FROM tag_subscriptions WHERE tag_subscriptions.user_id = $1),
+    deleted_role_scopes AS (DELETE FROM role_scopes WHERE role_scopes.user_id = $1),
+    deleted_class_staff AS (DELETE FROM class_staff WHERE class_staff.user_id = $1),
+    deleted_views AS (DELETE FROM drop_views WHERE drop_views.user_id = $1),
+    deleted_confirmations AS (DELETE FROM drop_confirmations WHERE drop_confirmations.user_id = $1),
+    deleted_lessons AS (DELETE FROM lessons WHERE lessons.user_id = $1)
+DELETE FROM refresh_tokens WHERE refresh_tokens.user_id = $1;
+
+-- Deletes a pupil with the drop targets and staff subscriptions naming them; their class history and
+-- custom group memberships go with the pupil row
+-- name: ErasePupil :execrows
+WITH deleted_targets AS (
+        DELETE FROM drop_targets
+        WHERE drop_targets.school_id = @school_id::uuid AND drop_targets.type = 'Student' AND drop_targets.target_id = @pupil_id::int),
+    deleted_subscriptions AS (
+        DELETE FROM target_subscriptions
+        WHERE target_subscriptions.school_id = @school_id::uuid AND target_subscriptions.type = 'Student' AND target_subscriptions.target_id = @pupil_id::int)
+DELETE FROM pupils WHERE pupils.id = @pupil_id::int AND pupils.school_id = @school_id::uuid;
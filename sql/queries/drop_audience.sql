@@ -0,0 +1,66 @@
+-- Where drop_audience disagrees with the audience rebuilt from drop_audience_expected:
+-- rows it is missing (missing = true) and rows it should not have (missing = false)
+-- name: DiffDropAudience :many
+SELECT e.drop_id, e.user_id, true AS missing
+FROM drop_audience_expected e
+WHERE NOT EXISTS (SELECT 1 FROM drop_audience a WHERE a.drop_id = e.drop_id AND a.user_id = e.user_id)
+UNION ALL
+SELECT a.drop_id, a.user_id, false AS missing
+FROM drop_audience a
+WHERE NOT EXISTS (SELECT 1 FROM drop_audience_expected e WHERE e.drop_id = a.drop_id AND e.user_id = a.user_id)
+ORDER BY drop_id, user_id;
+
+-- name: DeleteUnexpectedDropAudience :execrows
+DELETE FROM drop_audience a
+WHERE NOT EXISTS (SELECT 1 FROM drop_audience_expected e WHERE e.drop_id = a.drop_id AND e.user_id = a.user_id);
+
+-- name: InsertMissingDropAudience :execrows
+INSERT INTO drop_audience (drop_id, user_id)
+SELECT drop_id, user_id FROM drop_audience_expected
+ON CONFLICT DO NOTHING;
+
+-- The staff a drop with these targets would reach in "My Drops" (before any tags), by the target
+-- rules drop_audience_expected uses. Targets are passed as parallel type and ID arrays; Lesson targets
+-- that don't have an ID yet are passed as parallel class, room, period and date arrays (0 for no class
+-- or room) and matched to the timetable the way target_staff matches lesson targets.
+-- name: GetTargetStaff :many
+SELECT u.id, u.title, u.first_name, u.surname, u.role
+FROM users u
+WHERE u.school_id = @school_id
+  AND (EXISTS (
+    SELECT 1
+    FROM unnest(@target_types::text[], @target_ids::int[]) AS t(type, target_id)
+    JOIN target_staff ts ON ts.type = t.type::target_type AND (t.type = 'General' OR ts.target_id = t.target_id)
+    WHERE ts.user_id = u.id
+  ) OR EXISTS (
+    SELECT 1
+    FROM unnest(@lesson_class_ids::int[], @lesson_room_ids::int[], @lesson_period_ids::int[], @lesson_dates::text[])
+        AS lt(class_id, room_id, period_id, lesson_date)
+    JOIN lessons l ON l.school_id = @school_id AND l.period_id = lt.period_id
+        AND (l.class_id = lt.class_id OR l.room_id = lt.room_id)
+        AND l.cycle_day = timetable_cycle_day(@school_id, lt.lesson_date::date)
+    WHERE l.user_id = u.id
+  ))
+ORDER BY u.surname, u.first_name, u.id;
+
+-- The pupils a drop with these targets is aimed at, with targets passed as for GetTargetStaff
+-- name: GetTargetPupils :many
+SELECT p.id, p.first_name, p.surname, COALESCE(cls.class_name, '')::text AS class_name
+FROM pupils p
+LEFT JOIN classes cls ON cls.id = p.class_id
+WHERE p.school_id = @school_id
+  AND (EXISTS (
+    SELECT 1
+    FROM unnest(@target_types::text[], @target_ids::int[]) AS t(type, target_id)
+    JOIN target_pupils tp ON tp.type = t.type::target_type AND (t.type = 'General' OR tp.target_id = t.target_id)
+    WHERE tp.pupil_id = p.id
+  ) OR EXISTS (
+    SELECT 1
+    FROM unnest(@lesson_class_ids::int[], @lesson_room_ids::int[], @lesson_period_ids::int[], @lesson_dates::text[])
+        AS lt(class_id, room_id, period_id, lesson_date)
+    JOIN lessons l ON l.school_id = @school_id AND l.period_id = lt.period_id
+        AND (l.class_id = lt.class_id OR l.room_id = lt.room_id)
+        AND l.cycle_day = timetable_cycle_day(@school_id, lt.lesson_date::date)
+    WHERE l.class_id = p.class_id
+  ))
+ORDER BY p.surname, p.first_name, p.id;
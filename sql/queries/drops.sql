@@ -1,5 +1,5 @@
 -- name: CreateDrop :one
-INSERT INTO drops (id, user_id, school_id, title, content, created_at, updated_at, post_date, expire_date)
+INSERT INTO drops (id, user_id, school_id, title, content, created_at, updated_at, post_date, expire_date, status, priority, pinned_until)
 VALUES (
     gen_random_uuid(),
     $1,
@@ -9,7 +9,10 @@ VALUES (
     NOW(),
     NOW(),
     $5,
-    $6   
+    $6,
+    $7,
+    $8,
+    $9
 )
 RETURNING *;
 
@@ -23,14 +26,35 @@ SELECT * FROM drops WHERE id = $1 AND school_id = $2;
 SELECT user_id FROM drops WHERE id = $1 AND school_id = $2;
 
 -- name: GetActiveDrops :many
-SELECT * FROM drops WHERE expire_date > NOW() AND school_id = $1 ORDER BY post_date DESC;
+SELECT * FROM drops WHERE expire_date > NOW() AND school_id = $1 AND status = 'published' ORDER BY post_date DESC;
 
 -- name: UpdateDrop :exec
 UPDATE drops
-SET title = $3, content = $4, post_date = $5, expire_date = $6, updated_at = NOW(), edited_by = $7
+SET title = $3, content = $4, post_date = $5, expire_date = $6, updated_at = NOW(), edited_by = $7, priority = $8, pinned_until = $9
 WHERE id = $1 and school_id = $2;
 
--- name: GetDropWithTargetsByID :many
+-- name: SetDropStatus :exec
+UPDATE drops
+SET status = $3, reviewed_by = NULL, reviewed_at = NULL, rejection_reason = NULL
+WHERE id = $1 AND school_id = $2;
+
+-- name: ApproveDrop :execrows
+UPDATE drops
+SET status = 'published', reviewed_by = $3, reviewed_at = NOW(), rejection_reason = NULL
+WHERE id = $1 AND school_id = $2 AND status = 'pending_approval';
+
+-- name: RejectDrop :execrows
+UPDATE drops
+SET status = 'rejected', reviewed_by = $3, reviewed_at = NOW(), rejection_reason = $4
+WHERE id = $1 AND school_id = $2 AND status = 'pending_approval';
+
+-- Sends a rejected drop back to the approval queue
+-- name: ResubmitDrop :execrows
+UPDATE drops
+SET status = 'pending_approval', reviewed_by = NULL, reviewed_at = NULL, rejection_reason = NULL
+WHERE id = $1 AND school_id = $2 AND status = 'rejected';
+
+-- name: GetDropWithTargetsByID :one
 SELECT
     d.id AS drop_id,
     d.user_id AS drop_user_id,
@@ -38,43 +62,54 @@ SELECT
     d.content AS drop_content,
     d.post_date AS drop_post_date,
     d.expire_date AS drop_expire_date,
-    d.created_at AS drop_created_at,
     d.updated_at AS drop_updated_at,
-    d.edited_by AS drop_edited_by,
-    -- Target details (will be NULL if drop has no targets)
-    dt.type AS target_type,
-    dt.target_id AS target_id,
-    -- Target name (will be NULL if no target or name missing)
-    COALESCE(
-        cls.class_name,
-        yg.year_group_name,
-        div.division_name,
-        p.surname || ', ' || p.first_name,
-        'General' -- fallback
-    ) AS target_name,
-    COALESCE(cls.class_name, yg.year_group_name, div.division_name, p.surname || ', ' || p.first_name, 'General') AS target_name,
+    d.status AS drop_status,
+    d.rejection_reason AS drop_rejection_reason,
+    d.priority AS drop_priority,
+    d.pinned_until AS drop_pinned_until,
     -- Author Name (Concatenated, assumes author exists)
     COALESCE(CONCAT_WS(' ', author.first_name, author.surname), 'Unknown Author')::text AS author_name,
-    -- Editor Name (Concatenated, handles NULL editor via LEFT JOIN + COALESCE on final result)
-    COALESCE(CONCAT_WS(' ', editor.first_name,  editor.surname))::text AS editor_name -- This might be NULL if no editor
+    -- Editor Name (empty if the drop has not been edited)
+    CONCAT_WS(' ', editor.first_name, editor.surname)::text AS editor_name,
+    -- Targets as a JSON array of {"type", "id", "name"} objects, in target type order
+    COALESCE((
+        SELECT json_agg(json_build_object('type', tn.type, 'id', tn.target_id, 'name', tn.name) ORDER BY tn.type, tn.target_id)
+        FROM drop_target_names tn
+        WHERE tn.drop_id = d.id
+    ), '[]')::json AS targets
 FROM
     drops d
-LEFT JOIN
-    drop_targets dt ON d.id = dt.drop_id
-LEFT JOIN
-    classes cls ON dt.type = 'Class' AND dt.target_id = cls.id
-LEFT JOIN
-    year_groups yg ON dt.type = 'YearGroup' AND dt.target_id = yg.id
-LEFT JOIN
-    divisions div ON dt.type = 'Division' AND dt.target_id = div.id
-LEFT JOIN
-    pupils p ON dt.type = 'Student' AND dt.target_id = p.id
 LEFT JOIN
     users AS author ON d.user_id = author.id
 LEFT JOIN
-    users AS editor on d.edited_by = editor.id
+    users AS editor ON d.edited_by = editor.id
 WHERE
     d.id = $1 -- Filter for the specific drop ID
-AND d.school_id = $2
-ORDER BY
-    dt.type;
\ No newline at end of file
+AND d.school_id = $2;
+
+-- Claims the push for a live urgent drop that hasn't been pushed yet. Whoever claims it sends it.
+-- name: ClaimUrgentDropPush :execrows
+INSERT INTO urgent_drop_pushes (drop_id, school_id)
+SELECT id, school_id FROM drops
+WHERE id = $1 AND school_id = $2 AND priority = 'urgent' AND status = 'published'
+    AND post_date <= NOW() AND expire_date > NOW()
+ON CONFLICT (drop_id) DO NOTHING;
+
+-- Claims the push for every live urgent drop, in any school, that hasn't been pushed yet
+-- name: ClaimDueUrgentDropPushes :many
+WITH claimed AS (
+    INSERT INTO urgent_drop_pushes (drop_id, school_id)
+    SELECT d.id, d.school_id FROM drops d
+    WHERE d.priority = 'urgent' AND d.status = 'published' AND d.post_date <= NOW() AND d.expire_date > NOW()
+        AND NOT EXISTS (SELECT 1 FROM urgent_drop_pushes p WHERE p.drop_id = d.id)
+    ON CONFLICT (drop_id) DO NOTHING
+    RETURNING drop_id
+)
+SELECT d.* FROM drops d JOIN claimed c ON c.drop_id = d.id ORDER BY d.post_date;
+
+-- Marks a school's live urgent drops as pushed without sending them, e.g. after importing them
+-- name: MarkLiveUrgentDropsPushed :exec
+INSERT INTO urgent_drop_pushes (drop_id, school_id)
+SELECT id, school_id FROM drops
+WHERE school_id = $1 AND priority = 'urgent' AND status = 'published' AND post_date <= NOW()
+ON CONFLICT (drop_id) DO NOTHING;
@@ -8,13 +8,29 @@ VALUES (
 )
 RETURNING *;
 
+-- One page of a school's pupils, by class then name or by name alone
+-- (keyset on sort_class, surname, first_name, id; sort_class is '' when sorting by name)
 -- name: GetAllPupils :many
 SELECT p.id, p.school_id, p.first_name, p.surname, p.class_id,
 COALESCE(c.class_name, 'Unassigned') AS class_name
 FROM pupils p
 LEFT JOIN classes c ON p.class_id = c.id
-WHERE p.school_id = $1
-ORDER BY c.class_name, p.surname, p.first_name;
+LEFT JOIN LATERAL (
+    SELECT CASE WHEN @sort_by_class::boolean THEN COALESCE(c.class_name, 'Unassigned') ELSE '' END AS sort_class
+) k ON true
+WHERE p.school_id = @school_id
+    AND (sqlc.narg('class_id')::int IS NULL OR p.class_id = sqlc.narg('class_id')::int)
+    AND (sqlc.narg('year_group_id')::int IS NULL OR c.year_group_id = sqlc.narg('year_group_id')::int)
+    AND (sqlc.narg('after_surname')::text IS NULL
+        OR (@sort_desc::boolean AND (k.sort_class, p.surname, p.first_name, p.id) < (@after_class::text, sqlc.narg('after_surname')::text, @after_first_name::text, @after_id::int))
+        OR (NOT @sort_desc::boolean AND (k.sort_class, p.surname, p.first_name, p.id) > (@after_class::text, sqlc.narg('after_surname')::text, @after_first_name::text, @after_id::int)))
+ORDER BY
+    CASE WHEN @sort_desc::boolean THEN k.sort_class END DESC,
+    CASE WHEN @sort_desc::boolean THEN p.surname END DESC,
+    CASE WHEN @sort_desc::boolean THEN p.first_name END DESC,
+    CASE WHEN @sort_desc::boolean THEN p.id END DESC,
+    k.sort_class, p.surname, p.first_name, p.id
+LIMIT @page_limit;
 
 -- name: UpdatePupil :exec
 UPDATE pupils
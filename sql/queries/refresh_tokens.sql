@@ -1,5 +1,5 @@
 -- name: CreateRefreshToken :one
-INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, school_id, role, expires_at, revoked_at)
+INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, school_id, role, expires_at, revoked_at, remember_me, last_used_at, session_expires_at)
 VALUES (
     $1,
     NOW(),
@@ -8,7 +8,10 @@ VALUES (
     $3,
     $4,
     $5,
-    NULL
+    NULL,
+    $6,
+    $7,
+    $8
 )
 RETURNING *;
 
@@ -19,4 +22,9 @@ WHERE token = $1 and expires_at > NOW() and revoked_at IS NULL;
 -- name: RevokeToken :exec
 UPDATE refresh_tokens
 SET updated_at = NOW(), revoked_at = NOW()
-WHERE token = $1;
\ No newline at end of file
+WHERE token = $1;
+
+-- name: TouchRefreshToken :exec
+UPDATE refresh_tokens
+SET updated_at = NOW(), last_used_at = $2, expires_at = $3
+WHERE token = $1;
@@ -0,0 +1,111 @@
+-- name: AddRoleScope :exec
+INSERT INTO role_scopes (user_id, school_id, type, target_id)
+VALUES ($1, $2, $3, $4);
+
+-- name: DeleteRoleScopes :exec
+DELETE FROM role_scopes WHERE user_id = $1 AND school_id = $2;
+
+-- name: GetRoleScopesForUser :many
+SELECT
+    rs.type AS target_type,
+    rs.target_id AS target_id,
+    COALESCE(
+        cls.class_name,
+        yg.year_group_name,
+        div.division_name
+    )::text AS target_name
+FROM
+    role_scopes rs
+LEFT JOIN
+    classes cls ON rs.type = 'Class' AND rs.target_id = cls.id
+LEFT JOIN
+    year_groups yg ON rs.type = 'YearGroup' AND rs.target_id = yg.id
+LEFT JOIN
+    divisions div ON rs.type = 'Division' AND rs.target_id = div.id
+WHERE
+    rs.user_id = $1 AND rs.school_id = $2
+ORDER BY
+    rs.type, target_name;
+
+-- SCOPE CHECKS
+-- A user's scope is the set of divisions, year groups and classes assigned in role_scopes,
+-- expanded down the school hierarchy (a Division scope covers its year groups and their classes).
+
+-- name: IsDropInUserScope :one
+WITH scoped_divisions AS (
+    SELECT target_id AS id FROM role_scopes
+    WHERE user_id = @user_id AND school_id = @school_id AND type = 'Division'
+), scoped_year_groups AS (
+    SELECT target_id AS id FROM role_scopes
+    WHERE user_id = @user_id AND school_id = @school_id AND type = 'YearGroup'
+    UNION
+    SELECT yg.id FROM year_groups yg
+    WHERE yg.school_id = @school_id AND yg.division_id IN (SELECT id FROM scoped_divisions)
+), scoped_classes AS (
+    SELECT target_id AS id FROM role_scopes
+    WHERE user_id = @user_id AND school_id = @school_id AND type = 'Class'
+    UNION
+    SELECT cls.id FROM classes cls
+    WHERE cls.school_id = @school_id AND cls.year_group_id IN (SELECT id FROM scoped_year_groups)
+)
+SELECT (
+    EXISTS (SELECT 1 FROM drop_targets WHERE drop_id = @drop_id AND school_id = @school_id)
+    AND NOT EXISTS (
+        SELECT 1 FROM drop_targets dt
+        WHERE dt.drop_id = @drop_id AND dt.school_id = @school_id
+          AND NOT COALESCE(
+                (dt.type = 'Division' AND dt.target_id IN (SELECT id FROM scoped_divisions))
+             OR (dt.type = 'YearGroup' AND dt.target_id IN (SELECT id FROM scoped_year_groups))
+             OR (dt.type = 'Class' AND dt.target_id IN (SELECT id FROM scoped_classes))
+             OR (dt.type = 'Student' AND dt.target_id IN (
+                    SELECT p.id FROM pupils p WHERE p.class_id IN (SELECT id FROM scoped_classes)
+                ))
+             OR (dt.type = 'Lesson' AND dt.target_id IN (
+                    SELECT lt.id FROM lesson_targets lt WHERE lt.class_id IN (SELECT id FROM scoped_classes)
+                )),
+              false)
+    )
+)::boolean AS in_scope;
+
+-- name: IsClassInUserScope :one
+WITH scoped_divisions AS (
+    SELECT target_id AS id FROM role_scopes
+    WHERE user_id = @user_id AND school_id = @school_id AND type = 'Division'
+), scoped_year_groups AS (
+    SELECT target_id AS id FROM role_scopes
+    WHERE user_id = @user_id AND school_id = @school_id AND type = 'YearGroup'
+    UNION
+    SELECT yg.id FROM year_groups yg
+    WHERE yg.school_id = @school_id AND yg.division_id IN (SELECT id FROM scoped_divisions)
+)
+SELECT EXISTS (
+    SELECT 1 FROM role_scopes
+    WHERE user_id = @user_id AND school_id = @school_id AND type = 'Class' AND target_id = @class_id
+    UNION ALL
+    SELECT 1 FROM classes cls
+    WHERE cls.id = @class_id AND cls.school_id = @school_id
+      AND cls.year_group_id IN (SELECT id FROM scoped_year_groups)
+)::boolean AS in_scope;
+
+-- name: IsPupilInUserScope :one
+WITH scoped_divisions AS (
+    SELECT target_id AS id FROM role_scopes
+    WHERE user_id = @user_id AND school_id = @school_id AND type = 'Division'
+), scoped_year_groups AS (
+    SELECT target_id AS id FROM role_scopes
+    WHERE user_id = @user_id AND school_id = @school_id AND type = 'YearGroup'
+    UNION
+    SELECT yg.id FROM year_groups yg
+    WHERE yg.school_id = @school_id AND yg.division_id IN (SELECT id FROM scoped_divisions)
+), scoped_classes AS (
+    SELECT target_id AS id FROM role_scopes
+    WHERE user_id = @user_id AND school_id = @school_id AND type = 'Class'
+    UNION
+    SELECT cls.id FROM classes cls
+    WHERE cls.school_id = @school_id AND cls.year_group_id IN (SELECT id FROM scoped_year_groups)
+)
+SELECT EXISTS (
+    SELECT 1 FROM pupils p
+    WHERE p.id = @pupil_id AND p.school_id = @school_id
+      AND p.class_id IN (SELECT id FROM scoped_classes)
+)::boolean AS in_scope;
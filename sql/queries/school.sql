@@ -1,2 +1,32 @@
 -- name: GetSchoolName :one
-SELECT name FROM schools WHERE id = $1;
\ No newline at end of file
+SELECT name FROM schools WHERE id = $1;
+
+-- name: CreateSchool :one
+INSERT INTO schools (id, name, address, contact_email, contact_phone, subdomain, status)
+VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, 'active')
+RETURNING *;
+
+-- name: GetSchools :many
+SELECT * FROM schools ORDER BY name;
+
+-- name: GetSchoolByID :one
+SELECT * FROM schools WHERE id = $1;
+
+-- name: GetSchoolIDBySubdomain :one
+SELECT id FROM schools WHERE subdomain = $1;
+
+-- name: GetSchoolBranding :one
+SELECT name, logo_url, settings FROM schools WHERE id = $1;
+
+-- name: GetSchoolStatus :one
+SELECT status FROM schools WHERE id = $1;
+
+-- name: SetSchoolStatus :one
+UPDATE schools SET status = $2, updated_at = NOW() WHERE id = $1
+RETURNING *;
+
+-- name: GetSchoolSettings :one
+SELECT settings FROM schools WHERE id = $1;
+
+-- name: SetSchoolSettings :exec
+UPDATE schools SET settings = $2, updated_at = NOW() WHERE id = $1;
@@ -0,0 +1,47 @@
+-- name: CreateTag :one
+INSERT INTO tags (school_id, name)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: GetTagsForSchool :many
+SELECT * FROM tags WHERE school_id = $1 ORDER BY LOWER(name);
+
+-- name: RenameTag :execrows
+UPDATE tags SET name = $3, updated_at = NOW() WHERE id = $1 AND school_id = $2;
+
+-- name: DeleteTag :execrows
+DELETE FROM tags WHERE id = $1 AND school_id = $2;
+
+-- name: CountValidTagsForSchool :one
+SELECT count(*) FROM tags
+WHERE school_id = $1 AND id = ANY($2::integer[]);
+
+-- DROP TAGS
+-- name: AddDropTag :exec
+INSERT INTO drop_tags (drop_id, tag_id, school_id)
+VALUES ($1, $2, $3);
+
+-- name: DeleteAllTagsForDrop :exec
+DELETE FROM drop_tags WHERE drop_id = $1 AND school_id = $2;
+
+-- name: GetTagsForDrops :many
+SELECT dtg.drop_id, t.id AS tag_id, t.name AS tag_name
+FROM drop_tags dtg
+JOIN tags t ON t.id = dtg.tag_id
+WHERE dtg.school_id = $1 AND dtg.drop_id = ANY($2::uuid[])
+ORDER BY LOWER(t.name);
+
+-- TAG SUBSCRIPTIONS
+-- name: AddTagSubscription :exec
+INSERT INTO tag_subscriptions (user_id, school_id, tag_id, muted)
+VALUES ($1, $2, $3, $4);
+
+-- name: DeleteTagSubscriptions :exec
+DELETE FROM tag_subscriptions WHERE user_id = $1 AND school_id = $2;
+
+-- name: GetTagSubscriptionsForUser :many
+SELECT t.id AS tag_id, t.name AS tag_name, ts.muted
+FROM tag_subscriptions ts
+JOIN tags t ON t.id = ts.tag_id
+WHERE ts.user_id = $1 AND ts.school_id = $2
+ORDER BY LOWER(t.name);
@@ -20,4 +20,7 @@ WHERE school_id = $1 AND id = ANY($2::integer[]);
 
 -- name: CountValidPupilsForSchool :one
 SELECT count(*) FROM pupils
-WHERE school_id = $1 AND id = ANY($2::integer[]);
\ No newline at end of file
+WHERE school_id = $1 AND id = ANY($2::integer[]);
+-- name: CountValidLessonTargetsForSchool :one
+SELECT count(*) FROM lesson_targets
+WHERE school_id = $1 AND id = ANY($2::integer[]);
@@ -0,0 +1,132 @@
+-- name: ExportDivisions :many
+SELECT * FROM divisions WHERE school_id = $1 ORDER BY id;
+
+-- name: ExportYearGroups :many
+SELECT * FROM year_groups WHERE school_id = $1 ORDER BY id;
+
+-- name: ExportClasses :many
+SELECT * FROM classes WHERE school_id = $1 ORDER BY id;
+
+-- name: ExportClassStaff :many
+SELECT * FROM class_staff WHERE school_id = $1 ORDER BY class_id, created_at, user_id;
+
+-- name: ExportPupils :many
+SELECT * FROM pupils WHERE school_id = $1 ORDER BY id;
+
+-- name: ExportCustomGroups :many
+SELECT * FROM custom_groups WHERE school_id = $1 ORDER BY id;
+
+-- name: ExportCustomGroupMembers :many
+SELECT m.* FROM custom_group_members m
+JOIN custom_groups g ON g.id = m.group_id
+WHERE g.school_id = $1
+ORDER BY m.group_id, m.pupil_id;
+
+-- name: ExportTimetablePeriods :many
+SELECT * FROM timetable_periods WHERE school_id = $1 ORDER BY id;
+
+-- name: ExportRooms :many
+SELECT * FROM rooms WHERE school_id = $1 ORDER BY id;
+
+-- name: ExportTimetableCycles :many
+SELECT * FROM timetable_cycles WHERE school_id = $1;
+
+-- name: ExportLessons :many
+SELECT * FROM lessons WHERE school_id = $1 ORDER BY id;
+
+-- name: ExportLessonTargets :many
+SELECT * FROM lesson_targets WHERE school_id = $1 ORDER BY id;
+
+-- name: ExportUsers :many
+SELECT * FROM users WHERE school_id = $1 ORDER BY created_at, id;
+
+-- name: ExportUserSettings :many
+SELECT * FROM user_settings WHERE school_id = $1;
+
+-- name: ExportRoleScopes :many
+SELECT * FROM role_scopes WHERE school_id = $1;
+
+-- name: ExportTargetSubscriptions :many
+SELECT * FROM target_subscriptions WHERE school_id = $1;
+
+-- name: ExportTags :many
+SELECT * FROM tags WHERE school_id = $1 ORDER BY id;
+
+-- name: ExportTagSubscriptions :many
+SELECT * FROM tag_subscriptions WHERE school_id = $1;
+
+-- name: ExportDrops :many
+SELECT * FROM drops WHERE school_id = $1 ORDER BY created_at, id;
+
+-- name: ExportDropTargets :many
+SELECT * FROM drop_targets WHERE school_id = $1 ORDER BY id;
+
+-- name: ExportDropTags :many
+SELECT * FROM drop_tags WHERE school_id = $1;
+
+-- name: ImportSchool :one
+INSERT INTO schools (id, name, address, contact_email, contact_phone, subdomain, logo_url, status, settings)
+VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, 'active', $7)
+RETURNING *;
+
+-- name: ImportDivision :one
+INSERT INTO divisions (school_id, division_name) VALUES ($1, $2)
+RETURNING id;
+
+-- name: ImportYearGroup :one
+INSERT INTO year_groups (school_id, year_group_name, division_id) VALUES ($1, $2, $3)
+RETURNING id;
+
+-- name: ImportClass :one
+INSERT INTO classes (school_id, class_name, year_group_id) VALUES ($1, $2, $3)
+RETURNING id;
+
+-- name: ImportClassStaff :exec
+INSERT INTO class_staff (class_id, user_id, school_id, role, created_at) VALUES ($1, $2, $3, $4, $5);
+
+-- name: ImportPupil :one
+INSERT INTO pupils (school_id, first_name, surname, class_id) VALUES ($1, $2, $3, $4)
+RETURNING id;
+
+-- name: ImportCustomGroup :one
+INSERT INTO custom_groups (school_id, group_name, teacher_id) VALUES ($1, $2, $3)
+RETURNING id;
+
+-- name: ImportCustomGroupMember :exec
+INSERT INTO custom_group_members (group_id, pupil_id) VALUES ($1, $2);
+
+-- name: ImportLessonTarget :one
+INSERT INTO lesson_targets (school_id, class_id, room_id, period_id, lesson_date) VALUES ($1, $2, $3, $4, $5)
+RETURNING id;
+
+-- name: ImportUser :one
+INSERT INTO users (id, school_id, created_at, updated_at, email, hashed_password, role, title, first_name, surname, erased_at)
+VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING id;
+
+-- name: ImportUserSettings :exec
+INSERT INTO user_settings (user_id, school_id, color_theme, layout_pref, updated_at) VALUES ($1, $2, $3, $4, $5);
+
+-- name: ImportRoleScope :exec
+INSERT INTO role_scopes (user_id, school_id, type, target_id) VALUES ($1, $2, $3, $4);
+
+-- name: ImportTargetSubscription :exec
+INSERT INTO target_subscriptions (user_id, school_id, type, target_id) VALUES ($1, $2, $3, $4);
+
+-- name: ImportTag :one
+INSERT INTO tags (school_id, name, created_at, updated_at) VALUES ($1, $2, $3, $4)
+RETURNING id;
+
+-- name: ImportTagSubscription :exec
+INSERT INTO tag_subscriptions (user_id, school_id, tag_id, muted) VALUES ($1, $2, $3, $4);
+
+-- name: ImportDrop :one
+INSERT INTO drops (id, school_id, user_id, title, content, created_at, updated_at, post_date, expire_date, edited_by, status, reviewed_by, reviewed_at, rejection_reason, priority, pinned_until)
+VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+RETURNING id;
+
+-- name: ImportDropTarget :exec
+INSERT INTO drop_targets (school_id, drop_id, type, target_id) VALUES ($1, $2, $3, $4);
+
+-- name: ImportDropTag :exec
+INSERT INTO drop_tags (school_id, drop_id, tag_id) VALUES ($1, $2, $3);
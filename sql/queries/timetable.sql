@@ -0,0 +1,83 @@
+-- name: GetTimetablePeriods :many
+SELECT * FROM timetable_periods WHERE school_id = $1 ORDER BY id;
+
+-- name: GetRooms :many
+SELECT * FROM rooms WHERE school_id = $1 ORDER BY name;
+
+-- The school's day cycle, or the default one-week cycle starting on Mondays (as timetable_cycle_day)
+-- name: GetTimetableCycle :one
+SELECT COALESCE(MAX(cycle_days), 5)::int AS cycle_days, COALESCE(MAX(starts_on), DATE '2024-01-01')::date AS starts_on
+FROM timetable_cycles WHERE school_id = $1;
+
+-- name: SetTimetableCycle :one
+INSERT INTO timetable_cycles (school_id, cycle_days, starts_on)
+VALUES ($1, $2, $3)
+ON CONFLICT (school_id) DO UPDATE SET cycle_days = EXCLUDED.cycle_days, starts_on = EXCLUDED.starts_on
+RETURNING *;
+
+-- name: GetLessons :many
+SELECT l.id, l.cycle_day, l.period_id, tp.name AS period_name, l.class_id, cls.class_name,
+    l.user_id, u.title, u.first_name, u.surname, l.room_id, r.name AS room_name
+FROM lessons l
+JOIN timetable_periods tp ON tp.id = l.period_id
+JOIN classes cls ON cls.id = l.class_id
+JOIN users u ON u.id = l.user_id
+LEFT JOIN rooms r ON r.id = l.room_id
+WHERE l.school_id = $1
+ORDER BY l.cycle_day, l.period_id, cls.class_name, u.surname, l.id;
+
+-- Staff a timetable can name, by email
+-- name: GetTimetableStaff :many
+SELECT id, email FROM users WHERE school_id = $1 AND erased_at IS NULL;
+
+-- name: UpsertTimetablePeriod :one
+INSERT INTO timetable_periods (school_id, name) VALUES ($1, $2)
+ON CONFLICT (school_id, name) DO UPDATE SET name = EXCLUDED.name
+RETURNING id;
+
+-- name: UpsertRoom :one
+INSERT INTO rooms (school_id, name) VALUES ($1, $2)
+ON CONFLICT (school_id, name) DO UPDATE SET name = EXCLUDED.name
+RETURNING id;
+
+-- name: DeleteLessons :execrows
+DELETE FROM lessons WHERE school_id = $1;
+
+-- Inserts a whole timetable in one statement, so drop_audience is refreshed once per member of staff.
+-- A room ID of 0 is no room.
+-- name: CreateLessons :execrows
+INSERT INTO lessons (school_id, cycle_day, period_id, class_id, user_id, room_id)
+SELECT @school_id, t.cycle_day, t.period_id, t.class_id, t.user_id, NULLIF(t.room_id, 0)
+FROM unnest(@cycle_days::int[], @period_ids::int[], @class_ids::int[], @user_ids::uuid[], @room_ids::int[])
+    AS t(cycle_day, period_id, class_id, user_id, room_id);
+
+-- Whether a lesson target's class or room and period are in the school, checked as UpsertLessonTarget
+-- checks them but without creating the lesson target
+-- name: IsLessonInSchool :one
+SELECT EXISTS (
+    SELECT 1 FROM timetable_periods tp
+    WHERE tp.id = @period_id AND tp.school_id = @school_id
+      AND (sqlc.narg('class_id')::int IS NULL OR EXISTS (
+        SELECT 1 FROM classes WHERE id = sqlc.narg('class_id')::int AND school_id = @school_id
+      ))
+      AND (sqlc.narg('room_id')::int IS NULL OR EXISTS (
+        SELECT 1 FROM rooms WHERE id = sqlc.narg('room_id')::int AND school_id = @school_id
+      ))
+)::boolean AS in_school;
+
+-- Finds or creates the lesson target for a class or room in a period on a date. Returns no rows when
+-- the class, room or period isn't in the school.
+-- name: UpsertLessonTarget :one
+INSERT INTO lesson_targets (school_id, class_id, room_id, period_id, lesson_date)
+SELECT tp.school_id, sqlc.narg('class_id')::int, sqlc.narg('room_id')::int, tp.id, @lesson_date::date
+FROM timetable_periods tp
+WHERE tp.id = @period_id AND tp.school_id = @school_id
+  AND (sqlc.narg('class_id')::int IS NULL OR EXISTS (
+    SELECT 1 FROM classes WHERE id = sqlc.narg('class_id')::int AND school_id = @school_id
+  ))
+  AND (sqlc.narg('room_id')::int IS NULL OR EXISTS (
+    SELECT 1 FROM rooms WHERE id = sqlc.narg('room_id')::int AND school_id = @school_id
+  ))
+ON CONFLICT (school_id, COALESCE(class_id, 0), COALESCE(room_id, 0), period_id, lesson_date)
+DO UPDATE SET lesson_date = EXCLUDED.lesson_date
+RETURNING id;
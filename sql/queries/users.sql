@@ -14,8 +14,20 @@ VALUES (
 )
 RETURNING *;
 
+-- One page of a school's users by name (keyset on surname, first_name, id), optionally with one role
 -- name: GetUsers :many
-SELECT id, email, role, title, first_name, surname FROM users WHERE school_id = $1;
+SELECT id, email, role, title, first_name, surname FROM users
+WHERE school_id = @school_id AND erased_at IS NULL
+    AND (sqlc.narg('role')::user_role IS NULL OR role = sqlc.narg('role')::user_role)
+    AND (sqlc.narg('after_surname')::text IS NULL
+        OR (@sort_desc::boolean AND (surname, first_name, id) < (sqlc.narg('after_surname')::text, @after_first_name::text, @after_id::uuid))
+        OR (NOT @sort_desc::boolean AND (surname, first_name, id) > (sqlc.narg('after_surname')::text, @after_first_name::text, @after_id::uuid)))
+ORDER BY
+    CASE WHEN @sort_desc::boolean THEN surname END DESC,
+    CASE WHEN @sort_desc::boolean THEN first_name END DESC,
+    CASE WHEN @sort_desc::boolean THEN id END DESC,
+    surname, first_name, id
+LIMIT @page_limit;
 
 -- name: GetUserById :one
 SELECT id, school_id, created_at, updated_at, email, role, title, first_name, surname FROM users where id = $1 and school_id = $2;
@@ -42,9 +54,6 @@ UPDATE users
 SET role = $3, updated_at = NOW()
 where id = $1 and school_id = $2;
 
--- name: DeleteUser :execrows
-DELETE FROM users WHERE id = $1 and school_id = $2;
-
 -- name: DeleteUsers :exec
 DELETE FROM users where school_id = $1;
 
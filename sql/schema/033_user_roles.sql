@@ -0,0 +1,18 @@
+-- +goose Up
+ALTER TYPE user_role ADD VALUE IF NOT EXISTS 'head_of_year';
+ALTER TYPE user_role ADD VALUE IF NOT EXISTS 'office';
+ALTER TYPE user_role ADD VALUE IF NOT EXISTS 'read_only';
+
+-- +goose Down
+UPDATE users SET role = 'user' WHERE role NOT IN ('user', 'admin');
+UPDATE refresh_tokens SET role = 'user' WHERE role NOT IN ('user', 'admin');
+
+ALTER TYPE user_role RENAME TO user_role_old;
+CREATE TYPE user_role AS ENUM ('user', 'admin');
+
+ALTER TABLE users ALTER COLUMN role DROP DEFAULT;
+ALTER TABLE users ALTER COLUMN role TYPE user_role USING role::text::user_role;
+ALTER TABLE users ALTER COLUMN role SET DEFAULT 'user';
+ALTER TABLE refresh_tokens ALTER COLUMN role TYPE user_role USING role::text::user_role;
+
+DROP TYPE user_role_old;
@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE role_scopes (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    school_id UUID NOT NULL REFERENCES schools(id) ON DELETE CASCADE,
+    type target_type NOT NULL,
+    target_id INT NOT NULL,
+    PRIMARY KEY (user_id, type, target_id)
+);
+
+CREATE INDEX idx_role_scopes_school_id ON role_scopes(school_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_role_scopes_school_id;
+DROP TABLE role_scopes;
@@ -0,0 +1,40 @@
+-- +goose Up
+ALTER TYPE user_role ADD VALUE IF NOT EXISTS 'trainee';
+
+CREATE TYPE drop_status AS ENUM ('published', 'pending_approval', 'rejected');
+
+ALTER TABLE drops
+    ADD COLUMN status drop_status NOT NULL DEFAULT 'published',
+    ADD COLUMN reviewed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    ADD COLUMN reviewed_at TIMESTAMP,
+    ADD COLUMN rejection_reason TEXT;
+
+CREATE INDEX idx_drops_school_status ON drops(school_id, status);
+
+ALTER TABLE schools ADD COLUMN require_drop_approval BOOLEAN NOT NULL DEFAULT false;
+
+-- +goose Down
+ALTER TABLE schools DROP COLUMN require_drop_approval;
+
+DROP INDEX IF EXISTS idx_drops_school_status;
+
+ALTER TABLE drops
+    DROP COLUMN rejection_reason,
+    DROP COLUMN reviewed_at,
+    DROP COLUMN reviewed_by,
+    DROP COLUMN status;
+
+DROP TYPE drop_status;
+
+UPDATE users SET role = 'user' WHERE role = 'trainee';
+UPDATE refresh_tokens SET role = 'user' WHERE role = 'trainee';
+
+ALTER TYPE user_role RENAME TO user_role_old;
+CREATE TYPE user_role AS ENUM ('user', 'admin', 'head_of_year', 'office', 'read_only');
+
+ALTER TABLE users ALTER COLUMN role DROP DEFAULT;
+ALTER TABLE users ALTER COLUMN role TYPE user_role USING role::text::user_role;
+ALTER TABLE users ALTER COLUMN role SET DEFAULT 'user';
+ALTER TABLE refresh_tokens ALTER COLUMN role TYPE user_role USING role::text::user_role;
+
+DROP TYPE user_role_old;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Declared in ascending order of importance so ORDER BY priority DESC puts urgent first
+CREATE TYPE drop_priority AS ENUM ('normal', 'important', 'urgent');
+
+ALTER TABLE drops
+    ADD COLUMN priority drop_priority NOT NULL DEFAULT 'normal',
+    ADD COLUMN pinned_until TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE drops
+    DROP COLUMN pinned_until,
+    DROP COLUMN priority;
+
+DROP TYPE drop_priority;
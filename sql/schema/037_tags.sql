@@ -0,0 +1,39 @@
+-- +goose Up
+CREATE TABLE tags (
+    id SERIAL PRIMARY KEY,
+    school_id UUID NOT NULL REFERENCES schools(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Tag names are unique per school, ignoring case
+CREATE UNIQUE INDEX idx_tags_school_name ON tags(school_id, LOWER(name));
+
+CREATE TABLE drop_tags (
+    drop_id UUID NOT NULL REFERENCES drops(id) ON DELETE CASCADE,
+    tag_id INT NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    school_id UUID NOT NULL REFERENCES schools(id) ON DELETE CASCADE,
+    PRIMARY KEY (drop_id, tag_id)
+);
+
+CREATE INDEX idx_drop_tags_tag_id ON drop_tags(tag_id);
+
+-- A user either follows a tag (muted = false) or mutes it (muted = true)
+CREATE TABLE tag_subscriptions (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    school_id UUID NOT NULL REFERENCES schools(id) ON DELETE CASCADE,
+    tag_id INT NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    muted BOOLEAN NOT NULL DEFAULT false,
+    PRIMARY KEY (user_id, tag_id)
+);
+
+CREATE INDEX idx_tag_subscriptions_tag_id ON tag_subscriptions(tag_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_tag_subscriptions_tag_id;
+DROP TABLE tag_subscriptions;
+DROP INDEX IF EXISTS idx_drop_tags_tag_id;
+DROP TABLE drop_tags;
+DROP INDEX IF EXISTS idx_tags_school_name;
+DROP TABLE tags;
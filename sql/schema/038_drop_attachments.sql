@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE drop_attachments (
+    id SERIAL PRIMARY KEY,
+    drop_id UUID NOT NULL REFERENCES drops(id) ON DELETE CASCADE,
+    school_id UUID NOT NULL REFERENCES schools(id) ON DELETE CASCADE,
+    filename TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    storage_key TEXT NOT NULL UNIQUE,
+    uploaded_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_drop_attachments_drop_id ON drop_attachments(drop_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_drop_attachments_drop_id;
+DROP TABLE drop_attachments;
@@ -0,0 +1,28 @@
+-- +goose Up
+ALTER TYPE user_role ADD VALUE IF NOT EXISTS 'platform_admin';
+
+UPDATE schools SET status = 'active' WHERE status IS NULL;
+
+ALTER TABLE schools
+    ALTER COLUMN status SET DEFAULT 'active',
+    ALTER COLUMN status SET NOT NULL,
+    ADD CONSTRAINT schools_status_check CHECK (status IN ('active', 'suspended'));
+
+-- +goose Down
+ALTER TABLE schools
+    DROP CONSTRAINT schools_status_check,
+    ALTER COLUMN status DROP NOT NULL,
+    ALTER COLUMN status DROP DEFAULT;
+
+UPDATE users SET role = 'admin' WHERE role = 'platform_admin';
+UPDATE refresh_tokens SET role = 'admin' WHERE role = 'platform_admin';
+
+ALTER TYPE user_role RENAME TO user_role_old;
+CREATE TYPE user_role AS ENUM ('user', 'admin', 'head_of_year', 'office', 'read_only', 'trainee');
+
+ALTER TABLE users ALTER COLUMN role DROP DEFAULT;
+ALTER TABLE users ALTER COLUMN role TYPE user_role USING role::text::user_role;
+ALTER TABLE users ALTER COLUMN role SET DEFAULT 'user';
+ALTER TABLE refresh_tokens ALTER COLUMN role TYPE user_role USING role::text::user_role;
+
+DROP TYPE user_role_old;
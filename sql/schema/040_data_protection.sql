@@ -0,0 +1,58 @@
+-- +goose Up
+-- Erased staff accounts are kept (anonymised) so the drops they wrote survive
+ALTER TABLE users ADD COLUMN erased_at TIMESTAMP;
+
+-- Editors are cleared rather than blocking deletion of a user
+ALTER TABLE drops DROP CONSTRAINT IF EXISTS drops_edited_by_fkey;
+ALTER TABLE drops ADD CONSTRAINT drops_edited_by_fkey FOREIGN KEY (edited_by) REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE TABLE pupil_class_history (
+    id SERIAL PRIMARY KEY,
+    pupil_id INT NOT NULL REFERENCES pupils(id) ON DELETE CASCADE,
+    school_id UUID NOT NULL REFERENCES schools(id) ON DELETE CASCADE,
+    class_id INT REFERENCES classes(id) ON DELETE SET NULL,
+    class_name TEXT NOT NULL,
+    started_at TIMESTAMP, -- NULL for placements that predate this table
+    ended_at TIMESTAMP
+);
+
+CREATE INDEX idx_pupil_class_history_pupil ON pupil_class_history(pupil_id);
+
+-- Record class moves however the pupil is changed (API, seed scripts, imports)
+-- +goose StatementBegin
+CREATE FUNCTION record_pupil_class_change() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND NEW.class_id IS NOT DISTINCT FROM OLD.class_id THEN
+        RETURN NEW;
+    END IF;
+
+    UPDATE pupil_class_history SET ended_at = NOW()
+    WHERE pupil_id = NEW.id AND ended_at IS NULL;
+
+    IF NEW.class_id IS NOT NULL THEN
+        INSERT INTO pupil_class_history (pupil_id, school_id, class_id, class_name, started_at)
+        SELECT NEW.id, NEW.school_id, c.id, c.class_name, NOW()
+        FROM classes c WHERE c.id = NEW.class_id;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER pupils_class_history
+AFTER INSERT OR UPDATE OF class_id ON pupils
+FOR EACH ROW EXECUTE FUNCTION record_pupil_class_change();
+
+INSERT INTO pupil_class_history (pupil_id, school_id, class_id, class_name, started_at)
+SELECT p.id, p.school_id, c.id, c.class_name, NULL
+FROM pupils p JOIN classes c ON c.id = p.class_id;
+
+-- +goose Down
+DROP TRIGGER IF EXISTS pupils_class_history ON pupils;
+DROP FUNCTION IF EXISTS record_pupil_class_change();
+DROP TABLE pupil_class_history;
+
+ALTER TABLE drops DROP CONSTRAINT IF EXISTS drops_edited_by_fkey;
+ALTER TABLE drops ADD CONSTRAINT drops_edited_by_fkey FOREIGN KEY (edited_by) REFERENCES users(id);
+
+ALTER TABLE users DROP COLUMN erased_at;
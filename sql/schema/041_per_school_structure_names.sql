@@ -0,0 +1,18 @@
+-- +goose Up
+-- Structure names were unique across the whole instance, which stops two schools both having a "Year 7"
+ALTER TABLE divisions DROP CONSTRAINT IF EXISTS divisions_division_name_key;
+ALTER TABLE year_groups DROP CONSTRAINT IF EXISTS year_groups_year_group_name_key;
+ALTER TABLE classes DROP CONSTRAINT IF EXISTS classes_class_name_key;
+
+ALTER TABLE divisions ADD CONSTRAINT divisions_school_name_key UNIQUE (school_id, division_name);
+ALTER TABLE year_groups ADD CONSTRAINT year_groups_school_name_key UNIQUE (school_id, year_group_name);
+ALTER TABLE classes ADD CONSTRAINT classes_school_name_key UNIQUE (school_id, class_name);
+
+-- +goose Down
+ALTER TABLE classes DROP CONSTRAINT IF EXISTS classes_school_name_key;
+ALTER TABLE year_groups DROP CONSTRAINT IF EXISTS year_groups_school_name_key;
+ALTER TABLE divisions DROP CONSTRAINT IF EXISTS divisions_school_name_key;
+
+ALTER TABLE divisions ADD CONSTRAINT divisions_division_name_key UNIQUE (division_name);
+ALTER TABLE year_groups ADD CONSTRAINT year_groups_year_group_name_key UNIQUE (year_group_name);
+ALTER TABLE classes ADD CONSTRAINT classes_class_name_key UNIQUE (class_name);
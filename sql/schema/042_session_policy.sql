@@ -0,0 +1,21 @@
+-- +goose Up
+-- Sessions slide: each refresh pushes expires_at forward, up to session_expires_at (fixed at login).
+-- last_used_at drives the idle timeout for sessions that weren't started with "remember me".
+ALTER TABLE refresh_tokens
+    ADD COLUMN remember_me BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN last_used_at TIMESTAMP,
+    ADD COLUMN session_expires_at TIMESTAMP;
+
+-- Existing tokens were all issued for 60 days, so treat them as remembered sessions that end when they do now
+UPDATE refresh_tokens
+SET remember_me = TRUE, last_used_at = updated_at, session_expires_at = expires_at;
+
+ALTER TABLE refresh_tokens
+    ALTER COLUMN last_used_at SET NOT NULL,
+    ALTER COLUMN session_expires_at SET NOT NULL;
+
+-- +goose Down
+ALTER TABLE refresh_tokens
+    DROP COLUMN session_expires_at,
+    DROP COLUMN last_used_at,
+    DROP COLUMN remember_me;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- Keyset indexes for the paged list endpoints: drops by (post_date, id), users and pupils by name
+CREATE INDEX idx_drops_school_post_date ON drops(school_id, post_date, id);
+CREATE INDEX idx_users_school_name ON users(school_id, surname, first_name, id);
+CREATE INDEX idx_pupils_school_name ON pupils(school_id, surname, first_name, id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_pupils_school_name;
+DROP INDEX IF EXISTS idx_users_school_name;
+DROP INDEX IF EXISTS idx_drops_school_post_date;
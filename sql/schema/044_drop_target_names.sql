@@ -0,0 +1,27 @@
+-- +goose Up
+-- Every drop target with its display name, for the drop queries to json_agg into one targets array per drop.
+-- General targets have no target_id (reported as 0); a target whose class, year group, division or pupil
+-- has gone is named after its type and id, e.g. "Class 12".
+CREATE VIEW drop_target_names AS
+SELECT
+    dt.drop_id,
+    dt.type,
+    COALESCE(dt.target_id, 0) AS target_id,
+    (CASE
+        WHEN dt.type = 'General' THEN 'General'
+        ELSE COALESCE(
+            cls.class_name,
+            yg.year_group_name,
+            div.division_name,
+            p.surname || ', ' || p.first_name,
+            dt.type::text || ' ' || COALESCE(dt.target_id, 0)
+        )
+    END)::text AS name
+FROM drop_targets dt
+LEFT JOIN classes cls ON dt.type = 'Class' AND dt.target_id = cls.id
+LEFT JOIN year_groups yg ON dt.type = 'YearGroup' AND dt.target_id = yg.id
+LEFT JOIN divisions div ON dt.type = 'Division' AND dt.target_id = div.id
+LEFT JOIN pupils p ON dt.type = 'Student' AND dt.target_id = p.id;
+
+-- +goose Down
+DROP VIEW IF EXISTS drop_target_names;
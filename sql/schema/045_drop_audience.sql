@@ -0,0 +1,219 @@
+-- +goose Up
+-- Which users each drop reaches in "My Drops", so GET /api/mydrops is an indexed join instead of
+-- evaluating every visibility rule for every drop on every request.
+--
+-- drop_audience_expected is the single statement of those rules; drop_audience is its stored copy,
+-- kept current by the triggers below and checked with `droplet audience check`.
+-- A user sees a drop when it has a General target, a target they subscribe to, a target covering a class
+-- they teach (the class, its year group or division, or a pupil in it), or a tag they follow,
+-- unless they have muted one of its tags and it is not urgent.
+CREATE VIEW drop_audience_expected AS
+SELECT d.id AS drop_id, u.id AS user_id
+FROM drops d
+JOIN users u ON u.school_id = d.school_id
+WHERE
+    (EXISTS (
+        SELECT 1
+        FROM drop_targets dt
+        WHERE dt.drop_id = d.id
+          AND (
+            dt.type = 'General'
+            OR EXISTS (
+                SELECT 1 FROM target_subscriptions sub
+                WHERE sub.user_id = u.id AND sub.type = dt.type AND sub.target_id = dt.target_id
+            )
+            OR (dt.type = 'Class' AND dt.target_id IN (SELECT cls.id FROM classes cls WHERE cls.teacher_id = u.id))
+            OR (dt.type = 'YearGroup' AND dt.target_id IN (SELECT cls.year_group_id FROM classes cls WHERE cls.teacher_id = u.id))
+            OR (dt.type = 'Division' AND dt.target_id IN (
+                SELECT yg.division_id FROM year_groups yg JOIN classes cls ON yg.id = cls.year_group_id WHERE cls.teacher_id = u.id
+            ))
+            OR (dt.type = 'Student' AND dt.target_id IN (
+                SELECT p.id FROM pupils p JOIN classes cls ON cls.id = p.class_id WHERE cls.teacher_id = u.id
+            ))
+          )
+    )
+    OR EXISTS (
+        SELECT 1
+        FROM drop_tags dtg
+        JOIN tag_subscriptions tsub ON tsub.tag_id = dtg.tag_id
+        WHERE dtg.drop_id = d.id AND tsub.user_id = u.id AND tsub.muted = false
+    ))
+    AND (d.priority = 'urgent' OR NOT EXISTS (
+        SELECT 1
+        FROM drop_tags dtg
+        JOIN tag_subscriptions tsub ON tsub.tag_id = dtg.tag_id
+        WHERE dtg.drop_id = d.id AND tsub.user_id = u.id AND tsub.muted = true
+    ));
+
+CREATE TABLE drop_audience (
+    drop_id UUID NOT NULL REFERENCES drops(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (drop_id, user_id)
+);
+
+CREATE INDEX idx_drop_audience_user_id ON drop_audience(user_id, drop_id);
+
+-- +goose StatementBegin
+CREATE FUNCTION refresh_drop_audience(p_drop_id UUID) RETURNS void AS $$
+    DELETE FROM drop_audience WHERE drop_id = p_drop_id;
+    INSERT INTO drop_audience (drop_id, user_id)
+    SELECT drop_id, user_id FROM drop_audience_expected WHERE drop_id = p_drop_id;
+$$ LANGUAGE sql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE FUNCTION refresh_user_audience(p_user_id UUID) RETURNS void AS $$
+    DELETE FROM drop_audience WHERE user_id = p_user_id;
+    INSERT INTO drop_audience (drop_id, user_id)
+    SELECT drop_id, user_id FROM drop_audience_expected WHERE user_id = p_user_id;
+$$ LANGUAGE sql;
+-- +goose StatementEnd
+
+-- A drop's audience changes with its priority (urgent drops ignore mutes), targets and tags
+-- +goose StatementBegin
+CREATE FUNCTION drop_audience_drop_changed() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        PERFORM refresh_drop_audience(OLD.drop_id);
+    END IF;
+    IF TG_OP = 'INSERT' OR (TG_OP = 'UPDATE' AND NEW.drop_id <> OLD.drop_id) THEN
+        PERFORM refresh_drop_audience(NEW.drop_id);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE FUNCTION drop_audience_drop_updated() RETURNS trigger AS $$
+BEGIN
+    PERFORM refresh_drop_audience(NEW.id);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER drops_audience
+AFTER UPDATE OF priority, school_id ON drops
+FOR EACH ROW EXECUTE FUNCTION drop_audience_drop_updated();
+
+CREATE TRIGGER drop_targets_audience
+AFTER INSERT OR UPDATE OR DELETE ON drop_targets
+FOR EACH ROW EXECUTE FUNCTION drop_audience_drop_changed();
+
+CREATE TRIGGER drop_tags_audience
+AFTER INSERT OR UPDATE OR DELETE ON drop_tags
+FOR EACH ROW EXECUTE FUNCTION drop_audience_drop_changed();
+
+-- A user's audience changes with their subscriptions, their school and (for new users) General drops
+-- +goose StatementBegin
+CREATE FUNCTION drop_audience_user_changed() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        PERFORM refresh_user_audience(OLD.user_id);
+    END IF;
+    IF TG_OP = 'INSERT' OR (TG_OP = 'UPDATE' AND NEW.user_id <> OLD.user_id) THEN
+        PERFORM refresh_user_audience(NEW.user_id);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE FUNCTION drop_audience_user_updated() RETURNS trigger AS $$
+BEGIN
+    PERFORM refresh_user_audience(NEW.id);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER users_audience
+AFTER INSERT OR UPDATE OF school_id ON users
+FOR EACH ROW EXECUTE FUNCTION drop_audience_user_updated();
+
+CREATE TRIGGER target_subscriptions_audience
+AFTER INSERT OR UPDATE OR DELETE ON target_subscriptions
+FOR EACH ROW EXECUTE FUNCTION drop_audience_user_changed();
+
+CREATE TRIGGER tag_subscriptions_audience
+AFTER INSERT OR UPDATE OR DELETE ON tag_subscriptions
+FOR EACH ROW EXECUTE FUNCTION drop_audience_user_changed();
+
+-- Class teachers see their class, its year group and division and its pupils: refresh the old and new
+-- teacher when a class changes hands or year group, and every teacher in a year group that changes division
+-- +goose StatementBegin
+CREATE FUNCTION drop_audience_class_changed() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' AND OLD.teacher_id IS NOT NULL THEN
+        PERFORM refresh_user_audience(OLD.teacher_id);
+    END IF;
+    IF TG_OP <> 'DELETE' AND NEW.teacher_id IS NOT NULL
+        AND (TG_OP = 'INSERT' OR NEW.teacher_id IS DISTINCT FROM OLD.teacher_id) THEN
+        PERFORM refresh_user_audience(NEW.teacher_id);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE FUNCTION drop_audience_year_group_changed() RETURNS trigger AS $$
+BEGIN
+    PERFORM refresh_user_audience(t.teacher_id)
+    FROM (SELECT DISTINCT teacher_id FROM classes WHERE year_group_id = NEW.id AND teacher_id IS NOT NULL) t;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- A pupil moving class changes who sees drops targeted at them
+-- +goose StatementBegin
+CREATE FUNCTION drop_audience_pupil_changed() RETURNS trigger AS $$
+DECLARE
+    v_pupil_id INT := CASE WHEN TG_OP = 'DELETE' THEN OLD.id ELSE NEW.id END;
+BEGIN
+    PERFORM refresh_drop_audience(dt.drop_id)
+    FROM (SELECT DISTINCT drop_id FROM drop_targets WHERE type = 'Student' AND target_id = v_pupil_id) dt;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER classes_audience
+AFTER INSERT OR UPDATE OF teacher_id, year_group_id OR DELETE ON classes
+FOR EACH ROW EXECUTE FUNCTION drop_audience_class_changed();
+
+CREATE TRIGGER year_groups_audience
+AFTER UPDATE OF division_id ON year_groups
+FOR EACH ROW EXECUTE FUNCTION drop_audience_year_group_changed();
+
+CREATE TRIGGER pupils_audience
+AFTER INSERT OR UPDATE OF class_id OR DELETE ON pupils
+FOR EACH ROW EXECUTE FUNCTION drop_audience_pupil_changed();
+
+INSERT INTO drop_audience (drop_id, user_id)
+SELECT drop_id, user_id FROM drop_audience_expected;
+
+-- +goose Down
+DROP TRIGGER IF EXISTS pupils_audience ON pupils;
+DROP TRIGGER IF EXISTS year_groups_audience ON year_groups;
+DROP TRIGGER IF EXISTS classes_audience ON classes;
+DROP TRIGGER IF EXISTS tag_subscriptions_audience ON tag_subscriptions;
+DROP TRIGGER IF EXISTS target_subscriptions_audience ON target_subscriptions;
+DROP TRIGGER IF EXISTS users_audience ON users;
+DROP TRIGGER IF EXISTS drop_tags_audience ON drop_tags;
+DROP TRIGGER IF EXISTS drop_targets_audience ON drop_targets;
+DROP TRIGGER IF EXISTS drops_audience ON drops;
+DROP FUNCTION IF EXISTS drop_audience_pupil_changed();
+DROP FUNCTION IF EXISTS drop_audience_year_group_changed();
+DROP FUNCTION IF EXISTS drop_audience_class_changed();
+DROP FUNCTION IF EXISTS drop_audience_user_updated();
+DROP FUNCTION IF EXISTS drop_audience_user_changed();
+DROP FUNCTION IF EXISTS drop_audience_drop_updated();
+DROP FUNCTION IF EXISTS drop_audience_drop_changed();
+DROP FUNCTION IF EXISTS refresh_user_audience(UUID);
+DROP FUNCTION IF EXISTS refresh_drop_audience(UUID);
+DROP TABLE drop_audience;
+DROP VIEW IF EXISTS drop_audience_expected;
@@ -0,0 +1,122 @@
+-- +goose Up
+-- Who each kind of target reaches, for "My Drops" (drop_audience_expected) and the audience preview
+-- (POST /api/drops/preview-audience) to share. General targets have no target_id here.
+
+-- Staff: everyone in the school for General; otherwise subscribers to the target and the teachers
+-- of the classes it covers (the class, its year group and division, and its pupils)
+CREATE VIEW target_staff AS
+SELECT u.school_id, 'General'::target_type AS type, NULL::int AS target_id, u.id AS user_id
+FROM users u
+UNION ALL
+SELECT u.school_id, sub.type, sub.target_id, sub.user_id
+FROM target_subscriptions sub
+JOIN users u ON u.id = sub.user_id
+UNION ALL
+SELECT cls.school_id, 'Class', cls.id, cls.teacher_id
+FROM classes cls
+WHERE cls.teacher_id IS NOT NULL
+UNION ALL
+SELECT cls.school_id, 'YearGroup', cls.year_group_id, cls.teacher_id
+FROM classes cls
+WHERE cls.teacher_id IS NOT NULL AND cls.year_group_id IS NOT NULL
+UNION ALL
+SELECT cls.school_id, 'Division', yg.division_id, cls.teacher_id
+FROM classes cls
+JOIN year_groups yg ON yg.id = cls.year_group_id
+WHERE cls.teacher_id IS NOT NULL AND yg.division_id IS NOT NULL
+UNION ALL
+SELECT cls.school_id, 'Student', p.id, cls.teacher_id
+FROM pupils p
+JOIN classes cls ON cls.id = p.class_id
+WHERE cls.teacher_id IS NOT NULL;
+
+-- Pupils: everyone in the school for General; otherwise the pupils in the class, year group or
+-- division, or the pupil themselves
+CREATE VIEW target_pupils AS
+SELECT p.school_id, 'General'::target_type AS type, NULL::int AS target_id, p.id AS pupil_id
+FROM pupils p
+UNION ALL
+SELECT p.school_id, 'Class', p.class_id, p.id
+FROM pupils p
+WHERE p.class_id IS NOT NULL
+UNION ALL
+SELECT p.school_id, 'YearGroup', cls.year_group_id, p.id
+FROM pupils p
+JOIN classes cls ON cls.id = p.class_id
+WHERE cls.year_group_id IS NOT NULL
+UNION ALL
+SELECT p.school_id, 'Division', yg.division_id, p.id
+FROM pupils p
+JOIN classes cls ON cls.id = p.class_id
+JOIN year_groups yg ON yg.id = cls.year_group_id
+WHERE yg.division_id IS NOT NULL
+UNION ALL
+SELECT p.school_id, 'Student', p.id, p.id
+FROM pupils p;
+
+-- The same rules as before, with the target rules read from target_staff
+CREATE OR REPLACE VIEW drop_audience_expected AS
+SELECT d.id AS drop_id, u.id AS user_id
+FROM drops d
+JOIN users u ON u.school_id = d.school_id
+WHERE
+    (EXISTS (
+        SELECT 1
+        FROM drop_targets dt
+        JOIN target_staff ts ON ts.type = dt.type AND (dt.type = 'General' OR ts.target_id = dt.target_id)
+        WHERE dt.drop_id = d.id AND ts.user_id = u.id
+    )
+    OR EXISTS (
+        SELECT 1
+        FROM drop_tags dtg
+        JOIN tag_subscriptions tsub ON tsub.tag_id = dtg.tag_id
+        WHERE dtg.drop_id = d.id AND tsub.user_id = u.id AND tsub.muted = false
+    ))
+    AND (d.priority = 'urgent' OR NOT EXISTS (
+        SELECT 1
+        FROM drop_tags dtg
+        JOIN tag_subscriptions tsub ON tsub.tag_id = dtg.tag_id
+        WHERE dtg.drop_id = d.id AND tsub.user_id = u.id AND tsub.muted = true
+    ));
+
+-- +goose Down
+CREATE OR REPLACE VIEW drop_audience_expected AS
+SELECT d.id AS drop_id, u.id AS user_id
+FROM drops d
+JOIN users u ON u.school_id = d.school_id
+WHERE
+    (EXISTS (
+        SELECT 1
+        FROM drop_targets dt
+        WHERE dt.drop_id = d.id
+          AND (
+            dt.type = 'General'
+            OR EXISTS (
+                SELECT 1 FROM target_subscriptions sub
+                WHERE sub.user_id = u.id AND sub.type = dt.type AND sub.target_id = dt.target_id
+            )
+            OR (dt.type = 'Class' AND dt.target_id IN (SELECT cls.id FROM classes cls WHERE cls.teacher_id = u.id))
+            OR (dt.type = 'YearGroup' AND dt.target_id IN (SELECT cls.year_group_id FROM classes cls WHERE cls.teacher_id = u.id))
+            OR (dt.type = 'Division' AND dt.target_id IN (
+                SELECT yg.division_id FROM year_groups yg JOIN classes cls ON yg.id = cls.year_group_id WHERE cls.teacher_id = u.id
+            ))
+            OR (dt.type = 'Student' AND dt.target_id IN (
+                SELECT p.id FROM pupils p JOIN classes cls ON cls.id = p.class_id WHERE cls.teacher_id = u.id
+            ))
+          )
+    )
+    OR EXISTS (
+        SELECT 1
+        FROM drop_tags dtg
+        JOIN tag_subscriptions tsub ON tsub.tag_id = dtg.tag_id
+        WHERE dtg.drop_id = d.id AND tsub.user_id = u.id AND tsub.muted = false
+    ))
+    AND (d.priority = 'urgent' OR NOT EXISTS (
+        SELECT 1
+        FROM drop_tags dtg
+        JOIN tag_subscriptions tsub ON tsub.tag_id = dtg.tag_id
+        WHERE dtg.drop_id = d.id AND tsub.user_id = u.id AND tsub.muted = true
+    ));
+
+DROP VIEW IF EXISTS target_pupils;
+DROP VIEW IF EXISTS target_staff;
@@ -0,0 +1,164 @@
+-- +goose Up
+-- Classes can have several staff (tutors, subject teachers and teaching assistants) instead of one teacher_id.
+-- Everyone assigned to a class sees drops aimed at it, its year group and division and its pupils.
+CREATE TYPE class_staff_role AS ENUM ('tutor', 'subject_teacher', 'teaching_assistant');
+
+CREATE TABLE class_staff (
+    class_id INT NOT NULL REFERENCES classes(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    school_id UUID NOT NULL REFERENCES schools(id) ON DELETE CASCADE,
+    role class_staff_role NOT NULL DEFAULT 'tutor',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (class_id, user_id)
+);
+
+CREATE INDEX idx_class_staff_user_id ON class_staff(user_id);
+CREATE INDEX idx_class_staff_school_id ON class_staff(school_id);
+
+-- Existing class teachers become their class's tutor
+INSERT INTO class_staff (class_id, user_id, school_id, role)
+SELECT id, teacher_id, school_id, 'tutor' FROM classes WHERE teacher_id IS NOT NULL;
+
+CREATE OR REPLACE VIEW target_staff AS
+SELECT u.school_id, 'General'::target_type AS type, NULL::int AS target_id, u.id AS user_id
+FROM users u
+UNION ALL
+SELECT u.school_id, sub.type, sub.target_id, sub.user_id
+FROM target_subscriptions sub
+JOIN users u ON u.id = sub.user_id
+UNION ALL
+SELECT cs.school_id, 'Class', cs.class_id, cs.user_id
+FROM class_staff cs
+UNION ALL
+SELECT cs.school_id, 'YearGroup', cls.year_group_id, cs.user_id
+FROM class_staff cs
+JOIN classes cls ON cls.id = cs.class_id
+WHERE cls.year_group_id IS NOT NULL
+UNION ALL
+SELECT cs.school_id, 'Division', yg.division_id, cs.user_id
+FROM class_staff cs
+JOIN classes cls ON cls.id = cs.class_id
+JOIN year_groups yg ON yg.id = cls.year_group_id
+WHERE yg.division_id IS NOT NULL
+UNION ALL
+SELECT cs.school_id, 'Student', p.id, cs.user_id
+FROM class_staff cs
+JOIN pupils p ON p.class_id = cs.class_id;
+
+-- drop_audience follows class_staff instead of classes.teacher_id: staff joining or leaving a class,
+-- or the class or its year group moving, changes what its staff see
+DROP TRIGGER IF EXISTS classes_audience ON classes;
+DROP FUNCTION IF EXISTS drop_audience_class_changed();
+
+-- +goose StatementBegin
+CREATE FUNCTION drop_audience_class_moved() RETURNS trigger AS $$
+BEGIN
+    PERFORM refresh_user_audience(cs.user_id)
+    FROM class_staff cs WHERE cs.class_id = NEW.id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION drop_audience_year_group_changed() RETURNS trigger AS $$
+BEGIN
+    PERFORM refresh_user_audience(t.user_id)
+    FROM (
+        SELECT DISTINCT cs.user_id FROM class_staff cs JOIN classes cls ON cls.id = cs.class_id
+        WHERE cls.year_group_id = NEW.id
+    ) t;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER classes_audience
+AFTER UPDATE OF year_group_id ON classes
+FOR EACH ROW EXECUTE FUNCTION drop_audience_class_moved();
+
+CREATE TRIGGER class_staff_audience
+AFTER INSERT OR UPDATE OR DELETE ON class_staff
+FOR EACH ROW EXECUTE FUNCTION drop_audience_user_changed();
+
+ALTER TABLE classes DROP COLUMN teacher_id;
+
+-- +goose Down
+ALTER TABLE classes ADD COLUMN teacher_id UUID REFERENCES users(id) ON DELETE SET NULL;
+
+-- Each class keeps one teacher: its longest-serving tutor, or failing that its longest-serving staff member
+UPDATE classes cls SET teacher_id = (
+    SELECT cs.user_id FROM class_staff cs
+    WHERE cs.class_id = cls.id
+    ORDER BY cs.role <> 'tutor', cs.created_at, cs.user_id
+    LIMIT 1
+);
+
+DROP TRIGGER IF EXISTS class_staff_audience ON class_staff;
+DROP TRIGGER IF EXISTS classes_audience ON classes;
+DROP FUNCTION IF EXISTS drop_audience_class_moved();
+
+CREATE OR REPLACE VIEW target_staff AS
+SELECT u.school_id, 'General'::target_type AS type, NULL::int AS target_id, u.id AS user_id
+FROM users u
+UNION ALL
+SELECT u.school_id, sub.type, sub.target_id, sub.user_id
+FROM target_subscriptions sub
+JOIN users u ON u.id = sub.user_id
+UNION ALL
+SELECT cls.school_id, 'Class', cls.id, cls.teacher_id
+FROM classes cls
+WHERE cls.teacher_id IS NOT NULL
+UNION ALL
+SELECT cls.school_id, 'YearGroup', cls.year_group_id, cls.teacher_id
+FROM classes cls
+WHERE cls.teacher_id IS NOT NULL AND cls.year_group_id IS NOT NULL
+UNION ALL
+SELECT cls.school_id, 'Division', yg.division_id, cls.teacher_id
+FROM classes cls
+JOIN year_groups yg ON yg.id = cls.year_group_id
+WHERE cls.teacher_id IS NOT NULL AND yg.division_id IS NOT NULL
+UNION ALL
+SELECT cls.school_id, 'Student', p.id, cls.teacher_id
+FROM pupils p
+JOIN classes cls ON cls.id = p.class_id
+WHERE cls.teacher_id IS NOT NULL;
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION drop_audience_year_group_changed() RETURNS trigger AS $$
+BEGIN
+    PERFORM refresh_user_audience(t.teacher_id)
+    FROM (SELECT DISTINCT teacher_id FROM classes WHERE year_group_id = NEW.id AND teacher_id IS NOT NULL) t;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE FUNCTION drop_audience_class_changed() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' AND OLD.teacher_id IS NOT NULL THEN
+        PERFORM refresh_user_audience(OLD.teacher_id);
+    END IF;
+    IF TG_OP <> 'DELETE' AND NEW.teacher_id IS NOT NULL
+        AND (TG_OP = 'INSERT' OR NEW.teacher_id IS DISTINCT FROM OLD.teacher_id) THEN
+        PERFORM refresh_user_audience(NEW.teacher_id);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER classes_audience
+AFTER INSERT OR UPDATE OF teacher_id, year_group_id OR DELETE ON classes
+FOR EACH ROW EXECUTE FUNCTION drop_audience_class_changed();
+
+DROP INDEX IF EXISTS idx_class_staff_school_id;
+DROP INDEX IF EXISTS idx_class_staff_user_id;
+DROP TABLE class_staff;
+DROP TYPE class_staff_role;
+
+-- Staff other than the kept teacher lose their classes' drops
+DELETE FROM drop_audience;
+INSERT INTO drop_audience (drop_id, user_id)
+SELECT drop_id, user_id FROM drop_audience_expected;
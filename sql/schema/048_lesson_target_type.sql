@@ -0,0 +1,11 @@
+-- +goose Up
+-- Lesson targets reach whoever the timetable has teaching a class, or in a room, in a period on a date.
+-- The value is added on its own so the next migration can use it: Postgres won't use a new enum value
+-- in the transaction that added it.
+ALTER TYPE target_type ADD VALUE IF NOT EXISTS 'Lesson';
+
+-- +goose Down
+-- Postgres can't drop an enum value, so remove what uses it instead
+DELETE FROM drop_targets WHERE type = 'Lesson';
+DELETE FROM target_subscriptions WHERE type = 'Lesson';
+DELETE FROM role_scopes WHERE type = 'Lesson';
@@ -0,0 +1,317 @@
+-- +goose Up
+-- A timetable: named periods, a cycle of school days (a one-week timetable is 5 days, a fortnightly one 10)
+-- and lessons placing a member of staff with a class, and optionally a room, in a period on a cycle day.
+CREATE TABLE timetable_periods (
+    id SERIAL PRIMARY KEY,
+    school_id UUID NOT NULL REFERENCES schools(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    UNIQUE (school_id, name)
+);
+
+CREATE TABLE rooms (
+    id SERIAL PRIMARY KEY,
+    school_id UUID NOT NULL REFERENCES schools(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    UNIQUE (school_id, name)
+);
+
+-- Day 1 of the cycle falls on starts_on; weekends are skipped. Schools without a row have a
+-- one-week cycle starting on Mondays.
+CREATE TABLE timetable_cycles (
+    school_id UUID PRIMARY KEY REFERENCES schools(id) ON DELETE CASCADE,
+    cycle_days INT NOT NULL DEFAULT 5 CHECK (cycle_days BETWEEN 1 AND 20),
+    starts_on DATE NOT NULL CHECK (EXTRACT(ISODOW FROM starts_on) <= 5)
+);
+
+CREATE TABLE lessons (
+    id SERIAL PRIMARY KEY,
+    school_id UUID NOT NULL REFERENCES schools(id) ON DELETE CASCADE,
+    cycle_day INT NOT NULL CHECK (cycle_day BETWEEN 1 AND 20),
+    period_id INT NOT NULL REFERENCES timetable_periods(id) ON DELETE CASCADE,
+    class_id INT NOT NULL REFERENCES classes(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    room_id INT REFERENCES rooms(id) ON DELETE SET NULL,
+    UNIQUE (cycle_day, period_id, class_id, user_id)
+);
+
+CREATE INDEX idx_lessons_school_period ON lessons(school_id, period_id, cycle_day);
+CREATE INDEX idx_lessons_user_id ON lessons(user_id);
+
+-- What a Lesson drop target points at: a class or a room, in a period on a date
+CREATE TABLE lesson_targets (
+    id SERIAL PRIMARY KEY,
+    school_id UUID NOT NULL REFERENCES schools(id) ON DELETE CASCADE,
+    class_id INT REFERENCES classes(id) ON DELETE CASCADE,
+    room_id INT REFERENCES rooms(id) ON DELETE CASCADE,
+    period_id INT NOT NULL REFERENCES timetable_periods(id) ON DELETE CASCADE,
+    lesson_date DATE NOT NULL,
+    CHECK ((class_id IS NULL) <> (room_id IS NULL))
+);
+
+CREATE UNIQUE INDEX idx_lesson_targets_unique
+ON lesson_targets(school_id, COALESCE(class_id, 0), COALESCE(room_id, 0), period_id, lesson_date);
+
+-- The cycle day a date falls on for a school, or NULL at weekends
+-- +goose StatementBegin
+CREATE FUNCTION timetable_cycle_day(p_school_id UUID, p_date DATE) RETURNS INT AS $$
+    SELECT CASE WHEN EXTRACT(ISODOW FROM p_date) > 5 THEN NULL ELSE
+        ((floor((p_date - (c.starts_on - EXTRACT(ISODOW FROM c.starts_on)::int + 1)) / 7.0)::int * 5
+            + EXTRACT(ISODOW FROM p_date)::int - EXTRACT(ISODOW FROM c.starts_on)::int)
+            % c.cycle_days + c.cycle_days) % c.cycle_days + 1
+    END
+    FROM (
+        SELECT COALESCE(MAX(cycle_days), 5) AS cycle_days, COALESCE(MAX(starts_on), DATE '2024-01-01') AS starts_on
+        FROM timetable_cycles WHERE school_id = p_school_id
+    ) c;
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- Lesson targets reach the staff teaching the class, or teaching in the room, in that period on the
+-- cycle day the date falls on, and the pupils in those lessons' classes
+CREATE OR REPLACE VIEW target_staff AS
+SELECT u.school_id, 'General'::target_type AS type, NULL::int AS target_id, u.id AS user_id
+FROM users u
+UNION ALL
+SELECT u.school_id, sub.type, sub.target_id, sub.user_id
+FROM target_subscriptions sub
+JOIN users u ON u.id = sub.user_id
+UNION ALL
+SELECT cs.school_id, 'Class', cs.class_id, cs.user_id
+FROM class_staff cs
+UNION ALL
+SELECT cs.school_id, 'YearGroup', cls.year_group_id, cs.user_id
+FROM class_staff cs
+JOIN classes cls ON cls.id = cs.class_id
+WHERE cls.year_group_id IS NOT NULL
+UNION ALL
+SELECT cs.school_id, 'Division', yg.division_id, cs.user_id
+FROM class_staff cs
+JOIN classes cls ON cls.id = cs.class_id
+JOIN year_groups yg ON yg.id = cls.year_group_id
+WHERE yg.division_id IS NOT NULL
+UNION ALL
+SELECT cs.school_id, 'Student', p.id, cs.user_id
+FROM class_staff cs
+JOIN pupils p ON p.class_id = cs.class_id
+UNION ALL
+SELECT lt.school_id, 'Lesson', lt.id, l.user_id
+FROM lesson_targets lt
+JOIN lessons l ON l.school_id = lt.school_id AND l.period_id = lt.period_id
+    AND (l.class_id = lt.class_id OR l.room_id = lt.room_id)
+    AND l.cycle_day = timetable_cycle_day(lt.school_id, lt.lesson_date);
+
+CREATE OR REPLACE VIEW target_pupils AS
+SELECT p.school_id, 'General'::target_type AS type, NULL::int AS target_id, p.id AS pupil_id
+FROM pupils p
+UNION ALL
+SELECT p.school_id, 'Class', p.class_id, p.id
+FROM pupils p
+WHERE p.class_id IS NOT NULL
+UNION ALL
+SELECT p.school_id, 'YearGroup', cls.year_group_id, p.id
+FROM pupils p
+JOIN classes cls ON cls.id = p.class_id
+WHERE cls.year_group_id IS NOT NULL
+UNION ALL
+SELECT p.school_id, 'Division', yg.division_id, p.id
+FROM pupils p
+JOIN classes cls ON cls.id = p.class_id
+JOIN year_groups yg ON yg.id = cls.year_group_id
+WHERE yg.division_id IS NOT NULL
+UNION ALL
+SELECT p.school_id, 'Student', p.id, p.id
+FROM pupils p
+UNION ALL
+SELECT lt.school_id, 'Lesson', lt.id, p.id
+FROM lesson_targets lt
+JOIN lessons l ON l.school_id = lt.school_id AND l.period_id = lt.period_id
+    AND (l.class_id = lt.class_id OR l.room_id = lt.room_id)
+    AND l.cycle_day = timetable_cycle_day(lt.school_id, lt.lesson_date)
+JOIN pupils p ON p.class_id = l.class_id;
+
+-- Lesson targets are named after the class or room, period and date, e.g. "9B, Period 3, 2026-10-20"
+CREATE OR REPLACE VIEW drop_target_names AS
+SELECT
+    dt.drop_id,
+    dt.type,
+    COALESCE(dt.target_id, 0) AS target_id,
+    (CASE
+        WHEN dt.type = 'General' THEN 'General'
+        ELSE COALESCE(
+            cls.class_name,
+            yg.year_group_name,
+            div.division_name,
+            p.surname || ', ' || p.first_name,
+            COALESCE(lcls.class_name, 'Room ' || lr.name) || ', ' || lp.name || ', ' || to_char(lt.lesson_date, 'YYYY-MM-DD'),
+            dt.type::text || ' ' || COALESCE(dt.target_id, 0)
+        )
+    END)::text AS name
+FROM drop_targets dt
+LEFT JOIN classes cls ON dt.type = 'Class' AND dt.target_id = cls.id
+LEFT JOIN year_groups yg ON dt.type = 'YearGroup' AND dt.target_id = yg.id
+LEFT JOIN divisions div ON dt.type = 'Division' AND dt.target_id = div.id
+LEFT JOIN pupils p ON dt.type = 'Student' AND dt.target_id = p.id
+LEFT JOIN lesson_targets lt ON dt.type = 'Lesson' AND dt.target_id = lt.id
+LEFT JOIN classes lcls ON lcls.id = lt.class_id
+LEFT JOIN rooms lr ON lr.id = lt.room_id
+LEFT JOIN timetable_periods lp ON lp.id = lt.period_id;
+
+-- drop_audience follows the timetable. Lessons are replaced a whole timetable at a time, so their
+-- triggers run once per statement and refresh each member of staff once.
+-- +goose StatementBegin
+CREATE FUNCTION drop_audience_lessons_changed() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        PERFORM refresh_user_audience(t.user_id) FROM (SELECT DISTINCT user_id FROM new_lessons) t;
+    ELSIF TG_OP = 'DELETE' THEN
+        PERFORM refresh_user_audience(t.user_id) FROM (SELECT DISTINCT user_id FROM old_lessons) t;
+    ELSE
+        PERFORM refresh_user_audience(t.user_id)
+        FROM (SELECT user_id FROM old_lessons UNION SELECT user_id FROM new_lessons) t;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER lessons_audience_insert
+AFTER INSERT ON lessons
+REFERENCING NEW TABLE AS new_lessons
+FOR EACH STATEMENT EXECUTE FUNCTION drop_audience_lessons_changed();
+
+CREATE TRIGGER lessons_audience_update
+AFTER UPDATE ON lessons
+REFERENCING OLD TABLE AS old_lessons NEW TABLE AS new_lessons
+FOR EACH STATEMENT EXECUTE FUNCTION drop_audience_lessons_changed();
+
+CREATE TRIGGER lessons_audience_delete
+AFTER DELETE ON lessons
+REFERENCING OLD TABLE AS old_lessons
+FOR EACH STATEMENT EXECUTE FUNCTION drop_audience_lessons_changed();
+
+-- Changing the cycle moves every date onto a different cycle day
+-- +goose StatementBegin
+CREATE FUNCTION drop_audience_cycle_changed() RETURNS trigger AS $$
+BEGIN
+    PERFORM refresh_user_audience(t.user_id)
+    FROM (
+        SELECT DISTINCT user_id FROM lessons
+        WHERE school_id = CASE WHEN TG_OP = 'DELETE' THEN OLD.school_id ELSE NEW.school_id END
+    ) t;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER timetable_cycles_audience
+AFTER INSERT OR UPDATE OR DELETE ON timetable_cycles
+FOR EACH ROW EXECUTE FUNCTION drop_audience_cycle_changed();
+
+-- A lesson target going (with its class, room or period) takes its staff off the drops aimed at it
+-- +goose StatementBegin
+CREATE FUNCTION drop_audience_lesson_target_removed() RETURNS trigger AS $$
+BEGIN
+    PERFORM refresh_drop_audience(t.drop_id)
+    FROM (SELECT DISTINCT drop_id FROM drop_targets WHERE type = 'Lesson' AND target_id = OLD.id) t;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER lesson_targets_audience
+AFTER DELETE ON lesson_targets
+FOR EACH ROW EXECUTE FUNCTION drop_audience_lesson_target_removed();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS lesson_targets_audience ON lesson_targets;
+DROP TRIGGER IF EXISTS timetable_cycles_audience ON timetable_cycles;
+DROP TRIGGER IF EXISTS lessons_audience_delete ON lessons;
+DROP TRIGGER IF EXISTS lessons_audience_update ON lessons;
+DROP TRIGGER IF EXISTS lessons_audience_insert ON lessons;
+DROP FUNCTION IF EXISTS drop_audience_lesson_target_removed();
+DROP FUNCTION IF EXISTS drop_audience_cycle_changed();
+DROP FUNCTION IF EXISTS drop_audience_lessons_changed();
+
+CREATE OR REPLACE VIEW drop_target_names AS
+SELECT
+    dt.drop_id,
+    dt.type,
+    COALESCE(dt.target_id, 0) AS target_id,
+    (CASE
+        WHEN dt.type = 'General' THEN 'General'
+        ELSE COALESCE(
+            cls.class_name,
+            yg.year_group_name,
+            div.division_name,
+            p.surname || ', ' || p.first_name,
+            dt.type::text || ' ' || COALESCE(dt.target_id, 0)
+        )
+    END)::text AS name
+FROM drop_targets dt
+LEFT JOIN classes cls ON dt.type = 'Class' AND dt.target_id = cls.id
+LEFT JOIN year_groups yg ON dt.type = 'YearGroup' AND dt.target_id = yg.id
+LEFT JOIN divisions div ON dt.type = 'Division' AND dt.target_id = div.id
+LEFT JOIN pupils p ON dt.type = 'Student' AND dt.target_id = p.id;
+
+CREATE OR REPLACE VIEW target_pupils AS
+SELECT p.school_id, 'General'::target_type AS type, NULL::int AS target_id, p.id AS pupil_id
+FROM pupils p
+UNION ALL
+SELECT p.school_id, 'Class', p.class_id, p.id
+FROM pupils p
+WHERE p.class_id IS NOT NULL
+UNION ALL
+SELECT p.school_id, 'YearGroup', cls.year_group_id, p.id
+FROM pupils p
+JOIN classes cls ON cls.id = p.class_id
+WHERE cls.year_group_id IS NOT NULL
+UNION ALL
+SELECT p.school_id, 'Division', yg.division_id, p.id
+FROM pupils p
+JOIN classes cls ON cls.id = p.class_id
+JOIN year_groups yg ON yg.id = cls.year_group_id
+WHERE yg.division_id IS NOT NULL
+UNION ALL
+SELECT p.school_id, 'Student', p.id, p.id
+FROM pupils p;
+
+CREATE OR REPLACE VIEW target_staff AS
+SELECT u.school_id, 'General'::target_type AS type, NULL::int AS target_id, u.id AS user_id
+FROM users u
+UNION ALL
+SELECT u.school_id, sub.type, sub.target_id, sub.user_id
+FROM target_subscriptions sub
+JOIN users u ON u.id = sub.user_id
+UNION ALL
+SELECT cs.school_id, 'Class', cs.class_id, cs.user_id
+FROM class_staff cs
+UNION ALL
+SELECT cs.school_id, 'YearGroup', cls.year_group_id, cs.user_id
+FROM class_staff cs
+JOIN classes cls ON cls.id = cs.class_id
+WHERE cls.year_group_id IS NOT NULL
+UNION ALL
+SELECT cs.school_id, 'Division', yg.division_id, cs.user_id
+FROM class_staff cs
+JOIN classes cls ON cls.id = cs.class_id
+JOIN year_groups yg ON yg.id = cls.year_group_id
+WHERE yg.division_id IS NOT NULL
+UNION ALL
+SELECT cs.school_id, 'Student', p.id, cs.user_id
+FROM class_staff cs
+JOIN pupils p ON p.class_id = cs.class_id;
+
+DROP FUNCTION IF EXISTS timetable_cycle_day(UUID, DATE);
+DROP INDEX IF EXISTS idx_lesson_targets_unique;
+DROP TABLE lesson_targets;
+DROP INDEX IF EXISTS idx_lessons_user_id;
+DROP INDEX IF EXISTS idx_lessons_school_period;
+DROP TABLE lessons;
+DROP TABLE timetable_cycles;
+DROP TABLE rooms;
+DROP TABLE timetable_periods;
+
+-- Staff lose the drops that reached them through lessons
+DELETE FROM drop_audience;
+INSERT INTO drop_audience (drop_id, user_id)
+SELECT drop_id, user_id FROM drop_audience_expected;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- Records each urgent drop once it has been pushed through the notification channels. A push claims its
+-- row first, so whichever of the request handler or the scheduler gets there first sends it, and only once.
+CREATE TABLE urgent_drop_pushes (
+    drop_id UUID PRIMARY KEY REFERENCES drops(id) ON DELETE CASCADE,
+    school_id UUID NOT NULL REFERENCES schools(id) ON DELETE CASCADE,
+    pushed_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_urgent_drop_pushes_school_id ON urgent_drop_pushes(school_id);
+
+-- Urgent drops already live were pushed when they were saved; don't send them again
+INSERT INTO urgent_drop_pushes (drop_id, school_id)
+SELECT id, school_id FROM drops
+WHERE priority = 'urgent' AND status = 'published' AND post_date <= NOW();
+
+-- +goose Down
+DROP TABLE IF EXISTS urgent_drop_pushes;
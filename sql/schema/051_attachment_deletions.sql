@@ -0,0 +1,27 @@
+-- +goose Up
+-- Attachment files are kept outside the database, so deleting a drop_attachments row can't delete its
+-- file. Every deleted row, however it went (a drop or user deleted, a school removed or replaced), leaves
+-- its storage key here until the file has been deleted too.
+CREATE TABLE attachment_deletions (
+    storage_key TEXT PRIMARY KEY,
+    deleted_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- +goose StatementBegin
+CREATE FUNCTION queue_attachment_deletion() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO attachment_deletions (storage_key) VALUES (OLD.storage_key)
+    ON CONFLICT (storage_key) DO NOTHING;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER drop_attachments_deleted
+AFTER DELETE ON drop_attachments
+FOR EACH ROW EXECUTE FUNCTION queue_attachment_deletion();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS drop_attachments_deleted ON drop_attachments;
+DROP FUNCTION IF EXISTS queue_attachment_deletion();
+DROP TABLE IF EXISTS attachment_deletions;
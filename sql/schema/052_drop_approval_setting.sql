@@ -0,0 +1,16 @@
+-- +goose Up
+-- The drop approval policy moves into the versioned school settings document as drops.require_approval
+UPDATE schools
+SET settings = COALESCE(settings, '{}'::jsonb)
+    || jsonb_build_object('drops', COALESCE(settings->'drops', '{}'::jsonb) || '{"require_approval": true}'::jsonb)
+WHERE require_drop_approval;
+
+ALTER TABLE schools DROP COLUMN require_drop_approval;
+
+-- +goose Down
+ALTER TABLE schools ADD COLUMN require_drop_approval BOOLEAN NOT NULL DEFAULT false;
+
+UPDATE schools
+SET require_drop_approval = COALESCE((settings->'drops'->>'require_approval')::boolean, false),
+    settings = jsonb_set(settings, '{drops}', (settings->'drops') - 'require_approval')
+WHERE settings->'drops' ? 'require_approval';